@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"media-crawler-go/internal/config"
 	"media-crawler-go/internal/crawler"
 	"media-crawler-go/internal/logger"
+	"media-crawler-go/internal/metrics"
 	"media-crawler-go/internal/platform"
 	_ "media-crawler-go/internal/platform/bilibili"
 	_ "media-crawler-go/internal/platform/douyin"
@@ -18,9 +20,14 @@ import (
 	_ "media-crawler-go/internal/platform/xhs"
 	_ "media-crawler-go/internal/platform/zhihu"
 	"media-crawler-go/internal/store"
+	"media-crawler-go/internal/webhook"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 )
 
 type optionalBool struct {
@@ -86,6 +93,9 @@ type overrides struct {
 	cdpHeadless   optionalBool
 	saveLogin     optionalBool
 	getMedias     optionalBool
+	getDanmaku    optionalBool
+	mergeDash     optionalBool
+	getReposts    optionalBool
 	getWordcloud  optionalBool
 	pythonCompat  optionalBool
 	biliQn                 int
@@ -157,6 +167,15 @@ func applyOverrides(cfg *config.Config, o overrides) {
 	if o.getMedias.set {
 		cfg.EnableGetMedias = o.getMedias.value
 	}
+	if o.getDanmaku.set {
+		cfg.EnableGetDanmaku = o.getDanmaku.value
+	}
+	if o.mergeDash.set {
+		cfg.BiliMergeDash = o.mergeDash.value
+	}
+	if o.getReposts.set {
+		cfg.EnableGetReposts = o.getReposts.value
+	}
 	if o.getWordcloud.set {
 		cfg.EnableGetWordcloud = o.getWordcloud.value
 	}
@@ -260,18 +279,30 @@ func applyOverrides(cfg *config.Config, o overrides) {
 		case "xhs":
 			if mode == "creator" {
 				cfg.XhsCreatorIdList = items
+			} else if mode == "topic" {
+				cfg.XhsTopicIdList = items
+			} else if mode == "feed" || mode == "homefeed" {
+				cfg.XhsFeedChannelList = items
 			} else {
 				cfg.XhsSpecifiedNoteUrls = items
 			}
 		case "douyin", "dy":
 			if mode == "creator" {
 				cfg.DouyinCreatorIdList = items
+			} else if mode == "music" {
+				cfg.DouyinMusicIdList = items
+			} else if mode == "mix" {
+				cfg.DouyinMixIdList = items
 			} else {
 				cfg.DouyinSpecifiedNoteUrls = items
 			}
 		case "bilibili", "bili", "b站", "b":
 			if mode == "creator" {
 				cfg.BiliCreatorIdList = items
+			} else if mode == "live" {
+				cfg.BiliLiveRoomIdList = items
+			} else if mode == "collection" {
+				cfg.BiliFavMediaIdList = items
 			} else {
 				cfg.BiliSpecifiedVideoUrls = items
 			}
@@ -306,8 +337,8 @@ func applyOverrides(cfg *config.Config, o overrides) {
 
 func registerRunFlags(fs *flag.FlagSet, o *overrides) {
 	fs.StringVar(&o.platform, "platform", "", "platform: xhs/douyin/bilibili/weibo/tieba/zhihu/kuaishou")
-	fs.StringVar(&o.mode, "mode", "", "mode: search/detail/creator")
-	fs.StringVar(&o.mode, "crawler_type", "", "mode: search/detail/creator")
+	fs.StringVar(&o.mode, "mode", "", "mode: search/detail/creator/trending/music/mix/live/collection/topic/feed")
+	fs.StringVar(&o.mode, "crawler_type", "", "mode: search/detail/creator/trending/music/mix/live/collection/topic/feed")
 	fs.StringVar(&o.keywords, "keywords", "", "keywords csv")
 	fs.StringVar(&o.inputs, "inputs", "", "inputs csv (meaning depends on platform+mode)")
 	fs.StringVar(&o.specifiedID, "specified_id", "", "detail inputs csv (alias of -inputs)")
@@ -319,6 +350,9 @@ func registerRunFlags(fs *flag.FlagSet, o *overrides) {
 	fs.Var(&o.cdpHeadless, "cdp_headless", "enable headless in cdp mode")
 	fs.Var(&o.saveLogin, "save_login_state", "save login state")
 	fs.Var(&o.getMedias, "get_medias", "enable media download")
+	fs.Var(&o.getDanmaku, "get_danmaku", "enable bilibili danmaku collection")
+	fs.Var(&o.mergeDash, "merge_dash", "merge bilibili DASH video+audio into one mp4")
+	fs.Var(&o.getReposts, "get_reposts", "enable weibo repost tree collection")
 	fs.Var(&o.getWordcloud, "get_wordcloud", "enable wordcloud")
 	fs.Var(&o.pythonCompat, "python_compat_output", "enable python compatible output")
 	fs.IntVar(&o.startPage, "start_page", 0, "start page")
@@ -360,6 +394,7 @@ func main() {
 	configPath := root.String("config", ".", "path to config file")
 	apiMode := root.Bool("api", false, "start api server")
 	apiAddr := root.String("addr", ":8080", "api server address")
+	metricsAddr := root.String("metrics_addr", "", "serve Prometheus metrics at /metrics on this address while crawling (api mode serves them on -addr)")
 	registerRunFlags(root, &o)
 	registerStoreFlags(root, &o)
 	_ = root.Parse(os.Args[1:])
@@ -389,6 +424,97 @@ func main() {
 		}
 		logger.Info("init db ok", "store_backend", config.AppConfig.StoreBackend)
 		return
+	case "export-graph", "export_graph":
+		graphFlags := flag.NewFlagSet("export-graph", flag.ExitOnError)
+		registerStoreFlags(graphFlags, &o)
+		graphFlags.StringVar(&o.platform, "platform", "", "platform whose creator_edges are exported")
+		graphFormat := graphFlags.String("format", "graphml", "graph format: graphml/gexf")
+		graphOut := graphFlags.String("out", "", "output file (default data/<platform>/creator_graph.<format>)")
+		_ = graphFlags.Parse(args)
+
+		if err := config.LoadConfig(*configPath); err != nil {
+			fmt.Printf("Failed to load config: %v\n", err)
+			os.Exit(1)
+		}
+		applyOverrides(&config.AppConfig, o)
+		logger.InitFromConfig()
+		if err := exportCreatorGraph(context.Background(), *graphFormat, *graphOut); err != nil {
+			logger.Error("export graph failed", "err", err)
+			os.Exit(1)
+		}
+		return
+	case "export-thread", "export_thread":
+		threadFlags := flag.NewFlagSet("export-thread", flag.ExitOnError)
+		registerStoreFlags(threadFlags, &o)
+		threadFlags.StringVar(&o.platform, "platform", "", "platform of the note")
+		threadNoteID := threadFlags.String("note_id", "", "note whose comments are exported")
+		threadOut := threadFlags.String("out", "", "output file (default data/<platform>/notes/<note_id>/comment_thread.json)")
+		_ = threadFlags.Parse(args)
+
+		if err := config.LoadConfig(*configPath); err != nil {
+			fmt.Printf("Failed to load config: %v\n", err)
+			os.Exit(1)
+		}
+		applyOverrides(&config.AppConfig, o)
+		logger.InitFromConfig()
+		if err := exportCommentThread(*threadNoteID, *threadOut); err != nil {
+			logger.Error("export thread failed", "err", err)
+			os.Exit(1)
+		}
+		return
+	case "rebuild-fts", "rebuild_fts":
+		ftsFlags := flag.NewFlagSet("rebuild-fts", flag.ExitOnError)
+		registerStoreFlags(ftsFlags, &o)
+		_ = ftsFlags.Parse(args)
+
+		if err := config.LoadConfig(*configPath); err != nil {
+			fmt.Printf("Failed to load config: %v\n", err)
+			os.Exit(1)
+		}
+		applyOverrides(&config.AppConfig, o)
+		logger.InitFromConfig()
+		notes, comments, err := store.RebuildSearchIndex(context.Background())
+		if err != nil {
+			logger.Error("rebuild search index failed", "err", err)
+			os.Exit(1)
+		}
+		logger.Info("search index rebuilt", "notes", notes, "comments", comments)
+		return
+	case "refill-comments", "refill_comments":
+		refillFlags := flag.NewFlagSet("refill-comments", flag.ExitOnError)
+		registerRunFlags(refillFlags, &o)
+		registerStoreFlags(refillFlags, &o)
+		refillThreshold := refillFlags.Float64("threshold", 0.9, "re-fetch notes whose comment coverage ratio is below this")
+		_ = refillFlags.Parse(args)
+
+		if err := config.LoadConfig(*configPath); err != nil {
+			fmt.Printf("Failed to load config: %v\n", err)
+			os.Exit(1)
+		}
+		applyOverrides(&config.AppConfig, o)
+		logger.InitFromConfig()
+		if err := webhook.InitFromConfig(); err != nil {
+			fmt.Printf("Invalid webhook config: %v\n", err)
+			os.Exit(1)
+		}
+		ctx, stop := signalContext()
+		defer stop()
+		_, err := runDrained(ctx, stop, func(ctx context.Context) (crawler.Result, error) {
+			return crawler.Result{}, refillComments(ctx, *refillThreshold, o.maxNotes, o.maxComments)
+		})
+		if !errors.Is(err, errNotStopped) {
+			closeStores()
+		}
+		flushWebhooks()
+		if ctx.Err() != nil {
+			logger.Warn("refill comments canceled", "err", err)
+			os.Exit(130)
+		}
+		if err != nil {
+			logger.Error("refill comments failed", "err", err)
+			os.Exit(1)
+		}
+		return
 	case "run":
 		runFlags := flag.NewFlagSet("run", flag.ExitOnError)
 		registerRunFlags(runFlags, &o)
@@ -408,17 +534,40 @@ func main() {
 	}
 	applyOverrides(&config.AppConfig, o)
 	logger.InitFromConfig()
+	if err := webhook.InitFromConfig(); err != nil {
+		fmt.Printf("Invalid webhook config: %v\n", err)
+		os.Exit(1)
+	}
 
 	if *apiMode {
+		auth, err := api.NewAuthFromConfig(config.AppConfig)
+		if err != nil {
+			fmt.Printf("Invalid api auth config: %v\n", err)
+			os.Exit(1)
+		}
+		if !auth.Enabled() {
+			logger.Warn("api authentication is disabled; every caller is admin, do not expose beyond localhost", "addr", *apiAddr)
+		}
 		srv := api.NewServer(nil)
-		logger.Info("starting api server", "addr", *apiAddr)
-		if err := http.ListenAndServe(*apiAddr, srv.Handler()); err != nil {
+		srv.SetAuth(auth)
+		logger.Info("starting api server", "addr", *apiAddr, "auth", auth.Enabled())
+		if err := serveAPI(*apiAddr, srv); err != nil {
 			logger.Error("api server failed", "err", err)
 			os.Exit(1)
 		}
 		return
 	}
 
+	if *metricsAddr != "" {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("GET /metrics", metrics.Handler())
+			if err := http.ListenAndServe(*metricsAddr, mux); err != nil {
+				logger.Error("metrics server failed", "err", err)
+			}
+		}()
+	}
+
 	logger.Info("starting crawler", "platform", config.AppConfig.Platform)
 	store.BeginRunWorkbook()
 
@@ -428,8 +577,23 @@ func main() {
 		os.Exit(1)
 	}
 	req := crawler.RequestFromConfig(config.AppConfig)
-	res, err := r.Run(context.Background(), req)
+	ctx, stop := signalContext()
+	defer stop()
+	startedAt := time.Now()
+	res, err := runDrained(ctx, stop, func(ctx context.Context) (crawler.Result, error) {
+		return r.Run(ctx, req)
+	})
+	canceled := ctx.Err() != nil
+	writeRunSummary(startedAt, res, err, canceled)
+	if !errors.Is(err, errNotStopped) {
+		closeStores()
+	}
 
+	if canceled {
+		logger.Warn("crawler canceled", "err", err, "platform", res.Platform, "mode", res.Mode, "processed", res.Processed, "succeeded", res.Succeeded, "failed", res.Failed, "failure_kinds", res.FailureKinds)
+		flushWebhooks()
+		os.Exit(130)
+	}
 	if err != nil {
 		errorKind := crawler.KindOf(err)
 		riskHint := ""
@@ -444,8 +608,278 @@ func main() {
 			}
 		}
 		logger.Error("crawler failed", "err", err, "error_kind", errorKind, "risk_hint", riskHint, "error_url", errorURL, "http_status", httpStatus, "platform", res.Platform, "mode", res.Mode, "processed", res.Processed, "succeeded", res.Succeeded, "failed", res.Failed, "failure_kinds", res.FailureKinds)
+		flushWebhooks()
 		os.Exit(1)
 	}
 
 	logger.Info("crawler finished successfully", "platform", res.Platform, "mode", res.Mode, "processed", res.Processed, "succeeded", res.Succeeded, "failed", res.Failed, "failure_kinds", res.FailureKinds)
+	flushWebhooks()
+}
+
+// signalContext returns a context canceled by Ctrl-C or SIGTERM. Calling
+// stop restores the default handling, so a second signal exits at once.
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+func shutdownTimeout() time.Duration {
+	sec := config.AppConfig.ShutdownTimeoutSec
+	if sec <= 0 {
+		sec = 30
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// errNotStopped is returned by runDrained when the task is still running
+// after the shutdown timeout. Its stores are then left open, because the
+// task may still be writing to them.
+var errNotStopped = errors.New("task did not stop")
+
+// runDrained runs fn with ctx. Once ctx is canceled it gives fn
+// SHUTDOWN_TIMEOUT_SEC to return, so stores are flushed and browsers
+// closed, and gives up on it after that with errNotStopped.
+func runDrained(ctx context.Context, stop context.CancelFunc, fn func(context.Context) (crawler.Result, error)) (crawler.Result, error) {
+	type outcome struct {
+		res crawler.Result
+		err error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		res, err := fn(ctx)
+		done <- outcome{res, err}
+	}()
+
+	select {
+	case out := <-done:
+		return out.res, out.err
+	case <-ctx.Done():
+	}
+	stop()
+	timeout := shutdownTimeout()
+	logger.Warn("shutting down; waiting for the task to stop (signal again to exit now)", "timeout_sec", int(timeout.Seconds()))
+	select {
+	case out := <-done:
+		return out.res, out.err
+	case <-time.After(timeout):
+		return crawler.Result{Platform: config.AppConfig.Platform}, fmt.Errorf("%w within %s: %w", errNotStopped, timeout, ctx.Err())
+	}
+}
+
+// serveAPI serves srv on addr until Ctrl-C or SIGTERM, then cancels the
+// running task, waits up to SHUTDOWN_TIMEOUT_SEC for it and open requests,
+// and closes the stores if both stopped in time.
+func serveAPI(addr string, srv *api.Server) error {
+	ctx, stop := signalContext()
+	defer stop()
+	httpSrv := &http.Server{Addr: addr, Handler: srv.Handler()}
+	errc := make(chan error, 1)
+	go func() { errc <- httpSrv.ListenAndServe() }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+	timeout := shutdownTimeout()
+	logger.Info("shutting down api server", "timeout_sec", int(timeout.Seconds()))
+	drainCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	drained := true
+	if err := srv.Shutdown(drainCtx); err != nil {
+		logger.Warn("task did not stop before the shutdown timeout", "err", err)
+		drained = false
+	}
+	if err := httpSrv.Shutdown(drainCtx); err != nil {
+		logger.Warn("api server shutdown incomplete", "err", err)
+		drained = false
+	}
+	if drained {
+		closeStores()
+	}
+	flushWebhooks()
+	return nil
+}
+
+// runSummary is written to DATA_DIR/<platform>/run_summary.json after every
+// CLI run.
+type runSummary struct {
+	Status string `json:"status"`
+	crawler.Result
+	Error     string `json:"error,omitempty"`
+	ErrorKind string `json:"error_kind,omitempty"`
+}
+
+func writeRunSummary(startedAt time.Time, res crawler.Result, err error, canceled bool) {
+	sum := runSummary{Status: "finished", Result: res}
+	if sum.Platform == "" {
+		sum.Platform = config.AppConfig.Platform
+	}
+	if sum.StartedAt == 0 {
+		sum.StartedAt = startedAt.Unix()
+	}
+	if sum.FinishedAt == 0 {
+		sum.FinishedAt = time.Now().Unix()
+	}
+	switch {
+	case canceled:
+		sum.Status = "canceled"
+	case err != nil:
+		sum.Status = "failed"
+	}
+	if err != nil {
+		sum.Error = err.Error()
+		sum.ErrorKind = string(crawler.KindOf(err))
+	}
+	b, merr := json.MarshalIndent(sum, "", "  ")
+	if merr != nil {
+		return
+	}
+	path := filepath.Join(store.PlatformDir(), "run_summary.json")
+	werr := os.MkdirAll(filepath.Dir(path), 0755)
+	if werr == nil {
+		werr = os.WriteFile(path, append(b, '\n'), 0644)
+	}
+	if werr != nil {
+		logger.Warn("write run summary failed", "path", path, "err", werr)
+		return
+	}
+	logger.Info("run summary written", "path", path, "status", sum.Status)
+}
+
+// closeStores waits for an in-flight workbook write and closes the database
+// backends.
+func closeStores() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := store.Close(ctx); err != nil {
+		logger.Warn("close store failed", "err", err)
+	}
+}
+
+// flushWebhooks gives queued webhook deliveries, including their retries,
+// a bounded time to finish before the process exits.
+func flushWebhooks() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := webhook.Close(ctx); err != nil {
+		logger.Warn("webhook deliveries left undelivered", "err", err)
+	}
+}
+
+func exportCreatorGraph(ctx context.Context, format string, out string) error {
+	format = strings.ToLower(strings.TrimSpace(format))
+	platformName := strings.TrimSpace(config.AppConfig.Platform)
+	if platformName == "" {
+		return fmt.Errorf("empty platform")
+	}
+	edges, err := store.LoadCreatorEdges(ctx, platformName)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(out) == "" {
+		out = filepath.Join(store.PlatformDir(), "creator_graph."+format)
+	}
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	if err := store.WriteCreatorGraph(f, format, edges); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	logger.Info("creator graph exported", "platform", platformName, "format", format, "edges", len(edges), "path", out)
+	return nil
+}
+
+// refillComments re-runs detail mode with comments enabled for the notes of
+// the configured platform whose recorded comment coverage is below
+// threshold. Comments already stored are skipped through comments.idx.
+func refillComments(ctx context.Context, threshold float64, maxNotes int, maxComments int) error {
+	platformName := strings.TrimSpace(config.AppConfig.Platform)
+	if platformName == "" {
+		return fmt.Errorf("empty platform")
+	}
+	if threshold <= 0 || threshold > 1 {
+		return fmt.Errorf("threshold must be in (0, 1], got %v", threshold)
+	}
+	records, err := store.LoadCommentCoverage(platformName)
+	if err != nil {
+		return err
+	}
+	low := store.CoverageBelow(records, threshold, maxNotes)
+	logger.Info("comment coverage loaded", "platform", platformName, "notes", len(records), "below_threshold", len(low), "threshold", threshold)
+	if len(low) == 0 {
+		return nil
+	}
+	inputs := make([]string, 0, len(low))
+	for _, c := range low {
+		logger.Info("refill note", "note_id", c.NoteID, "fetched", c.FetchedCount, "reported", c.ReportedCount, "ratio", c.Ratio)
+		inputs = append(inputs, c.Input)
+	}
+
+	config.AppConfig.EnableGetComments = true
+	config.AppConfig.EnableGetMedias = false
+	if maxComments <= 0 {
+		// Fetch as deep as the platform allows; the configured cap is what
+		// left these notes short.
+		config.AppConfig.CrawlerMaxComments = -1
+	}
+	r, err := platform.New(platformName)
+	if err != nil {
+		return err
+	}
+	req := crawler.RequestFromConfig(config.AppConfig)
+	req.Mode = crawler.ModeDetail
+	req.Inputs = inputs
+	res, err := r.Run(ctx, req)
+	if err != nil {
+		return err
+	}
+	logger.Info("refill comments finished", "platform", res.Platform, "processed", res.Processed, "succeeded", res.Succeeded, "failed", res.Failed, "failure_kinds", res.FailureKinds)
+	return nil
+}
+
+func exportCommentThread(noteID string, out string) error {
+	noteID = strings.TrimSpace(noteID)
+	if noteID == "" {
+		return fmt.Errorf("empty note_id")
+	}
+	platformName := strings.TrimSpace(config.AppConfig.Platform)
+	if platformName == "" {
+		return fmt.Errorf("empty platform")
+	}
+	comments, err := store.LoadNoteComments(platformName, noteID)
+	if err != nil {
+		return err
+	}
+	if len(comments) == 0 {
+		return fmt.Errorf("no comments for note %s", noteID)
+	}
+	if strings.TrimSpace(out) == "" {
+		out = filepath.Join(store.NoteDir(noteID), "comment_thread.json")
+	}
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(map[string]any{
+		"platform": platformName,
+		"note_id":  noteID,
+		"total":    len(comments),
+		"threads":  store.BuildCommentThreads(comments),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(out, b, 0644); err != nil {
+		return err
+	}
+	logger.Info("comment thread exported", "platform", platformName, "note_id", noteID, "comments", len(comments), "path", out)
+	return nil
 }
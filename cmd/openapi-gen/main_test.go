@@ -0,0 +1,22 @@
+package main
+
+import (
+	"bytes"
+	"media-crawler-go/internal/api"
+	"os"
+	"testing"
+)
+
+func TestGeneratedClientTypesUpToDate(t *testing.T) {
+	want, err := generate(api.OpenAPIDocument(), "client")
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	got, err := os.ReadFile("../../pkg/client/types_gen.go")
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("pkg/client/types_gen.go is stale; run go generate ./pkg/client")
+	}
+}
@@ -3,6 +3,7 @@ module media-crawler-go
 go 1.24.11
 
 require (
+	github.com/andybalholm/brotli v1.1.1
 	github.com/dop251/goja v0.0.0-20260106131823-651366fbe6e3
 	github.com/go-resty/resty/v2 v2.17.1
 	github.com/go-sql-driver/mysql v1.9.3
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/logger"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditEntry records one call to an operator or admin route: who made it,
+// what it asked for and how it was answered.
+type AuditEntry struct {
+	Time       string         `json:"time"`
+	Actor      string         `json:"actor"`
+	Role       string         `json:"role,omitempty"`
+	AuthMethod string         `json:"auth_method,omitempty"`
+	RemoteAddr string         `json:"remote_addr,omitempty"`
+	Action     string         `json:"action"`
+	Status     int            `json:"status"`
+	Details    map[string]any `json:"details,omitempty"`
+}
+
+type auditKey struct{}
+
+type auditRecord struct {
+	mu        sync.Mutex
+	principal Principal
+	details   map[string]any
+}
+
+// auditSet attaches a detail to the audit entry of r, if the route is
+// audited. Values must already be redacted.
+func auditSet(r *http.Request, key string, value any) {
+	rec, _ := r.Context().Value(auditKey{}).(*auditRecord)
+	if rec == nil {
+		return
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.details == nil {
+		rec.details = map[string]any{}
+	}
+	rec.details[key] = value
+}
+
+// auditLog appends entries as JSON lines to API_AUDIT_LOG, by default
+// <DATA_DIR>/audit.jsonl, and mirrors them to the application log.
+type auditLog struct {
+	mu sync.Mutex
+}
+
+func auditLogPath() string {
+	if p := strings.TrimSpace(config.AppConfig.APIAuditLog); p != "" {
+		return p
+	}
+	dataDir := strings.TrimSpace(config.AppConfig.DataDir)
+	if dataDir == "" {
+		dataDir = "data"
+	}
+	return filepath.Join(dataDir, "audit.jsonl")
+}
+
+func (l *auditLog) write(r *http.Request, rec *auditRecord, status int) {
+	rec.mu.Lock()
+	e := AuditEntry{
+		Time:       time.Now().UTC().Format(time.RFC3339Nano),
+		Actor:      rec.principal.Name,
+		AuthMethod: rec.principal.Method,
+		RemoteAddr: r.RemoteAddr,
+		Action:     r.Method + " " + r.URL.Path,
+		Status:     status,
+		Details:    rec.details,
+	}
+	if e.Actor == "" {
+		e.Actor = "unauthenticated"
+	} else {
+		e.Role = rec.principal.Role.String()
+	}
+	rec.mu.Unlock()
+
+	logger.Info("audit", "actor", e.Actor, "action", e.Action, "status", e.Status)
+	b, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	path := auditLogPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		logger.Warn("write audit log failed", "err", err)
+		return
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		logger.Warn("write audit log failed", "err", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		logger.Warn("write audit log failed", "err", err)
+	}
+}
+
+// readAuditEntries returns a page of the audit log, newest first, and the
+// total number of entries.
+func readAuditEntries(path string, offset, limit int) ([]AuditEntry, int, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []AuditEntry{}, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	var lines [][]byte
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		if len(sc.Bytes()) > 0 {
+			lines = append(lines, append([]byte(nil), sc.Bytes()...))
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, 0, err
+	}
+	total := len(lines)
+	out := []AuditEntry{}
+	for i := total - 1 - offset; i >= 0 && len(out) < limit; i-- {
+		var e AuditEntry
+		if json.Unmarshal(lines[i], &e) == nil {
+			out = append(out, e)
+		}
+	}
+	return out, total, nil
+}
+
+// handleAudit serves /api/audit: audit entries, newest first (limit, offset).
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	v := r.URL.Query()
+	limit := queryIntDefault(v, "limit", 100)
+	if limit < 1 {
+		limit = 1
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+	offset := queryIntDefault(v, "offset", 0)
+	if offset < 0 {
+		offset = 0
+	}
+	items, total, err := readAuditEntries(auditLogPath(), offset, limit)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"total": total, "limit": limit, "offset": offset, "items": items})
+}
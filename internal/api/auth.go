@@ -0,0 +1,451 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"media-crawler-go/internal/config"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Role is the access level of an API caller. Each route requires a minimum
+// role; higher roles include the lower ones.
+type Role int
+
+const (
+	RolePublic Role = iota
+	RoleViewer
+	RoleOperator
+	RoleAdmin
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleViewer:
+		return "viewer"
+	case RoleOperator:
+		return "operator"
+	case RoleAdmin:
+		return "admin"
+	default:
+		return "public"
+	}
+}
+
+func ParseRole(s string) (Role, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "viewer":
+		return RoleViewer, nil
+	case "operator":
+		return RoleOperator, nil
+	case "admin":
+		return RoleAdmin, nil
+	default:
+		return RolePublic, fmt.Errorf("unknown role %q (viewer|operator|admin)", s)
+	}
+}
+
+// Principal is an authenticated API caller.
+type Principal struct {
+	Name   string `json:"name"`
+	Role   Role   `json:"-"`
+	Method string `json:"method"`
+}
+
+// Authenticator checks the credentials of a request. It returns ok=false and
+// no error when the request carries no credentials it understands, and an
+// error when it carries credentials that are invalid.
+type Authenticator interface {
+	Authenticate(r *http.Request) (p Principal, ok bool, err error)
+}
+
+// Auth authenticates API requests with a chain of authenticators. With no
+// authenticators every request is treated as an anonymous admin, which keeps
+// the server usable on localhost without configuration.
+type Auth struct {
+	authenticators []Authenticator
+	anonymous      Role
+	basic          bool
+}
+
+var errUnauthenticated = errors.New("authentication required")
+
+// NewAuth returns an Auth that tries authenticators in order. anonymous is
+// the role of requests without credentials; RolePublic denies them.
+func NewAuth(anonymous Role, authenticators ...Authenticator) *Auth {
+	a := &Auth{authenticators: authenticators, anonymous: anonymous}
+	for _, it := range authenticators {
+		if _, ok := it.(*BasicAuthenticator); ok {
+			a.basic = true
+		}
+	}
+	return a
+}
+
+// NewAuthFromConfig builds the authenticators configured by API_TOKENS,
+// API_BASIC_AUTH and API_JWT_SECRET.
+func NewAuthFromConfig(cfg config.Config) (*Auth, error) {
+	var list []Authenticator
+	if len(cfg.APITokens) > 0 {
+		a, err := NewTokenAuthenticator(cfg.APITokens)
+		if err != nil {
+			return nil, fmt.Errorf("API_TOKENS: %w", err)
+		}
+		list = append(list, a)
+	}
+	if len(cfg.APIBasicAuth) > 0 {
+		a, err := NewBasicAuthenticator(cfg.APIBasicAuth)
+		if err != nil {
+			return nil, fmt.Errorf("API_BASIC_AUTH: %w", err)
+		}
+		list = append(list, a)
+	}
+	if secret := strings.TrimSpace(cfg.APIJWTSecret); secret != "" {
+		list = append(list, &JWTAuthenticator{Secret: []byte(secret)})
+	}
+	anonymous := RolePublic
+	if v := strings.TrimSpace(cfg.APIAnonymousRole); v != "" {
+		r, err := ParseRole(v)
+		if err != nil {
+			return nil, fmt.Errorf("API_ANONYMOUS_ROLE: %w", err)
+		}
+		anonymous = r
+	}
+	return NewAuth(anonymous, list...), nil
+}
+
+// Enabled reports whether any authenticator is configured.
+func (a *Auth) Enabled() bool {
+	return a != nil && len(a.authenticators) > 0
+}
+
+// Authenticate returns the caller of r. Requests without credentials get the
+// anonymous role, or every role when auth is disabled.
+func (a *Auth) Authenticate(r *http.Request) (Principal, error) {
+	if !a.Enabled() {
+		return Principal{Name: "anonymous", Role: RoleAdmin, Method: "none"}, nil
+	}
+	var firstErr error
+	for _, it := range a.authenticators {
+		p, ok, err := it.Authenticate(r)
+		if ok {
+			return p, nil
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return Principal{}, firstErr
+	}
+	return Principal{Name: "anonymous", Role: a.anonymous, Method: "anonymous"}, nil
+}
+
+func (a *Auth) challenge(w http.ResponseWriter) {
+	if a != nil && a.basic {
+		w.Header().Set("WWW-Authenticate", `Basic realm="media-crawler", charset="UTF-8"`)
+		return
+	}
+	w.Header().Set("WWW-Authenticate", `Bearer realm="media-crawler"`)
+}
+
+type principalKey struct{}
+
+// PrincipalFrom returns the caller stored in ctx by the auth middleware.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// credential is one "name:role:secret" entry of API_TOKENS or API_BASIC_AUTH.
+// A secret of the form "sha256:<hex>" is compared by its digest.
+type credential struct {
+	name   string
+	role   Role
+	digest [sha256.Size]byte
+}
+
+func parseCredentials(entries []string) ([]credential, error) {
+	out := make([]credential, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || strings.TrimSpace(parts[0]) == "" || parts[2] == "" {
+			return nil, fmt.Errorf("entry %q is not name:role:secret", redactEntry(entry))
+		}
+		role, err := ParseRole(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		c := credential{name: strings.TrimSpace(parts[0]), role: role}
+		if hexDigest, ok := strings.CutPrefix(parts[2], "sha256:"); ok {
+			b, err := hex.DecodeString(hexDigest)
+			if err != nil || len(b) != sha256.Size {
+				return nil, fmt.Errorf("entry for %q has an invalid sha256 digest", c.name)
+			}
+			copy(c.digest[:], b)
+		} else {
+			c.digest = sha256.Sum256([]byte(parts[2]))
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func redactEntry(entry string) string {
+	if i := strings.LastIndex(entry, ":"); i >= 0 {
+		return entry[:i+1] + redacted
+	}
+	return redacted
+}
+
+// match compares secret with every credential in constant time.
+func match(creds []credential, name string, secret string) (credential, bool) {
+	digest := sha256.Sum256([]byte(secret))
+	var found credential
+	ok := false
+	for _, c := range creds {
+		if name != "" && c.name != name {
+			continue
+		}
+		if subtle.ConstantTimeCompare(c.digest[:], digest[:]) == 1 && !ok {
+			found, ok = c, true
+		}
+	}
+	return found, ok
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" or X-API-Key
+// header, or of the access_token query parameter, which browsers need for
+// WebSocket and EventSource connections.
+func bearerToken(r *http.Request) string {
+	if v, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(v)
+	}
+	if v := strings.TrimSpace(r.Header.Get("X-API-Key")); v != "" {
+		return v
+	}
+	return strings.TrimSpace(r.URL.Query().Get("access_token"))
+}
+
+// TokenAuthenticator accepts static API tokens.
+type TokenAuthenticator struct {
+	creds []credential
+}
+
+func NewTokenAuthenticator(entries []string) (*TokenAuthenticator, error) {
+	creds, err := parseCredentials(entries)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenAuthenticator{creds: creds}, nil
+}
+
+func (a *TokenAuthenticator) Authenticate(r *http.Request) (Principal, bool, error) {
+	tok := bearerToken(r)
+	if tok == "" {
+		return Principal{}, false, nil
+	}
+	c, ok := match(a.creds, "", tok)
+	if !ok {
+		return Principal{}, false, errors.New("invalid api token")
+	}
+	return Principal{Name: c.name, Role: c.role, Method: "token"}, true, nil
+}
+
+// BasicAuthenticator accepts HTTP basic auth users.
+type BasicAuthenticator struct {
+	creds []credential
+}
+
+func NewBasicAuthenticator(entries []string) (*BasicAuthenticator, error) {
+	creds, err := parseCredentials(entries)
+	if err != nil {
+		return nil, err
+	}
+	return &BasicAuthenticator{creds: creds}, nil
+}
+
+func (a *BasicAuthenticator) Authenticate(r *http.Request) (Principal, bool, error) {
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return Principal{}, false, nil
+	}
+	c, ok := match(a.creds, user, pass)
+	if !ok {
+		return Principal{}, false, errors.New("invalid username or password")
+	}
+	return Principal{Name: c.name, Role: c.role, Method: "basic"}, true, nil
+}
+
+// JWTAuthenticator accepts HS256 JSON Web Tokens signed with Secret. The
+// caller is named by the "sub" claim and authorized by the "role" claim;
+// "exp" and "nbf" are enforced when present.
+type JWTAuthenticator struct {
+	Secret []byte
+	// Now is the clock used for exp/nbf; nil means time.Now.
+	Now func() time.Time
+}
+
+type jwtClaims struct {
+	Sub  string   `json:"sub"`
+	Role string   `json:"role"`
+	Exp  *float64 `json:"exp"`
+	Nbf  *float64 `json:"nbf"`
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (Principal, bool, error) {
+	tok := bearerToken(r)
+	parts := strings.Split(tok, ".")
+	if len(parts) != 3 {
+		return Principal{}, false, nil
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return Principal{}, false, errors.New("invalid jwt: unsupported header")
+	}
+	mac := hmac.New(sha256.New, a.Secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(sig, mac.Sum(nil)) {
+		return Principal{}, false, errors.New("invalid jwt: bad signature")
+	}
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return Principal{}, false, errors.New("invalid jwt: bad claims")
+	}
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+	ts := float64(now().Unix())
+	if claims.Exp != nil && ts >= *claims.Exp {
+		return Principal{}, false, errors.New("invalid jwt: expired")
+	}
+	if claims.Nbf != nil && ts < *claims.Nbf {
+		return Principal{}, false, errors.New("invalid jwt: not yet valid")
+	}
+	role, err := ParseRole(claims.Role)
+	if err != nil {
+		return Principal{}, false, fmt.Errorf("invalid jwt: %w", err)
+	}
+	name := strings.TrimSpace(claims.Sub)
+	if name == "" {
+		name = "jwt"
+	}
+	return Principal{Name: name, Role: role, Method: "jwt"}, true, nil
+}
+
+func decodeJWTPart(part string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// guard authenticates requests to a route and rejects callers below min.
+// Routes of RoleOperator and above are written to the audit log.
+func (s *Server) guard(min Role, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if min == RolePublic {
+			next.ServeHTTP(w, r)
+			return
+		}
+		var rec *auditRecord
+		if min >= RoleOperator {
+			rec = &auditRecord{}
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			w = sw
+			defer func() { s.audit.write(r, rec, sw.status) }()
+			r = r.WithContext(context.WithValue(r.Context(), auditKey{}, rec))
+		}
+
+		p, err := s.auth.Authenticate(r)
+		if rec != nil {
+			rec.principal = p
+		}
+		if err == nil && p.Role < min && p.Method == "anonymous" {
+			err = errUnauthenticated
+		}
+		if err != nil {
+			s.auth.challenge(w)
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"error": err.Error()})
+			return
+		}
+		if p.Role < min {
+			writeJSON(w, http.StatusForbidden, map[string]any{"error": fmt.Sprintf("%s role required", min)})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, p)))
+	})
+}
+
+// requireRole reports whether the caller of r has at least min, writing a
+// 403 otherwise. It is used for request fields that need more than the
+// route's role.
+func requireRole(w http.ResponseWriter, r *http.Request, min Role, what string, errKey string) bool {
+	p, _ := PrincipalFrom(r.Context())
+	if p.Role >= min {
+		return true
+	}
+	writeJSON(w, http.StatusForbidden, map[string]any{errKey: fmt.Sprintf("%s requires the %s role", what, min)})
+	return false
+}
+
+// runRequestRole is the role needed to start a task with req. Supplying
+// login credentials or redirecting the store is reserved to admins.
+func runRequestRole(req RunRequest) (Role, string) {
+	switch {
+	case strings.TrimSpace(req.Cookies) != "":
+		return RoleAdmin, "cookies"
+	case strings.TrimSpace(req.LoginPhone) != "":
+		return RoleAdmin, "login_phone"
+	case strings.TrimSpace(req.StoreBackend) != "", strings.TrimSpace(req.SQLitePath) != "":
+		return RoleAdmin, "store_backend/sqlite_path"
+	}
+	return RoleOperator, ""
+}
+
+func (s *Server) handleWhoAmI(w http.ResponseWriter, r *http.Request) {
+	p, _ := PrincipalFrom(r.Context())
+	writeJSON(w, http.StatusOK, map[string]any{"name": p.Name, "role": p.Role.String(), "method": p.Method})
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	wrote  bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wrote {
+		w.status, w.wrote = code, true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wrote = true
+	return w.ResponseWriter.Write(b)
+}
+
+// denyAll rejects every request; it stands in for an invalid auth config.
+type denyAll struct{ err error }
+
+func (d denyAll) Authenticate(*http.Request) (Principal, bool, error) {
+	return Principal{}, false, d.err
+}
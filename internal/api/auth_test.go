@@ -0,0 +1,219 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func signJWT(t *testing.T, secret string, claims map[string]any) string {
+	t.Helper()
+	enc := func(v any) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	unsigned := enc(map[string]any{"alg": "HS256", "typ": "JWT"}) + "." + enc(claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestAPIAuthRolesAndAudit(t *testing.T) {
+	dataDir := t.TempDir()
+	digest := sha256.Sum256([]byte("op-secret"))
+	oldCfg := config.AppConfig
+	config.AppConfig = config.Config{
+		Platform:     "xhs",
+		CrawlerType:  "search",
+		DataDir:      dataDir,
+		APITokens:    []string{"ci:viewer:view-token", "bot:operator:sha256:" + hex.EncodeToString(digest[:])},
+		APIBasicAuth: []string{"root:admin:pa:ss"},
+		APIJWTSecret: "jwt-secret",
+	}
+	t.Cleanup(func() { config.AppConfig = oldCfg })
+
+	release := make(chan struct{})
+	srv := NewServer(NewTaskManagerWithRunner(func(ctx context.Context) (crawler.Result, error) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return crawler.Result{}, nil
+	}))
+	defer close(release)
+
+	do := func(method, path, body string, set func(r *http.Request)) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if set != nil {
+			set(r)
+		}
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, r)
+		return w
+	}
+	bearer := func(tok string) func(r *http.Request) {
+		return func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+tok) }
+	}
+
+	if w := do(http.MethodGet, "/healthz", "", nil); w.Code != http.StatusOK {
+		t.Fatalf("healthz code=%d", w.Code)
+	}
+	if w := do(http.MethodGet, "/status", "", nil); w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+		t.Fatalf("anonymous status code=%d header=%q", w.Code, w.Header().Get("WWW-Authenticate"))
+	}
+	if w := do(http.MethodGet, "/status", "", bearer("wrong")); w.Code != http.StatusUnauthorized {
+		t.Fatalf("bad token code=%d", w.Code)
+	}
+	if w := do(http.MethodGet, "/status", "", bearer("view-token")); w.Code != http.StatusOK {
+		t.Fatalf("viewer status code=%d body=%s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodGet, "/tasks/history?access_token=view-token", "", nil); w.Code != http.StatusOK {
+		t.Fatalf("query token rejected")
+	}
+	if w := do(http.MethodPost, "/run", `{"keywords":"go"}`, bearer("view-token")); w.Code != http.StatusForbidden {
+		t.Fatalf("viewer run code=%d", w.Code)
+	}
+	if w := do(http.MethodPost, "/run", `{"keywords":"go","cookies":"a=1"}`, bearer("op-secret")); w.Code != http.StatusForbidden {
+		t.Fatalf("operator run with cookies code=%d", w.Code)
+	}
+	if w := do(http.MethodPost, "/run", `{"keywords":"go"}`, bearer("op-secret")); w.Code != http.StatusAccepted {
+		t.Fatalf("operator run code=%d body=%s", w.Code, w.Body.String())
+	}
+
+	expired := signJWT(t, "jwt-secret", map[string]any{"sub": "ops", "role": "operator", "exp": time.Now().Add(-time.Minute).Unix()})
+	if w := do(http.MethodPost, "/stop", "", bearer(expired)); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expired jwt code=%d", w.Code)
+	}
+	forged := signJWT(t, "other-secret", map[string]any{"sub": "ops", "role": "admin"})
+	if w := do(http.MethodPost, "/stop", "", bearer(forged)); w.Code != http.StatusUnauthorized {
+		t.Fatalf("forged jwt code=%d", w.Code)
+	}
+	valid := signJWT(t, "jwt-secret", map[string]any{"sub": "ops", "role": "operator", "exp": time.Now().Add(time.Hour).Unix()})
+	if w := do(http.MethodPost, "/stop", "", bearer(valid)); w.Code != http.StatusAccepted {
+		t.Fatalf("jwt stop code=%d body=%s", w.Code, w.Body.String())
+	}
+
+	if w := do(http.MethodGet, "/api/audit", "", bearer("op-secret")); w.Code != http.StatusForbidden {
+		t.Fatalf("operator audit code=%d", w.Code)
+	}
+	w := do(http.MethodGet, "/api/audit?limit=10", "", func(r *http.Request) { r.SetBasicAuth("root", "pa:ss") })
+	if w.Code != http.StatusOK {
+		t.Fatalf("admin audit code=%d body=%s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "a=1") {
+		t.Fatalf("audit log leaks cookies: %s", w.Body.String())
+	}
+	var audit struct {
+		Total int          `json:"total"`
+		Items []AuditEntry `json:"items"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &audit); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	// newest first: denied audit read, jwt stop, forged stop, expired stop,
+	// bot run, bot run with cookies, viewer run
+	if audit.Total != 7 || len(audit.Items) != 7 {
+		t.Fatalf("unexpected audit: %s", w.Body.String())
+	}
+	stop, run, cookieRun := audit.Items[1], audit.Items[4], audit.Items[5]
+	if stop.Actor != "ops" || stop.AuthMethod != "jwt" || stop.Action != "POST /stop" || stop.Status != http.StatusAccepted || stop.Details["stopped"] != true {
+		t.Fatalf("unexpected stop entry: %+v", stop)
+	}
+	if run.Actor != "bot" || run.Role != "operator" || run.Status != http.StatusAccepted || run.Details["task_id"] == "" {
+		t.Fatalf("unexpected run entry: %+v", run)
+	}
+	req, _ := cookieRun.Details["request"].(map[string]any)
+	if cookieRun.Status != http.StatusForbidden || req["cookies"] != redacted {
+		t.Fatalf("unexpected cookie run entry: %+v", cookieRun)
+	}
+	if audit.Items[2].Actor != "unauthenticated" || audit.Items[2].Status != http.StatusUnauthorized {
+		t.Fatalf("unexpected forged entry: %+v", audit.Items[2])
+	}
+}
+
+func TestAPIAuthDisabledAndInvalidConfig(t *testing.T) {
+	oldCfg := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = oldCfg })
+
+	config.AppConfig = config.Config{DataDir: t.TempDir()}
+	srv := NewServer(NewTaskManagerWithRunner(func(ctx context.Context) (crawler.Result, error) {
+		return crawler.Result{}, nil
+	}))
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/auth/whoami", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"role":"admin"`) {
+		t.Fatalf("whoami without auth code=%d body=%s", w.Code, w.Body.String())
+	}
+
+	config.AppConfig.APITokens = []string{"ci:superuser:tok"}
+	if _, err := NewAuthFromConfig(config.AppConfig); err == nil {
+		t.Fatalf("expected invalid role error")
+	}
+	srv = NewServer(NewTaskManagerWithRunner(nil))
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/status", nil)
+	r.Header.Set("Authorization", "Bearer tok")
+	srv.Handler().ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("invalid config should deny, code=%d", w.Code)
+	}
+}
+
+func TestDataEndpointsHideInternalFiles(t *testing.T) {
+	dataDir := t.TempDir()
+	oldCfg := config.AppConfig
+	config.AppConfig = config.Config{DataDir: dataDir, APITokens: []string{"ci:viewer:view-token"}}
+	t.Cleanup(func() { config.AppConfig = oldCfg })
+	for _, name := range []string{"audit.jsonl", "webhooks_dead.jsonl", "tasks/t1/task.json", "exports/k.zip", "xhs/notes.jsonl"} {
+		p := filepath.Join(dataDir, filepath.FromSlash(name))
+		_ = os.MkdirAll(filepath.Dir(p), 0755)
+		if err := os.WriteFile(p, []byte("{}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv := NewServer(NewTaskManagerWithRunner(nil))
+	get := func(path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("Authorization", "Bearer view-token")
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, r)
+		return w
+	}
+
+	for _, path := range []string{
+		"/data/download/audit.jsonl",
+		"/data/download/webhooks_dead.jsonl",
+		"/data/download/tasks/t1/task.json",
+		"/data/files/exports/k.zip?preview=false",
+		"/data/files/audit.jsonl",
+	} {
+		if w := get(path); w.Code != http.StatusForbidden {
+			t.Fatalf("%s code=%d body=%s", path, w.Code, w.Body.String())
+		}
+	}
+	if w := get("/data/download/xhs/notes.jsonl"); w.Code != http.StatusOK {
+		t.Fatalf("data file code=%d", w.Code)
+	}
+	w := get("/data/files")
+	var list struct {
+		Files []dataFileInfo `json:"files"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list.Files) != 1 || list.Files[0].Path != "xhs/notes.jsonl" {
+		t.Fatalf("files code=%d body=%s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"errors"
+	"io/fs"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/store"
+	"net/http"
+	"strings"
+)
+
+// handleCommentThread returns a note's stored comments as a nested reply
+// tree.
+func (s *Server) handleCommentThread(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	platform := strings.TrimSpace(q.Get("platform"))
+	if platform == "" {
+		platform = strings.TrimSpace(config.AppConfig.Platform)
+	}
+	if platform == "" {
+		platform = "xhs"
+	}
+	if !validPathSegment(platform) || platform == "exports" || platform == "tasks" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid platform"})
+		return
+	}
+	noteID := strings.TrimSpace(q.Get("note_id"))
+	if noteID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "note_id is required"})
+		return
+	}
+	if !validPathSegment(noteID) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid note_id"})
+		return
+	}
+
+	comments, err := store.LoadNoteComments(platform, noteID)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			writeJSON(w, http.StatusNotFound, map[string]any{"error": "no comments found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	if len(comments) == 0 {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "no comments found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"platform": platform,
+		"note_id":  noteID,
+		"total":    len(comments),
+		"threads":  store.BuildCommentThreads(comments),
+	})
+}
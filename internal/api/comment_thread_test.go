@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"media-crawler-go/internal/store"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommentThreadEndpoint(t *testing.T) {
+	dataDir := t.TempDir()
+	oldCfg := config.AppConfig
+	config.AppConfig = config.Config{DataDir: dataDir, Platform: "xhs"}
+	t.Cleanup(func() { config.AppConfig = oldCfg })
+
+	if err := os.MkdirAll(filepath.Join(dataDir, "douyin"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	lines := `{"Platform":"douyin","NoteID":"a1","CommentID":"c1","CreateTime":1}
+{"Platform":"douyin","NoteID":"a1","CommentID":"c2","ParentCommentID":"c1","CreateTime":2}
+{"Platform":"douyin","NoteID":"a1","CommentID":"c3","RootCommentID":"c1","ReplyToCommentID":"c2","CreateTime":3}
+{"Platform":"douyin","NoteID":"a2","CommentID":"c4","CreateTime":4}
+`
+	if err := os.WriteFile(filepath.Join(dataDir, "douyin", "comments.jsonl"), []byte(lines), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	runFn := func(ctx context.Context) (crawler.Result, error) { return crawler.Result{}, nil }
+	srv := NewServer(NewTaskManagerWithRunner(runFn))
+
+	r := httptest.NewRequest(http.MethodGet, "/api/data/comments/thread?platform=douyin&note_id=a1", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("code=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Total   int                    `json:"total"`
+		Threads []*store.CommentThread `json:"threads"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v body=%s", err, w.Body.String())
+	}
+	if resp.Total != 3 || len(resp.Threads) != 1 {
+		t.Fatalf("unexpected response: %s", w.Body.String())
+	}
+	root := resp.Threads[0]
+	if root.Comment.CommentID != "c1" || len(root.Replies) != 1 || len(root.Replies[0].Replies) != 1 {
+		t.Fatalf("unexpected tree: %s", w.Body.String())
+	}
+	if got := root.Replies[0].Replies[0].Comment; got.CommentID != "c3" || got.Depth != 2 {
+		t.Fatalf("unexpected nested reply: %+v", got)
+	}
+
+	for _, path := range []string{"/data/comments/thread?platform=douyin", "/data/comments/thread?platform=douyin&note_id=zz", "/data/comments/thread?platform=weibo&note_id=a1"} {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, r)
+		if w.Code != http.StatusBadRequest && w.Code != http.StatusNotFound {
+			t.Fatalf("%s: code=%d body=%s", path, w.Code, w.Body.String())
+		}
+	}
+
+	for _, path := range []string{"/data/comments/thread?platform=..&note_id=a1", "/data/comments/thread?platform=tasks&note_id=a1", "/data/comments/thread?platform=douyin&note_id=../../x", "/data/comments/thread?platform=douyin&note_id=.."} {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, r)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: code=%d body=%s", path, w.Code, w.Body.String())
+		}
+	}
+}
@@ -11,23 +11,36 @@ type platformInfo struct {
 	Modes []string `json:"modes"`
 }
 
+var configPlatforms = []platformInfo{
+	{Key: "xhs", Label: "小红书", Modes: []string{"search", "detail", "creator", "topic", "feed"}},
+	{Key: "douyin", Label: "抖音", Modes: []string{"search", "detail", "creator", "music", "mix"}},
+	{Key: "bilibili", Label: "Bilibili", Modes: []string{"search", "detail", "creator", "live", "collection"}},
+	{Key: "weibo", Label: "微博", Modes: []string{"search", "detail", "creator", "trending"}},
+	{Key: "tieba", Label: "贴吧", Modes: []string{"search", "detail", "creator"}},
+	{Key: "zhihu", Label: "知乎", Modes: []string{"search", "detail", "creator"}},
+	{Key: "kuaishou", Label: "快手", Modes: []string{"search", "detail", "creator"}},
+}
+
 func (s *Server) handleConfigPlatforms(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]any{
-		"platforms": []platformInfo{
-			{Key: "xhs", Label: "小红书", Modes: []string{"search", "detail", "creator"}},
-			{Key: "douyin", Label: "抖音", Modes: []string{"search", "detail", "creator"}},
-			{Key: "bilibili", Label: "Bilibili", Modes: []string{"search", "detail", "creator"}},
-			{Key: "weibo", Label: "微博", Modes: []string{"search", "detail", "creator"}},
-			{Key: "tieba", Label: "贴吧", Modes: []string{"search", "detail", "creator"}},
-			{Key: "zhihu", Label: "知乎", Modes: []string{"search", "detail", "creator"}},
-			{Key: "kuaishou", Label: "快手", Modes: []string{"search", "detail", "creator"}},
-		},
+		"platforms": configPlatforms,
 	})
 }
 
+// platformCrawlerTypes maps each platform to the crawler types it
+// implements; crawler_types is their union.
+func platformCrawlerTypes() map[string][]string {
+	out := make(map[string][]string, len(configPlatforms))
+	for _, p := range configPlatforms {
+		out[p.Key] = p.Modes
+	}
+	return out
+}
+
 func (s *Server) handleConfigOptions(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]any{
-		"crawler_types":    []string{"search", "detail", "creator"},
+		"crawler_types":    []string{"search", "detail", "creator", "trending", "music", "mix", "live", "collection", "topic", "feed"},
+		"platform_crawler_types": platformCrawlerTypes(),
 		"login_types":      []string{"qrcode", "phone", "cookie"},
 		"store_backends":   []string{"file", "sqlite", "mysql", "postgres", "mongodb"},
 		"save_data_option": []string{"json", "csv", "xlsx", "xlsx_book", "excel"},
@@ -8,6 +8,7 @@ import (
 	"io"
 	"io/fs"
 	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/webhook"
 	"net/http"
 	"net/url"
 	"os"
@@ -164,11 +165,21 @@ func listDataFiles(dataDir string, q url.Values) ([]dataFileInfo, error) {
 		return nil, err
 	}
 
+	dataAbs, err := filepath.Abs(dataDir)
+	if err != nil {
+		return nil, err
+	}
 	out := make([]dataFileInfo, 0, 64)
-	err := filepath.WalkDir(dataDir, func(path string, d fs.DirEntry, err error) error {
+	err = filepath.WalkDir(dataAbs, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return nil
 		}
+		if path != dataAbs && internalDataPath(dataAbs, path) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 		if d.IsDir() {
 			return nil
 		}
@@ -180,7 +191,7 @@ func listDataFiles(dataDir string, q url.Values) ([]dataFileInfo, error) {
 		if fileType != "" && strings.ToLower(strings.TrimPrefix(ext, ".")) != strings.ToLower(fileType) {
 			return nil
 		}
-		rel, err := filepath.Rel(dataDir, path)
+		rel, err := filepath.Rel(dataAbs, path)
 		if err != nil {
 			return nil
 		}
@@ -258,9 +269,34 @@ func safeDataPath(dataDir, rel string) (string, error) {
 	if strings.HasPrefix(relTo, ".."+string(filepath.Separator)) || relTo == ".." {
 		return "", errors.New("access denied")
 	}
+	if internalDataPath(dataAbs, fullAbs) {
+		return "", errors.New("access denied")
+	}
 	return fullAbs, nil
 }
 
+// internalDataPath reports whether fullAbs is one of the server's own files
+// kept under DATA_DIR rather than crawled data: task history, the export
+// cache, the audit log and the webhook dead letters. They hold request
+// details and event payloads that the data endpoints must not hand to
+// viewers.
+func internalDataPath(dataAbs, fullAbs string) bool {
+	rel, err := filepath.Rel(dataAbs, fullAbs)
+	if err != nil {
+		return false
+	}
+	switch strings.SplitN(filepath.ToSlash(rel), "/", 2)[0] {
+	case "tasks", "exports":
+		return true
+	}
+	for _, p := range []string{auditLogPath(), webhook.DeadLetterPath()} {
+		if abs, err := filepath.Abs(p); err == nil && abs == fullAbs {
+			return true
+		}
+	}
+	return false
+}
+
 func queryBoolDefault(q url.Values, key string, defaultValue bool) bool {
 	raw := strings.TrimSpace(q.Get(key))
 	if raw == "" {
@@ -526,10 +562,20 @@ func dataStats(dataDir string) (map[string]any, error) {
 
 	platformKeys := []string{"xhs", "dy", "ks", "bili", "wb", "tieba", "zhihu", "douyin", "bilibili", "weibo"}
 
-	err := filepath.WalkDir(dataDir, func(path string, d fs.DirEntry, err error) error {
+	dataAbs, err := filepath.Abs(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	err = filepath.WalkDir(dataAbs, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return nil
 		}
+		if path != dataAbs && internalDataPath(dataAbs, path) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 		if d.IsDir() {
 			return nil
 		}
@@ -548,7 +594,7 @@ func dataStats(dataDir string) (map[string]any, error) {
 		typ := strings.TrimPrefix(ext, ".")
 		byType[typ]++
 
-		rel, err := filepath.Rel(dataDir, path)
+		rel, err := filepath.Rel(dataAbs, path)
 		if err != nil {
 			return nil
 		}
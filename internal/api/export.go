@@ -0,0 +1,601 @@
+package api
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/logger"
+	"media-crawler-go/internal/store"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// exportCacheTTL is how long a built archive is kept under
+// DATA_DIR/exports for resumed downloads.
+const exportCacheTTL = 24 * time.Hour
+
+// exportCacheMaxBytes caps the total size of the cached archives; the
+// oldest are removed first.
+var exportCacheMaxBytes int64 = 2 << 30
+
+// exportSelection picks the files of a bundle export. The filters combine:
+// a file must be under Platform, belong to TaskID and NoteIDs when given,
+// and be modified within [Since, Until].
+type exportSelection struct {
+	Platform  string   `json:"platform"`
+	TaskID    string   `json:"task_id,omitempty"`
+	NoteIDs   []string `json:"note_ids,omitempty"`
+	Since     int64    `json:"since,omitempty"`
+	Until     int64    `json:"until,omitempty"`
+	Manifest  bool     `json:"manifest"`
+	Summaries bool     `json:"summaries"`
+}
+
+// exportFile is a data file of an export, Path being relative to DATA_DIR.
+type exportFile struct {
+	Path       string `json:"path"`
+	Size       int64  `json:"size"`
+	ModifiedAt int64  `json:"modified_at"`
+	SHA256     string `json:"sha256"`
+
+	full    string
+	modTime time.Time
+}
+
+// exportManifest is manifest.json, the last entry of an archive.
+type exportManifest struct {
+	Selection exportSelection `json:"selection"`
+	Files     []exportFile    `json:"files"`
+	FileCount int             `json:"file_count"`
+	TotalSize int64           `json:"total_size"`
+}
+
+var errExportSelection = errors.New("invalid export selection")
+
+// handleDataExport streams a zip of the selected data files. The archive is
+// also written to DATA_DIR/exports/<etag>.zip, so a download resumed with a
+// Range header (and If-Range: <etag>) continues from the same bytes.
+func (s *Server) handleDataExport(w http.ResponseWriter, r *http.Request) {
+	dataDir := strings.TrimSpace(config.AppConfig.DataDir)
+	if dataDir == "" {
+		dataDir = "data"
+	}
+	sel, taskFiles, err := parseExportSelection(dataDir, r.URL.Query())
+	if err != nil {
+		switch {
+		case errors.Is(err, errTaskNotFound):
+			writeJSON(w, http.StatusNotFound, map[string]any{"error": err.Error()})
+		case errors.Is(err, errExportSelection):
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		default:
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		}
+		return
+	}
+	files, err := selectExportFiles(dataDir, sel, taskFiles)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	if len(files) == 0 {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "no files match the selection"})
+		return
+	}
+
+	key := exportKey(sel, files)
+	auditSet(r, "export", key)
+	auditSet(r, "files", len(files))
+	w.Header().Set("ETag", `"`+key+`"`)
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+exportFilename(sel, key)+`"`)
+	s.exports.serve(w, r, filepath.Join(dataDir, "exports"), key, func(out io.Writer) error {
+		return writeExportArchive(out, sel, files)
+	})
+}
+
+// parseExportSelection reads platform, task_id, note_id (repeated or comma
+// separated), since, until, manifest and summaries. The platform defaults to
+// the task's, then to PLATFORM. For a task it also returns the files listed
+// in the task record, or nil if that list was truncated.
+func parseExportSelection(dataDir string, q url.Values) (exportSelection, []string, error) {
+	sel := exportSelection{
+		Platform:  strings.TrimSpace(q.Get("platform")),
+		TaskID:    strings.TrimSpace(q.Get("task_id")),
+		Manifest:  queryBoolDefault(q, "manifest", true),
+		Summaries: queryBoolDefault(q, "summaries", true),
+	}
+	var taskFiles []string
+	if sel.TaskID != "" {
+		rec, err := loadTaskRecord(dataDir, sel.TaskID)
+		if err != nil {
+			return sel, nil, err
+		}
+		if p, _ := rec.Config["PLATFORM"].(string); sel.Platform == "" {
+			sel.Platform = strings.TrimSpace(p)
+		}
+		if !rec.FilesTruncated {
+			taskFiles = []string{}
+			for _, f := range rec.Files {
+				taskFiles = append(taskFiles, f.Path)
+			}
+		} else {
+			// the record lists the first files only; fall back to the
+			// files modified while the task ran
+			if sel.Since == 0 || rec.StartedAt > sel.Since {
+				sel.Since = rec.StartedAt
+			}
+			if rec.FinishedAt > 0 && (sel.Until == 0 || rec.FinishedAt < sel.Until) {
+				sel.Until = rec.FinishedAt
+			}
+		}
+	}
+	if sel.Platform == "" {
+		sel.Platform = strings.TrimSpace(config.AppConfig.Platform)
+	}
+	sel.Platform = strings.ToLower(sel.Platform)
+	if !validPathSegment(sel.Platform) || sel.Platform == "exports" || sel.Platform == "tasks" {
+		return sel, nil, fmt.Errorf("%w: platform %q", errExportSelection, sel.Platform)
+	}
+
+	seen := map[string]bool{}
+	for _, v := range q["note_id"] {
+		for _, id := range strings.Split(v, ",") {
+			id = strings.TrimSpace(id)
+			if id == "" || seen[id] {
+				continue
+			}
+			if !validPathSegment(id) {
+				return sel, nil, fmt.Errorf("%w: note_id %q", errExportSelection, id)
+			}
+			seen[id] = true
+			sel.NoteIDs = append(sel.NoteIDs, id)
+		}
+	}
+	sort.Strings(sel.NoteIDs)
+
+	since, err := parseQueryTime(q.Get("since"))
+	if err != nil {
+		return sel, nil, fmt.Errorf("%w: since: %v", errExportSelection, err)
+	}
+	until, err := parseQueryTime(q.Get("until"))
+	if err != nil {
+		return sel, nil, fmt.Errorf("%w: until: %v", errExportSelection, err)
+	}
+	if isDate(q.Get("until")) {
+		// a date includes the whole day
+		until += 24*60*60 - 1
+	}
+	if since > sel.Since {
+		sel.Since = since
+	}
+	if until > 0 && (sel.Until == 0 || until < sel.Until) {
+		sel.Until = until
+	}
+	return sel, taskFiles, nil
+}
+
+func isDate(s string) bool {
+	_, err := time.Parse("2006-01-02", strings.TrimSpace(s))
+	return err == nil
+}
+
+// validPathSegment accepts a single file name, not "." or "..".
+func validPathSegment(s string) bool {
+	return s != "" && s != "." && s != ".." && !strings.ContainsAny(s, "/\\\x00")
+}
+
+// selectExportFiles lists the selected files in path order: the task's
+// files (or every file) under the platform directory, filtered by note and
+// time, plus the task record and log for a task.
+func selectExportFiles(dataDir string, sel exportSelection, taskFiles []string) ([]exportFile, error) {
+	dataDir, err := filepath.Abs(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []string
+	if taskFiles != nil {
+		for _, rel := range taskFiles {
+			if full, err := safeDataPath(dataDir, rel); err == nil {
+				candidates = append(candidates, full)
+			}
+		}
+	} else {
+		root := filepath.Join(dataDir, sel.Platform)
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if p == root && errors.Is(err, fs.ErrNotExist) {
+					return fs.SkipAll
+				}
+				return err
+			}
+			if !d.IsDir() {
+				candidates = append(candidates, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var noteDirs []string
+	for _, id := range sel.NoteIDs {
+		noteDirs = append(noteDirs, path.Join(sel.Platform, "notes", id)+"/")
+	}
+	out := make([]exportFile, 0, len(candidates))
+	add := func(full string, filter bool) error {
+		info, err := os.Stat(full)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !info.Mode().IsRegular() || strings.HasSuffix(info.Name(), ".tmp") || strings.HasSuffix(info.Name(), ".part") {
+			return nil
+		}
+		rel, err := filepath.Rel(dataDir, full)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if filter {
+			if !strings.HasPrefix(rel, sel.Platform+"/") || !hasAnyPrefix(rel, noteDirs) || internalDataPath(dataDir, full) {
+				return nil
+			}
+			mod := info.ModTime().Unix()
+			if (sel.Since > 0 && mod < sel.Since) || (sel.Until > 0 && mod > sel.Until) {
+				return nil
+			}
+		}
+		out = append(out, exportFile{Path: rel, Size: info.Size(), ModifiedAt: info.ModTime().Unix(), full: full, modTime: info.ModTime()})
+		return nil
+	}
+	for _, full := range candidates {
+		if err := add(full, true); err != nil {
+			return nil, err
+		}
+	}
+	if sel.TaskID != "" && len(out) > 0 {
+		entries, err := os.ReadDir(filepath.Join(tasksDir(dataDir), sel.TaskID))
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if err := add(filepath.Join(tasksDir(dataDir), sel.TaskID, e.Name()), false); err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, nil
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// exportKey identifies the archive of a selection: it changes whenever a
+// selected file does.
+func exportKey(sel exportSelection, files []exportFile) string {
+	h := sha256.New()
+	b, _ := json.Marshal(sel)
+	h.Write(b)
+	for _, f := range files {
+		fmt.Fprintf(h, "\n%s\x00%d\x00%d", f.Path, f.Size, f.modTime.UnixNano())
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+func exportFilename(sel exportSelection, key string) string {
+	name := sel.Platform
+	switch {
+	case sel.TaskID != "":
+		name += "_task_" + sel.TaskID
+	case len(sel.NoteIDs) == 1:
+		name += "_note_" + sel.NoteIDs[0]
+	case len(sel.NoteIDs) > 1:
+		name += fmt.Sprintf("_%d_notes", len(sel.NoteIDs))
+	}
+	name = strings.Map(func(r rune) rune {
+		if r == '"' || r < ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, name)
+	return name + "_" + key[:8] + ".zip"
+}
+
+// storedExts are not compressed again.
+var storedExts = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true, ".heic": true,
+	".mp4": true, ".flv": true, ".m4s": true, ".mov": true, ".webm": true, ".mp3": true, ".m4a": true,
+	".zip": true, ".gz": true, ".xlsx": true,
+}
+
+// writeExportArchive writes the files, the summaries of their notes under
+// summary/ and manifest.json as a zip. Entry times are the files' own, so the same files
+// give the same bytes.
+func writeExportArchive(w io.Writer, sel exportSelection, files []exportFile) error {
+	zw := zip.NewWriter(w)
+	var newest time.Time
+	var total int64
+	for i := range files {
+		f := &files[i]
+		method := zip.Deflate
+		if storedExts[strings.ToLower(path.Ext(f.Path))] {
+			method = zip.Store
+		}
+		src, err := os.Open(f.full)
+		if err != nil {
+			return err
+		}
+		entry, err := zw.CreateHeader(&zip.FileHeader{Name: f.Path, Method: method, Modified: f.modTime})
+		if err != nil {
+			_ = src.Close()
+			return err
+		}
+		// the file may be growing; export the size that was selected
+		h := sha256.New()
+		n, err := io.Copy(io.MultiWriter(entry, h), io.LimitReader(src, f.Size))
+		_ = src.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Path, err)
+		}
+		f.Size, f.SHA256 = n, hex.EncodeToString(h.Sum(nil))
+		total += n
+		if f.modTime.After(newest) {
+			newest = f.modTime
+		}
+	}
+
+	var extra []exportFile
+	if noteIDs, ok := summaryNoteIDs(sel, files); sel.Summaries && ok {
+		for _, kind := range []string{store.QueryNotes, store.QueryComments} {
+			var buf bytes.Buffer
+			buf.WriteString("\xEF\xBB\xBF")
+			if _, err := store.WriteSummaryCSV(&buf, kind, sel.Platform, noteIDs); err != nil {
+				logger.Warn("export summary failed", "kind", kind, "err", err)
+				continue
+			}
+			name := "summary/" + kind + ".csv"
+			if err := writeZipEntry(zw, name, newest, buf.Bytes()); err != nil {
+				return err
+			}
+			sum := sha256.Sum256(buf.Bytes())
+			extra = append(extra, exportFile{Path: name, Size: int64(buf.Len()), ModifiedAt: newest.Unix(), SHA256: hex.EncodeToString(sum[:])})
+		}
+	}
+	if sel.Manifest {
+		m := exportManifest{Selection: sel, Files: append(files[:len(files):len(files)], extra...), FileCount: len(files), TotalSize: total}
+		b, err := json.MarshalIndent(m, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeZipEntry(zw, "manifest.json", newest, append(b, '\n')); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// summaryNoteIDs returns the notes the summaries cover: nil (every note of
+// the platform) for an unfiltered export, else the notes with a
+// <platform>/notes/<id>/ directory among the files. ok is false when a
+// filtered export holds no such note.
+func summaryNoteIDs(sel exportSelection, files []exportFile) ([]string, bool) {
+	if sel.TaskID == "" && len(sel.NoteIDs) == 0 && sel.Since == 0 && sel.Until == 0 {
+		return nil, true
+	}
+	var ids []string
+	seen := map[string]bool{}
+	for _, f := range files {
+		rest, ok := strings.CutPrefix(f.Path, sel.Platform+"/notes/")
+		id, _, inDir := strings.Cut(rest, "/")
+		if !ok || !inDir || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids, len(ids) > 0
+}
+
+func writeZipEntry(zw *zip.Writer, name string, modified time.Time, b []byte) error {
+	entry, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	_, err = entry.Write(b)
+	return err
+}
+
+// exportCache builds archives into a directory and serves them from there,
+// one build per key at a time.
+type exportCache struct {
+	mu       sync.Mutex
+	building map[string]chan struct{}
+}
+
+func newExportCache() *exportCache {
+	return &exportCache{building: map[string]chan struct{}{}}
+}
+
+// serve answers with the archive key from dir, building it with build
+// first if needed. A plain GET streams the archive while it is built; HEAD
+// and Range requests wait for the build and are then served from the file.
+func (c *exportCache) serve(w http.ResponseWriter, r *http.Request, dir string, key string, build func(io.Writer) error) {
+	file := filepath.Join(dir, key+".zip")
+	for {
+		c.mu.Lock()
+		done, busy := c.building[key]
+		if !busy {
+			if info, err := os.Stat(file); err == nil && time.Since(info.ModTime()) < exportCacheTTL {
+				c.mu.Unlock()
+				serveExportFile(w, r, file)
+				return
+			}
+			done = make(chan struct{})
+			c.building[key] = done
+			c.mu.Unlock()
+			break
+		}
+		c.mu.Unlock()
+		select {
+		case <-done:
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	stream := r.Method == http.MethodGet && r.Header.Get("Range") == ""
+	err := c.build(dir, file, key, build, w, stream)
+	if err != nil {
+		logger.Warn("export failed", "export", key, "err", err)
+		if !stream {
+			w.Header().Del("ETag")
+			w.Header().Del("Content-Disposition")
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		}
+		return
+	}
+	if !stream {
+		serveExportFile(w, r, file)
+	}
+}
+
+func (c *exportCache) build(dir, file, key string, build func(io.Writer) error, w http.ResponseWriter, stream bool) error {
+	defer func() {
+		c.mu.Lock()
+		close(c.building[key])
+		delete(c.building, key)
+		c.mu.Unlock()
+	}()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	part := file + ".part"
+	f, err := os.Create(part)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(part)
+
+	bw := bufio.NewWriterSize(f, 256*1024)
+	tw := &exportWriter{file: bw}
+	if stream {
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.WriteHeader(http.StatusOK)
+		tw.client = w
+	}
+	// the build goes on when the client leaves, so it can resume
+	err = build(tw)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(part, file); err != nil {
+		return err
+	}
+	pruneExports(dir, file)
+	return nil
+}
+
+// exportWriter writes to the cache file and, until the client goes away,
+// to the response.
+type exportWriter struct {
+	file      io.Writer
+	client    io.Writer
+	clientErr error
+}
+
+func (w *exportWriter) Write(p []byte) (int, error) {
+	n, err := w.file.Write(p)
+	if err != nil {
+		return n, err
+	}
+	if w.client != nil && w.clientErr == nil {
+		_, w.clientErr = w.client.Write(p)
+	}
+	return n, nil
+}
+
+func serveExportFile(w http.ResponseWriter, r *http.Request, file string) {
+	f, err := os.Open(file)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+	// no Last-Modified: If-Range must use the ETag
+	http.ServeContent(w, r, "", time.Time{}, f)
+}
+
+// pruneExports removes archives older than exportCacheTTL, then the oldest
+// others until the cache fits exportCacheMaxBytes. keep, the archive just
+// built, is left alone.
+func pruneExports(dir string, keep string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	var cached []fs.FileInfo
+	var total int64
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".zip") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		full := filepath.Join(dir, e.Name())
+		if full != keep && time.Since(info.ModTime()) > exportCacheTTL {
+			_ = os.Remove(full)
+			continue
+		}
+		cached = append(cached, info)
+		total += info.Size()
+	}
+	sort.Slice(cached, func(i, j int) bool { return cached[i].ModTime().Before(cached[j].ModTime()) })
+	for _, info := range cached {
+		if total <= exportCacheMaxBytes {
+			break
+		}
+		full := filepath.Join(dir, info.Name())
+		if full == keep {
+			continue
+		}
+		if os.Remove(full) == nil {
+			total -= info.Size()
+		}
+	}
+}
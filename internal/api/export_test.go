@@ -0,0 +1,215 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDataExport(t *testing.T) {
+	dataDir := t.TempDir()
+	oldCfg := config.AppConfig
+	config.AppConfig = config.Config{DataDir: dataDir, Platform: "xhs"}
+	t.Cleanup(func() { config.AppConfig = oldCfg })
+
+	files := map[string]string{
+		"xhs/notes/n1/note.json":      `{"note_id":"n1","title":"hello","interact_info":{"liked_count":"12"}}`,
+		"xhs/notes/n1/comments.jsonl": "{\"comment_id\":\"c1\"}\n",
+		"xhs/notes/n1/media/1.jpg":    "\xff\xd8jpeg",
+		"xhs/notes/n2/note.json":      `{"note_id":"n2","title":"other"}`,
+		"xhs/comments.jsonl":          "{\"Platform\":\"xhs\",\"NoteID\":\"n1\",\"CommentID\":\"c1\",\"Content\":\"hi\"}\n{\"Platform\":\"xhs\",\"NoteID\":\"n2\",\"CommentID\":\"c2\",\"Content\":\"yo\"}\n",
+		"douyin/notes/a1/note.json":   `{"aweme_id":"a1"}`,
+	}
+	for name, content := range files {
+		p := filepath.Join(dataDir, filepath.FromSlash(name))
+		_ = os.MkdirAll(filepath.Dir(p), 0755)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv := NewServer(NewTaskManagerWithRunner(func(ctx context.Context) (crawler.Result, error) { return crawler.Result{}, nil }))
+	get := func(target string, header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		for k, v := range header {
+			r.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, r)
+		return w
+	}
+
+	w := get("/api/data/export?note_id=n1", nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/zip" || w.Header().Get("ETag") == "" {
+		t.Fatalf("export code=%d headers=%v body=%s", w.Code, w.Header(), w.Body.String())
+	}
+	full := w.Body.Bytes()
+	entries := readZip(t, full)
+	var names []string
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	want := "manifest.json,summary/comments.csv,summary/notes.csv,xhs/notes/n1/comments.jsonl,xhs/notes/n1/media/1.jpg,xhs/notes/n1/note.json"
+	if strings.Join(names, ",") != want {
+		t.Fatalf("entries %v", names)
+	}
+	if !strings.Contains(entries["summary/notes.csv"], "xhs,n1,") || strings.Contains(entries["summary/notes.csv"], "n2") ||
+		!strings.Contains(entries["summary/comments.csv"], "xhs,c1,n1") || strings.Contains(entries["summary/comments.csv"], "c2") {
+		t.Fatalf("summaries:\n%s\n%s", entries["summary/notes.csv"], entries["summary/comments.csv"])
+	}
+	var m exportManifest
+	if err := json.Unmarshal([]byte(entries["manifest.json"]), &m); err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	if m.FileCount != 3 || len(m.Files) != 5 || m.Selection.Platform != "xhs" {
+		t.Fatalf("manifest %+v", m)
+	}
+	for _, f := range m.Files {
+		sum := sha256.Sum256([]byte(entries[f.Path]))
+		if f.SHA256 != hex.EncodeToString(sum[:]) || f.Size != int64(len(entries[f.Path])) {
+			t.Fatalf("checksum of %s: %+v", f.Path, f)
+		}
+	}
+
+	// a resumed download continues with the same bytes
+	etag := w.Header().Get("ETag")
+	w = get("/api/data/export?note_id=n1", http.Header{"Range": {"bytes=100-"}, "If-Range": {etag}})
+	if w.Code != http.StatusPartialContent || !bytes.Equal(w.Body.Bytes(), full[100:]) {
+		t.Fatalf("range code=%d len=%d want %d", w.Code, w.Body.Len(), len(full)-100)
+	}
+	w = get("/api/data/export?note_id=n1", http.Header{"Range": {"bytes=100-"}, "If-Range": {`"stale"`}})
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), full) {
+		t.Fatalf("stale If-Range code=%d", w.Code)
+	}
+
+	// a task exports the files it wrote plus its record
+	rec := TaskRecord{ID: "20240101-000000000-abcdef", State: "finished", Config: map[string]any{"PLATFORM": "xhs"},
+		Files: []TaskOutputFile{{Path: "xhs/notes/n2/note.json"}, {Path: "../outside"}}}
+	if err := saveTaskRecord(dataDir, rec); err != nil {
+		t.Fatal(err)
+	}
+	w = get("/data/export?task_id="+rec.ID+"&manifest=false&summaries=false", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("task export code=%d body=%s", w.Code, w.Body.String())
+	}
+	names = names[:0]
+	for name := range readZip(t, w.Body.Bytes()) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "tasks/"+rec.ID+"/task.json,xhs/notes/n2/note.json" {
+		t.Fatalf("task entries %v", names)
+	}
+
+	// the summaries cover the task's notes only
+	entries = readZip(t, get("/data/export?task_id="+rec.ID+"&manifest=false", nil).Body.Bytes())
+	if !strings.Contains(entries["summary/notes.csv"], "xhs,n2,") || strings.Contains(entries["summary/notes.csv"], "n1") ||
+		!strings.Contains(entries["summary/comments.csv"], "xhs,c2,n2") || strings.Contains(entries["summary/comments.csv"], "c1") {
+		t.Fatalf("task summaries:\n%s\n%s", entries["summary/notes.csv"], entries["summary/comments.csv"])
+	}
+
+	for target, code := range map[string]int{
+		"/data/export?platform=douyin&since=2999-01-01": http.StatusNotFound,
+		"/data/export?note_id=..":                       http.StatusBadRequest,
+		"/data/export?platform=exports":                 http.StatusBadRequest,
+		"/data/export?until=yesterday":                  http.StatusBadRequest,
+		"/data/export?task_id=nope":                     http.StatusNotFound,
+	} {
+		if w := get(target, nil); w.Code != code {
+			t.Fatalf("%s code=%d want %d body=%s", target, w.Code, code, w.Body.String())
+		}
+	}
+}
+
+func readZip(t *testing.T, b []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatalf("zip: %v", err)
+	}
+	out := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		out[f.Name] = string(data)
+	}
+	return out
+}
+
+func TestPruneExports(t *testing.T) {
+	dir := t.TempDir()
+	oldMax := exportCacheMaxBytes
+	exportCacheMaxBytes = 250
+	t.Cleanup(func() { exportCacheMaxBytes = oldMax })
+
+	now := time.Now()
+	for i, name := range []string{"expired", "old", "mid", "new", "built"} {
+		p := filepath.Join(dir, name+".zip")
+		if err := os.WriteFile(p, bytes.Repeat([]byte("x"), 100), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mod := now.Add(time.Duration(i-4) * time.Minute)
+		if name == "expired" {
+			mod = now.Add(-exportCacheTTL - time.Hour)
+		}
+		if name == "built" {
+			// the archive just built stays even if it is not the newest
+			mod = now.Add(-2 * time.Hour)
+		}
+		if err := os.Chtimes(p, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pruneExports(dir, filepath.Join(dir, "built.zip"))
+
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if strings.Join(names, ",") != "built.zip,new.zip" {
+		t.Fatalf("kept %v", names)
+	}
+}
+
+func TestSelectExportFilesSkipsInternalFiles(t *testing.T) {
+	dataDir := t.TempDir()
+	oldCfg := config.AppConfig
+	config.AppConfig = config.Config{DataDir: dataDir, APIAuditLog: filepath.Join(dataDir, "xhs", "audit.jsonl")}
+	t.Cleanup(func() { config.AppConfig = oldCfg })
+
+	for _, rel := range []string{"xhs/notes/n1/note.json", "xhs/audit.jsonl"} {
+		full := filepath.Join(dataDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(full, []byte("{}\n"), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	files, err := selectExportFiles(dataDir, exportSelection{Platform: "xhs"}, nil)
+	if err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "xhs/notes/n1/note.json" {
+		t.Fatalf("unexpected export files: %+v", files)
+	}
+}
@@ -0,0 +1,596 @@
+package api
+
+import (
+	"encoding/json"
+	"media-crawler-go/internal/crawler"
+	"media-crawler-go/internal/logger"
+	"media-crawler-go/internal/store"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The OpenAPI document is assembled from the Go types the handlers encode
+// (reflected into component schemas) and from apiOperations, which lists
+// every registered route. TestOpenAPICoversRoutes keeps the two in sync, and
+// pkg/client's types are generated from the components with
+// cmd/openapi-gen.
+
+// apiModels are the Go types exposed as named component schemas. Struct
+// types reached from them must be listed too.
+var apiModels = []struct {
+	name string
+	v    any
+}{
+	{"RunRequest", RunRequest{}},
+	{"Status", Status{}},
+	{"Progress", crawler.Progress{}},
+	{"Result", crawler.Result{}},
+	{"TaskRecord", TaskRecord{}},
+	{"TaskOutputFile", TaskOutputFile{}},
+	{"AuditEntry", AuditEntry{}},
+	{"LogEvent", logger.Event{}},
+	{"DataFile", dataFileInfo{}},
+	{"QueryResult", store.QueryResult{}},
+	{"SearchResult", store.SearchResult{}},
+	{"SearchHit", store.SearchHit{}},
+	{"CommentThread", store.CommentThread{}},
+	{"UnifiedComment", store.UnifiedComment{}},
+	{"PlatformInfo", platformInfo{}},
+	{"EnvReport", envReport{}},
+	{"SMSNotification", smsNotification{}},
+	{"PythonCrawlerStartRequest", pythonCrawlerStartRequest{}},
+}
+
+// Schemas of responses that handlers build as maps.
+var apiResponseSchemas = map[string]any{
+	"Error":  objectSchema(props{"error": strSchema}, "error"),
+	"Detail": objectSchema(props{"detail": strSchema}, "detail"),
+	"Health": objectSchema(props{"ok": boolSchema}, "ok"),
+	"StopResponse": objectSchema(props{
+		"stopped": boolSchema,
+	}, "stopped"),
+	"LogsResponse": objectSchema(props{"logs": arrayOf(refSchema("LogEvent"))}, "logs"),
+	"TaskHistory": objectSchema(props{
+		"total":  intSchema,
+		"limit":  intSchema,
+		"offset": intSchema,
+		"items":  arrayOf(refSchema("TaskRecord")),
+	}, "total", "limit", "offset", "items"),
+	"TaskLogs": objectSchema(props{
+		"total":       intSchema,
+		"offset":      intSchema,
+		"next_offset": intSchema,
+		"logs":        arrayOf(refSchema("LogEvent")),
+	}, "total", "offset", "logs"),
+	"AuditPage": objectSchema(props{
+		"total":  intSchema,
+		"limit":  intSchema,
+		"offset": intSchema,
+		"items":  arrayOf(refSchema("AuditEntry")),
+	}, "total", "limit", "offset", "items"),
+	"WhoAmI": objectSchema(props{
+		"name":   strSchema,
+		"role":   enumSchema("viewer", "operator", "admin"),
+		"method": strSchema,
+	}, "name", "role", "method"),
+	"DataFiles":   objectSchema(props{"files": arrayOf(refSchema("DataFile"))}, "files"),
+	"DataPreview": objectSchema(props{"data": arrayOf(anySchema), "total": intSchema, "columns": arrayOf(strSchema)}, "data", "total"),
+	"DataStats": objectSchema(props{
+		"total_files":  intSchema,
+		"total_size":   int64Schema,
+		"by_platform":  mapOf(intSchema),
+		"by_type":      mapOf(intSchema),
+		"generated_at": int64Schema,
+	}, "total_files", "total_size", "by_platform", "by_type"),
+	"CommentThreads": objectSchema(props{
+		"platform": strSchema,
+		"note_id":  strSchema,
+		"total":    intSchema,
+		"threads":  arrayOf(refSchema("CommentThread")),
+	}, "platform", "note_id", "total", "threads"),
+	"Platforms": objectSchema(props{"platforms": arrayOf(refSchema("PlatformInfo"))}, "platforms"),
+	"Options":   mapOf(anySchema),
+	"OKStatus":  objectSchema(props{"status": strSchema}, "status"),
+	"PythonMessage": objectSchema(props{
+		"status":  strSchema,
+		"message": strSchema,
+	}, "status", "message"),
+	"PythonStatus": objectSchema(props{
+		"status":        enumSchema("idle", "running", "stopping", "error"),
+		"platform":      strSchema,
+		"crawler_type":  strSchema,
+		"started_at":    strSchema,
+		"error_message": strSchema,
+	}, "status", "platform", "crawler_type"),
+	"PythonLogEntry": objectSchema(props{
+		"id":        intSchema,
+		"timestamp": strSchema,
+		"level":     strSchema,
+		"message":   strSchema,
+	}, "id", "timestamp", "level", "message"),
+	"PythonLogs": objectSchema(props{"logs": arrayOf(refSchema("PythonLogEntry"))}, "logs"),
+	"PythonEnvCheck": objectSchema(props{
+		"success": boolSchema,
+		"message": strSchema,
+		"output":  strSchema,
+		"error":   strSchema,
+	}, "success", "message"),
+	"PythonOption": objectSchema(props{
+		"value": strSchema,
+		"label": strSchema,
+		"icon":  strSchema,
+	}, "value", "label"),
+	"PythonPlatforms": objectSchema(props{"platforms": arrayOf(refSchema("PythonOption"))}, "platforms"),
+	"PythonOptions": objectSchema(props{
+		"login_types":   arrayOf(refSchema("PythonOption")),
+		"crawler_types": arrayOf(refSchema("PythonOption")),
+		"save_options":  arrayOf(refSchema("PythonOption")),
+	}, "login_types", "crawler_types", "save_options"),
+}
+
+type props map[string]any
+
+var (
+	strSchema   = map[string]any{"type": "string"}
+	boolSchema  = map[string]any{"type": "boolean"}
+	intSchema   = map[string]any{"type": "integer"}
+	int64Schema = map[string]any{"type": "integer", "format": "int64"}
+	anySchema   = map[string]any{}
+)
+
+func objectSchema(p props, required ...string) map[string]any {
+	out := map[string]any{"type": "object", "properties": map[string]any(p)}
+	if len(required) > 0 {
+		out["required"] = required
+	}
+	return out
+}
+
+func refSchema(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+func arrayOf(items map[string]any) map[string]any {
+	return map[string]any{"type": "array", "items": items}
+}
+
+func mapOf(values map[string]any) map[string]any {
+	return map[string]any{"type": "object", "additionalProperties": values}
+}
+
+func enumSchema(values ...string) map[string]any {
+	return map[string]any{"type": "string", "enum": values}
+}
+
+// apiParam is a path or query parameter.
+type apiParam struct {
+	name     string
+	in       string
+	schema   map[string]any
+	desc     string
+	required bool
+}
+
+func query(name string, schema map[string]any, desc string) apiParam {
+	return apiParam{name: name, in: "query", schema: schema, desc: desc}
+}
+
+func pathParam(name string, desc string) apiParam {
+	return apiParam{name: name, in: "path", schema: strSchema, desc: desc, required: true}
+}
+
+// apiOperation documents one handler. aliases are further paths served by
+// the same handler; they share the operation without its operationId.
+type apiOperation struct {
+	method  string
+	path    string
+	aliases []string
+	id      string
+	summary string
+	tag     string
+	role    Role
+	params  []apiParam
+	body    string
+	status  int
+	// response is a component schema name, or a media type for responses
+	// that are not JSON.
+	response string
+	errors   []int
+	// errorSchema is the component describing error bodies: Error, or
+	// Detail for the Python-compatible routes.
+	errorSchema string
+	// ranges marks responses that honour Range requests.
+	ranges bool
+}
+
+var (
+	limitParam  = query("limit", intSchema, "page size")
+	offsetParam = query("offset", intSchema, "number of items to skip")
+)
+
+var storeQueryParams = []apiParam{
+	query("platform", strSchema, "platform key, e.g. xhs"),
+	query("note_id", strSchema, "note id"),
+	query("creator", strSchema, "creator id or exact name"),
+	query("keyword", strSchema, "case-insensitive substring of title/content/name/description"),
+	query("since", strSchema, "unix seconds or ms, RFC 3339 or YYYY-MM-DD"),
+	query("until", strSchema, "unix seconds or ms, RFC 3339 or YYYY-MM-DD"),
+	query("sort", strSchema, "field, or -field for descending"),
+	query("limit", intSchema, "page size (default 20, max 200)"),
+	query("cursor", strSchema, "next_cursor of the previous page"),
+	query("fields", strSchema, "comma-separated fields to return; data is the raw record"),
+}
+
+var apiOperations = []apiOperation{
+	{method: "GET", path: "/healthz", id: "healthz", summary: "Liveness check", tag: "system", role: RolePublic, response: "Health"},
+	{method: "GET", path: "/api/health", id: "pythonHealth", summary: "Liveness check (Python-compatible)", tag: "python", role: RolePublic, response: "OKStatus"},
+	{method: "GET", path: "/openapi.json", aliases: []string{"/api/openapi.json"}, id: "openapi", summary: "This OpenAPI document", tag: "system", role: RolePublic, response: "application/json"},
+	{method: "GET", path: "/metrics", id: "metrics", summary: "Prometheus metrics", tag: "system", role: RoleViewer, response: "text/plain"},
+	{method: "GET", path: "/api/auth/whoami", id: "whoAmI", summary: "The authenticated caller", tag: "auth", role: RoleViewer, response: "WhoAmI"},
+	{method: "GET", path: "/api/audit", id: "listAudit", summary: "Audit log entries, newest first", tag: "auth", role: RoleAdmin,
+		params: []apiParam{limitParam, offsetParam}, response: "AuditPage", errors: []int{500}},
+
+	{method: "GET", path: "/status", id: "getStatus", summary: "Task status and live progress", tag: "task", role: RoleViewer, response: "Status"},
+	{method: "POST", path: "/run", id: "run", summary: "Start a task", tag: "task", role: RoleOperator, body: "RunRequest",
+		status: http.StatusAccepted, response: "Status", errors: []int{400, 403, 409, 500, 503}},
+	{method: "POST", path: "/stop", id: "stop", summary: "Stop the running task", tag: "task", role: RoleOperator, status: http.StatusAccepted, response: "StopResponse"},
+	{method: "POST", path: "/sms", aliases: []string{"/api/sms"}, id: "submitSMS", summary: "Submit an SMS verification code for phone login", tag: "task", role: RoleOperator,
+		body: "SMSNotification", response: "OKStatus"},
+	{method: "GET", path: "/logs", aliases: []string{"/crawler/logs", "/api/logs"}, id: "recentLogs", summary: "Recent log events", tag: "task", role: RoleViewer,
+		params: []apiParam{query("limit", intSchema, "number of events (default 100, max 2000)")}, response: "LogsResponse"},
+	{method: "GET", path: "/ws/logs", aliases: []string{"/api/ws/logs"}, id: "streamLogs", summary: "WebSocket stream of log events (JSON text frames)", tag: "task", role: RoleViewer,
+		status: http.StatusSwitchingProtocols},
+	{method: "GET", path: "/ws/status", aliases: []string{"/api/ws/status"}, id: "streamStatusWS", summary: "WebSocket stream of Status JSON", tag: "task", role: RoleViewer,
+		params: []apiParam{query("interval_ms", intSchema, "maximum time between messages (100-5000)")}, status: http.StatusSwitchingProtocols},
+	{method: "GET", path: "/sse/status", aliases: []string{"/api/sse/status"}, id: "streamStatusSSE", summary: "Server-Sent Events stream of Status JSON (event: status)", tag: "task", role: RoleViewer,
+		params: []apiParam{query("interval_ms", intSchema, "maximum time between events (100-5000)")}, response: "text/event-stream"},
+	{method: "GET", path: "/tasks/history", aliases: []string{"/api/tasks/history"}, id: "listTasks", summary: "Recorded tasks, newest first", tag: "task", role: RoleViewer,
+		params: []apiParam{limitParam, offsetParam}, response: "TaskHistory", errors: []int{500}},
+	{method: "GET", path: "/tasks/{id}", aliases: []string{"/api/tasks/{id}"}, id: "getTask", summary: "One recorded task", tag: "task", role: RoleViewer,
+		params: []apiParam{pathParam("id", "task id")}, response: "TaskRecord", errors: []int{404, 500}},
+	{method: "GET", path: "/tasks/{id}/logs", aliases: []string{"/api/tasks/{id}/logs"}, id: "getTaskLogs", summary: "Log lines of a task, oldest first", tag: "task", role: RoleViewer,
+		params: []apiParam{pathParam("id", "task id"), limitParam, offsetParam}, response: "TaskLogs", errors: []int{404, 500}},
+
+	{method: "POST", path: "/api/crawler/start", aliases: []string{"/crawler/start"}, id: "pythonCrawlerStart", summary: "Start a task (Python-compatible)", tag: "python", role: RoleOperator,
+		body: "PythonCrawlerStartRequest", response: "PythonMessage", errors: []int{400, 403, 500, 503}, errorSchema: "Detail"},
+	{method: "POST", path: "/api/crawler/stop", aliases: []string{"/crawler/stop"}, id: "pythonCrawlerStop", summary: "Stop the running task (Python-compatible)", tag: "python", role: RoleOperator,
+		response: "PythonMessage", errors: []int{400}, errorSchema: "Detail"},
+	{method: "GET", path: "/api/crawler/status", aliases: []string{"/crawler/status"}, id: "pythonCrawlerStatus", summary: "Task status (Python-compatible)", tag: "python", role: RoleViewer, response: "PythonStatus"},
+	{method: "GET", path: "/api/crawler/logs", id: "pythonCrawlerLogs", summary: "Recent log events (Python-compatible)", tag: "python", role: RoleViewer,
+		params: []apiParam{query("limit", intSchema, "number of events (default 100, max 2000)")}, response: "PythonLogs"},
+	{method: "GET", path: "/api/env/check", id: "pythonEnvCheck", summary: "Environment check (Python-compatible)", tag: "python", role: RoleViewer, response: "PythonEnvCheck"},
+	{method: "GET", path: "/api/config/platforms", id: "pythonConfigPlatforms", summary: "Platforms (Python-compatible)", tag: "python", role: RoleViewer, response: "PythonPlatforms"},
+	{method: "GET", path: "/api/config/options", id: "pythonConfigOptions", summary: "Option lists (Python-compatible)", tag: "python", role: RoleViewer, response: "PythonOptions"},
+	{method: "GET", path: "/config/platforms", id: "configPlatforms", summary: "Platforms and their crawler modes", tag: "config", role: RoleViewer, response: "Platforms"},
+	{method: "GET", path: "/config/options", id: "configOptions", summary: "Option lists, descriptions and current defaults", tag: "config", role: RoleViewer, response: "Options"},
+	{method: "GET", path: "/env/check", id: "envCheck", summary: "Environment check", tag: "config", role: RoleViewer, response: "EnvReport"},
+
+	{method: "GET", path: "/data/files", aliases: []string{"/api/data/files"}, id: "listDataFiles", summary: "Data files under DATA_DIR", tag: "data", role: RoleViewer,
+		params:   []apiParam{query("platform", strSchema, "only files whose path contains the platform"), query("file_type", strSchema, "extension, e.g. jsonl")},
+		response: "DataFiles", errors: []int{500}},
+	{method: "GET", path: "/data/files/{path}", aliases: []string{"/api/data/files/{path}"}, id: "getDataFile", summary: "Preview a data file, or download it with preview=false", tag: "data", role: RoleViewer,
+		params:   []apiParam{pathParam("path", "file path relative to DATA_DIR"), query("preview", boolSchema, "default true"), query("limit", intSchema, "preview rows (default 100, max 1000)")},
+		response: "DataPreview", errors: []int{400, 403, 404, 500}},
+	{method: "GET", path: "/data/download/{path}", aliases: []string{"/api/data/download/{path}"}, id: "downloadDataFile", summary: "Download a data file", tag: "data", role: RoleViewer,
+		params: []apiParam{pathParam("path", "file path relative to DATA_DIR")}, response: "application/octet-stream", errors: []int{400, 403, 404, 500}},
+	{method: "GET", path: "/data/export", aliases: []string{"/api/data/export"}, id: "exportBundle", summary: "Download a zip of data files selected by platform, task, notes and modification time", tag: "data", role: RoleViewer,
+		params: []apiParam{
+			query("platform", strSchema, "default the task's platform, then PLATFORM"),
+			query("task_id", strSchema, "files written by this task, plus its record and log"),
+			{name: "note_id", in: "query", schema: arrayOf(strSchema), desc: "only these notes (repeated or comma separated)"},
+			query("since", strSchema, "modified at or after: unix seconds or ms, RFC 3339 or YYYY-MM-DD"),
+			query("until", strSchema, "modified at or before; a YYYY-MM-DD date includes the whole day"),
+			query("manifest", boolSchema, "add manifest.json with sha256 checksums (default true)"),
+			query("summaries", boolSchema, "add summary/notes.csv and summary/comments.csv (default true)"),
+		},
+		response: "application/zip", ranges: true, errors: []int{400, 404, 500}},
+	{method: "GET", path: "/data/stats", aliases: []string{"/api/data/stats"}, id: "dataStats", summary: "File counts and sizes under DATA_DIR", tag: "data", role: RoleViewer,
+		response: "DataStats", errors: []int{500}},
+	{method: "GET", path: "/data/wordcloud", aliases: []string{"/api/data/wordcloud"}, id: "wordcloud", summary: "Render a comment word cloud (X-Generated-File names the saved file)", tag: "data", role: RoleViewer,
+		params: []apiParam{
+			query("platform", strSchema, "default PLATFORM"),
+			query("note_id", strSchema, "only comments of this note"),
+			query("format", enumSchema("svg", "png"), "default svg"),
+			query("save", boolSchema, "save svg/png/word frequencies under DATA_DIR (default true)"),
+			query("cache", boolSchema, "serve unsaved renders from the cache (default true)"),
+			query("max_comments", intSchema, "default 5000"),
+			query("max_words", intSchema, "default 200"),
+			query("min_count", intSchema, "default 2"),
+			query("width", intSchema, "default 1200"),
+			query("height", intSchema, "default 800"),
+		},
+		response: "image/svg+xml", errors: []int{404, 500}},
+	{method: "GET", path: "/data/comments/thread", aliases: []string{"/api/data/comments/thread"}, id: "commentThread", summary: "Comments of a note nested by reply", tag: "data", role: RoleViewer,
+		params:   []apiParam{query("platform", strSchema, "default PLATFORM"), {name: "note_id", in: "query", schema: strSchema, required: true}},
+		response: "CommentThreads", errors: []int{400, 404, 500}},
+	{method: "GET", path: "/api/notes", id: "queryNotes", summary: "Query stored notes (also min_<count field> filters)", tag: "data", role: RoleViewer,
+		params: storeQueryParams, response: "QueryResult", errors: []int{400, 500}},
+	{method: "GET", path: "/api/comments", id: "queryComments", summary: "Query stored comments (also min_<count field> filters)", tag: "data", role: RoleViewer,
+		params: storeQueryParams, response: "QueryResult", errors: []int{400, 500}},
+	{method: "GET", path: "/api/creators", id: "queryCreators", summary: "Query stored creators (also min_<count field> filters)", tag: "data", role: RoleViewer,
+		params: storeQueryParams, response: "QueryResult", errors: []int{400, 500}},
+	{method: "GET", path: "/api/search", id: "search", summary: "Full-text search over notes and comments (sqlite backend)", tag: "data", role: RoleViewer,
+		params: []apiParam{
+			{name: "q", in: "query", schema: strSchema, required: true},
+			query("kind", enumSchema("notes", "comments"), "default both"),
+			query("platform", strSchema, ""),
+			limitParam, offsetParam,
+		},
+		response: "SearchResult", errors: []int{400, 500, 501}},
+
+	{method: "GET", path: "/assets/{path}", id: "webUIAsset", summary: "Web UI assets", tag: "webui", role: RolePublic, response: "application/octet-stream"},
+	{method: "GET", path: "/", id: "webUI", summary: "Web UI", tag: "webui", role: RolePublic, response: "text/html"},
+}
+
+var (
+	openAPIOnce sync.Once
+	openAPIDoc  map[string]any
+)
+
+// OpenAPIDocument returns the OpenAPI 3 description of the HTTP API.
+func OpenAPIDocument() map[string]any {
+	openAPIOnce.Do(func() { openAPIDoc = buildOpenAPI() })
+	return openAPIDoc
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, OpenAPIDocument())
+}
+
+func buildOpenAPI() map[string]any {
+	g := &schemaGen{schemas: map[string]any{}, names: map[reflect.Type]string{}}
+	for _, m := range apiModels {
+		g.names[reflect.TypeOf(m.v)] = m.name
+	}
+	for _, m := range apiModels {
+		g.define(reflect.TypeOf(m.v))
+	}
+	for name, s := range apiResponseSchemas {
+		g.schemas[name] = s
+	}
+
+	paths := map[string]any{}
+	for _, op := range apiOperations {
+		for i, p := range append([]string{op.path}, op.aliases...) {
+			item, _ := paths[p].(map[string]any)
+			if item == nil {
+				item = map[string]any{}
+				paths[p] = item
+			}
+			o := op.document()
+			if i > 0 {
+				delete(o, "operationId")
+			}
+			item[strings.ToLower(op.method)] = o
+		}
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "MediaCrawler Go API",
+			"version":     "1.0.0",
+			"description": "Task control, live status, task history and stored data of media-crawler-go. Routes need the role in x-required-role when API authentication is configured.",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": g.schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth":  map[string]any{"type": "http", "scheme": "bearer", "description": "API token or HS256 JWT"},
+				"basicAuth":   map[string]any{"type": "http", "scheme": "basic"},
+				"apiKey":      map[string]any{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+				"accessToken": map[string]any{"type": "apiKey", "in": "query", "name": "access_token"},
+			},
+		},
+	}
+}
+
+func (op apiOperation) document() map[string]any {
+	o := map[string]any{
+		"operationId":     op.id,
+		"summary":         op.summary,
+		"tags":            []string{op.tag},
+		"x-required-role": op.role.String(),
+	}
+	if op.role == RolePublic {
+		o["security"] = []any{}
+	} else {
+		o["security"] = []any{
+			map[string]any{"bearerAuth": []string{}},
+			map[string]any{"basicAuth": []string{}},
+			map[string]any{"apiKey": []string{}},
+			map[string]any{"accessToken": []string{}},
+		}
+	}
+	if len(op.params) > 0 {
+		params := make([]any, 0, len(op.params))
+		for _, p := range op.params {
+			m := map[string]any{"name": p.name, "in": p.in, "schema": p.schema}
+			if p.required {
+				m["required"] = true
+			}
+			if p.desc != "" {
+				m["description"] = p.desc
+			}
+			params = append(params, m)
+		}
+		o["parameters"] = params
+	}
+	if op.body != "" {
+		o["requestBody"] = map[string]any{
+			"required": true,
+			"content":  map[string]any{"application/json": map[string]any{"schema": refSchema(op.body)}},
+		}
+	}
+
+	status := op.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	ok := map[string]any{"description": http.StatusText(status)}
+	switch {
+	case op.response == "":
+	case strings.Contains(op.response, "/"):
+		content := map[string]any{op.response: map[string]any{"schema": map[string]any{"type": "string"}}}
+		if op.response == "application/zip" {
+			content[op.response] = map[string]any{"schema": map[string]any{"type": "string", "format": "binary"}}
+		}
+		if op.response == "image/svg+xml" {
+			content["image/png"] = map[string]any{"schema": map[string]any{"type": "string", "format": "binary"}}
+		}
+		if op.response == "application/json" {
+			content[op.response] = map[string]any{"schema": map[string]any{"type": "object"}}
+		}
+		ok["content"] = content
+	default:
+		ok["content"] = map[string]any{"application/json": map[string]any{"schema": refSchema(op.response)}}
+	}
+	responses := map[string]any{strconv.Itoa(status): ok}
+	if op.ranges {
+		responses["206"] = map[string]any{"description": http.StatusText(http.StatusPartialContent), "content": ok["content"]}
+		responses["416"] = map[string]any{"description": http.StatusText(http.StatusRequestedRangeNotSatisfiable)}
+	}
+
+	errSchema := op.errorSchema
+	if errSchema == "" {
+		errSchema = "Error"
+	}
+	for _, code := range op.errors {
+		responses[strconv.Itoa(code)] = errorResponse(code, refSchema(errSchema))
+	}
+	if op.role != RolePublic {
+		// guard answers with the native error shape; a route may also deny
+		// a request itself in its own shape.
+		denied := refSchema("Error")
+		if _, own := responses["403"]; own && errSchema != "Error" {
+			denied = map[string]any{"oneOf": []any{refSchema("Error"), refSchema(errSchema)}}
+		}
+		responses["401"] = errorResponse(http.StatusUnauthorized, refSchema("Error"))
+		responses["403"] = errorResponse(http.StatusForbidden, denied)
+	}
+	o["responses"] = responses
+	return o
+}
+
+func errorResponse(code int, schema map[string]any) map[string]any {
+	return map[string]any{
+		"description": http.StatusText(code),
+		"content":     map[string]any{"application/json": map[string]any{"schema": schema}},
+	}
+}
+
+// schemaGen reflects Go types into JSON schemas following encoding/json.
+type schemaGen struct {
+	schemas map[string]any
+	names   map[reflect.Type]string
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+func (g *schemaGen) define(t reflect.Type) {
+	name := g.names[t]
+	if _, done := g.schemas[name]; done {
+		return
+	}
+	g.schemas[name] = map[string]any{} // placeholder for recursive types
+	g.schemas[name] = g.structSchema(t)
+}
+
+func (g *schemaGen) structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			tag := f.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+				walk(f.Type)
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			s := g.schemaFor(f.Type)
+			properties[name] = s
+			if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Pointer {
+				required = append(required, name)
+			}
+		}
+	}
+	walk(t)
+	sort.Strings(required)
+	out := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		out["required"] = required
+	}
+	return out
+}
+
+func (g *schemaGen) schemaFor(t reflect.Type) map[string]any {
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	if t == rawType {
+		return map[string]any{}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := g.schemaFor(t.Elem())
+		if _, isRef := s["$ref"]; isRef {
+			return s
+		}
+		out := map[string]any{"nullable": true}
+		for k, v := range s {
+			out[k] = v
+		}
+		return out
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return arrayOf(g.schemaFor(t.Elem()))
+	case reflect.Map:
+		return mapOf(g.schemaFor(t.Elem()))
+	case reflect.Struct:
+		if name, ok := g.names[t]; ok {
+			g.define(t)
+			return refSchema(name)
+		}
+		return g.structSchema(t)
+	default:
+		return map[string]any{}
+	}
+}
+
+// openAPIPath turns a ServeMux pattern into its OpenAPI path: subtree
+// patterns ("/data/files/") take the rest of the path as {path}.
+func openAPIPath(pattern string) (method string, path string) {
+	method, path, _ = strings.Cut(pattern, " ")
+	if path != "/" && strings.HasSuffix(path, "/") {
+		path += "{path}"
+	}
+	return method, path
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	oldCfg := config.AppConfig
+	config.AppConfig = config.Config{DataDir: t.TempDir()}
+	t.Cleanup(func() { config.AppConfig = oldCfg })
+	srv := NewServer(NewTaskManagerWithRunner(func(ctx context.Context) (crawler.Result, error) {
+		return crawler.Result{}, nil
+	}))
+
+	rr := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("openapi code=%d", rr.Code)
+	}
+	var doc struct {
+		OpenAPI string                               `json:"openapi"`
+		Paths   map[string]map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Fatalf("unexpected openapi version %q", doc.OpenAPI)
+	}
+
+	documented := map[string]bool{}
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			key := strings.ToUpper(method) + " " + path
+			documented[key] = true
+			if _, ok := op["responses"]; !ok {
+				t.Errorf("%s has no responses", key)
+			}
+		}
+	}
+	for pattern, role := range srv.registered {
+		method, path := openAPIPath(pattern)
+		key := method + " " + path
+		if !documented[key] {
+			t.Errorf("route %q is not in the OpenAPI document", pattern)
+			continue
+		}
+		op := doc.Paths[path][strings.ToLower(method)]
+		if op["x-required-role"] != role.String() {
+			t.Errorf("%s: documented role %v, registered %s", key, op["x-required-role"], role)
+		}
+		delete(documented, key)
+	}
+	for key := range documented {
+		t.Errorf("%s is documented but not registered", key)
+	}
+
+	// every $ref must resolve
+	schemas := OpenAPIDocument()["components"].(map[string]any)["schemas"].(map[string]any)
+	b, _ := json.Marshal(OpenAPIDocument())
+	for _, part := range strings.Split(string(b), `"#/components/schemas/`)[1:] {
+		name, _, _ := strings.Cut(part, `"`)
+		if _, ok := schemas[name]; !ok {
+			t.Errorf("unresolved schema reference %q", name)
+		}
+	}
+}
@@ -174,12 +174,20 @@ func (s *Server) handleAPICrawlerStart(w http.ResponseWriter, r *http.Request) {
 	}
 
 	applyPythonSaveOption(&runReq, req.SaveOption)
+	auditSet(r, "request", redactRunRequest(runReq))
+	if role, what := runRequestRole(runReq); !requireRole(w, r, role, what, "detail") {
+		return
+	}
 
 	if err := s.manager.Run(runReq); err != nil {
 		if errors.Is(err, ErrTaskRunning) {
 			writeJSON(w, http.StatusBadRequest, map[string]any{"detail": "Crawler is already running"})
 			return
 		}
+		if errors.Is(err, ErrShuttingDown) {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]any{"detail": err.Error()})
+			return
+		}
 		var ve ValidationError
 		if errors.As(err, &ve) {
 			writeJSON(w, http.StatusBadRequest, map[string]any{"detail": err.Error()})
@@ -189,10 +197,12 @@ func (s *Server) handleAPICrawlerStart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	auditSet(r, "task_id", s.manager.Status().TaskID)
 	writeJSON(w, http.StatusOK, map[string]any{"status": "ok", "message": "Crawler started successfully"})
 }
 
 func (s *Server) handleAPICrawlerStop(w http.ResponseWriter, r *http.Request) {
+	auditSet(r, "task_id", s.manager.Status().TaskID)
 	if !s.manager.Stop() {
 		writeJSON(w, http.StatusBadRequest, map[string]any{"detail": "No crawler is running"})
 		return
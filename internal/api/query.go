@@ -0,0 +1,123 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"media-crawler-go/internal/store"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// queryParams are the query string keys that are not min_<field> bounds.
+var queryParams = map[string]bool{
+	"platform": true, "note_id": true, "creator": true, "keyword": true,
+	"since": true, "until": true, "sort": true, "cursor": true, "limit": true, "fields": true,
+}
+
+func (s *Server) handleQueryNotes(w http.ResponseWriter, r *http.Request) {
+	s.handleQuery(w, r, store.QueryNotes)
+}
+
+func (s *Server) handleQueryComments(w http.ResponseWriter, r *http.Request) {
+	s.handleQuery(w, r, store.QueryComments)
+}
+
+func (s *Server) handleQueryCreators(w http.ResponseWriter, r *http.Request) {
+	s.handleQuery(w, r, store.QueryCreators)
+}
+
+// handleQuery serves /api/notes, /api/comments and /api/creators: stored
+// records of the configured backend filtered, sorted and paged by the query
+// string (platform, note_id, creator, keyword, since, until, min_<field>,
+// sort, cursor, limit, fields).
+func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request, kind string) {
+	q, err := parseStoreQuery(kind, r.URL.Query())
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	res, err := store.RunQuery(q)
+	if err != nil {
+		if errors.Is(err, store.ErrInvalidQuery) {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error(), "fields": store.QueryFields(kind)})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func parseStoreQuery(kind string, v url.Values) (store.Query, error) {
+	q := store.Query{
+		Kind:     kind,
+		Platform: strings.TrimSpace(v.Get("platform")),
+		NoteID:   strings.TrimSpace(v.Get("note_id")),
+		Creator:  strings.TrimSpace(v.Get("creator")),
+		Keyword:  strings.TrimSpace(v.Get("keyword")),
+		Sort:     strings.TrimSpace(v.Get("sort")),
+		Cursor:   strings.TrimSpace(v.Get("cursor")),
+		Limit:    queryIntDefault(v, "limit", store.DefaultQueryLimit),
+	}
+	if q.Platform != "" && (!validPathSegment(q.Platform) || q.Platform == "exports" || q.Platform == "tasks") {
+		return q, fmt.Errorf("invalid platform %q", q.Platform)
+	}
+	if q.NoteID != "" && !validPathSegment(q.NoteID) {
+		return q, fmt.Errorf("invalid note_id %q", q.NoteID)
+	}
+	var err error
+	if q.Since, err = parseQueryTime(v.Get("since")); err != nil {
+		return q, fmt.Errorf("since: %w", err)
+	}
+	if q.Until, err = parseQueryTime(v.Get("until")); err != nil {
+		return q, fmt.Errorf("until: %w", err)
+	}
+	for _, f := range strings.Split(v.Get("fields"), ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			q.Fields = append(q.Fields, f)
+		}
+	}
+	for key := range v {
+		if queryParams[key] {
+			continue
+		}
+		name, ok := strings.CutPrefix(key, "min_")
+		if !ok {
+			return q, fmt.Errorf("unknown parameter %q", key)
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(v.Get(key)), 10, 64)
+		if err != nil {
+			return q, fmt.Errorf("%s: %w", key, err)
+		}
+		if q.Min == nil {
+			q.Min = make(map[string]int64)
+		}
+		q.Min[name] = n
+	}
+	return q, nil
+}
+
+// parseQueryTime accepts unix seconds or milliseconds, RFC 3339 times and
+// local YYYY-MM-DD dates.
+func parseQueryTime(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if n > 1e12 {
+			n /= 1000
+		}
+		return n, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.Unix(), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t.Unix(), nil
+	}
+	return 0, fmt.Errorf("invalid time %q", s)
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestQueryEndpoints(t *testing.T) {
+	dataDir := t.TempDir()
+	oldCfg := config.AppConfig
+	config.AppConfig = config.Config{DataDir: dataDir, Platform: "xhs"}
+	t.Cleanup(func() { config.AppConfig = oldCfg })
+
+	for id, body := range map[string]string{
+		"n1": `{"note_id":"n1","title":"go tips","user":{"user_id":"u1","nickname":"alice"},"interact_info":{"liked_count":"2000"}}`,
+		"n2": `{"note_id":"n2","title":"go again","user":{"user_id":"u1","nickname":"alice"},"interact_info":{"liked_count":"3000"}}`,
+		"n3": `{"note_id":"n3","title":"go","user":{"user_id":"u2","nickname":"bob"},"interact_info":{"liked_count":"10"}}`,
+	} {
+		dir := filepath.Join(dataDir, "xhs", "notes", id)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "note.json"), []byte(body), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	runFn := func(ctx context.Context) (crawler.Result, error) { return crawler.Result{}, nil }
+	srv := NewServer(NewTaskManagerWithRunner(runFn))
+	get := func(path string) (int, map[string]any) {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, r)
+		var body map[string]any
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body
+	}
+
+	code, body := get("/api/notes?platform=xhs&creator=u1&keyword=go&min_like_count=1000&sort=-like_count&limit=1&fields=note_id,like_count")
+	if code != http.StatusOK {
+		t.Fatalf("code=%d body=%v", code, body)
+	}
+	items, _ := body["items"].([]any)
+	if body["matched"] != float64(2) || len(items) != 1 || body["next_cursor"] == nil {
+		t.Fatalf("unexpected first page: %v", body)
+	}
+	if first := items[0].(map[string]any); first["note_id"] != "n2" || len(first) != 2 {
+		t.Fatalf("unexpected first item: %v", first)
+	}
+
+	code, body = get("/api/notes?platform=xhs&creator=u1&keyword=go&min_like_count=1000&sort=-like_count&limit=1&fields=note_id,like_count&cursor=" + body["next_cursor"].(string))
+	items, _ = body["items"].([]any)
+	if code != http.StatusOK || len(items) != 1 || items[0].(map[string]any)["note_id"] != "n1" || body["next_cursor"] != nil {
+		t.Fatalf("unexpected second page: code=%d body=%v", code, body)
+	}
+
+	if code, body := get("/api/comments?platform=weibo"); code != http.StatusOK || body["matched"] != float64(0) {
+		t.Fatalf("empty comments: code=%d body=%v", code, body)
+	}
+	for _, path := range []string{"/api/notes?sort=bogus", "/api/notes?since=yesterday", "/api/creators?color=red", "/api/notes?min_like_count=x", "/api/notes?platform=..", "/api/notes?platform=tasks", "/api/comments?platform=exports", "/api/notes?platform=xhs&note_id=../../tasks", "/api/comments?note_id=.."} {
+		if code, body := get(path); code != http.StatusBadRequest {
+			t.Fatalf("%s: code=%d body=%v", path, code, body)
+		}
+	}
+}
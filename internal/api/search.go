@@ -0,0 +1,39 @@
+package api
+
+import (
+	"errors"
+	"media-crawler-go/internal/store"
+	"net/http"
+	"strings"
+)
+
+// handleSearch serves /api/search: full-text search over stored note
+// titles/content and comment content (q, platform, kind=notes|comments,
+// limit, offset). Only the sqlite backend keeps a search index.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	v := r.URL.Query()
+	q := strings.TrimSpace(v.Get("q"))
+	if q == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "missing q"})
+		return
+	}
+	res, err := store.Search(r.Context(), store.SearchQuery{
+		Q:        q,
+		Kind:     strings.TrimSpace(v.Get("kind")),
+		Platform: strings.TrimSpace(v.Get("platform")),
+		Limit:    queryIntDefault(v, "limit", store.DefaultQueryLimit),
+		Offset:   queryIntDefault(v, "offset", 0),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrSearchUnavailable):
+			writeJSON(w, http.StatusNotImplemented, map[string]any{"error": err.Error()})
+		case errors.Is(err, store.ErrInvalidQuery):
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		default:
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"media-crawler-go/internal/store"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSearchEndpoint(t *testing.T) {
+	tmp := t.TempDir()
+	oldCfg := config.AppConfig
+	config.AppConfig = config.Config{DataDir: filepath.Join(tmp, "data"), Platform: "xhs"}
+	t.Cleanup(func() { config.AppConfig = oldCfg })
+
+	runFn := func(ctx context.Context) (crawler.Result, error) { return crawler.Result{}, nil }
+	srv := NewServer(NewTaskManagerWithRunner(runFn))
+	get := func(path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, r)
+		return w
+	}
+
+	if w := get("/api/search"); w.Code != http.StatusBadRequest {
+		t.Fatalf("missing q: code=%d", w.Code)
+	}
+	if w := get("/api/search?q=go"); w.Code != http.StatusNotImplemented {
+		t.Fatalf("file backend: code=%d body=%s", w.Code, w.Body.String())
+	}
+
+	config.AppConfig.StoreBackend = "sqlite"
+	config.AppConfig.SQLitePath = filepath.Join(tmp, "data", "media_crawler.db")
+	if err := store.SaveNoteDetail("n1", map[string]any{"note_id": "n1", "title": "周末露营装备清单"}); err != nil {
+		t.Fatalf("save note: %v", err)
+	}
+	w := get("/api/search?q=%E9%9C%B2%E8%90%A5&platform=xhs")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"id":"n1"`) || !strings.Contains(w.Body.String(), `<mark>露营</mark>`) {
+		t.Fatalf("search: code=%d body=%s", w.Code, w.Body.String())
+	}
+	if w := get("/api/search?q=go&kind=videos"); w.Code != http.StatusBadRequest {
+		t.Fatalf("bad kind: code=%d", w.Code)
+	}
+}
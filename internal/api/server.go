@@ -1,12 +1,16 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
 	"media-crawler-go/internal/cache"
 	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/logger"
+	"media-crawler-go/internal/metrics"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -14,65 +18,141 @@ type Server struct {
 	manager *TaskManager
 	mux     *http.ServeMux
 	cache   cache.Cache
+	auth    *Auth
+	audit   *auditLog
+	exports *exportCache
+	// registered records the patterns and their roles, so the
+	// OpenAPI document can be checked against them.
+	registered map[string]Role
+
+	// done is closed by Shutdown to end the status and log streams.
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
+// NewServer serves manager with the authentication configured in
+// config.AppConfig. An invalid auth config denies every guarded route;
+// callers that want to fail fast validate it with NewAuthFromConfig first.
 func NewServer(manager *TaskManager) *Server {
 	if manager == nil {
 		manager = NewTaskManager()
 	}
+	auth, err := NewAuthFromConfig(config.AppConfig)
+	if err != nil {
+		logger.Error("invalid api auth config, denying access", "err", err)
+		auth = NewAuth(RolePublic, denyAll{err})
+	}
 	s := &Server{
 		manager: manager,
 		mux:     http.NewServeMux(),
 		cache:   cache.NewFromConfig(config.AppConfig),
+		auth:    auth,
+		audit:   &auditLog{},
+		exports: newExportCache(),
+
+		registered: map[string]Role{},
+		done:       make(chan struct{}),
 	}
 	s.routes()
 	return s
 }
 
+// SetAuth replaces the authenticators of the server.
+func (s *Server) SetAuth(a *Auth) {
+	s.auth = a
+}
+
 func (s *Server) Handler() http.Handler {
 	return s.mux
 }
 
+// Shutdown stops the running task within ctx, ends the open status and log
+// streams so http.Server.Shutdown is not held up by them, and closes the
+// cache. The task error, if any, is ctx.Err() from TaskManager.Shutdown.
+func (s *Server) Shutdown(ctx context.Context) error {
+	err := s.manager.Shutdown(ctx)
+	s.closeOnce.Do(func() {
+		close(s.done)
+		if s.cache != nil {
+			if cerr := s.cache.Close(); cerr != nil {
+				logger.Warn("close cache failed", "err", cerr)
+			}
+		}
+	})
+	return err
+}
+
+// handle registers h for pattern, reachable by callers with at least min.
+func (s *Server) handle(pattern string, min Role, h http.Handler) {
+	s.registered[pattern] = min
+	s.mux.Handle(pattern, s.guard(min, h))
+}
+
+func (s *Server) handleFunc(pattern string, min Role, h http.HandlerFunc) {
+	s.handle(pattern, min, h)
+}
+
 func (s *Server) routes() {
-	s.mux.HandleFunc("GET /healthz", s.handleHealthz)
-	s.mux.HandleFunc("GET /api/health", s.handleAPIHealth)
-	s.mux.HandleFunc("GET /status", s.handleStatus)
-	s.mux.HandleFunc("POST /run", s.handleRun)
-	s.mux.HandleFunc("POST /stop", s.handleStop)
-	s.mux.HandleFunc("POST /sms", s.handleSMS)
-	s.mux.HandleFunc("POST /api/sms", s.handleSMS)
-	s.mux.HandleFunc("GET /logs", s.handleLogs)
-	s.mux.HandleFunc("GET /crawler/logs", s.handleLogs)
-	s.mux.HandleFunc("GET /api/logs", s.handleLogs)
-	s.mux.HandleFunc("GET /api/crawler/logs", s.handleAPICrawlerLogs)
-	s.mux.HandleFunc("POST /api/crawler/start", s.handleAPICrawlerStart)
-	s.mux.HandleFunc("POST /api/crawler/stop", s.handleAPICrawlerStop)
-	s.mux.HandleFunc("GET /api/crawler/status", s.handleAPICrawlerStatus)
-	s.mux.HandleFunc("POST /crawler/start", s.handleAPICrawlerStart)
-	s.mux.HandleFunc("POST /crawler/stop", s.handleAPICrawlerStop)
-	s.mux.HandleFunc("GET /crawler/status", s.handleAPICrawlerStatus)
-	s.mux.HandleFunc("GET /config/platforms", s.handleConfigPlatforms)
-	s.mux.HandleFunc("GET /config/options", s.handleConfigOptions)
-	s.mux.HandleFunc("GET /env/check", s.handleEnvCheck)
-	s.mux.HandleFunc("GET /api/env/check", s.handleAPIEnvCheck)
-	s.mux.HandleFunc("GET /api/config/platforms", s.handleAPIConfigPlatforms)
-	s.mux.HandleFunc("GET /api/config/options", s.handleAPIConfigOptions)
-	s.mux.HandleFunc("GET /data/files", s.handleDataFilesList)
-	s.mux.HandleFunc("GET /data/files/", s.handleDataFile)
-	s.mux.HandleFunc("GET /data/download/", s.handleDataDownload)
-	s.mux.HandleFunc("GET /data/stats", s.handleDataStats)
-	s.mux.HandleFunc("GET /data/wordcloud", s.handleDataWordcloud)
-	s.mux.HandleFunc("GET /ws/logs", s.handleWSLogs)
-	s.mux.HandleFunc("GET /ws/status", s.handleWSStatus)
-	s.mux.HandleFunc("GET /api/data/files", s.handleDataFilesList)
-	s.mux.HandleFunc("GET /api/data/files/", s.handleDataFile)
-	s.mux.HandleFunc("GET /api/data/download/", s.handleDataDownload)
-	s.mux.HandleFunc("GET /api/data/stats", s.handleDataStats)
-	s.mux.HandleFunc("GET /api/data/wordcloud", s.handleDataWordcloud)
-	s.mux.HandleFunc("GET /api/ws/logs", s.handleWSLogs)
-	s.mux.HandleFunc("GET /api/ws/status", s.handleWSStatus)
-	s.mux.Handle("GET /assets/", http.StripPrefix("/assets/", s.webUIAssetsHandler()))
-	s.mux.HandleFunc("GET /", s.handleWebUIIndex)
+	s.handleFunc("GET /healthz", RolePublic, s.handleHealthz)
+	s.handleFunc("GET /api/health", RolePublic, s.handleAPIHealth)
+	s.handleFunc("GET /openapi.json", RolePublic, s.handleOpenAPI)
+	s.handleFunc("GET /api/openapi.json", RolePublic, s.handleOpenAPI)
+	s.handle("GET /metrics", RoleViewer, metrics.Handler())
+	s.handleFunc("GET /status", RoleViewer, s.handleStatus)
+	s.handleFunc("POST /run", RoleOperator, s.handleRun)
+	s.handleFunc("POST /stop", RoleOperator, s.handleStop)
+	s.handleFunc("POST /sms", RoleOperator, s.handleSMS)
+	s.handleFunc("POST /api/sms", RoleOperator, s.handleSMS)
+	s.handleFunc("GET /logs", RoleViewer, s.handleLogs)
+	s.handleFunc("GET /crawler/logs", RoleViewer, s.handleLogs)
+	s.handleFunc("GET /api/logs", RoleViewer, s.handleLogs)
+	s.handleFunc("GET /api/crawler/logs", RoleViewer, s.handleAPICrawlerLogs)
+	s.handleFunc("POST /api/crawler/start", RoleOperator, s.handleAPICrawlerStart)
+	s.handleFunc("POST /api/crawler/stop", RoleOperator, s.handleAPICrawlerStop)
+	s.handleFunc("GET /api/crawler/status", RoleViewer, s.handleAPICrawlerStatus)
+	s.handleFunc("POST /crawler/start", RoleOperator, s.handleAPICrawlerStart)
+	s.handleFunc("POST /crawler/stop", RoleOperator, s.handleAPICrawlerStop)
+	s.handleFunc("GET /crawler/status", RoleViewer, s.handleAPICrawlerStatus)
+	s.handleFunc("GET /config/platforms", RoleViewer, s.handleConfigPlatforms)
+	s.handleFunc("GET /config/options", RoleViewer, s.handleConfigOptions)
+	s.handleFunc("GET /env/check", RoleViewer, s.handleEnvCheck)
+	s.handleFunc("GET /api/env/check", RoleViewer, s.handleAPIEnvCheck)
+	s.handleFunc("GET /api/config/platforms", RoleViewer, s.handleAPIConfigPlatforms)
+	s.handleFunc("GET /api/config/options", RoleViewer, s.handleAPIConfigOptions)
+	s.handleFunc("GET /data/files", RoleViewer, s.handleDataFilesList)
+	s.handleFunc("GET /data/files/", RoleViewer, s.handleDataFile)
+	s.handleFunc("GET /data/download/", RoleViewer, s.handleDataDownload)
+	s.handleFunc("GET /data/stats", RoleViewer, s.handleDataStats)
+	s.handleFunc("GET /data/export", RoleViewer, s.handleDataExport)
+	s.handleFunc("GET /data/wordcloud", RoleViewer, s.handleDataWordcloud)
+	s.handleFunc("GET /data/comments/thread", RoleViewer, s.handleCommentThread)
+	s.handleFunc("GET /ws/logs", RoleViewer, s.handleWSLogs)
+	s.handleFunc("GET /ws/status", RoleViewer, s.handleWSStatus)
+	s.handleFunc("GET /sse/status", RoleViewer, s.handleSSEStatus)
+	s.handleFunc("GET /api/data/files", RoleViewer, s.handleDataFilesList)
+	s.handleFunc("GET /api/data/files/", RoleViewer, s.handleDataFile)
+	s.handleFunc("GET /api/data/download/", RoleViewer, s.handleDataDownload)
+	s.handleFunc("GET /api/data/stats", RoleViewer, s.handleDataStats)
+	s.handleFunc("GET /api/data/export", RoleViewer, s.handleDataExport)
+	s.handleFunc("GET /api/data/wordcloud", RoleViewer, s.handleDataWordcloud)
+	s.handleFunc("GET /api/data/comments/thread", RoleViewer, s.handleCommentThread)
+	s.handleFunc("GET /api/notes", RoleViewer, s.handleQueryNotes)
+	s.handleFunc("GET /api/comments", RoleViewer, s.handleQueryComments)
+	s.handleFunc("GET /api/creators", RoleViewer, s.handleQueryCreators)
+	s.handleFunc("GET /api/search", RoleViewer, s.handleSearch)
+	s.handleFunc("GET /tasks/history", RoleViewer, s.handleTaskHistory)
+	s.handleFunc("GET /tasks/{id}", RoleViewer, s.handleTaskGet)
+	s.handleFunc("GET /tasks/{id}/logs", RoleViewer, s.handleTaskLogs)
+	s.handleFunc("GET /api/tasks/history", RoleViewer, s.handleTaskHistory)
+	s.handleFunc("GET /api/tasks/{id}", RoleViewer, s.handleTaskGet)
+	s.handleFunc("GET /api/tasks/{id}/logs", RoleViewer, s.handleTaskLogs)
+	s.handleFunc("GET /api/auth/whoami", RoleViewer, s.handleWhoAmI)
+	s.handleFunc("GET /api/audit", RoleAdmin, s.handleAudit)
+	s.handleFunc("GET /api/ws/logs", RoleViewer, s.handleWSLogs)
+	s.handleFunc("GET /api/ws/status", RoleViewer, s.handleWSStatus)
+	s.handleFunc("GET /api/sse/status", RoleViewer, s.handleSSEStatus)
+	s.handle("GET /assets/", RolePublic, http.StripPrefix("/assets/", s.webUIAssetsHandler()))
+	s.handleFunc("GET /", RolePublic, s.handleWebUIIndex)
 }
 
 func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
@@ -91,12 +171,20 @@ func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
 		return
 	}
+	auditSet(r, "request", redactRunRequest(req))
+	if role, what := runRequestRole(req); !requireRole(w, r, role, what, "error") {
+		return
+	}
 
 	if err := s.manager.Run(req); err != nil {
 		if errors.Is(err, ErrTaskRunning) {
 			writeJSON(w, http.StatusConflict, map[string]any{"error": err.Error()})
 			return
 		}
+		if errors.Is(err, ErrShuttingDown) {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": err.Error()})
+			return
+		}
 		var ve ValidationError
 		if errors.As(err, &ve) {
 			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
@@ -105,11 +193,15 @@ func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
 		return
 	}
-	writeJSON(w, http.StatusAccepted, s.manager.Status())
+	st := s.manager.Status()
+	auditSet(r, "task_id", st.TaskID)
+	writeJSON(w, http.StatusAccepted, st)
 }
 
 func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
+	auditSet(r, "task_id", s.manager.Status().TaskID)
 	stopped := s.manager.Stop()
+	auditSet(r, "stopped", stopped)
 	writeJSON(w, http.StatusAccepted, map[string]any{"stopped": stopped})
 }
 
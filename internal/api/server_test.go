@@ -10,6 +10,7 @@ import (
 	"media-crawler-go/internal/sms"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"sync"
 	"testing"
 	"time"
@@ -23,6 +24,22 @@ import (
 	_ "media-crawler-go/internal/platform/zhihu"
 )
 
+// TestMain runs the package from a temporary directory, so tests that
+// leave DATA_DIR at its default write task history and the audit log there
+// instead of into the source tree.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "api-test-")
+	if err != nil {
+		panic(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
 func TestServerRunStopStatus(t *testing.T) {
 	config.AppConfig = config.Config{}
 	done := make(chan struct{})
@@ -495,3 +512,17 @@ func TestBiliAdvancedConfigCompat(t *testing.T) {
 		t.Errorf("expected BiliQn=80, got %d", c.BiliQn)
 	}
 }
+
+func TestMetricsEndpoint(t *testing.T) {
+	runFn := func(ctx context.Context) (crawler.Result, error) { return crawler.Result{}, nil }
+	srv := NewServer(NewTaskManagerWithRunner(runFn))
+	r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, r)
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte("# TYPE media_crawler_http_requests_total counter")) {
+		t.Fatalf("code=%d body=%s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Fatalf("content-type=%q", ct)
+	}
+}
@@ -48,6 +48,8 @@ func (s *Server) handleSMS(w http.ResponseWriter, r *http.Request) {
 		code = sms.ExtractCode(content)
 	}
 
+	auditSet(r, "platform", platform)
+	auditSet(r, "code_received", code != "")
 	if phone != "" && code != "" {
 		_ = sms.Store(platform, phone, code, 3*time.Minute)
 	}
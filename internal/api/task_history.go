@@ -0,0 +1,439 @@
+package api
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"media-crawler-go/internal/logger"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Task history lives under <DATA_DIR>/tasks/<id>/: task.json is written
+// when a task starts and rewritten when it ends, logs.jsonl holds every log
+// event emitted while it ran and is rotated to logs.1.jsonl ... once it
+// reaches taskLogMaxBytes.
+const (
+	taskLogMaxBytes = 8 << 20
+	taskLogKeep     = 5
+	taskMaxFiles    = 500
+)
+
+var errTaskNotFound = errors.New("task not found")
+
+// TaskRecord is the history entry of one task run.
+type TaskRecord struct {
+	ID         string         `json:"id"`
+	State      string         `json:"state"`
+	Request    RunRequest     `json:"request"`
+	Config     map[string]any `json:"config,omitempty"`
+	StartedAt  int64          `json:"started_at"`
+	FinishedAt int64          `json:"finished_at,omitempty"`
+	Result     crawler.Result `json:"result"`
+
+	LastError     string `json:"last_error,omitempty"`
+	LastErrorKind string `json:"last_error_kind,omitempty"`
+
+	Progress       *crawler.Progress `json:"progress,omitempty"`
+	Files          []TaskOutputFile  `json:"files,omitempty"`
+	FilesTruncated bool              `json:"files_truncated,omitempty"`
+}
+
+// TaskOutputFile is a data file created or modified by a task, relative to
+// DATA_DIR.
+type TaskOutputFile struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	Created bool   `json:"created"`
+}
+
+func tasksDir(dataDir string) string {
+	if strings.TrimSpace(dataDir) == "" {
+		dataDir = "data"
+	}
+	return filepath.Join(dataDir, "tasks")
+}
+
+func newTaskID(now time.Time) string {
+	var b [3]byte
+	_, _ = rand.Read(b[:])
+	now = now.UTC()
+	return fmt.Sprintf("%s%03d-%s", now.Format("20060102-150405"), now.Nanosecond()/int(time.Millisecond), hex.EncodeToString(b[:]))
+}
+
+func validTaskID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		if !(r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func saveTaskRecord(dataDir string, rec TaskRecord) error {
+	dir := filepath.Join(tasksDir(dataDir), rec.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, "task.json.tmp")
+	if err := os.WriteFile(tmp, append(b, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, "task.json"))
+}
+
+func loadTaskRecord(dataDir string, id string) (TaskRecord, error) {
+	var rec TaskRecord
+	if !validTaskID(id) {
+		return rec, errTaskNotFound
+	}
+	b, err := os.ReadFile(filepath.Join(tasksDir(dataDir), id, "task.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return rec, errTaskNotFound
+	}
+	if err != nil {
+		return rec, err
+	}
+	err = json.Unmarshal(b, &rec)
+	return rec, err
+}
+
+// listTaskRecords returns a page of task records, newest first, and the
+// total number of recorded tasks.
+func listTaskRecords(dataDir string, offset, limit int) ([]TaskRecord, int, error) {
+	entries, err := os.ReadDir(tasksDir(dataDir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return []TaskRecord{}, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	ids := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() && validTaskID(e.Name()) {
+			ids = append(ids, e.Name())
+		}
+	}
+	// IDs start with the UTC start time in milliseconds, so they sort
+	// chronologically.
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	total := len(ids)
+	if offset > len(ids) {
+		offset = len(ids)
+	}
+	ids = ids[offset:]
+	if limit > 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+	out := make([]TaskRecord, 0, len(ids))
+	for _, id := range ids {
+		rec, err := loadTaskRecord(dataDir, id)
+		if err != nil {
+			logger.Warn("load task record failed", "task_id", id, "err", err)
+			continue
+		}
+		out = append(out, rec)
+	}
+	return out, total, nil
+}
+
+// taskLogWriter appends log events of one task to its rotating log files.
+type taskLogWriter struct {
+	mu   sync.Mutex
+	dir  string
+	f    *os.File
+	size int64
+}
+
+func openTaskLog(dataDir string, id string) (*taskLogWriter, error) {
+	dir := filepath.Join(tasksDir(dataDir), id)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	w := &taskLogWriter{dir: dir}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *taskLogWriter) open() error {
+	f, err := os.OpenFile(filepath.Join(w.dir, "logs.jsonl"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	w.f, w.size = f, st.Size()
+	return nil
+}
+
+func (w *taskLogWriter) rotate() error {
+	_ = w.f.Close()
+	w.f = nil
+	for i := taskLogKeep - 1; i >= 1; i-- {
+		from := taskLogFile(w.dir, i-1)
+		if _, err := os.Stat(from); err == nil {
+			_ = os.Rename(from, taskLogFile(w.dir, i))
+		}
+	}
+	return w.open()
+}
+
+func (w *taskLogWriter) Write(evt logger.Event) {
+	b, err := json.Marshal(evt)
+	if err != nil {
+		return
+	}
+	b = append(b, '\n')
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return
+	}
+	if w.size > 0 && w.size+int64(len(b)) > taskLogMaxBytes {
+		if err := w.rotate(); err != nil {
+			return
+		}
+	}
+	n, _ := w.f.Write(b)
+	w.size += int64(n)
+}
+
+func (w *taskLogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
+
+// taskLogFile is logs.jsonl for i == 0 and logs.<i>.jsonl for rotated
+// files; higher i is older.
+func taskLogFile(dir string, i int) string {
+	if i == 0 {
+		return filepath.Join(dir, "logs.jsonl")
+	}
+	return filepath.Join(dir, fmt.Sprintf("logs.%d.jsonl", i))
+}
+
+// readTaskLogs returns log lines [offset, offset+limit) of a task in
+// chronological order across its rotated files, and the total line count.
+func readTaskLogs(dataDir string, id string, offset, limit int) ([]json.RawMessage, int, error) {
+	if !validTaskID(id) {
+		return nil, 0, errTaskNotFound
+	}
+	dir := filepath.Join(tasksDir(dataDir), id)
+	if _, err := os.Stat(dir); err != nil {
+		return nil, 0, errTaskNotFound
+	}
+	out := []json.RawMessage{}
+	total := 0
+	for i := taskLogKeep - 1; i >= 0; i-- {
+		f, err := os.Open(taskLogFile(dir, i))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+		for sc.Scan() {
+			line := sc.Bytes()
+			if len(line) == 0 {
+				continue
+			}
+			if total >= offset && (limit <= 0 || len(out) < limit) && json.Valid(line) {
+				out = append(out, json.RawMessage(append([]byte(nil), line...)))
+			}
+			total++
+		}
+		err = sc.Err()
+		_ = f.Close()
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return out, total, nil
+}
+
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// snapshotFiles records size and mtime of every file under dir.
+func snapshotFiles(dir string) map[string]fileStamp {
+	out := map[string]fileStamp{}
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			out[path] = fileStamp{size: info.Size(), modTime: info.ModTime()}
+		}
+		return nil
+	})
+	return out
+}
+
+// changedFiles lists the files under dir that are new or changed since
+// before, relative to base and at most taskMaxFiles of them.
+func changedFiles(base string, dir string, before map[string]fileStamp) ([]TaskOutputFile, bool) {
+	after := snapshotFiles(dir)
+	paths := make([]string, 0, len(after))
+	for p, st := range after {
+		if old, ok := before[p]; ok && old.size == st.size && old.modTime.Equal(st.modTime) {
+			continue
+		}
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	truncated := len(paths) > taskMaxFiles
+	if truncated {
+		paths = paths[:taskMaxFiles]
+	}
+	out := make([]TaskOutputFile, 0, len(paths))
+	for _, p := range paths {
+		rel, err := filepath.Rel(base, p)
+		if err != nil {
+			rel = p
+		}
+		_, existed := before[p]
+		out = append(out, TaskOutputFile{Path: filepath.ToSlash(rel), Size: after[p].size, Created: !existed})
+	}
+	return out, truncated
+}
+
+// secretConfigKeys are config values replaced in task records.
+var secretConfigKeys = map[string]bool{
+	"COOKIES": true, "LOGIN_PHONE": true, "MYSQL_DSN": true, "POSTGRES_DSN": true,
+	"MONGO_URI": true, "REDIS_PASSWORD": true, "IP_PROXY_LIST": true,
+	"API_TOKENS": true, "API_BASIC_AUTH": true, "API_JWT_SECRET": true,
+	"WEBHOOKS": true,
+}
+
+const redacted = "***"
+
+// effectiveConfig returns cfg keyed by its config file names with secrets
+// redacted.
+func effectiveConfig(cfg config.Config) map[string]any {
+	out := map[string]any{}
+	v := reflect.ValueOf(cfg)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := t.Field(i).Tag.Get("mapstructure")
+		if key == "" || key == "-" {
+			continue
+		}
+		val := v.Field(i).Interface()
+		if secretConfigKeys[key] && !v.Field(i).IsZero() {
+			val = redacted
+		}
+		out[key] = val
+	}
+	return out
+}
+
+func redactRunRequest(req RunRequest) RunRequest {
+	if req.Cookies != "" {
+		req.Cookies = redacted
+	}
+	if req.LoginPhone != "" {
+		req.LoginPhone = redacted
+	}
+	return req
+}
+
+// handleTaskHistory serves /tasks/history: recorded tasks, newest first
+// (limit, offset).
+func (s *Server) handleTaskHistory(w http.ResponseWriter, r *http.Request) {
+	v := r.URL.Query()
+	limit := queryIntDefault(v, "limit", 20)
+	if limit < 1 {
+		limit = 1
+	}
+	if limit > 200 {
+		limit = 200
+	}
+	offset := queryIntDefault(v, "offset", 0)
+	if offset < 0 {
+		offset = 0
+	}
+	items, total, err := listTaskRecords(config.AppConfig.DataDir, offset, limit)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"total": total, "limit": limit, "offset": offset, "items": items})
+}
+
+func (s *Server) handleTaskGet(w http.ResponseWriter, r *http.Request) {
+	rec, err := loadTaskRecord(config.AppConfig.DataDir, r.PathValue("id"))
+	if err != nil {
+		writeTaskError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rec)
+}
+
+// handleTaskLogs serves /tasks/{id}/logs: the log lines of one task, oldest
+// first (limit, offset). next_offset is omitted once the end is reached.
+func (s *Server) handleTaskLogs(w http.ResponseWriter, r *http.Request) {
+	v := r.URL.Query()
+	limit := queryIntDefault(v, "limit", 200)
+	if limit < 1 {
+		limit = 1
+	}
+	if limit > 2000 {
+		limit = 2000
+	}
+	offset := queryIntDefault(v, "offset", 0)
+	if offset < 0 {
+		offset = 0
+	}
+	logs, total, err := readTaskLogs(config.AppConfig.DataDir, r.PathValue("id"), offset, limit)
+	if err != nil {
+		writeTaskError(w, err)
+		return
+	}
+	out := map[string]any{"total": total, "offset": offset, "logs": logs}
+	if next := offset + len(logs); next < total {
+		out["next_offset"] = next
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func writeTaskError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errTaskNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+}
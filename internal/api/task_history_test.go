@@ -0,0 +1,206 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"media-crawler-go/internal/logger"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTaskHistoryEndpoints(t *testing.T) {
+	dataDir := t.TempDir()
+	oldCfg := config.AppConfig
+	config.AppConfig = config.Config{Platform: "xhs", CrawlerType: "search", DataDir: dataDir, Cookies: "a=secret"}
+	t.Cleanup(func() { config.AppConfig = oldCfg })
+	logger.InitFromConfig()
+
+	runs := 0
+	runFn := func(ctx context.Context) (crawler.Result, error) {
+		runs++
+		for i := 0; i < 5; i++ {
+			logger.Info("task step", "run", runs, "i", i)
+		}
+		out := filepath.Join(dataDir, "xhs", "jsonl", "search_contents.jsonl")
+		_ = os.MkdirAll(filepath.Dir(out), 0755)
+		_ = os.WriteFile(out, []byte("{}\n"), 0644)
+		if runs == 2 {
+			return crawler.Result{Processed: 1, Failed: 1}, errors.New("boom")
+		}
+		return crawler.Result{Processed: 2, Succeeded: 2}, nil
+	}
+	m := NewTaskManagerWithRunner(runFn)
+	srv := NewServer(m)
+
+	var ids []string
+	for i := 0; i < 2; i++ {
+		if err := m.Run(RunRequest{Keywords: "golang", Cookies: "b=secret"}); err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		ids = append(ids, m.Status().TaskID)
+		waitIdle(t, m)
+		time.Sleep(5 * time.Millisecond) // task IDs sort by their millisecond start time
+	}
+	if ids[0] == "" || ids[0] == ids[1] {
+		t.Fatalf("unexpected task ids: %v", ids)
+	}
+
+	rr := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/tasks/history?limit=10", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("history code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var hist struct {
+		Total int          `json:"total"`
+		Items []TaskRecord `json:"items"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &hist); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if hist.Total != 2 || len(hist.Items) != 2 {
+		t.Fatalf("unexpected history: %s", rr.Body.String())
+	}
+	latest, first := hist.Items[0], hist.Items[1]
+	if latest.ID != ids[1] || latest.State != "failed" || latest.LastError != "boom" || latest.Result.Failed != 1 {
+		t.Fatalf("unexpected latest record: %+v", latest)
+	}
+	if first.ID != ids[0] || first.State != "finished" || first.Result.Succeeded != 2 || first.FinishedAt == 0 {
+		t.Fatalf("unexpected first record: %+v", first)
+	}
+	if first.Request.Keywords != "golang" || first.Request.Cookies != redacted || first.Config["COOKIES"] != redacted || first.Config["PLATFORM"] != "xhs" {
+		t.Fatalf("request/config not recorded or not redacted: %+v %v", first.Request, first.Config)
+	}
+	if len(first.Files) != 1 || first.Files[0].Path != "xhs/jsonl/search_contents.jsonl" || !first.Files[0].Created {
+		t.Fatalf("unexpected files: %+v", first.Files)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/tasks/"+ids[0]+"/logs?offset=1&limit=3", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("logs code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var logs struct {
+		Total      int            `json:"total"`
+		NextOffset int            `json:"next_offset"`
+		Logs       []logger.Event `json:"logs"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &logs); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if logs.Total != 5 || len(logs.Logs) != 3 || logs.NextOffset != 4 || logs.Logs[0].Msg != "task step" {
+		t.Fatalf("unexpected logs: %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/tasks/"+ids[1], nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("task code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	for _, path := range []string{"/api/tasks/nope", "/api/tasks/nope/logs", "/api/tasks/..%2f..%2fetc/logs"} {
+		rr = httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		if rr.Code != http.StatusNotFound {
+			t.Fatalf("%s code=%d", path, rr.Code)
+		}
+	}
+}
+
+func TestServerShutdownCancelsTask(t *testing.T) {
+	dataDir := t.TempDir()
+	oldCfg := config.AppConfig
+	config.AppConfig = config.Config{Platform: "xhs", CrawlerType: "search", DataDir: dataDir}
+	t.Cleanup(func() { config.AppConfig = oldCfg })
+
+	started := make(chan struct{})
+	m := NewTaskManagerWithRunner(func(ctx context.Context) (crawler.Result, error) {
+		close(started)
+		<-ctx.Done()
+		time.Sleep(20 * time.Millisecond) // flushing partial results
+		return crawler.Result{Processed: 1, Succeeded: 1}, ctx.Err()
+	})
+	srv := NewServer(m)
+	if err := m.Run(RunRequest{Keywords: "golang"}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	id := m.Status().TaskID
+	<-started
+
+	streamDone := make(chan struct{})
+	go func() {
+		srv.streamStatus(nil, time.Second, func([]byte) bool { return true })
+		close(streamDone)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	rec, err := loadTaskRecord(dataDir, id)
+	if err != nil {
+		t.Fatalf("load record: %v", err)
+	}
+	if rec.State != "canceled" || rec.Result.Processed != 1 || rec.FinishedAt == 0 {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+	select {
+	case <-streamDone:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("status stream still open after shutdown")
+	}
+
+	if err := m.Run(RunRequest{Keywords: "golang"}); !errors.Is(err, ErrShuttingDown) {
+		t.Fatalf("run after shutdown: %v", err)
+	}
+	rr := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/crawler/start", strings.NewReader(`{"platform":"xhs","keywords":"go"}`)))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("start after shutdown code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestTaskLogWriterRotates(t *testing.T) {
+	dir := t.TempDir()
+	w, err := openTaskLog(dir, "t1")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	w.size = taskLogMaxBytes - 10
+	w.Write(logger.Event{Msg: "first"})
+	w.Write(logger.Event{Msg: "second"})
+	_ = w.Close()
+
+	if _, err := os.Stat(taskLogFile(filepath.Join(tasksDir(dir), "t1"), 1)); err != nil {
+		t.Fatalf("expected rotated file: %v", err)
+	}
+	logs, total, err := readTaskLogs(dir, "t1", 0, 10)
+	if err != nil || total != 2 || len(logs) != 2 {
+		t.Fatalf("logs=%v total=%d err=%v", logs, total, err)
+	}
+	var evt logger.Event
+	_ = json.Unmarshal(logs[0], &evt)
+	if evt.Msg != "first" {
+		t.Fatalf("expected oldest first, got %q", evt.Msg)
+	}
+}
+
+func waitIdle(t *testing.T, m *TaskManager) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if m.Status().State == "idle" {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("task did not finish: %+v", m.Status())
+}
@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"media-crawler-go/internal/config"
 	"media-crawler-go/internal/crawler"
+	"media-crawler-go/internal/logger"
 	"media-crawler-go/internal/platform"
 	"media-crawler-go/internal/store"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -15,6 +17,7 @@ import (
 
 type Status struct {
 	State          string         `json:"state"`
+	TaskID         string         `json:"task_id,omitempty"`
 	Platform       string         `json:"platform,omitempty"`
 	Crawler        string         `json:"crawler_type,omitempty"`
 	StartedAt      int64          `json:"started_at,omitempty"`
@@ -28,6 +31,10 @@ type Status struct {
 	LastRiskHint   string         `json:"last_risk_hint,omitempty"`
 	LastErrorURL   string         `json:"last_error_url,omitempty"`
 	LastHTTPStatus int            `json:"last_http_status,omitempty"`
+
+	// Progress is the live progress of the running task, or the final
+	// progress of the last one.
+	Progress *crawler.Progress `json:"progress,omitempty"`
 }
 
 type RunRequest struct {
@@ -46,12 +53,23 @@ type RunRequest struct {
 
 	XhsSpecifiedNoteUrls []string `json:"xhs_specified_note_url_list,omitempty"`
 	XhsCreatorIdList     []string `json:"xhs_creator_id_list,omitempty"`
+	XhsTopicIdList       []string `json:"xhs_topic_id_list,omitempty"`
+	XhsTopicSort         string   `json:"xhs_topic_sort,omitempty"`
+	XhsFeedChannelList   []string `json:"xhs_feed_channel_list,omitempty"`
 
 	DouyinSpecifiedNoteUrls []string `json:"dy_specified_note_url_list,omitempty"`
 	DouyinCreatorIdList     []string `json:"dy_creator_id_list,omitempty"`
+	DouyinMusicIdList       []string `json:"dy_music_id_list,omitempty"`
+	DouyinMixIdList         []string `json:"dy_mix_id_list,omitempty"`
 
 	BiliSpecifiedVideoUrls []string `json:"bili_specified_video_url_list,omitempty"`
 	BiliCreatorIdList      []string `json:"bili_creator_id_list,omitempty"`
+	BiliLiveRoomIdList     []string `json:"bili_live_room_id_list,omitempty"`
+	BiliLiveRecordSec      *int     `json:"bili_live_record_sec,omitempty"`
+	BiliFavMediaIdList     []string `json:"bili_fav_media_id_list,omitempty"`
+	BiliSeasonIdList       []string `json:"bili_season_id_list,omitempty"`
+	BiliSeriesIdList       []string `json:"bili_series_id_list,omitempty"`
+	BiliEnableWatchLater   *bool    `json:"bili_enable_watch_later,omitempty"`
 	WBSpecifiedNoteUrls    []string `json:"wb_specified_note_url_list,omitempty"`
 	WBCreatorIdList        []string `json:"wb_creator_id_list,omitempty"`
 
@@ -68,14 +86,23 @@ type RunRequest struct {
 }
 
 type TaskManager struct {
-	mu     sync.Mutex
-	cancel context.CancelFunc
-	status Status
-	runFn  func(context.Context) (crawler.Result, error)
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	status  Status
+	tracker *crawler.ProgressTracker
+	runFn   func(context.Context) (crawler.Result, error)
+	// closing rejects new tasks once Shutdown has begun.
+	closing bool
+
+	subsMu sync.Mutex
+	subs   map[chan struct{}]struct{}
 }
 
 var ErrTaskRunning = errors.New("task is running")
 
+// ErrShuttingDown is returned by Run once Shutdown has begun.
+var ErrShuttingDown = errors.New("server is shutting down")
+
 type ValidationError struct {
 	Msg string
 }
@@ -98,11 +125,54 @@ func NewTaskManagerWithRunner(runFn func(context.Context) (crawler.Result, error
 func (m *TaskManager) Status() Status {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	return m.status
+	st := m.status
+	if m.tracker != nil {
+		p := m.tracker.Snapshot()
+		st.Progress = &p
+		if m.cancel != nil {
+			st.Processed = p.Done
+			st.Succeeded = p.Succeeded
+			st.Failed = p.Failed
+		}
+	}
+	return st
+}
+
+// Subscribe returns a channel that receives a value whenever the status or
+// progress changes. Notifications are coalesced: a slow reader sees one
+// pending value, not every update.
+func (m *TaskManager) Subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	m.subsMu.Lock()
+	if m.subs == nil {
+		m.subs = make(map[chan struct{}]struct{})
+	}
+	m.subs[ch] = struct{}{}
+	m.subsMu.Unlock()
+	return ch, func() {
+		m.subsMu.Lock()
+		delete(m.subs, ch)
+		m.subsMu.Unlock()
+	}
+}
+
+func (m *TaskManager) notify() {
+	m.subsMu.Lock()
+	defer m.subsMu.Unlock()
+	for ch := range m.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
 }
 
 func (m *TaskManager) Run(req RunRequest) error {
 	m.mu.Lock()
+	if m.closing {
+		m.mu.Unlock()
+		return ErrShuttingDown
+	}
 	if m.cancel != nil {
 		m.mu.Unlock()
 		return ErrTaskRunning
@@ -119,15 +189,45 @@ func (m *TaskManager) Run(req RunRequest) error {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	m.cancel = cancel
+	now := time.Now()
 	m.status = Status{
 		State:     "running",
+		TaskID:    newTaskID(now),
 		Platform:  config.AppConfig.Platform,
 		Crawler:   config.AppConfig.CrawlerType,
-		StartedAt: time.Now().Unix(),
+		StartedAt: now.Unix(),
+	}
+	m.tracker = crawler.NewProgressTracker(config.AppConfig.Platform, m.notify)
+	ctx = crawler.WithProgress(ctx, m.tracker)
+	ctx = crawler.WithTaskID(ctx, m.status.TaskID)
+	rec := TaskRecord{
+		ID:        m.status.TaskID,
+		State:     "running",
+		Request:   redactRunRequest(req),
+		Config:    effectiveConfig(config.AppConfig),
+		StartedAt: m.status.StartedAt,
+	}
+	dataDir := config.AppConfig.DataDir
+	if err := saveTaskRecord(dataDir, rec); err != nil {
+		logger.Warn("save task record failed", "task_id", rec.ID, "err", err)
+	}
+	removeSink := func() {}
+	if lw, err := openTaskLog(dataDir, rec.ID); err != nil {
+		logger.Warn("open task log failed", "task_id", rec.ID, "err", err)
+	} else {
+		remove := logger.AddSink(lw.Write)
+		removeSink = func() {
+			remove()
+			_ = lw.Close()
+		}
 	}
+	tracker := m.tracker
 	m.mu.Unlock()
+	m.notify()
 
 	go func() {
+		outDir := filepath.Join(dataDir, strings.ToLower(config.AppConfig.Platform))
+		before := snapshotFiles(outDir)
 		res, err := m.runFn(ctx)
 		cfgSnapshot := config.AppConfig
 		auto := cfgSnapshot.EnableGetWordcloud && cfgSnapshot.EnableGetComments && ctx.Err() == nil
@@ -145,6 +245,10 @@ func (m *TaskManager) Run(req RunRequest) error {
 			CustomWords:   cfgSnapshot.CustomWords,
 		}
 
+		removeSink()
+		progress := tracker.Snapshot()
+		files, truncated := changedFiles(dataDir, outDir, before)
+
 		m.mu.Lock()
 		m.cancel = nil
 		m.status.State = "idle"
@@ -174,7 +278,27 @@ func (m *TaskManager) Run(req RunRequest) error {
 			m.status.LastErrorURL = ""
 			m.status.LastHTTPStatus = 0
 		}
+		rec.FinishedAt = m.status.FinishedAt
+		rec.Result = res
+		rec.LastError = m.status.LastError
+		rec.LastErrorKind = m.status.LastErrorKind
+		rec.Progress = &progress
+		rec.Files, rec.FilesTruncated = files, truncated
+		switch {
+		case ctx.Err() != nil:
+			rec.State = "canceled"
+		case err != nil:
+			rec.State = "failed"
+		default:
+			rec.State = "finished"
+		}
+		// Saved before unlocking so the record is final once the status
+		// reads idle.
+		if err := saveTaskRecord(dataDir, rec); err != nil {
+			logger.Warn("save task record failed", "task_id", rec.ID, "err", err)
+		}
 		m.mu.Unlock()
+		m.notify()
 
 		if auto {
 			go func() {
@@ -193,9 +317,36 @@ func (m *TaskManager) Stop() bool {
 	}
 	m.cancel()
 	m.status.State = "stopping"
+	m.notify()
 	return true
 }
 
+// Shutdown rejects new tasks, cancels the running one and waits until its
+// record is saved as canceled. It returns ctx.Err() if the task does not
+// stop before ctx is done.
+func (m *TaskManager) Shutdown(ctx context.Context) error {
+	changes, unsubscribe := m.Subscribe()
+	defer unsubscribe()
+
+	m.mu.Lock()
+	m.closing = true
+	m.mu.Unlock()
+	m.Stop()
+	for {
+		m.mu.Lock()
+		running := m.cancel != nil
+		m.mu.Unlock()
+		if !running {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changes:
+		}
+	}
+}
+
 func runCrawler(ctx context.Context) (crawler.Result, error) {
 	store.BeginRunWorkbook()
 	r, err := platform.New(config.AppConfig.Platform)
@@ -246,18 +397,51 @@ func applyRunRequestToConfig(cfg *config.Config, req RunRequest) {
 	if len(req.XhsCreatorIdList) > 0 {
 		cfg.XhsCreatorIdList = req.XhsCreatorIdList
 	}
+	if len(req.XhsTopicIdList) > 0 {
+		cfg.XhsTopicIdList = req.XhsTopicIdList
+	}
+	if strings.TrimSpace(req.XhsTopicSort) != "" {
+		cfg.XhsTopicSort = req.XhsTopicSort
+	}
+	if len(req.XhsFeedChannelList) > 0 {
+		cfg.XhsFeedChannelList = req.XhsFeedChannelList
+	}
 	if len(req.DouyinSpecifiedNoteUrls) > 0 {
 		cfg.DouyinSpecifiedNoteUrls = req.DouyinSpecifiedNoteUrls
 	}
 	if len(req.DouyinCreatorIdList) > 0 {
 		cfg.DouyinCreatorIdList = req.DouyinCreatorIdList
 	}
+	if len(req.DouyinMusicIdList) > 0 {
+		cfg.DouyinMusicIdList = req.DouyinMusicIdList
+	}
+	if len(req.DouyinMixIdList) > 0 {
+		cfg.DouyinMixIdList = req.DouyinMixIdList
+	}
 	if len(req.BiliSpecifiedVideoUrls) > 0 {
 		cfg.BiliSpecifiedVideoUrls = req.BiliSpecifiedVideoUrls
 	}
 	if len(req.BiliCreatorIdList) > 0 {
 		cfg.BiliCreatorIdList = req.BiliCreatorIdList
 	}
+	if len(req.BiliLiveRoomIdList) > 0 {
+		cfg.BiliLiveRoomIdList = req.BiliLiveRoomIdList
+	}
+	if req.BiliLiveRecordSec != nil {
+		cfg.BiliLiveRecordSec = *req.BiliLiveRecordSec
+	}
+	if len(req.BiliFavMediaIdList) > 0 {
+		cfg.BiliFavMediaIdList = req.BiliFavMediaIdList
+	}
+	if len(req.BiliSeasonIdList) > 0 {
+		cfg.BiliSeasonIdList = req.BiliSeasonIdList
+	}
+	if len(req.BiliSeriesIdList) > 0 {
+		cfg.BiliSeriesIdList = req.BiliSeriesIdList
+	}
+	if req.BiliEnableWatchLater != nil {
+		cfg.BiliEnableWatchLater = *req.BiliEnableWatchLater
+	}
 	if len(req.WBSpecifiedNoteUrls) > 0 {
 		cfg.WBSpecifiedNoteUrls = req.WBSpecifiedNoteUrls
 	}
@@ -330,6 +514,17 @@ func validateRunConfig(cfg config.Config) error {
 			if len(cfg.XhsCreatorIdList) == 0 {
 				return ValidationError{Msg: "xhs_creator_id_list is required for creator"}
 			}
+		case "topic":
+			if len(cfg.XhsTopicIdList) == 0 {
+				return ValidationError{Msg: "xhs_topic_id_list is required for topic"}
+			}
+			if v := strings.ToLower(strings.TrimSpace(cfg.XhsTopicSort)); v != "" && v != "hot" && v != "new" && v != "time" {
+				return ValidationError{Msg: fmt.Sprintf("invalid xhs_topic_sort: %s", cfg.XhsTopicSort)}
+			}
+		case "feed", "homefeed":
+			if len(cfg.XhsFeedChannelList) == 0 {
+				return ValidationError{Msg: "xhs_feed_channel_list is required for feed"}
+			}
 		default:
 			return ValidationError{Msg: fmt.Sprintf("unsupported crawler_type for xhs: %s", crawlerType)}
 		}
@@ -347,6 +542,14 @@ func validateRunConfig(cfg config.Config) error {
 			if len(cfg.DouyinCreatorIdList) == 0 {
 				return ValidationError{Msg: "dy_creator_id_list is required for creator"}
 			}
+		case "music":
+			if len(cfg.DouyinMusicIdList) == 0 {
+				return ValidationError{Msg: "dy_music_id_list is required for music"}
+			}
+		case "mix":
+			if len(cfg.DouyinMixIdList) == 0 {
+				return ValidationError{Msg: "dy_mix_id_list is required for mix"}
+			}
 		default:
 			return ValidationError{Msg: fmt.Sprintf("unsupported crawler_type for douyin: %s", crawlerType)}
 		}
@@ -364,6 +567,14 @@ func validateRunConfig(cfg config.Config) error {
 			if len(cfg.BiliCreatorIdList) == 0 {
 				return ValidationError{Msg: "bili_creator_id_list is required for creator"}
 			}
+		case "live":
+			if len(cfg.BiliLiveRoomIdList) == 0 {
+				return ValidationError{Msg: "bili_live_room_id_list is required for live"}
+			}
+		case "collection":
+			if len(crawler.BiliCollectionInputs(cfg)) == 0 {
+				return ValidationError{Msg: "bili_fav_media_id_list, bili_season_id_list, bili_series_id_list or bili_enable_watch_later is required for collection"}
+			}
 		default:
 			return ValidationError{Msg: fmt.Sprintf("unsupported crawler_type for bilibili: %s", crawlerType)}
 		}
@@ -381,6 +592,7 @@ func validateRunConfig(cfg config.Config) error {
 			if len(cfg.WBCreatorIdList) == 0 {
 				return ValidationError{Msg: "wb_creator_id_list is required for creator"}
 			}
+		case "trending":
 		default:
 			return ValidationError{Msg: fmt.Sprintf("unsupported crawler_type for weibo: %s", crawlerType)}
 		}
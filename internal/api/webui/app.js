@@ -17,9 +17,27 @@ function pretty(v) {
   }
 }
 
+function apiToken() {
+  return localStorage.getItem("apiToken") || "";
+}
+
+function authHeaders(headers) {
+  const token = apiToken();
+  return token ? { ...headers, Authorization: `Bearer ${token}` } : headers;
+}
+
+// withToken adds the API token to URLs the browser opens itself (WebSocket,
+// downloads), where no Authorization header can be set.
+function withToken(path) {
+  const token = apiToken();
+  if (!token) return path;
+  const sep = path.includes("?") ? "&" : "?";
+  return `${path}${sep}access_token=${encodeURIComponent(token)}`;
+}
+
 function wsURL(path) {
   const proto = location.protocol === "https:" ? "wss:" : "ws:";
-  return `${proto}//${location.host}${path}`;
+  return `${proto}//${location.host}${withToken(path)}`;
 }
 
 function buildRunPayload() {
@@ -58,13 +76,37 @@ function buildRunPayload() {
     if (platform === "kuaishou") payload.ks_creator_url_list = creators;
   }
 
+  if (crawlerType === "music" && platform === "douyin") {
+    payload.dy_music_id_list = urls;
+  }
+
+  if (crawlerType === "mix" && platform === "douyin") {
+    payload.dy_mix_id_list = urls;
+  }
+
+  if (crawlerType === "live" && platform === "bilibili") {
+    payload.bili_live_room_id_list = urls;
+  }
+
+  if (crawlerType === "collection" && platform === "bilibili") {
+    payload.bili_fav_media_id_list = urls;
+  }
+
+  if (crawlerType === "topic" && platform === "xhs") {
+    payload.xhs_topic_id_list = urls;
+  }
+
+  if (crawlerType === "feed" && platform === "xhs") {
+    payload.xhs_feed_channel_list = urls;
+  }
+
   return payload;
 }
 
 async function postJSON(path, body) {
   const res = await fetch(path, {
     method: "POST",
-    headers: { "content-type": "application/json" },
+    headers: authHeaders({ "content-type": "application/json" }),
     body: JSON.stringify(body || {}),
   });
   const data = await res.json().catch(() => ({}));
@@ -72,7 +114,7 @@ async function postJSON(path, body) {
 }
 
 async function getJSON(path) {
-  const res = await fetch(path, { method: "GET" });
+  const res = await fetch(path, { method: "GET", headers: authHeaders({}) });
   const data = await res.json().catch(() => ({}));
   return { ok: res.ok, status: res.status, data };
 }
@@ -93,7 +135,7 @@ function setFiles(files) {
       for (const n of ul.querySelectorAll("li")) n.classList.remove("active");
       li.classList.add("active");
       selectedFile = f;
-      el("btnDownload").setAttribute("href", `/data/download/${f.path}`);
+      el("btnDownload").setAttribute("href", withToken(`/data/download/${f.path}`));
     };
     ul.appendChild(li);
   }
@@ -117,6 +159,59 @@ async function previewSelectedFile() {
   el("preview").textContent = pretty(data);
 }
 
+let selectedTask = null;
+let taskLogOffset = 0;
+
+function setHistory(items) {
+  const ul = el("history");
+  ul.innerHTML = "";
+  for (const t of items) {
+    const li = document.createElement("li");
+    const started = new Date((t.started_at || 0) * 1000).toLocaleString();
+    const platform = (t.config && t.config.PLATFORM) || "";
+    const kw = (t.request && t.request.keywords) || "";
+    const r = t.result || {};
+    li.textContent = `${started} ${platform} ${t.state} ${kw} (${r.succeeded || 0}/${r.processed || 0})`;
+    li.onclick = () => {
+      for (const n of ul.querySelectorAll("li")) n.classList.remove("active");
+      li.classList.add("active");
+      selectTask(t);
+    };
+    if (selectedTask && selectedTask.id === t.id) li.classList.add("active");
+    ul.appendChild(li);
+  }
+}
+
+async function refreshHistory() {
+  const { ok, data } = await getJSON("/tasks/history?limit=50");
+  if (!ok) return;
+  setHistory(Array.isArray(data.items) ? data.items : []);
+}
+
+async function selectTask(t) {
+  selectedTask = t;
+  taskLogOffset = 0;
+  el("historyTask").textContent = pretty(t);
+  el("historyLogs").textContent = "";
+  await loadTaskLogs();
+}
+
+async function loadTaskLogs() {
+  if (!selectedTask) return;
+  const { ok, data } = await getJSON(
+    `/tasks/${encodeURIComponent(selectedTask.id)}/logs?offset=${taskLogOffset}&limit=500`
+  );
+  if (!ok) {
+    el("historyLogs").textContent += `${pretty(data)}\n`;
+    return;
+  }
+  const logs = Array.isArray(data.logs) ? data.logs : [];
+  for (const it of logs) {
+    el("historyLogs").textContent += `${JSON.stringify(it)}\n`;
+  }
+  taskLogOffset += logs.length;
+}
+
 function appendLogLine(line) {
   const logs = el("logs");
   logs.textContent += line;
@@ -141,12 +236,59 @@ function connectLogs() {
   ws.onclose = () => appendLogLine('{"level":"WARN","msg":"ws logs closed"}\n');
 }
 
+function formatDuration(sec) {
+  if (!sec || sec <= 0) return "-";
+  const h = Math.floor(sec / 3600);
+  const m = Math.floor((sec % 3600) / 60);
+  const s = sec % 60;
+  if (h > 0) return `${h}h${m}m`;
+  if (m > 0) return `${m}m${s}s`;
+  return `${s}s`;
+}
+
+function renderProgress(status) {
+  const p = status && status.progress;
+  const bar = el("progressBar");
+  const dl = el("progress");
+  dl.innerHTML = "";
+  if (!p) {
+    bar.max = 1;
+    bar.value = 0;
+    return;
+  }
+  bar.max = Math.max(p.total || 0, 1);
+  bar.value = p.done || 0;
+  const rows = [
+    ["状态", status.state],
+    ["关键词", p.keyword ? `${p.keyword}（第 ${p.page || 0} 页）` : "-"],
+    ["当前", p.current || "-"],
+    ["进度", `${p.done || 0} / ${p.total || 0}（成功 ${p.succeeded || 0}，失败 ${p.failed || 0}）`],
+    ["评论", p.comments_fetched || 0],
+    ["媒体", p.media_downloaded || 0],
+    ["剩余", status.state === "running" ? formatDuration(p.eta_sec) : "-"],
+  ];
+  for (const [k, v] of rows) {
+    const dt = document.createElement("dt");
+    dt.textContent = k;
+    const dd = document.createElement("dd");
+    dd.textContent = String(v);
+    dl.appendChild(dt);
+    dl.appendChild(dd);
+  }
+}
+
 function connectStatus() {
   const ws = new WebSocket(wsURL("/ws/status?interval_ms=500"));
+  let lastState = "";
   ws.onmessage = (ev) => {
     try {
       const v = JSON.parse(String(ev.data || "").trim());
       el("status").textContent = pretty(v);
+      renderProgress(v);
+      if (v.state !== lastState) {
+        lastState = v.state;
+        refreshHistory();
+      }
     } catch {
       el("status").textContent = String(ev.data || "");
     }
@@ -233,11 +375,18 @@ function bindEvents() {
       max_words: "200",
     });
     if (noteId) qs.set("note_id", noteId);
-    window.open(`/data/wordcloud?${qs.toString()}`, "_blank", "noreferrer");
+    window.open(withToken(`/data/wordcloud?${qs.toString()}`), "_blank", "noreferrer");
     await refreshDataFiles();
   };
   el("btnPreview").onclick = previewSelectedFile;
   el("btnClearLogs").onclick = () => (el("logs").textContent = "");
+  el("apiToken").value = apiToken();
+  el("apiToken").addEventListener("change", () => {
+    localStorage.setItem("apiToken", el("apiToken").value.trim());
+    location.reload();
+  });
+  el("btnHistory").onclick = refreshHistory;
+  el("btnHistoryMore").onclick = loadTaskLogs;
 
   updatePayload();
   updateModeOptions();
@@ -250,6 +399,7 @@ async function main() {
   connectLogs();
   connectStatus();
   await refreshDataFiles();
+  await refreshHistory();
 }
 
 main();
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"media-crawler-go/internal/config"
 	"media-crawler-go/internal/crawler"
 	"net/http"
@@ -63,6 +64,17 @@ func TestWebUIAndConfigEndpoints(t *testing.T) {
 		if _, ok := resp["defaults"]; !ok {
 			t.Fatalf("missing defaults")
 		}
+		byPlatform, _ := resp["platform_crawler_types"].(map[string]any)
+		for platform, modes := range byPlatform {
+			for _, m := range modes.([]any) {
+				if m == "trending" && platform != "weibo" {
+					t.Fatalf("trending advertised for %s", platform)
+				}
+			}
+		}
+		if len(byPlatform) == 0 || !strings.Contains(fmt.Sprint(byPlatform["weibo"]), "trending") {
+			t.Fatalf("platform_crawler_types: %v", byPlatform)
+		}
 		if v, ok := resp["store_backends"].([]any); ok {
 			found := false
 			for _, it := range v {
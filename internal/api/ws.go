@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"media-crawler-go/internal/logger"
 	"net/http"
 	"strconv"
@@ -18,16 +19,27 @@ func (s *Server) handleWSLogs(w http.ResponseWriter, r *http.Request) {
 			ch, cancel := logger.Subscribe()
 			defer cancel()
 
-			for msg := range ch {
-				if err := websocket.Message.Send(conn, string(msg)); err != nil {
+			for {
+				select {
+				case <-s.done:
 					return
+				case msg, ok := <-ch:
+					if !ok {
+						return
+					}
+					if err := websocket.Message.Send(conn, string(msg)); err != nil {
+						return
+					}
 				}
 			}
 		},
 	}.ServeHTTP(w, r)
 }
 
-func (s *Server) handleWSStatus(w http.ResponseWriter, r *http.Request) {
+// statusMinGap bounds how often a status stream sends on progress changes.
+const statusMinGap = 100 * time.Millisecond
+
+func statusInterval(r *http.Request) time.Duration {
 	interval := time.Second
 	if v := stringsTrimSpace(r.URL.Query().Get("interval_ms")); v != "" {
 		if n, err := strconv.Atoi(v); err == nil {
@@ -40,37 +52,77 @@ func (s *Server) handleWSStatus(w http.ResponseWriter, r *http.Request) {
 			interval = time.Duration(n) * time.Millisecond
 		}
 	}
+	return interval
+}
+
+// streamStatus sends the task status as JSON right away, on every status or
+// progress change (at most every statusMinGap) and at least once per
+// interval, until send fails, done is closed or the server shuts down.
+func (s *Server) streamStatus(done <-chan struct{}, interval time.Duration, send func([]byte) bool) {
+	changes, cancel := s.manager.Subscribe()
+	defer cancel()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
+	for {
+		b, err := json.Marshal(s.manager.Status())
+		if err != nil || !send(b) {
+			return
+		}
+		select {
+		case <-done:
+			return
+		case <-s.done:
+			return
+		case <-time.After(statusMinGap):
+		}
+		select {
+		case <-done:
+			return
+		case <-s.done:
+			return
+		case <-ticker.C:
+		case <-changes:
+		}
+	}
+}
+
+func (s *Server) handleWSStatus(w http.ResponseWriter, r *http.Request) {
+	interval := statusInterval(r)
 	websocket.Server{
 		Handshake: func(cfg *websocket.Config, req *http.Request) error { return nil },
 		Handler: func(conn *websocket.Conn) {
 			conn.PayloadType = websocket.TextFrame
-
-			send := func() bool {
-				st := s.manager.Status()
-				b, err := json.Marshal(st)
-				if err != nil {
-					return false
-				}
-				b = append(b, '\n')
-				return websocket.Message.Send(conn, string(b)) == nil
-			}
-
-			if !send() {
-				return
-			}
-			ticker := time.NewTicker(interval)
-			defer ticker.Stop()
-
-			for range ticker.C {
-				if !send() {
-					return
-				}
-			}
+			s.streamStatus(nil, interval, func(b []byte) bool {
+				return websocket.Message.Send(conn, string(b)+"\n") == nil
+			})
 		},
 	}.ServeHTTP(w, r)
 }
 
+// handleSSEStatus streams the same status messages as /ws/status as
+// Server-Sent Events ("event: status").
+func (s *Server) handleSSEStatus(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": "streaming unsupported"})
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	s.streamStatus(r.Context().Done(), statusInterval(r), func(b []byte) bool {
+		if _, err := fmt.Fprintf(w, "event: status\ndata: %s\n\n", b); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	})
+}
+
 func stringsTrimSpace(s string) string {
 	i := 0
 	j := len(s)
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"media-crawler-go/internal/config"
@@ -67,3 +68,84 @@ func TestWebSocketLogsAndStatus(t *testing.T) {
 	}
 }
 
+
+func TestLiveProgressStatusStreams(t *testing.T) {
+	oldCfg := config.AppConfig
+	config.AppConfig = config.Config{Platform: "xhs", CrawlerType: "search", DataDir: t.TempDir()}
+	t.Cleanup(func() { config.AppConfig = oldCfg })
+
+	step := make(chan struct{})
+	runFn := func(ctx context.Context) (crawler.Result, error) {
+		crawler.ProgressFrom(ctx).SetStage("golang", 2)
+		r := crawler.ForEachLimit(ctx, []string{"n1", "n2", "n3"}, 1, func(ctx context.Context, id string) error {
+			if id == "n2" {
+				<-step
+			}
+			return nil
+		})
+		return crawler.Result{Processed: r.Processed, Succeeded: r.Succeeded}, nil
+	}
+	m := NewTaskManagerWithRunner(runFn)
+	srv := NewServer(m)
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	if err := m.Run(RunRequest{Keywords: "golang"}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	var st Status
+	for time.Now().Before(deadline) {
+		st = m.Status()
+		if st.Progress != nil && st.Progress.Current == "n2" {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if st.State != "running" || st.Progress == nil || st.Progress.Keyword != "golang" || st.Progress.Page != 2 || st.Progress.Total != 3 || st.Progress.Done != 1 || st.Processed != 1 {
+		t.Fatalf("unexpected live status: %+v progress=%+v", st, st.Progress)
+	}
+
+	resp, err := ts.Client().Get(ts.URL + "/api/sse/status")
+	if err != nil {
+		t.Fatalf("sse: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content-type=%q", ct)
+	}
+	events := make(chan Status, 16)
+	go func() {
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			if data, ok := strings.CutPrefix(sc.Text(), "data: "); ok {
+				var st Status
+				if json.Unmarshal([]byte(data), &st) == nil {
+					events <- st
+				}
+			}
+		}
+		close(events)
+	}()
+	if first := <-events; first.Progress == nil || first.Progress.Done != 1 {
+		t.Fatalf("unexpected first event: %+v", first)
+	}
+	close(step)
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				t.Fatalf("stream closed before the task finished")
+			}
+			if ev.State == "idle" {
+				if ev.Progress == nil || ev.Progress.Done != 3 || ev.Progress.Succeeded != 3 || ev.Processed != 3 {
+					t.Fatalf("unexpected final event: %+v progress=%+v", ev, ev.Progress)
+				}
+				return
+			}
+		case <-timeout:
+			t.Fatalf("no final status event")
+		}
+	}
+}
@@ -69,7 +69,10 @@ func StartOrConnectCDP(ctx context.Context, pw *playwright.Playwright, opts CDPO
 			return nil, err
 		}
 
-		cmd = exec.CommandContext(ctx, bin, buildChromeArgs(opts.DebugPort, userDataDir, opts.Headless, opts.ProxyServer)...)
+		// not tied to ctx: the crawler decides in its teardown whether the
+		// browser outlives the run (AUTO_CLOSE_BROWSER) and stops it with
+		// StopBrowserProcess, so a canceled run does not SIGKILL Chrome
+		cmd = exec.Command(bin, buildChromeArgs(opts.DebugPort, userDataDir, opts.Headless, opts.ProxyServer)...)
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 		if err := cmd.Start(); err != nil {
@@ -131,6 +134,33 @@ func StartOrConnectCDP(ctx context.Context, pw *playwright.Playwright, opts CDPO
 	}, nil
 }
 
+// StopBrowserProcess asks a browser started by StartOrConnectCDP to exit so it
+// can flush its profile, kills it if it is still running after timeout and
+// reaps the process.
+func StopBrowserProcess(cmd *exec.Cmd, timeout time.Duration) {
+	if cmd == nil || cmd.Process == nil {
+		return
+	}
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	done := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(done)
+	}()
+	// os.Interrupt is not supported on Windows; kill right away there
+	if runtime.GOOS == "windows" || cmd.Process.Signal(os.Interrupt) != nil {
+		_ = cmd.Process.Kill()
+	}
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		_ = cmd.Process.Kill()
+		<-done
+	}
+}
+
 func waitCDPReady(ctx context.Context, endpoint string, interval time.Duration) error {
 	url := endpoint + "/json/version"
 	client := &http.Client{Timeout: 2 * time.Second}
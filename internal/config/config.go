@@ -63,14 +63,42 @@ type Config struct {
 	CustomWords          map[string]string `mapstructure:"CUSTOM_WORDS"`
 	StealthScriptPath    string            `mapstructure:"STEALTH_SCRIPT_PATH"`
 
+	// API server authentication. Tokens and basic auth users are
+	// "name:role:secret" entries; roles are viewer, operator and admin.
+	APITokens        []string `mapstructure:"API_TOKENS"`
+	APIBasicAuth     []string `mapstructure:"API_BASIC_AUTH"`
+	APIJWTSecret     string   `mapstructure:"API_JWT_SECRET"`
+	APIAnonymousRole string   `mapstructure:"API_ANONYMOUS_ROLE"`
+	APIAuditLog      string   `mapstructure:"API_AUDIT_LOG"`
+
+	// Outbound webhooks for task lifecycle and data events.
+	Webhooks                []WebhookConfig `mapstructure:"WEBHOOKS"`
+	WebhookMaxRetries       int             `mapstructure:"WEBHOOK_MAX_RETRIES"`
+	WebhookRetryBaseDelayMs int             `mapstructure:"WEBHOOK_RETRY_BASE_DELAY_MS"`
+	WebhookTimeoutSec       int             `mapstructure:"WEBHOOK_TIMEOUT_SEC"`
+	WebhookDeadLetter       string          `mapstructure:"WEBHOOK_DEAD_LETTER"`
+
+	// ShutdownTimeoutSec bounds how long Ctrl-C/SIGTERM waits for the
+	// running task to stop and flush before the process exits.
+	ShutdownTimeoutSec int `mapstructure:"SHUTDOWN_TIMEOUT_SEC"`
+
+	// Creator relationship graph (creator mode)
+	EnableGetCreatorRelations bool `mapstructure:"ENABLE_GET_CREATOR_RELATIONS"`
+	CrawlerMaxRelationsCount  int  `mapstructure:"CRAWLER_MAX_RELATIONS_COUNT"`
+
 	// XHS Specific
 	SortType             string   `mapstructure:"SORT_TYPE"`
 	XhsSpecifiedNoteUrls []string `mapstructure:"XHS_SPECIFIED_NOTE_URL_LIST"`
 	XhsCreatorIdList     []string `mapstructure:"XHS_CREATOR_ID_LIST"`
+	XhsTopicIdList       []string `mapstructure:"XHS_TOPIC_ID_LIST"`
+	XhsTopicSort         string   `mapstructure:"XHS_TOPIC_SORT"`
+	XhsFeedChannelList   []string `mapstructure:"XHS_FEED_CHANNEL_LIST"`
 
 	// Douyin Specific
 	DouyinSpecifiedNoteUrls []string `mapstructure:"DY_SPECIFIED_NOTE_URL_LIST"`
 	DouyinCreatorIdList     []string `mapstructure:"DY_CREATOR_ID_LIST"`
+	DouyinMusicIdList       []string `mapstructure:"DY_MUSIC_ID_LIST"`
+	DouyinMixIdList         []string `mapstructure:"DY_MIX_ID_LIST"`
 
 	// Bilibili Specific
 	BiliSpecifiedVideoUrls []string `mapstructure:"BILI_SPECIFIED_VIDEO_URL_LIST"`
@@ -81,11 +109,28 @@ type Config struct {
 	BiliDateRangeEnd       string   `mapstructure:"BILI_DATE_RANGE_END"`
 	BiliMaxNotesPerDay     int      `mapstructure:"BILI_MAX_NOTES_PER_DAY"`
 	BiliEnableGetDynamics  bool     `mapstructure:"BILI_ENABLE_GET_DYNAMICS"`
+	EnableGetDanmaku       bool     `mapstructure:"ENABLE_GET_DANMAKU"`
+	BiliDanmakuSource      string   `mapstructure:"BILI_DANMAKU_SOURCE"`
+	BiliMergeDash          bool     `mapstructure:"BILI_MERGE_DASH"`
+	BiliKeepDashOriginals  bool     `mapstructure:"BILI_KEEP_DASH_ORIGINALS"`
+	BiliLiveRoomIdList     []string `mapstructure:"BILI_LIVE_ROOM_ID_LIST"`
+	BiliLiveRecordSec      int      `mapstructure:"BILI_LIVE_RECORD_SEC"`
+	BiliFavMediaIdList     []string `mapstructure:"BILI_FAV_MEDIA_ID_LIST"`
+	BiliSeasonIdList       []string `mapstructure:"BILI_SEASON_ID_LIST"`
+	BiliSeriesIdList       []string `mapstructure:"BILI_SERIES_ID_LIST"`
+	BiliEnableWatchLater   bool     `mapstructure:"BILI_ENABLE_WATCH_LATER"`
 
 	// Weibo Specific
 	WBSpecifiedNoteUrls []string `mapstructure:"WB_SPECIFIED_NOTE_URL_LIST"`
 	WBCreatorIdList     []string `mapstructure:"WB_CREATOR_ID_LIST"`
 	WBSearchType        string   `mapstructure:"WB_SEARCH_TYPE"`
+	EnableGetReposts    bool     `mapstructure:"ENABLE_GET_REPOSTS"`
+	WBMaxReposts        int      `mapstructure:"WB_MAX_REPOSTS"`
+	WBMaxRepostPages    int      `mapstructure:"WB_MAX_REPOST_PAGES"`
+
+	WBTrendingPollIntervalSec int `mapstructure:"WB_TRENDING_POLL_INTERVAL_SEC"`
+	WBTrendingPollCount       int `mapstructure:"WB_TRENDING_POLL_COUNT"`
+	WBTrendingSearchTopN      int `mapstructure:"WB_TRENDING_SEARCH_TOP_N"`
 
 	// Tieba Specific
 	TiebaSpecifiedNoteUrls []string `mapstructure:"TIEBA_SPECIFIED_NOTE_URL_LIST"`
@@ -100,6 +145,18 @@ type Config struct {
 	KuaishouCreatorUrlList    []string `mapstructure:"KS_CREATOR_URL_LIST"`
 }
 
+// WebhookConfig is one webhook subscription. Events filters the event
+// types sent ("task.finished", "task.*"); empty or "*" means every event
+// except the per-note "note.saved". Format is json (default), dingtalk,
+// feishu, wecom or slack.
+type WebhookConfig struct {
+	Name   string   `mapstructure:"name"`
+	URL    string   `mapstructure:"url"`
+	Events []string `mapstructure:"events"`
+	Secret string   `mapstructure:"secret"`
+	Format string   `mapstructure:"format"`
+}
+
 var AppConfig Config
 
 func LoadConfig(path string) error {
@@ -161,7 +218,20 @@ func LoadConfig(path string) error {
 	viper.SetDefault("FONT_PATH", "")
 	viper.SetDefault("CUSTOM_WORDS", map[string]string{})
 	viper.SetDefault("STEALTH_SCRIPT_PATH", "")
+	viper.SetDefault("API_JWT_SECRET", "")
+	viper.SetDefault("API_ANONYMOUS_ROLE", "")
+	viper.SetDefault("API_AUDIT_LOG", "")
+	viper.SetDefault("WEBHOOK_MAX_RETRIES", 5)
+	viper.SetDefault("WEBHOOK_RETRY_BASE_DELAY_MS", 1000)
+	viper.SetDefault("WEBHOOK_TIMEOUT_SEC", 10)
+	viper.SetDefault("WEBHOOK_DEAD_LETTER", "")
+	viper.SetDefault("SHUTDOWN_TIMEOUT_SEC", 30)
+	viper.SetDefault("ENABLE_GET_CREATOR_RELATIONS", false)
+	viper.SetDefault("CRAWLER_MAX_RELATIONS_COUNT", 200)
 	viper.SetDefault("SORT_TYPE", "popularity_descending")
+	viper.SetDefault("XHS_TOPIC_ID_LIST", []string{})
+	viper.SetDefault("XHS_TOPIC_SORT", "hot")
+	viper.SetDefault("XHS_FEED_CHANNEL_LIST", []string{"recommend"})
 	viper.SetDefault("BILI_CREATOR_ID_LIST", []string{})
 	viper.SetDefault("BILI_SEARCH_MODE", "video")
 	viper.SetDefault("BILI_QN", 80)
@@ -169,6 +239,16 @@ func LoadConfig(path string) error {
 	viper.SetDefault("BILI_DATE_RANGE_END", "")
 	viper.SetDefault("BILI_MAX_NOTES_PER_DAY", 0)
 	viper.SetDefault("BILI_ENABLE_GET_DYNAMICS", false)
+	viper.SetDefault("ENABLE_GET_DANMAKU", false)
+	viper.SetDefault("BILI_DANMAKU_SOURCE", "protobuf")
+	viper.SetDefault("BILI_MERGE_DASH", true)
+	viper.SetDefault("BILI_KEEP_DASH_ORIGINALS", false)
+	viper.SetDefault("BILI_LIVE_ROOM_ID_LIST", []string{})
+	viper.SetDefault("BILI_LIVE_RECORD_SEC", 300)
+	viper.SetDefault("BILI_FAV_MEDIA_ID_LIST", []string{})
+	viper.SetDefault("BILI_SEASON_ID_LIST", []string{})
+	viper.SetDefault("BILI_SERIES_ID_LIST", []string{})
+	viper.SetDefault("BILI_ENABLE_WATCH_LATER", false)
 	viper.SetDefault("TIEBA_SPECIFIED_NOTE_URL_LIST", []string{})
 	viper.SetDefault("TIEBA_CREATOR_URL_LIST", []string{})
 	viper.SetDefault("ZHIHU_SPECIFIED_NOTE_URL_LIST", []string{})
@@ -177,6 +257,12 @@ func LoadConfig(path string) error {
 	viper.SetDefault("KS_CREATOR_URL_LIST", []string{})
 	viper.SetDefault("WB_CREATOR_ID_LIST", []string{})
 	viper.SetDefault("WB_SEARCH_TYPE", "1")
+	viper.SetDefault("ENABLE_GET_REPOSTS", false)
+	viper.SetDefault("WB_MAX_REPOSTS", 200)
+	viper.SetDefault("WB_MAX_REPOST_PAGES", 20)
+	viper.SetDefault("WB_TRENDING_POLL_INTERVAL_SEC", 0)
+	viper.SetDefault("WB_TRENDING_POLL_COUNT", 1)
+	viper.SetDefault("WB_TRENDING_SEARCH_TOP_N", 0)
 
 	viper.SetEnvPrefix("MEDIA_CRAWLER")
 	viper.AutomaticEnv()
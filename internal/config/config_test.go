@@ -35,3 +35,26 @@ func TestLoadConfig_SaveDataOptionExcelAlias(t *testing.T) {
 		t.Fatalf("LoginType = %q, want %q", AppConfig.LoginType, "cookie")
 	}
 }
+
+func TestLoadConfig_Webhooks(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	dir := t.TempDir()
+	cfg := []byte("WEBHOOKS:\n  - name: ops\n    url: https://example.com/hook\n    events: [\"task.*\", \"note.saved\"]\n    secret: s\n  - url: https://oapi.dingtalk.com/robot/send?access_token=x\n")
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), cfg, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadConfig(dir); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if len(AppConfig.Webhooks) != 2 {
+		t.Fatalf("Webhooks = %+v", AppConfig.Webhooks)
+	}
+	if w := AppConfig.Webhooks[0]; w.Name != "ops" || w.URL != "https://example.com/hook" || len(w.Events) != 2 || w.Secret != "s" {
+		t.Fatalf("Webhooks[0] = %+v", w)
+	}
+	if AppConfig.WebhookMaxRetries != 5 {
+		t.Fatalf("WebhookMaxRetries = %d, want 5", AppConfig.WebhookMaxRetries)
+	}
+}
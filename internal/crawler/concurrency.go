@@ -2,6 +2,9 @@ package crawler
 
 import (
 	"context"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/metrics"
+	"strings"
 )
 
 type ItemResult struct {
@@ -15,6 +18,9 @@ func ForEachLimit[T any](ctx context.Context, items []T, limit int, fn func(cont
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	progress := ProgressFrom(ctx)
+	progress.AddTotal(len(items))
+	fn = reportItemProgress(progress, fn)
 	if limit <= 1 {
 		var out ItemResult
 		for _, it := range items {
@@ -31,6 +37,7 @@ func ForEachLimit[T any](ctx context.Context, items []T, limit int, fn func(cont
 			}
 			out.Succeeded++
 		}
+		recordItemMetrics(out)
 		return out
 	}
 
@@ -69,9 +76,31 @@ func ForEachLimit[T any](ctx context.Context, items []T, limit int, fn func(cont
 		}
 		out.Succeeded++
 	}
+	recordItemMetrics(out)
 	return out
 }
 
+func reportItemProgress[T any](progress ProgressReporter, fn func(context.Context, T) error) func(context.Context, T) error {
+	return func(ctx context.Context, it T) error {
+		label := progressLabel(it)
+		progress.StartItem(label)
+		err := fn(ctx, it)
+		progress.FinishItem(label, err)
+		return err
+	}
+}
+
+// recordItemMetrics adds a ForEachLimit batch to the item and error kind
+// counters of the configured platform.
+func recordItemMetrics(r ItemResult) {
+	platform := strings.ToLower(strings.TrimSpace(config.AppConfig.Platform))
+	metrics.ItemsProcessed.Add(float64(r.Succeeded), platform, "succeeded")
+	metrics.ItemsProcessed.Add(float64(r.Failed), platform, "failed")
+	for kind, n := range r.FailureKinds {
+		metrics.Errors.Add(float64(n), platform, kind)
+	}
+}
+
 func mergeFailureKind(m map[string]int, kind ErrorKind) map[string]int {
 	if kind == "" {
 		kind = ErrorKindUnknown
@@ -0,0 +1,27 @@
+package crawler
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ParseCount reads a display count such as "1.2万", "3w", "1,024" or "10+".
+// Unparseable input yields 0.
+func ParseCount(s string) int64 {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	s = strings.TrimSuffix(s, "+")
+	mult := 1.0
+	switch {
+	case strings.HasSuffix(s, "亿"):
+		mult, s = 1e8, strings.TrimSuffix(s, "亿")
+	case strings.HasSuffix(s, "万"):
+		mult, s = 1e4, strings.TrimSuffix(s, "万")
+	case strings.HasSuffix(s, "w"), strings.HasSuffix(s, "W"):
+		mult, s = 1e4, s[:len(s)-1]
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0
+	}
+	return int64(f*mult + 0.5)
+}
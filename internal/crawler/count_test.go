@@ -0,0 +1,23 @@
+package crawler
+
+import "testing"
+
+func TestParseCount(t *testing.T) {
+	cases := map[string]int64{
+		"":      0,
+		"abc":   0,
+		"12":    12,
+		"1,024": 1024,
+		"10+":   10,
+		"1.2万":  12000,
+		"3w":    30000,
+		"1万+":   10000,
+		"2.5亿":  250000000,
+		" 7 ":   7,
+	}
+	for in, want := range cases {
+		if got := ParseCount(in); got != want {
+			t.Fatalf("ParseCount(%q)=%d want %d", in, got, want)
+		}
+	}
+}
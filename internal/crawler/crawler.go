@@ -9,9 +9,16 @@ import (
 type Mode string
 
 const (
-	ModeSearch  Mode = "search"
-	ModeDetail  Mode = "detail"
-	ModeCreator Mode = "creator"
+	ModeSearch     Mode = "search"
+	ModeDetail     Mode = "detail"
+	ModeCreator    Mode = "creator"
+	ModeTrending   Mode = "trending"
+	ModeMusic      Mode = "music"
+	ModeMix        Mode = "mix"
+	ModeLive       Mode = "live"
+	ModeCollection Mode = "collection"
+	ModeTopic      Mode = "topic"
+	ModeFeed       Mode = "feed"
 )
 
 func NormalizeMode(s string) Mode {
@@ -21,6 +28,20 @@ func NormalizeMode(s string) Mode {
 		return ModeDetail
 	case "creator":
 		return ModeCreator
+	case "trending":
+		return ModeTrending
+	case "music":
+		return ModeMusic
+	case "mix":
+		return ModeMix
+	case "live":
+		return ModeLive
+	case "collection":
+		return ModeCollection
+	case "topic":
+		return ModeTopic
+	case "feed", "homefeed":
+		return ModeFeed
 	default:
 		return ModeSearch
 	}
@@ -60,3 +81,21 @@ func NewResult(req Request) Result {
 type Runner interface {
 	Run(ctx context.Context, req Request) (Result, error)
 }
+
+type taskIDKey struct{}
+
+// WithTaskID returns a context carrying the id of the API task running the
+// crawl, for TaskIDFrom.
+func WithTaskID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, taskIDKey{}, id)
+}
+
+// TaskIDFrom returns the task id of ctx, or "" for crawls started outside a
+// task.
+func TaskIDFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(taskIDKey{}).(string)
+	return id
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"media-crawler-go/internal/metrics"
+	"media-crawler-go/internal/webhook"
 	"net"
 	"regexp"
 	"strconv"
+	"time"
 )
 
 type ErrorKind string
@@ -100,6 +103,12 @@ func MergeFailureKinds(dst map[string]int, src map[string]int) map[string]int {
 }
 
 func NewRiskHintError(platform, url, hint string) error {
+	metrics.RiskHints.Inc(platform, hint)
+	webhook.EmitThrottled(platform+"|"+hint, time.Minute, webhook.Event{
+		Type:     webhook.RiskHint,
+		Platform: platform,
+		Data:     map[string]any{"hint": hint, "url": url},
+	})
 	return Error{
 		Kind:     ErrorKindRiskHint,
 		Platform: platform,
@@ -25,6 +25,10 @@ func RequestFromConfig(cfg config.Config) Request {
 			out.Inputs = cfg.XhsSpecifiedNoteUrls
 		case ModeCreator:
 			out.Inputs = cfg.XhsCreatorIdList
+		case ModeTopic:
+			out.Inputs = cfg.XhsTopicIdList
+		case ModeFeed:
+			out.Inputs = cfg.XhsFeedChannelList
 		}
 	case "douyin", "dy":
 		switch mode {
@@ -32,6 +36,10 @@ func RequestFromConfig(cfg config.Config) Request {
 			out.Inputs = cfg.DouyinSpecifiedNoteUrls
 		case ModeCreator:
 			out.Inputs = cfg.DouyinCreatorIdList
+		case ModeMusic:
+			out.Inputs = cfg.DouyinMusicIdList
+		case ModeMix:
+			out.Inputs = cfg.DouyinMixIdList
 		}
 	case "bilibili", "bili", "b站", "b":
 		switch mode {
@@ -39,6 +47,10 @@ func RequestFromConfig(cfg config.Config) Request {
 			out.Inputs = cfg.BiliSpecifiedVideoUrls
 		case ModeCreator:
 			out.Inputs = cfg.BiliCreatorIdList
+		case ModeLive:
+			out.Inputs = cfg.BiliLiveRoomIdList
+		case ModeCollection:
+			out.Inputs = BiliCollectionInputs(cfg)
 		}
 	case "weibo", "wb", "微博":
 		switch mode {
@@ -73,6 +85,35 @@ func RequestFromConfig(cfg config.Config) Request {
 	return out
 }
 
+// BiliCollectionInputs merges the bilibili collection lists into typed inputs
+// ("fav:<media_id>", "season:<mid>:<season_id>", "series:<mid>:<series_id>",
+// "watchlater"). URLs and already typed values are kept as they are.
+func BiliCollectionInputs(cfg config.Config) []string {
+	var out []string
+	add := func(kind string, values []string) {
+		for _, v := range values {
+			v = strings.TrimSpace(v)
+			lower := strings.ToLower(v)
+			switch {
+			case v == "":
+				continue
+			case strings.Contains(v, "://"), strings.HasPrefix(lower, "fav:"), strings.HasPrefix(lower, "season:"),
+				strings.HasPrefix(lower, "series:"), lower == "watchlater", strings.HasPrefix(lower, "ml"):
+				out = append(out, v)
+			default:
+				out = append(out, kind+":"+v)
+			}
+		}
+	}
+	add("fav", cfg.BiliFavMediaIdList)
+	add("season", cfg.BiliSeasonIdList)
+	add("series", cfg.BiliSeriesIdList)
+	if cfg.BiliEnableWatchLater {
+		out = append(out, "watchlater")
+	}
+	return out
+}
+
 func splitCSV(s string) []string {
 	v := strings.TrimSpace(s)
 	if v == "" {
@@ -0,0 +1,183 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"media-crawler-go/internal/metrics"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProgressReporter receives live progress from a running crawl. Runners get
+// it from their context with ProgressFrom. ForEachLimit already reports its
+// items, so runners only need to call SetStage from their search loops.
+type ProgressReporter interface {
+	// SetStage records the keyword (or input) and page being crawled.
+	SetStage(keyword string, page int)
+	// AddTotal announces n more items to process, e.g. the notes of a
+	// search page.
+	AddTotal(n int)
+	// StartItem marks the item with the given label as in progress.
+	StartItem(label string)
+	// FinishItem marks an item as done, failed when err is not nil.
+	FinishItem(label string, err error)
+}
+
+// Progress is a snapshot of a ProgressTracker. Total only counts the items
+// announced so far, so it grows while a search pages on; EtaSec is
+// extrapolated from the average time per finished item.
+type Progress struct {
+	Keyword         string `json:"keyword,omitempty"`
+	Page            int    `json:"page,omitempty"`
+	Current         string `json:"current,omitempty"`
+	Total           int    `json:"total"`
+	Done            int    `json:"done"`
+	Succeeded       int    `json:"succeeded"`
+	Failed          int    `json:"failed"`
+	CommentsFetched int64  `json:"comments_fetched"`
+	MediaDownloaded int64  `json:"media_downloaded"`
+	StartedAt       int64  `json:"started_at"`
+	UpdatedAt       int64  `json:"updated_at"`
+	EtaSec          int64  `json:"eta_sec,omitempty"`
+}
+
+type progressKey struct{}
+
+// WithProgress returns a context carrying r for ProgressFrom.
+func WithProgress(ctx context.Context, r ProgressReporter) context.Context {
+	return context.WithValue(ctx, progressKey{}, r)
+}
+
+// ProgressFrom returns the reporter of ctx, or one that discards everything.
+func ProgressFrom(ctx context.Context) ProgressReporter {
+	if ctx != nil {
+		if r, ok := ctx.Value(progressKey{}).(ProgressReporter); ok && r != nil {
+			return r
+		}
+	}
+	return nopProgress{}
+}
+
+type nopProgress struct{}
+
+func (nopProgress) SetStage(string, int)     {}
+func (nopProgress) AddTotal(int)             {}
+func (nopProgress) StartItem(string)         {}
+func (nopProgress) FinishItem(string, error) {}
+
+// ProgressTracker is the ProgressReporter of one task. Comments fetched and
+// media downloaded are read from the metrics counters of the platform,
+// relative to their values when the tracker was created.
+type ProgressTracker struct {
+	platform string
+	onChange func()
+
+	mu        sync.Mutex
+	p         Progress
+	start     time.Time
+	comments0 float64
+	media0    float64
+}
+
+// NewProgressTracker starts tracking a task of platform. onChange, if set,
+// is called (without locks held) after every update.
+func NewProgressTracker(platform string, onChange func()) *ProgressTracker {
+	platform = strings.ToLower(strings.TrimSpace(platform))
+	now := time.Now()
+	return &ProgressTracker{
+		platform:  platform,
+		onChange:  onChange,
+		p:         Progress{StartedAt: now.Unix(), UpdatedAt: now.Unix()},
+		start:     now,
+		comments0: metrics.CommentsFetched.Value(platform),
+		media0:    metrics.Downloads.Value(platform, "ok"),
+	}
+}
+
+func (t *ProgressTracker) update(fn func(p *Progress)) {
+	t.mu.Lock()
+	fn(&t.p)
+	t.p.UpdatedAt = time.Now().Unix()
+	t.mu.Unlock()
+	if t.onChange != nil {
+		t.onChange()
+	}
+}
+
+func (t *ProgressTracker) SetStage(keyword string, page int) {
+	t.update(func(p *Progress) {
+		p.Keyword = keyword
+		p.Page = page
+	})
+}
+
+func (t *ProgressTracker) AddTotal(n int) {
+	if n <= 0 {
+		return
+	}
+	t.update(func(p *Progress) { p.Total += n })
+}
+
+func (t *ProgressTracker) StartItem(label string) {
+	if label == "" {
+		return
+	}
+	t.update(func(p *Progress) { p.Current = label })
+}
+
+func (t *ProgressTracker) FinishItem(label string, err error) {
+	t.update(func(p *Progress) {
+		p.Done++
+		if err != nil {
+			p.Failed++
+		} else {
+			p.Succeeded++
+		}
+		if p.Done > p.Total {
+			p.Total = p.Done
+		}
+	})
+}
+
+// Snapshot returns the current progress with counters and ETA filled in.
+func (t *ProgressTracker) Snapshot() Progress {
+	t.mu.Lock()
+	p := t.p
+	t.mu.Unlock()
+	p.CommentsFetched = int64(metrics.CommentsFetched.Value(t.platform) - t.comments0)
+	p.MediaDownloaded = int64(metrics.Downloads.Value(t.platform, "ok") - t.media0)
+	if p.Done > 0 && p.Total > p.Done {
+		perItem := time.Since(t.start) / time.Duration(p.Done)
+		p.EtaSec = int64((perItem * time.Duration(p.Total-p.Done)).Seconds())
+	}
+	return p
+}
+
+// progressIDFields are the struct fields tried, in order, to label
+// ForEachLimit items that are not strings.
+var progressIDFields = []string{"NoteID", "NoteId", "BVID", "PhotoID", "ID", "Id", "CollectionID"}
+
+func progressLabel(it any) string {
+	switch v := it.(type) {
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	}
+	rv := reflect.ValueOf(it)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return ""
+	}
+	for _, name := range progressIDFields {
+		f := rv.FieldByName(name)
+		if f.IsValid() && f.Kind() == reflect.String && f.String() != "" {
+			return f.String()
+		}
+	}
+	return ""
+}
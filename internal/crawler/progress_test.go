@@ -0,0 +1,53 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestForEachLimitReportsProgress(t *testing.T) {
+	tr := NewProgressTracker("test", nil)
+	ctx := WithProgress(context.Background(), tr)
+
+	type task struct{ NoteID string }
+	tr.SetStage("go", 3)
+	ForEachLimit(ctx, []task{{"a"}, {"b"}}, 2, func(ctx context.Context, it task) error {
+		if it.NoteID == "b" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	ForEachLimit(ctx, []string{"c"}, 1, func(ctx context.Context, it string) error { return nil })
+
+	p := tr.Snapshot()
+	if p.Keyword != "go" || p.Page != 3 || p.Total != 3 || p.Done != 3 || p.Succeeded != 2 || p.Failed != 1 || p.Current != "c" {
+		t.Fatalf("unexpected progress: %+v", p)
+	}
+
+	tr.AddTotal(3)
+	if p := tr.Snapshot(); p.EtaSec < 0 || p.Total != 6 {
+		t.Fatalf("unexpected eta: %+v", p)
+	}
+
+	// Without a reporter in the context nothing is recorded.
+	ForEachLimit(context.Background(), []string{"x"}, 1, func(ctx context.Context, it string) error { return nil })
+	if p := tr.Snapshot(); p.Done != 3 {
+		t.Fatalf("unexpected progress after unrelated run: %+v", p)
+	}
+}
+
+func TestProgressLabel(t *testing.T) {
+	type ref struct {
+		AID  int64
+		BVID string
+	}
+	for in, want := range map[any]string{"id1": "id1", ref{1, "BV1"}: "BV1", 42: ""} {
+		if got := progressLabel(in); got != want {
+			t.Fatalf("%v: got %q want %q", in, got, want)
+		}
+	}
+	if got := progressLabel(&ref{BVID: "BV2"}); got != "BV2" {
+		t.Fatalf("pointer: %q", got)
+	}
+}
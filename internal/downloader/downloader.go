@@ -7,6 +7,7 @@ import (
 	"media-crawler-go/internal/config"
 	"media-crawler-go/internal/crawler"
 	"media-crawler-go/internal/logger"
+	"media-crawler-go/internal/metrics"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -86,8 +87,11 @@ func (d *Downloader) download(url, filename string, headers map[string]string) e
 
 	path := filepath.Join(d.Dir, filename)
 
+	platform := strings.ToLower(strings.TrimSpace(config.AppConfig.Platform))
+
 	// Check if file exists
 	if _, err := os.Stat(path); err == nil {
+		metrics.Downloads.Inc(platform, "skipped")
 		return nil // File already exists
 	}
 
@@ -151,7 +155,9 @@ func (d *Downloader) download(url, filename string, headers map[string]string) e
 				_ = os.Remove(tmpPath)
 			}()
 
-			if _, err := io.Copy(tmp, resp.Body); err != nil {
+			n, err := io.Copy(tmp, resp.Body)
+			metrics.DownloadBytes.Add(float64(n), platform)
+			if err != nil {
 				lastErr = err
 				return
 			}
@@ -168,14 +174,17 @@ func (d *Downloader) download(url, filename string, headers map[string]string) e
 		}()
 
 		if lastErr == nil {
+			metrics.Downloads.Inc(platform, "ok")
 			return nil
 		}
 		if resp != nil && shouldRetryStatus(resp.StatusCode) {
 			time.Sleep(backoffDelay(attempt, baseDelay, maxDelay))
 			continue
 		}
+		metrics.Downloads.Inc(platform, "failed")
 		return lastErr
 	}
+	metrics.Downloads.Inc(platform, "failed")
 	return lastErr
 }
 
@@ -0,0 +1,822 @@
+package downloader
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// MuxOptions controls MuxFragmentedMP4.
+type MuxOptions struct {
+	// KeepOriginals leaves the input files on disk after a successful mux.
+	KeepOriginals bool
+}
+
+// MuxFragmentedMP4 merges single-track fragmented MP4 inputs (e.g. the DASH
+// video and audio streams served by bilibili) into one progressive MP4 with a
+// regular sample table, so the result plays in ordinary players. Sample data
+// is copied as-is; nothing is re-encoded.
+func MuxFragmentedMP4(outPath string, inputs []string, opts MuxOptions) error {
+	if len(inputs) == 0 {
+		return errors.New("mux: no inputs")
+	}
+	files := make([]*os.File, 0, len(inputs))
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	tracks := make([]*fmp4Track, 0, len(inputs))
+	for _, in := range inputs {
+		f, err := os.Open(in)
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+		st, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		t, err := parseFragmentedTrack(f, st.Size())
+		if err != nil {
+			return fmt.Errorf("mux: %s: %w", filepath.Base(in), err)
+		}
+		t.src = f
+		tracks = append(tracks, t)
+	}
+
+	dir := filepath.Dir(outPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(outPath)+".part-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+	}()
+
+	if err := writeProgressiveMP4(tmp, tracks); err != nil {
+		return fmt.Errorf("mux: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, outPath); err != nil {
+		return err
+	}
+	if !opts.KeepOriginals {
+		for i, f := range files {
+			_ = f.Close()
+			_ = os.Remove(inputs[i])
+		}
+		files = nil
+	}
+	return nil
+}
+
+type mp4Sample struct {
+	offset   int64
+	size     uint32
+	duration uint32
+	cto      int32
+	sync     bool
+}
+
+// mp4Chunk is a run of samples taken from one track fragment; it becomes one
+// chunk in the output sample table.
+type mp4Chunk struct {
+	first int
+	count int
+	dts   uint64
+}
+
+type fmp4Track struct {
+	src       io.ReaderAt
+	trak      []byte
+	trackID   uint32
+	timescale uint32
+	trex      trexDefaults
+	samples   []mp4Sample
+	chunks    []mp4Chunk
+}
+
+type trexDefaults struct {
+	duration uint32
+	size     uint32
+	flags    uint32
+}
+
+func (t *fmp4Track) mediaDuration() uint64 {
+	var d uint64
+	for _, s := range t.samples {
+		d += uint64(s.duration)
+	}
+	return d
+}
+
+type mp4Box struct {
+	typ    string
+	offset int64
+	hdr    int64
+	size   int64
+}
+
+func readBoxHeader(r io.ReaderAt, off, end int64) (mp4Box, error) {
+	var h [16]byte
+	if _, err := r.ReadAt(h[:8], off); err != nil {
+		return mp4Box{}, err
+	}
+	b := mp4Box{typ: string(h[4:8]), offset: off, hdr: 8, size: int64(binary.BigEndian.Uint32(h[:4]))}
+	switch b.size {
+	case 0:
+		b.size = end - off
+	case 1:
+		if _, err := r.ReadAt(h[8:16], off+8); err != nil {
+			return mp4Box{}, err
+		}
+		b.hdr = 16
+		b.size = int64(binary.BigEndian.Uint64(h[8:16]))
+	}
+	if b.size < b.hdr || off+b.size > end {
+		return mp4Box{}, fmt.Errorf("invalid %q box size %d at %d", b.typ, b.size, off)
+	}
+	return b, nil
+}
+
+func readBoxBody(r io.ReaderAt, b mp4Box) ([]byte, error) {
+	buf := make([]byte, b.size-b.hdr)
+	if _, err := r.ReadAt(buf, b.offset+b.hdr); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+type rawBox struct {
+	typ  string
+	full []byte
+	body []byte
+}
+
+func splitBoxes(buf []byte) ([]rawBox, error) {
+	var out []rawBox
+	for len(buf) > 0 {
+		if len(buf) < 8 {
+			return nil, errors.New("truncated box header")
+		}
+		size := uint64(binary.BigEndian.Uint32(buf))
+		hdr := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(buf))
+		case 1:
+			if len(buf) < 16 {
+				return nil, errors.New("truncated box header")
+			}
+			size = binary.BigEndian.Uint64(buf[8:])
+			hdr = 16
+		}
+		if size < hdr || size > uint64(len(buf)) {
+			return nil, fmt.Errorf("invalid %q box size %d", string(buf[4:8]), size)
+		}
+		out = append(out, rawBox{typ: string(buf[4:8]), full: buf[:size], body: buf[hdr:size]})
+		buf = buf[size:]
+	}
+	return out, nil
+}
+
+func findBox(boxes []rawBox, typ string) (rawBox, bool) {
+	for _, b := range boxes {
+		if b.typ == typ {
+			return b, true
+		}
+	}
+	return rawBox{}, false
+}
+
+func findPath(buf []byte, path ...string) (rawBox, bool) {
+	var cur rawBox
+	for _, typ := range path {
+		boxes, err := splitBoxes(buf)
+		if err != nil {
+			return rawBox{}, false
+		}
+		b, ok := findBox(boxes, typ)
+		if !ok {
+			return rawBox{}, false
+		}
+		cur = b
+		buf = b.body
+	}
+	return cur, true
+}
+
+func parseFragmentedTrack(r io.ReaderAt, size int64) (*fmp4Track, error) {
+	t := &fmp4Track{}
+	var haveMoov bool
+	var dts uint64
+	for off := int64(0); off < size; {
+		b, err := readBoxHeader(r, off, size)
+		if err != nil {
+			return nil, err
+		}
+		switch b.typ {
+		case "moov":
+			body, err := readBoxBody(r, b)
+			if err != nil {
+				return nil, err
+			}
+			if err := t.parseMoov(body); err != nil {
+				return nil, err
+			}
+			haveMoov = true
+		case "moof":
+			if !haveMoov {
+				return nil, errors.New("moof before moov")
+			}
+			body, err := readBoxBody(r, b)
+			if err != nil {
+				return nil, err
+			}
+			if dts, err = t.parseMoof(body, b.offset, dts); err != nil {
+				return nil, err
+			}
+		}
+		off += b.size
+	}
+	if !haveMoov {
+		return nil, errors.New("missing moov")
+	}
+	if len(t.samples) == 0 {
+		return nil, errors.New("no fragmented samples (not a fragmented mp4?)")
+	}
+	for _, s := range t.samples {
+		if s.offset < 0 || s.offset+int64(s.size) > size {
+			return nil, fmt.Errorf("sample data out of range at %d", s.offset)
+		}
+	}
+	return t, nil
+}
+
+func (t *fmp4Track) parseMoov(body []byte) error {
+	boxes, err := splitBoxes(body)
+	if err != nil {
+		return err
+	}
+	var traks []rawBox
+	for _, b := range boxes {
+		if b.typ == "trak" {
+			traks = append(traks, b)
+		}
+	}
+	if len(traks) != 1 {
+		return fmt.Errorf("expected exactly one trak, got %d", len(traks))
+	}
+	t.trak = traks[0].full
+
+	tkhd, ok := findPath(traks[0].body, "tkhd")
+	if !ok || len(tkhd.body) < 24 {
+		return errors.New("missing tkhd")
+	}
+	if tkhd.body[0] == 1 {
+		if len(tkhd.body) < 36 {
+			return errors.New("short tkhd")
+		}
+		t.trackID = binary.BigEndian.Uint32(tkhd.body[20:])
+	} else {
+		t.trackID = binary.BigEndian.Uint32(tkhd.body[12:])
+	}
+
+	mdhd, ok := findPath(traks[0].body, "mdia", "mdhd")
+	if !ok || len(mdhd.body) < 24 {
+		return errors.New("missing mdhd")
+	}
+	if mdhd.body[0] == 1 {
+		if len(mdhd.body) < 32 {
+			return errors.New("short mdhd")
+		}
+		t.timescale = binary.BigEndian.Uint32(mdhd.body[20:])
+	} else {
+		t.timescale = binary.BigEndian.Uint32(mdhd.body[12:])
+	}
+	if t.timescale == 0 {
+		return errors.New("zero timescale")
+	}
+
+	if mvex, ok := findBox(boxes, "mvex"); ok {
+		children, err := splitBoxes(mvex.body)
+		if err != nil {
+			return err
+		}
+		for _, c := range children {
+			if c.typ != "trex" || len(c.body) < 24 {
+				continue
+			}
+			if binary.BigEndian.Uint32(c.body[4:]) != t.trackID {
+				continue
+			}
+			t.trex = trexDefaults{
+				duration: binary.BigEndian.Uint32(c.body[12:]),
+				size:     binary.BigEndian.Uint32(c.body[16:]),
+				flags:    binary.BigEndian.Uint32(c.body[20:]),
+			}
+		}
+	}
+	return nil
+}
+
+const (
+	tfhdBaseDataOffset    = 0x000001
+	tfhdSampleDescIndex   = 0x000002
+	tfhdDefaultDuration   = 0x000008
+	tfhdDefaultSize       = 0x000010
+	tfhdDefaultFlags      = 0x000020
+	tfhdDurationIsEmpty   = 0x010000
+	tfhdDefaultBaseIsMoof = 0x020000
+
+	trunDataOffset       = 0x000001
+	trunFirstSampleFlags = 0x000004
+	trunSampleDuration   = 0x000100
+	trunSampleSize       = 0x000200
+	trunSampleFlags      = 0x000400
+	trunSampleCTO        = 0x000800
+
+	sampleIsNonSync = 0x00010000
+)
+
+// parseMoof appends the samples of this track found in one movie fragment and
+// returns the decode time following the fragment.
+func (t *fmp4Track) parseMoof(body []byte, moofOffset int64, dts uint64) (uint64, error) {
+	boxes, err := splitBoxes(body)
+	if err != nil {
+		return dts, err
+	}
+	// Without explicit base offsets, the first traf starts at the moof and each
+	// following traf continues where the previous one's data ended.
+	nextBase := moofOffset
+	for _, traf := range boxes {
+		if traf.typ != "traf" {
+			continue
+		}
+		children, err := splitBoxes(traf.body)
+		if err != nil {
+			return dts, err
+		}
+		tfhd, ok := findBox(children, "tfhd")
+		if !ok || len(tfhd.body) < 8 {
+			return dts, errors.New("missing tfhd")
+		}
+		flags := binary.BigEndian.Uint32(tfhd.body) & 0xFFFFFF
+		rd := byteReader{buf: tfhd.body[4:]}
+		trackID := rd.u32()
+		base := nextBase
+		if flags&tfhdBaseDataOffset != 0 {
+			base = int64(rd.u64())
+		} else if flags&tfhdDefaultBaseIsMoof != 0 {
+			base = moofOffset
+		}
+		if flags&tfhdSampleDescIndex != 0 {
+			rd.u32()
+		}
+		def := t.trex
+		if flags&tfhdDefaultDuration != 0 {
+			def.duration = rd.u32()
+		}
+		if flags&tfhdDefaultSize != 0 {
+			def.size = rd.u32()
+		}
+		if flags&tfhdDefaultFlags != 0 {
+			def.flags = rd.u32()
+		}
+		if rd.err != nil {
+			return dts, fmt.Errorf("tfhd: %w", rd.err)
+		}
+		if trackID != t.trackID || flags&tfhdDurationIsEmpty != 0 {
+			continue
+		}
+		if tfdt, ok := findBox(children, "tfdt"); ok && len(tfdt.body) >= 8 {
+			if tfdt.body[0] == 1 && len(tfdt.body) >= 12 {
+				dts = binary.BigEndian.Uint64(tfdt.body[4:])
+			} else {
+				dts = uint64(binary.BigEndian.Uint32(tfdt.body[4:]))
+			}
+		}
+
+		pos := base
+		for _, trun := range children {
+			if trun.typ != "trun" {
+				continue
+			}
+			if len(trun.body) < 8 {
+				return dts, errors.New("short trun")
+			}
+			version := trun.body[0]
+			tflags := binary.BigEndian.Uint32(trun.body) & 0xFFFFFF
+			rd := byteReader{buf: trun.body[4:]}
+			count := rd.u32()
+			if tflags&trunDataOffset != 0 {
+				pos = base + int64(int32(rd.u32()))
+			}
+			firstFlags, hasFirst := uint32(0), tflags&trunFirstSampleFlags != 0
+			if hasFirst {
+				firstFlags = rd.u32()
+			}
+			chunk := mp4Chunk{first: len(t.samples), dts: dts}
+			for i := uint32(0); i < count; i++ {
+				s := mp4Sample{offset: pos, duration: def.duration, size: def.size}
+				sflags := def.flags
+				if tflags&trunSampleDuration != 0 {
+					s.duration = rd.u32()
+				}
+				if tflags&trunSampleSize != 0 {
+					s.size = rd.u32()
+				}
+				if tflags&trunSampleFlags != 0 {
+					sflags = rd.u32()
+				} else if i == 0 && hasFirst {
+					sflags = firstFlags
+				}
+				if tflags&trunSampleCTO != 0 {
+					v := rd.u32()
+					if version == 0 {
+						s.cto = int32(min(v, uint32(1<<31-1)))
+					} else {
+						s.cto = int32(v)
+					}
+				}
+				if rd.err != nil {
+					return dts, fmt.Errorf("trun: %w", rd.err)
+				}
+				s.sync = sflags&sampleIsNonSync == 0
+				t.samples = append(t.samples, s)
+				pos += int64(s.size)
+				dts += uint64(s.duration)
+			}
+			chunk.count = len(t.samples) - chunk.first
+			if chunk.count > 0 {
+				t.chunks = append(t.chunks, chunk)
+			}
+		}
+		nextBase = pos
+	}
+	return dts, nil
+}
+
+type byteReader struct {
+	buf []byte
+	err error
+}
+
+func (r *byteReader) u32() uint32 {
+	if r.err != nil || len(r.buf) < 4 {
+		r.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.buf)
+	r.buf = r.buf[4:]
+	return v
+}
+
+func (r *byteReader) u64() uint64 {
+	if r.err != nil || len(r.buf) < 8 {
+		r.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	v := binary.BigEndian.Uint64(r.buf)
+	r.buf = r.buf[8:]
+	return v
+}
+
+const movieTimescale = 1000
+
+type outChunk struct {
+	track int
+	chunk mp4Chunk
+	bytes int64
+}
+
+func writeProgressiveMP4(w io.Writer, tracks []*fmp4Track) error {
+	// Interleave fragments by decode time so players do not have to seek back
+	// and forth between the video and audio halves of the file.
+	var order []outChunk
+	for ti, t := range tracks {
+		for _, c := range t.chunks {
+			var n int64
+			for _, s := range t.samples[c.first : c.first+c.count] {
+				n += int64(s.size)
+			}
+			order = append(order, outChunk{track: ti, chunk: c, bytes: n})
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		ta, tb := tracks[a.track].timescale, tracks[b.track].timescale
+		return a.chunk.dts*uint64(tb) < b.chunk.dts*uint64(ta)
+	})
+
+	var mdatPayload int64
+	rel := make([][]int64, len(tracks))
+	for i, t := range tracks {
+		rel[i] = make([]int64, len(t.chunks))
+	}
+	chunkIdx := make([]int, len(tracks))
+	for _, oc := range order {
+		rel[oc.track][chunkIdx[oc.track]] = mdatPayload
+		chunkIdx[oc.track]++
+		mdatPayload += oc.bytes
+	}
+	// order was sorted stably, so chunks of one track keep their original order
+	// and chunkIdx lines up with t.chunks.
+
+	mdatHdr := int64(8)
+	if mdatPayload+8 > 0xFFFFFFFF {
+		mdatHdr = 16
+	}
+	ftyp := buildFtyp()
+	useCo64 := int64(len(ftyp))+mdatHdr+mdatPayload > 0xFFFFFFFF
+	probe, err := buildMoov(tracks, rel, 0, useCo64)
+	if err != nil {
+		return err
+	}
+	dataStart := int64(len(ftyp)) + int64(len(probe)) + mdatHdr
+	moov, err := buildMoov(tracks, rel, dataStart, useCo64)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriterSize(w, 1<<20)
+	if _, err := bw.Write(ftyp); err != nil {
+		return err
+	}
+	if _, err := bw.Write(moov); err != nil {
+		return err
+	}
+	if mdatHdr == 16 {
+		var h [16]byte
+		binary.BigEndian.PutUint32(h[:], 1)
+		copy(h[4:], "mdat")
+		binary.BigEndian.PutUint64(h[8:], uint64(mdatPayload+16))
+		_, err = bw.Write(h[:])
+	} else {
+		var h [8]byte
+		binary.BigEndian.PutUint32(h[:], uint32(mdatPayload+8))
+		copy(h[4:], "mdat")
+		_, err = bw.Write(h[:])
+	}
+	if err != nil {
+		return err
+	}
+	for _, oc := range order {
+		t := tracks[oc.track]
+		samples := t.samples[oc.chunk.first : oc.chunk.first+oc.chunk.count]
+		for i := 0; i < len(samples); {
+			// Copy runs of samples that are contiguous in the source in one go.
+			start, n := samples[i].offset, int64(samples[i].size)
+			j := i + 1
+			for j < len(samples) && samples[j].offset == start+n {
+				n += int64(samples[j].size)
+				j++
+			}
+			if _, err := io.Copy(bw, io.NewSectionReader(t.src, start, n)); err != nil {
+				return err
+			}
+			i = j
+		}
+	}
+	return bw.Flush()
+}
+
+func mkBox(typ string, parts ...[]byte) []byte {
+	n := 8
+	for _, p := range parts {
+		n += len(p)
+	}
+	out := make([]byte, 8, n)
+	binary.BigEndian.PutUint32(out, uint32(n))
+	copy(out[4:], typ)
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+func mkFullBox(typ string, version byte, flags uint32, parts ...[]byte) []byte {
+	vf := []byte{version, byte(flags >> 16), byte(flags >> 8), byte(flags)}
+	return mkBox(typ, append([][]byte{vf}, parts...)...)
+}
+
+func u32s(vals ...uint32) []byte {
+	out := make([]byte, 4*len(vals))
+	for i, v := range vals {
+		binary.BigEndian.PutUint32(out[4*i:], v)
+	}
+	return out
+}
+
+func buildFtyp() []byte {
+	return mkBox("ftyp", []byte("isom"), u32s(0x200), []byte("isomiso2avc1mp41"))
+}
+
+func buildMoov(tracks []*fmp4Track, rel [][]int64, dataStart int64, useCo64 bool) ([]byte, error) {
+	var movieDur uint64
+	traks := make([][]byte, 0, len(tracks))
+	for i, t := range tracks {
+		d := t.mediaDuration() * movieTimescale / uint64(t.timescale)
+		if d > movieDur {
+			movieDur = d
+		}
+		offsets := make([]int64, len(rel[i]))
+		for j, r := range rel[i] {
+			offsets[j] = dataStart + r
+		}
+		trak, err := buildTrak(t, uint32(i+1), d, offsets, useCo64)
+		if err != nil {
+			return nil, err
+		}
+		traks = append(traks, trak)
+	}
+
+	mvhd := mkFullBox("mvhd", 1, 0,
+		make([]byte, 16), // creation/modification time
+		u32s(movieTimescale),
+		binary.BigEndian.AppendUint64(nil, movieDur),
+		u32s(0x00010000),   // rate 1.0
+		[]byte{0x01, 0x00}, // volume 1.0
+		make([]byte, 10),   // reserved
+		u32s(0x00010000, 0, 0, 0, 0x00010000, 0, 0, 0, 0x40000000), // unity matrix
+		make([]byte, 24), // pre_defined
+		u32s(uint32(len(tracks)+1)),
+	)
+	return mkBox("moov", append([][]byte{mvhd}, traks...)...), nil
+}
+
+func buildTrak(t *fmp4Track, trackID uint32, movieDur uint64, chunkOffsets []int64, useCo64 bool) ([]byte, error) {
+	trakChildren, err := splitBoxes(t.trak[8:])
+	if err != nil {
+		return nil, err
+	}
+	tkhd, ok := findBox(trakChildren, "tkhd")
+	if !ok {
+		return nil, errors.New("missing tkhd")
+	}
+	mdia, ok := findBox(trakChildren, "mdia")
+	if !ok {
+		return nil, errors.New("missing mdia")
+	}
+
+	tk := append([]byte(nil), tkhd.full...)
+	body := tk[len(tk)-len(tkhd.body):]
+	body[3] |= 0x03 // enabled, in movie
+	if body[0] == 1 {
+		binary.BigEndian.PutUint32(body[20:], trackID)
+		binary.BigEndian.PutUint64(body[28:], movieDur)
+	} else {
+		binary.BigEndian.PutUint32(body[12:], trackID)
+		binary.BigEndian.PutUint32(body[20:], uint32(min(movieDur, 0xFFFFFFFF)))
+	}
+
+	mdiaChildren, err := splitBoxes(mdia.body)
+	if err != nil {
+		return nil, err
+	}
+	var mdiaOut [][]byte
+	for _, c := range mdiaChildren {
+		switch c.typ {
+		case "mdhd":
+			m := append([]byte(nil), c.full...)
+			mb := m[len(m)-len(c.body):]
+			if mb[0] == 1 {
+				binary.BigEndian.PutUint64(mb[24:], t.mediaDuration())
+			} else {
+				binary.BigEndian.PutUint32(mb[16:], uint32(min(t.mediaDuration(), 0xFFFFFFFF)))
+			}
+			mdiaOut = append(mdiaOut, m)
+		case "minf":
+			minf, err := buildMinf(t, c, chunkOffsets, useCo64)
+			if err != nil {
+				return nil, err
+			}
+			mdiaOut = append(mdiaOut, minf)
+		default:
+			mdiaOut = append(mdiaOut, c.full)
+		}
+	}
+	return mkBox("trak", tk, mkBox("mdia", mdiaOut...)), nil
+}
+
+func buildMinf(t *fmp4Track, minf rawBox, chunkOffsets []int64, useCo64 bool) ([]byte, error) {
+	children, err := splitBoxes(minf.body)
+	if err != nil {
+		return nil, err
+	}
+	var out [][]byte
+	for _, c := range children {
+		if c.typ != "stbl" {
+			out = append(out, c.full)
+			continue
+		}
+		stsd, ok := findPath(c.body, "stsd")
+		if !ok {
+			return nil, errors.New("missing stsd")
+		}
+		out = append(out, mkBox("stbl", append([][]byte{stsd.full}, buildSampleTables(t, chunkOffsets, useCo64)...)...))
+	}
+	return mkBox("minf", out...), nil
+}
+
+func buildSampleTables(t *fmp4Track, chunkOffsets []int64, useCo64 bool) [][]byte {
+	var stts []byte
+	var sttsN uint32
+	for i := 0; i < len(t.samples); {
+		j := i + 1
+		for j < len(t.samples) && t.samples[j].duration == t.samples[i].duration {
+			j++
+		}
+		stts = append(stts, u32s(uint32(j-i), t.samples[i].duration)...)
+		sttsN++
+		i = j
+	}
+	out := [][]byte{mkFullBox("stts", 0, 0, u32s(sttsN), stts)}
+
+	var hasCTO, negCTO bool
+	for _, s := range t.samples {
+		if s.cto != 0 {
+			hasCTO = true
+		}
+		if s.cto < 0 {
+			negCTO = true
+		}
+	}
+	if hasCTO {
+		var ctts []byte
+		var n uint32
+		for i := 0; i < len(t.samples); {
+			j := i + 1
+			for j < len(t.samples) && t.samples[j].cto == t.samples[i].cto {
+				j++
+			}
+			ctts = append(ctts, u32s(uint32(j-i), uint32(t.samples[i].cto))...)
+			n++
+			i = j
+		}
+		var version byte
+		if negCTO {
+			version = 1
+		}
+		out = append(out, mkFullBox("ctts", version, 0, u32s(n), ctts))
+	}
+
+	var stss []byte
+	var syncN uint32
+	for i, s := range t.samples {
+		if s.sync {
+			stss = append(stss, u32s(uint32(i+1))...)
+			syncN++
+		}
+	}
+	if int(syncN) != len(t.samples) {
+		out = append(out, mkFullBox("stss", 0, 0, u32s(syncN), stss))
+	}
+
+	var stsc []byte
+	var stscN uint32
+	prev := -1
+	for i, c := range t.chunks {
+		if c.count == prev {
+			continue
+		}
+		stsc = append(stsc, u32s(uint32(i+1), uint32(c.count), 1)...)
+		stscN++
+		prev = c.count
+	}
+	out = append(out, mkFullBox("stsc", 0, 0, u32s(stscN), stsc))
+
+	stsz := make([]byte, 0, 4*len(t.samples))
+	for _, s := range t.samples {
+		stsz = append(stsz, u32s(s.size)...)
+	}
+	out = append(out, mkFullBox("stsz", 0, 0, u32s(0, uint32(len(t.samples))), stsz))
+
+	if useCo64 {
+		co := make([]byte, 0, 8*len(chunkOffsets))
+		for _, o := range chunkOffsets {
+			co = binary.BigEndian.AppendUint64(co, uint64(o))
+		}
+		out = append(out, mkFullBox("co64", 0, 0, u32s(uint32(len(chunkOffsets))), co))
+	} else {
+		co := make([]byte, 0, 4*len(chunkOffsets))
+		for _, o := range chunkOffsets {
+			co = append(co, u32s(uint32(o))...)
+		}
+		out = append(out, mkFullBox("stco", 0, 0, u32s(uint32(len(chunkOffsets))), co))
+	}
+	return out
+}
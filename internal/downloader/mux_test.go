@@ -0,0 +1,201 @@
+package downloader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func copyFixture(t *testing.T, dir string, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, b, 0644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	return p
+}
+
+type muxedTrack struct {
+	trackID uint32
+	handler string
+	sizes   []uint32
+	offsets []int64
+	syncs   []uint32
+	hasCTTS bool
+}
+
+func readMuxedTracks(t *testing.T, file []byte) []muxedTrack {
+	t.Helper()
+	top, err := splitBoxes(file)
+	if err != nil {
+		t.Fatalf("split output: %v", err)
+	}
+	var types []string
+	for _, b := range top {
+		types = append(types, b.typ)
+	}
+	if fmt.Sprint(types) != "[ftyp moov mdat]" {
+		t.Fatalf("unexpected top-level boxes: %v", types)
+	}
+	moov, _ := findBox(top, "moov")
+	children, _ := splitBoxes(moov.body)
+	var out []muxedTrack
+	for _, trak := range children {
+		if trak.typ != "trak" {
+			continue
+		}
+		var mt muxedTrack
+		tkhd, _ := findPath(trak.body, "tkhd")
+		mt.trackID = binary.BigEndian.Uint32(tkhd.body[12:])
+		hdlr, _ := findPath(trak.body, "mdia", "hdlr")
+		mt.handler = string(hdlr.body[8:12])
+		if _, ok := findPath(trak.body, "edts"); ok {
+			t.Fatalf("edts should be dropped")
+		}
+		stbl, ok := findPath(trak.body, "mdia", "minf", "stbl")
+		if !ok {
+			t.Fatalf("missing stbl")
+		}
+		_, mt.hasCTTS = findPath(stbl.body, "ctts")
+
+		stsz, _ := findPath(stbl.body, "stsz")
+		n := binary.BigEndian.Uint32(stsz.body[8:])
+		for i := uint32(0); i < n; i++ {
+			mt.sizes = append(mt.sizes, binary.BigEndian.Uint32(stsz.body[12+4*i:]))
+		}
+		stco, _ := findPath(stbl.body, "stco")
+		var chunkOffsets []int64
+		for i := uint32(0); i < binary.BigEndian.Uint32(stco.body[4:]); i++ {
+			chunkOffsets = append(chunkOffsets, int64(binary.BigEndian.Uint32(stco.body[8+4*i:])))
+		}
+		stsc, _ := findPath(stbl.body, "stsc")
+		entries := binary.BigEndian.Uint32(stsc.body[4:])
+		sample := 0
+		for ci := range chunkOffsets {
+			var perChunk uint32
+			for e := uint32(0); e < entries; e++ {
+				if int(binary.BigEndian.Uint32(stsc.body[8+12*e:])) <= ci+1 {
+					perChunk = binary.BigEndian.Uint32(stsc.body[12+12*e:])
+				}
+			}
+			off := chunkOffsets[ci]
+			for k := uint32(0); k < perChunk; k++ {
+				mt.offsets = append(mt.offsets, off)
+				off += int64(mt.sizes[sample])
+				sample++
+			}
+		}
+		if stss, ok := findPath(stbl.body, "stss"); ok {
+			for i := uint32(0); i < binary.BigEndian.Uint32(stss.body[4:]); i++ {
+				mt.syncs = append(mt.syncs, binary.BigEndian.Uint32(stss.body[8+4*i:]))
+			}
+		}
+		out = append(out, mt)
+	}
+	return out
+}
+
+func TestMuxFragmentedMP4(t *testing.T) {
+	dir := t.TempDir()
+	video := copyFixture(t, dir, "dash_video.mp4")
+	audio := copyFixture(t, dir, "dash_audio.m4a")
+	out := filepath.Join(dir, "merged.mp4")
+
+	if err := MuxFragmentedMP4(out, []string{video, audio}, MuxOptions{}); err != nil {
+		t.Fatalf("MuxFragmentedMP4: %v", err)
+	}
+	for _, p := range []string{video, audio} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Fatalf("expected %s removed, stat err=%v", filepath.Base(p), err)
+		}
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.part-*"))
+	if len(matches) != 0 {
+		t.Fatalf("expected no temp files, got %v", matches)
+	}
+
+	file, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	tracks := readMuxedTracks(t, file)
+	if len(tracks) != 2 {
+		t.Fatalf("expected 2 tracks, got %d", len(tracks))
+	}
+
+	v, a := tracks[0], tracks[1]
+	if v.trackID != 1 || v.handler != "vide" || a.trackID != 2 || a.handler != "soun" {
+		t.Fatalf("unexpected tracks: %+v / %+v", v, a)
+	}
+	if len(v.sizes) != 6 || len(a.sizes) != 8 {
+		t.Fatalf("unexpected sample counts: video=%d audio=%d", len(v.sizes), len(a.sizes))
+	}
+	if len(v.offsets) != len(v.sizes) || len(a.offsets) != len(a.sizes) {
+		t.Fatalf("chunk table does not cover all samples: video=%d audio=%d", len(v.offsets), len(a.offsets))
+	}
+	if fmt.Sprint(v.syncs) != "[1 4]" || !v.hasCTTS {
+		t.Fatalf("unexpected video sync/ctts: %v %v", v.syncs, v.hasCTTS)
+	}
+	if a.syncs != nil || a.hasCTTS {
+		t.Fatalf("audio should have neither stss nor ctts: %v %v", a.syncs, a.hasCTTS)
+	}
+	for i, off := range v.offsets {
+		want := []byte(fmt.Sprintf("V%02d", i))
+		if !bytes.Equal(file[off:off+3], want) || v.sizes[i] != uint32(3*(3+i%3)) {
+			t.Fatalf("video sample %d at %d: got %q size %d", i, off, file[off:off+3], v.sizes[i])
+		}
+	}
+	for i, off := range a.offsets {
+		want := bytes.Repeat([]byte(fmt.Sprintf("A%02d", i)), 2)
+		if !bytes.Equal(file[off:off+6], want) {
+			t.Fatalf("audio sample %d at %d: got %q", i, off, file[off:off+6])
+		}
+	}
+	// Fragments are interleaved by decode time: the second audio fragment
+	// (t=0.093s) lands before the second video fragment (t=0.1s).
+	if !(a.offsets[4] < v.offsets[3]) {
+		t.Fatalf("expected interleaved chunks, audio[4]=%d video[3]=%d", a.offsets[4], v.offsets[3])
+	}
+}
+
+func TestMuxFragmentedMP4KeepOriginals(t *testing.T) {
+	dir := t.TempDir()
+	video := copyFixture(t, dir, "dash_video.mp4")
+	audio := copyFixture(t, dir, "dash_audio.m4a")
+	out := filepath.Join(dir, "merged.mp4")
+
+	if err := MuxFragmentedMP4(out, []string{video, audio}, MuxOptions{KeepOriginals: true}); err != nil {
+		t.Fatalf("MuxFragmentedMP4: %v", err)
+	}
+	for _, p := range []string{video, audio, out} {
+		if _, err := os.Stat(p); err != nil {
+			t.Fatalf("expected %s to exist: %v", filepath.Base(p), err)
+		}
+	}
+}
+
+func TestMuxFragmentedMP4RejectsInvalidInput(t *testing.T) {
+	dir := t.TempDir()
+	video := copyFixture(t, dir, "dash_video.mp4")
+	bad := filepath.Join(dir, "bad.m4a")
+	if err := os.WriteFile(bad, []byte("not an mp4 at all"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	out := filepath.Join(dir, "merged.mp4")
+	if err := MuxFragmentedMP4(out, []string{video, bad}, MuxOptions{}); err == nil {
+		t.Fatalf("expected error for invalid input")
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Fatalf("expected no output on failure, stat err=%v", err)
+	}
+	if _, err := os.Stat(video); err != nil {
+		t.Fatalf("expected input kept on failure: %v", err)
+	}
+}
@@ -42,12 +42,14 @@ func (h *BroadcastHandler) Handle(ctx context.Context, r slog.Record) error {
 		return true
 	})
 	evt["attrs"] = attrs
-	addEvent(Event{
+	e := Event{
 		Time:  evt["time"].(string),
 		Level: evt["level"].(string),
 		Msg:   evt["msg"].(string),
 		Attrs: attrs,
-	})
+	}
+	addEvent(e)
+	publishSinks(e)
 
 	if defaultBus.count() > 0 {
 		b, mErr := json.Marshal(evt)
@@ -0,0 +1,32 @@
+package logger
+
+import "sync"
+
+var (
+	sinksMu sync.RWMutex
+	sinks   = map[int]func(Event){}
+	sinkSeq int
+)
+
+// AddSink calls fn synchronously for every log event until the returned
+// function is called. fn must not log.
+func AddSink(fn func(Event)) func() {
+	sinksMu.Lock()
+	sinkSeq++
+	id := sinkSeq
+	sinks[id] = fn
+	sinksMu.Unlock()
+	return func() {
+		sinksMu.Lock()
+		delete(sinks, id)
+		sinksMu.Unlock()
+	}
+}
+
+func publishSinks(evt Event) {
+	sinksMu.RLock()
+	defer sinksMu.RUnlock()
+	for _, fn := range sinks {
+		fn(evt)
+	}
+}
@@ -0,0 +1,40 @@
+package metrics
+
+// Metrics recorded by the crawler, platform HTTP clients, store and
+// downloader. Platform labels use the normalized platform name ("xhs",
+// "douyin", ...).
+var (
+	HTTPRequests = NewCounterVec("media_crawler_http_requests_total",
+		"Platform API requests by endpoint and HTTP status (\"error\" when no response was received).",
+		"platform", "endpoint", "status")
+	HTTPRequestDuration = NewHistogramVec("media_crawler_http_request_duration_seconds",
+		"Platform API request latency.", DefBuckets, "platform")
+	HTTPRetries = NewCounterVec("media_crawler_http_retries_total",
+		"Platform API request retries.", "platform")
+
+	ItemsProcessed = NewCounterVec("media_crawler_items_total",
+		"Items (notes, creators, inputs) processed by crawler workers, by result.", "platform", "result")
+	Errors = NewCounterVec("media_crawler_errors_total",
+		"Failed crawler items by error kind.", "platform", "kind")
+	RiskHints = NewCounterVec("media_crawler_risk_hints_total",
+		"Risk-control responses (captcha, forbidden, ...) detected.", "platform", "hint")
+	ProxySwitches = NewCounterVec("media_crawler_proxy_switches_total",
+		"Proxies taken from the pool, by reason (initial, expired, invalidated).", "reason")
+
+	StoreWriteDuration = NewHistogramVec("media_crawler_store_write_duration_seconds",
+		"Store write latency by backend and operation.", DefBuckets, "backend", "op")
+	StoreWriteErrors = NewCounterVec("media_crawler_store_write_errors_total",
+		"Failed store writes by backend and operation.", "backend", "op")
+	CommentsFetched = NewCounterVec("media_crawler_comments_fetched_total",
+		"New comments stored.", "platform")
+
+	DownloadBytes = NewCounterVec("media_crawler_download_bytes_total",
+		"Bytes written by the media downloader.", "platform")
+	Downloads = NewCounterVec("media_crawler_downloads_total",
+		"Media downloads by result (ok, failed, skipped when the file exists).", "platform", "result")
+
+	TaskDuration = NewHistogramVec("media_crawler_task_duration_seconds",
+		"Crawl task duration by result (ok, error, canceled).",
+		[]float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600, 7200},
+		"platform", "mode", "result")
+)
@@ -0,0 +1,238 @@
+// Package metrics is a small in-process registry of counters and histograms
+// exposed in the Prometheus text exposition format (version 0.0.4).
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type kind string
+
+const (
+	kindCounter   kind = "counter"
+	kindHistogram kind = "histogram"
+)
+
+// DefBuckets are latency buckets in seconds.
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type series struct {
+	labels  []string
+	value   float64
+	counts  []uint64
+	sum     float64
+	samples uint64
+}
+
+type family struct {
+	name    string
+	help    string
+	kind    kind
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]*family{}
+)
+
+func register(f *family) *family {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, exists := registry[f.name]; exists {
+		panic(fmt.Sprintf("metrics: duplicate register: %s", f.name))
+	}
+	f.series = map[string]*series{}
+	registry[f.name] = f
+	return f
+}
+
+func (f *family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	s := f.series[key]
+	if s == nil {
+		s = &series{labels: append([]string(nil), values...)}
+		if f.kind == kindHistogram {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// CounterVec is a monotonically increasing counter partitioned by labels.
+type CounterVec struct{ f *family }
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{f: register(&family{name: name, help: help, kind: kindCounter, labels: labels})}
+}
+
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increases the counter; negative values are ignored.
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 || math.IsNaN(v) {
+		return
+	}
+	c.f.mu.Lock()
+	c.f.get(labelValues).value += v
+	c.f.mu.Unlock()
+}
+
+// Value returns the current counter value for the label values.
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+	if s := c.f.series[strings.Join(labelValues, "\xff")]; s != nil {
+		return s.value
+	}
+	return 0
+}
+
+// HistogramVec counts observations into cumulative buckets.
+type HistogramVec struct{ f *family }
+
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+	return &HistogramVec{f: register(&family{name: name, help: help, kind: kindHistogram, labels: labels, buckets: b})}
+}
+
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+	s := h.f.get(labelValues)
+	for i, ub := range h.f.buckets {
+		if v <= ub {
+			s.counts[i]++
+		}
+	}
+	s.sum += v
+	s.samples++
+}
+
+// Count returns the number of observations for the label values.
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+	if s := h.f.series[strings.Join(labelValues, "\xff")]; s != nil {
+		return s.samples
+	}
+	return 0
+}
+
+// WriteText writes every registered metric in the Prometheus text format,
+// families and series in a stable order.
+func WriteText(w io.Writer) error {
+	registryMu.RLock()
+	fams := make([]*family, 0, len(registry))
+	for _, f := range registry {
+		fams = append(fams, f)
+	}
+	registryMu.RUnlock()
+	sort.Slice(fams, func(i, j int) bool { return fams[i].name < fams[j].name })
+
+	bw := bufio.NewWriter(w)
+	for _, f := range fams {
+		f.write(bw)
+	}
+	return bw.Flush()
+}
+
+func (f *family) write(w *bufio.Writer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+
+	keys := make([]string, 0, len(f.series))
+	for k := range f.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s := f.series[k]
+		switch f.kind {
+		case kindCounter:
+			fmt.Fprintf(w, "%s%s %s\n", f.name, formatLabels(f.labels, s.labels, "", ""), formatFloat(s.value))
+		case kindHistogram:
+			for i, ub := range f.buckets {
+				fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, formatLabels(f.labels, s.labels, "le", formatFloat(ub)), s.counts[i])
+			}
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, formatLabels(f.labels, s.labels, "le", "+Inf"), s.samples)
+			fmt.Fprintf(w, "%s_sum%s %s\n", f.name, formatLabels(f.labels, s.labels, "", ""), formatFloat(s.sum))
+			fmt.Fprintf(w, "%s_count%s %d\n", f.name, formatLabels(f.labels, s.labels, "", ""), s.samples)
+		}
+	}
+}
+
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, n := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(n)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(values[i]))
+		b.WriteByte('"')
+	}
+	if extraName != "" {
+		if len(names) > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(extraName)
+		b.WriteString(`="`)
+		b.WriteString(extraValue)
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+
+// Handler serves WriteText over HTTP.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = WriteText(w)
+	})
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+)
+
+func TestWriteText(t *testing.T) {
+	c := NewCounterVec("test_requests_total", "Test \"requests\".", "path")
+	c.Inc(`/a"b`)
+	c.Add(2, "/c")
+	c.Add(-1, "/c")
+	h := NewHistogramVec("test_latency_seconds", "Test latency.", []float64{1, 0.1}, "op")
+	h.Observe(0.05, "get")
+	h.Observe(0.5, "get")
+
+	var b strings.Builder
+	if err := WriteText(&b); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"# TYPE test_requests_total counter\n",
+		`test_requests_total{path="/a\"b"} 1` + "\n",
+		`test_requests_total{path="/c"} 2` + "\n",
+		"# TYPE test_latency_seconds histogram\n",
+		`test_latency_seconds_bucket{op="get",le="0.1"} 1` + "\n",
+		`test_latency_seconds_bucket{op="get",le="1"} 2` + "\n",
+		`test_latency_seconds_bucket{op="get",le="+Inf"} 2` + "\n",
+		`test_latency_seconds_sum{op="get"} 0.55` + "\n",
+		`test_latency_seconds_count{op="get"} 2` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "test_latency_seconds") > strings.Index(out, "test_requests_total") {
+		t.Fatalf("families should be sorted by name:\n%s", out)
+	}
+}
+
+func TestNormalizeEndpoint(t *testing.T) {
+	for in, want := range map[string]string{
+		"/api/v4/members/zhang-3/answers":  "/api/v4/members/:id/answers",
+		"/x/v2/reply/main":                 "/x/v2/reply/main",
+		"/api/sns/web/v1/feed?note_id=1":   "/api/sns/web/v1/feed",
+		"/p/9876543210":                    "/p/:id",
+		"/abcdefghijklmnopqrstuvwxyz/info": "/:id/info",
+		"":                                 "/",
+	} {
+		if got := NormalizeEndpoint(in); got != want {
+			t.Fatalf("%q: got %q want %q", in, got, want)
+		}
+	}
+}
+
+func TestInstrumentResty(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+
+	c := InstrumentResty(resty.New(), "testplatform")
+	c.SetRetryCount(2).SetRetryWaitTime(0).AddRetryCondition(func(r *resty.Response, err error) bool {
+		return r != nil && r.StatusCode() == http.StatusTooManyRequests
+	})
+	if _, err := c.R().Get(srv.URL + "/api/item/12345"); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got := HTTPRequests.Value("testplatform", "/api/item/:id", "429"); got != 1 {
+		t.Fatalf("429 requests: %v", got)
+	}
+	if got := HTTPRequests.Value("testplatform", "/api/item/:id", "200"); got != 1 {
+		t.Fatalf("200 requests: %v", got)
+	}
+	if got := HTTPRetries.Value("testplatform"); got != 1 {
+		t.Fatalf("retries: %v", got)
+	}
+	if got := HTTPRequestDuration.Count("testplatform"); got != 2 {
+		t.Fatalf("latency samples: %v", got)
+	}
+
+	c.SetRetryCount(0)
+	if _, err := c.R().Get("http://127.0.0.1:1/down"); err == nil {
+		t.Fatalf("expected connection error")
+	}
+	if got := HTTPRequests.Value("testplatform", "/down", "error"); got != 1 {
+		t.Fatalf("error requests: %v", got)
+	}
+}
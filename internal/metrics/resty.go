@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// InstrumentResty records requests, latency and retries of a platform
+// client in HTTPRequests, HTTPRequestDuration and HTTPRetries.
+func InstrumentResty(c *resty.Client, platform string) *resty.Client {
+	c.OnAfterResponse(func(_ *resty.Client, r *resty.Response) error {
+		HTTPRequests.Inc(platform, requestEndpoint(r.Request), strconv.Itoa(r.StatusCode()))
+		HTTPRequestDuration.Observe(r.Time().Seconds(), platform)
+		return nil
+	})
+	c.OnError(func(r *resty.Request, err error) {
+		// Responses were already counted by OnAfterResponse.
+		var re *resty.ResponseError
+		if errors.As(err, &re) && re.Response != nil && re.Response.RawResponse != nil {
+			return
+		}
+		HTTPRequests.Inc(platform, requestEndpoint(r), "error")
+	})
+	c.AddRetryHook(func(*resty.Response, error) {
+		HTTPRetries.Inc(platform)
+	})
+	return c
+}
+
+func requestEndpoint(r *resty.Request) string {
+	if r == nil {
+		return ""
+	}
+	raw := r.URL
+	if r.RawRequest != nil && r.RawRequest.URL != nil {
+		raw = r.RawRequest.URL.Path
+	} else if u, err := url.Parse(raw); err == nil {
+		raw = u.Path
+	}
+	return NormalizeEndpoint(raw)
+}
+
+// NormalizeEndpoint replaces path segments that look like ids (long, or
+// containing digits beyond a short version tag such as "v4") with ":id" so
+// that the endpoint label stays low-cardinality.
+func NormalizeEndpoint(path string) string {
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	segs := strings.Split(path, "/")
+	for i, s := range segs {
+		if looksLikeID(s) {
+			segs[i] = ":id"
+		}
+	}
+	out := strings.Join(segs, "/")
+	if out == "" {
+		return "/"
+	}
+	return out
+}
+
+func looksLikeID(s string) bool {
+	if len(s) >= 24 {
+		return true
+	}
+	digits := 0
+	for _, r := range s {
+		if unicode.IsDigit(r) {
+			digits++
+		}
+	}
+	return digits > 0 && len(s) > 3
+}
@@ -0,0 +1,368 @@
+package bilibili
+
+import (
+	"context"
+	"fmt"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"media-crawler-go/internal/logger"
+	"media-crawler-go/internal/store"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Collection kinds crawled by the collection mode.
+const (
+	CollectionFavorite   = "fav"
+	CollectionSeason     = "season"
+	CollectionSeries     = "series"
+	CollectionWatchLater = "watchlater"
+)
+
+var reCollectionDigits = regexp.MustCompile(`^\d+$`)
+
+// CollectionRef identifies a favorites folder (media id), a season or series
+// (which also need the owner mid) or the account's watch-later list.
+type CollectionRef struct {
+	Kind string
+	ID   string
+	Mid  string
+}
+
+// CollectionID is the directory name under collections/, e.g. "fav_123".
+func (r CollectionRef) CollectionID() string {
+	if r.Kind == CollectionWatchLater {
+		return CollectionWatchLater
+	}
+	return r.Kind + "_" + r.ID
+}
+
+// ParseCollectionRef accepts typed inputs ("fav:<media_id>",
+// "season:<mid>:<season_id>", "series:<mid>:<series_id>", "watchlater"),
+// "ml<media_id>", and the space.bilibili.com favlist / collectiondetail /
+// seriesdetail and www.bilibili.com/list/watchlater URLs.
+func ParseCollectionRef(input string) (CollectionRef, error) {
+	s := strings.TrimSpace(input)
+	if s == "" {
+		return CollectionRef{}, fmt.Errorf("empty input")
+	}
+	if u, err := url.Parse(s); err == nil && u != nil && u.Host != "" {
+		return parseCollectionURL(u, input)
+	}
+	lower := strings.ToLower(s)
+	if lower == CollectionWatchLater || lower == "toview" {
+		return CollectionRef{Kind: CollectionWatchLater}, nil
+	}
+	if strings.HasPrefix(lower, "ml") && reCollectionDigits.MatchString(s[2:]) {
+		return CollectionRef{Kind: CollectionFavorite, ID: s[2:]}, nil
+	}
+	parts := strings.Split(s, ":")
+	switch {
+	case len(parts) == 2 && strings.EqualFold(parts[0], CollectionFavorite) && reCollectionDigits.MatchString(parts[1]):
+		return CollectionRef{Kind: CollectionFavorite, ID: parts[1]}, nil
+	case len(parts) == 3 && (strings.EqualFold(parts[0], CollectionSeason) || strings.EqualFold(parts[0], CollectionSeries)) &&
+		reCollectionDigits.MatchString(parts[1]) && reCollectionDigits.MatchString(parts[2]):
+		return CollectionRef{Kind: strings.ToLower(parts[0]), Mid: parts[1], ID: parts[2]}, nil
+	}
+	return CollectionRef{}, fmt.Errorf("cannot parse bilibili collection from: %s", input)
+}
+
+func parseCollectionURL(u *url.URL, input string) (CollectionRef, error) {
+	path := strings.Trim(u.Path, "/")
+	q := u.Query()
+	if strings.Contains(u.Host, "space.bilibili.com") {
+		segs := strings.Split(path, "/")
+		mid := segs[0]
+		switch {
+		case strings.HasSuffix(path, "/favlist") && reCollectionDigits.MatchString(q.Get("fid")):
+			return CollectionRef{Kind: CollectionFavorite, ID: q.Get("fid")}, nil
+		case strings.Contains(path, "collectiondetail") && reCollectionDigits.MatchString(q.Get("sid")) && reCollectionDigits.MatchString(mid):
+			return CollectionRef{Kind: CollectionSeason, Mid: mid, ID: q.Get("sid")}, nil
+		case strings.Contains(path, "seriesdetail") && reCollectionDigits.MatchString(q.Get("sid")) && reCollectionDigits.MatchString(mid):
+			return CollectionRef{Kind: CollectionSeries, Mid: mid, ID: q.Get("sid")}, nil
+		}
+		// Newer space URLs: /<mid>/lists/<id>?type=season|series
+		if len(segs) == 3 && segs[1] == "lists" && reCollectionDigits.MatchString(mid) && reCollectionDigits.MatchString(segs[2]) {
+			switch q.Get("type") {
+			case "season":
+				return CollectionRef{Kind: CollectionSeason, Mid: mid, ID: segs[2]}, nil
+			case "series":
+				return CollectionRef{Kind: CollectionSeries, Mid: mid, ID: segs[2]}, nil
+			}
+		}
+	}
+	if strings.Contains(u.Host, "bilibili.com") {
+		if path == "list/watchlater" || path == "watchlater" {
+			return CollectionRef{Kind: CollectionWatchLater}, nil
+		}
+		if strings.HasPrefix(path, "list/ml") && reCollectionDigits.MatchString(strings.TrimPrefix(path, "list/ml")) {
+			return CollectionRef{Kind: CollectionFavorite, ID: strings.TrimPrefix(path, "list/ml")}, nil
+		}
+	}
+	return CollectionRef{}, fmt.Errorf("cannot parse bilibili collection from: %s", input)
+}
+
+// Collection is the folder metadata saved to collections/<id>/collection.json.
+type Collection struct {
+	CollectionID string `json:"collection_id"`
+	Kind         string `json:"kind"`
+	ID           string `json:"id"`
+	Title        string `json:"title"`
+	Intro        string `json:"intro"`
+	Cover        string `json:"cover"`
+	OwnerMid     int64  `json:"owner_mid"`
+	OwnerName    string `json:"owner_name"`
+	MediaCount   int64  `json:"media_count"`
+	CreateTime   int64  `json:"create_time"`
+	UpdateTime   int64  `json:"update_time"`
+}
+
+// CollectionItem is one video of a collection in list order. AddedAt is the
+// favorite/watch-later time when the API provides it.
+type CollectionItem struct {
+	CollectionID string `json:"collection_id"`
+	Index        int    `json:"index"`
+	NoteID       string `json:"note_id"`
+	AID          int64  `json:"aid"`
+	BVID         string `json:"bvid"`
+	Title        string `json:"title"`
+	AddedAt      int64  `json:"added_at"`
+}
+
+func (i *CollectionItem) CSVHeader() []string {
+	return []string{"collection_id", "index", "note_id", "aid", "bvid", "title", "added_at"}
+}
+
+func (i *CollectionItem) ToCSV() []string {
+	return []string{
+		i.CollectionID,
+		strconv.Itoa(i.Index),
+		i.NoteID,
+		strconv.FormatInt(i.AID, 10),
+		i.BVID,
+		i.Title,
+		strconv.FormatInt(i.AddedAt, 10),
+	}
+}
+
+func (i *CollectionItem) Key() string {
+	return i.CollectionID + ":" + i.NoteID
+}
+
+// VideoSource is stored as note.json "source" for videos crawled from a
+// collection.
+type VideoSource struct {
+	Kind         string `json:"kind"`
+	CollectionID string `json:"collection_id"`
+	Title        string `json:"title"`
+}
+
+type collectionClient interface {
+	ListFavResources(context.Context, string, int, int) (FavResourceResponse, error)
+	ListSeasonArchives(context.Context, string, string, int, int) (SeasonArchivesResponse, error)
+	GetSeries(context.Context, string) (SeriesResponse, error)
+	ListSeriesArchives(context.Context, string, string, int, int) (SeriesArchivesResponse, error)
+	GetWatchLater(context.Context) (WatchLaterResponse, error)
+}
+
+func collectionItem(collectionID string, bvid string, aid int64, title string, addedAt int64) (CollectionItem, bool) {
+	bvid = strings.TrimSpace(bvid)
+	if strings.HasPrefix(strings.ToLower(bvid), "bv") {
+		bvid = strings.ToUpper(bvid)
+	}
+	noteID := bvid
+	if noteID == "" && aid > 0 {
+		noteID = "av" + strconv.FormatInt(aid, 10)
+	}
+	if noteID == "" {
+		return CollectionItem{}, false
+	}
+	return CollectionItem{CollectionID: collectionID, NoteID: noteID, AID: aid, BVID: bvid, Title: title, AddedAt: addedAt}, true
+}
+
+// listCollection pages through a collection until max items (0 = all) and
+// returns its metadata with the items in list order.
+func listCollection(ctx context.Context, cc collectionClient, ref CollectionRef, max int, sleepSec int) (Collection, []CollectionItem, error) {
+	meta := Collection{CollectionID: ref.CollectionID(), Kind: ref.Kind, ID: ref.ID}
+	var items []CollectionItem
+	full := func() bool { return max > 0 && len(items) >= max }
+	add := func(bvid string, aid int64, title string, addedAt int64) {
+		if full() {
+			return
+		}
+		if it, ok := collectionItem(meta.CollectionID, bvid, aid, title, addedAt); ok {
+			it.Index = len(items) + 1
+			items = append(items, it)
+		}
+	}
+	pause := time.Duration(sleepSec) * time.Second
+
+	switch ref.Kind {
+	case CollectionFavorite:
+		for page := 1; !full(); page++ {
+			res, err := cc.ListFavResources(ctx, ref.ID, page, 20)
+			if err != nil {
+				return meta, items, err
+			}
+			info := res.Data.Info
+			meta.Title, meta.Intro, meta.Cover = info.Title, info.Intro, info.Cover
+			meta.OwnerMid, meta.OwnerName = info.Upper.Mid, info.Upper.Name
+			meta.MediaCount, meta.CreateTime, meta.UpdateTime = info.MediaCount, info.Ctime, info.Mtime
+			for _, m := range res.Data.Medias {
+				// Type 2 is a video; audio and removed videos (attr 9) are skipped.
+				if m.Type != 2 || m.Attr == 9 {
+					continue
+				}
+				add(m.BVID, m.ID, m.Title, m.FavTime)
+			}
+			if !res.Data.HasMore || len(res.Data.Medias) == 0 {
+				break
+			}
+			if !crawler.Sleep(ctx, pause) {
+				return meta, items, ctx.Err()
+			}
+		}
+	case CollectionSeason:
+		const pageSize = 30
+		for page := 1; !full(); page++ {
+			res, err := cc.ListSeasonArchives(ctx, ref.Mid, ref.ID, page, pageSize)
+			if err != nil {
+				return meta, items, err
+			}
+			m := res.Data.Meta
+			meta.Title, meta.Intro, meta.Cover = m.Name, m.Description, m.Cover
+			meta.OwnerMid, meta.MediaCount, meta.CreateTime = m.Mid, m.Total, m.Ptime
+			for _, a := range res.Data.Archives {
+				add(a.BVID, a.AID, a.Title, 0)
+			}
+			if len(res.Data.Archives) < pageSize || int64(page*pageSize) >= res.Data.Page.Total {
+				break
+			}
+			if !crawler.Sleep(ctx, pause) {
+				return meta, items, ctx.Err()
+			}
+		}
+	case CollectionSeries:
+		if res, err := cc.GetSeries(ctx, ref.ID); err == nil {
+			m := res.Data.Meta
+			meta.Title, meta.Intro = m.Name, m.Description
+			meta.OwnerMid, meta.MediaCount, meta.CreateTime, meta.UpdateTime = m.Mid, m.Total, m.Ctime, m.Mtime
+		} else {
+			logger.Warn("bilibili series meta failed", "series_id", ref.ID, "err", err)
+		}
+		const pageSize = 30
+		for page := 1; !full(); page++ {
+			res, err := cc.ListSeriesArchives(ctx, ref.Mid, ref.ID, page, pageSize)
+			if err != nil {
+				return meta, items, err
+			}
+			for _, a := range res.Data.Archives {
+				add(a.BVID, a.AID, a.Title, 0)
+			}
+			if len(res.Data.Archives) < pageSize || int64(page*pageSize) >= res.Data.Page.Total {
+				break
+			}
+			if !crawler.Sleep(ctx, pause) {
+				return meta, items, ctx.Err()
+			}
+		}
+	case CollectionWatchLater:
+		res, err := cc.GetWatchLater(ctx)
+		if err != nil {
+			return meta, items, err
+		}
+		meta.Title, meta.MediaCount = "稍后再看", res.Data.Count
+		for _, v := range res.Data.List {
+			add(v.BVID, v.AID, v.Title, v.AddAt)
+		}
+	default:
+		return meta, nil, fmt.Errorf("unsupported collection kind: %s", ref.Kind)
+	}
+	if meta.OwnerMid == 0 && ref.Mid != "" {
+		meta.OwnerMid, _ = strconv.ParseInt(ref.Mid, 10, 64)
+	}
+	return meta, items, nil
+}
+
+// runCollection crawls favorites folders, seasons, series and the watch-later
+// list: the folder metadata goes to collections/<id>/collection.json, the
+// ordered video list to collections/<id>/items.*, and every video runs
+// through fetchAndSaveVideo with the folder recorded as its source.
+func (c *Crawler) runCollection(ctx context.Context, req crawler.Request) (crawler.Result, error) {
+	cc, ok := c.client.(collectionClient)
+	if !ok {
+		return crawler.Result{}, fmt.Errorf("bilibili client does not support collections")
+	}
+	inputs := req.Inputs
+	if len(inputs) == 0 {
+		inputs = crawler.BiliCollectionInputs(config.AppConfig)
+	}
+	if len(inputs) == 0 {
+		return crawler.Result{}, fmt.Errorf("empty inputs (BILI_FAV_MEDIA_ID_LIST / BILI_SEASON_ID_LIST / BILI_SERIES_ID_LIST / BILI_ENABLE_WATCH_LATER)")
+	}
+	limit := req.MaxNotes
+	if limit == 0 {
+		limit = config.AppConfig.CrawlerMaxNotesCount
+	}
+	concurrency := req.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	out := crawler.NewResult(req)
+	for _, input := range inputs {
+		if ctx.Err() != nil {
+			out.FinishedAt = time.Now().Unix()
+			return out, ctx.Err()
+		}
+		ref, err := ParseCollectionRef(input)
+		if err != nil {
+			logger.Warn("skip invalid bilibili collection", "value", input, "err", err)
+			out.Processed++
+			out.Failed++
+			out.FailureKinds = crawler.MergeFailureKinds(out.FailureKinds, map[string]int{string(crawler.ErrorKindInvalidInput): 1})
+			continue
+		}
+		meta, items, err := listCollection(ctx, cc, ref, limit, config.AppConfig.CrawlerMaxSleepSec)
+		if err != nil {
+			logger.Error("bilibili collection list failed", "collection_id", meta.CollectionID, "listed", len(items), "err", err)
+			if len(items) == 0 {
+				out.Processed++
+				out.Failed++
+				out.FailureKinds = crawler.MergeFailureKinds(out.FailureKinds, map[string]int{string(crawler.KindOf(err)): 1})
+				continue
+			}
+		}
+		if err := store.SaveCollectionMeta(meta.CollectionID, meta); err != nil {
+			logger.Error("save collection meta failed", "collection_id", meta.CollectionID, "err", err)
+		}
+		rows := make([]any, 0, len(items))
+		for i := range items {
+			rows = append(rows, &items[i])
+		}
+		if _, err := store.AppendUniqueCollectionItems(
+			meta.CollectionID,
+			rows,
+			func(item any) (string, error) { return item.(*CollectionItem).Key(), nil },
+			(&CollectionItem{}).CSVHeader(),
+			func(item any) ([]string, error) { return item.(*CollectionItem).ToCSV(), nil },
+		); err != nil {
+			logger.Error("save collection items failed", "collection_id", meta.CollectionID, "err", err)
+		}
+		logger.Info("bilibili collection listed", "collection_id", meta.CollectionID, "title", meta.Title, "items", len(items))
+
+		source := &VideoSource{Kind: ref.Kind, CollectionID: meta.CollectionID, Title: meta.Title}
+		r := crawler.ForEachLimit(ctx, items, concurrency, func(ctx context.Context, it CollectionItem) error {
+			return c.fetchAndSaveVideoFrom(ctx, it.BVID, it.AID, it.NoteID, source)
+		})
+		out.Processed += r.Processed
+		out.Succeeded += r.Succeeded
+		out.Failed += r.Failed
+		out.FailureKinds = crawler.MergeFailureKinds(out.FailureKinds, r.FailureKinds)
+	}
+	out.FinishedAt = time.Now().Unix()
+	return out, nil
+}
@@ -0,0 +1,227 @@
+package bilibili
+
+import (
+	"context"
+	"fmt"
+	"media-crawler-go/internal/crawler"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type collectionUpper struct {
+	Mid  int64  `json:"mid"`
+	Name string `json:"name"`
+}
+
+type FavResourceResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Info struct {
+			ID         int64           `json:"id"`
+			Title      string          `json:"title"`
+			Intro      string          `json:"intro"`
+			Cover      string          `json:"cover"`
+			MediaCount int64           `json:"media_count"`
+			Ctime      int64           `json:"ctime"`
+			Mtime      int64           `json:"mtime"`
+			Upper      collectionUpper `json:"upper"`
+		} `json:"info"`
+		Medias  []favMedia `json:"medias"`
+		HasMore bool       `json:"has_more"`
+	} `json:"data"`
+}
+
+type favMedia struct {
+	ID      int64           `json:"id"`
+	Type    int             `json:"type"`
+	BVID    string          `json:"bvid"`
+	Title   string          `json:"title"`
+	Attr    int             `json:"attr"`
+	Pubtime int64           `json:"pubtime"`
+	FavTime int64           `json:"fav_time"`
+	Upper   collectionUpper `json:"upper"`
+}
+
+type collectionArchive struct {
+	AID     int64  `json:"aid"`
+	BVID    string `json:"bvid"`
+	Title   string `json:"title"`
+	Pubdate int64  `json:"pubdate"`
+}
+
+type SeasonArchivesResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Meta struct {
+			SeasonID    int64  `json:"season_id"`
+			Mid         int64  `json:"mid"`
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			Cover       string `json:"cover"`
+			Total       int64  `json:"total"`
+			Ptime       int64  `json:"ptime"`
+		} `json:"meta"`
+		Archives []collectionArchive `json:"archives"`
+		Page     struct {
+			PageNum  int   `json:"page_num"`
+			PageSize int   `json:"page_size"`
+			Total    int64 `json:"total"`
+		} `json:"page"`
+	} `json:"data"`
+}
+
+type SeriesResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Meta struct {
+			SeriesID    int64  `json:"series_id"`
+			Mid         int64  `json:"mid"`
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			Total       int64  `json:"total"`
+			Ctime       int64  `json:"ctime"`
+			Mtime       int64  `json:"mtime"`
+		} `json:"meta"`
+	} `json:"data"`
+}
+
+type SeriesArchivesResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Archives []collectionArchive `json:"archives"`
+		Page     struct {
+			Num   int   `json:"num"`
+			Size  int   `json:"size"`
+			Total int64 `json:"total"`
+		} `json:"page"`
+	} `json:"data"`
+}
+
+type WatchLaterResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Count int64 `json:"count"`
+		List  []struct {
+			collectionArchive
+			AddAt int64           `json:"add_at"`
+			Owner collectionUpper `json:"owner"`
+		} `json:"list"`
+	} `json:"data"`
+}
+
+// getCollectionJSON issues a GET against api.bilibili.com and checks both the
+// HTTP status and the API code of responses shaped {code, message, data}.
+func (c *Client) getCollectionJSON(ctx context.Context, path string, params map[string]string, out any, code func() (int, string)) error {
+	if err := c.ensureProxy(ctx); err != nil {
+		return err
+	}
+	resp, err := c.httpClient.R().
+		SetContext(ctx).
+		SetQueryParams(params).
+		SetResult(out).
+		Get(path)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return crawler.NewHTTPStatusError("bilibili", path, resp.StatusCode(), resp.String())
+	}
+	if n, msg := code(); n != 0 {
+		return fmt.Errorf("bilibili api error: code=%d message=%s", n, msg)
+	}
+	return nil
+}
+
+// ListFavResources lists one page of a public favorites folder (收藏夹); the
+// folder metadata comes along in Data.Info.
+func (c *Client) ListFavResources(ctx context.Context, mediaID string, page int, pageSize int) (FavResourceResponse, error) {
+	mediaID = strings.TrimSpace(mediaID)
+	if mediaID == "" {
+		return FavResourceResponse{}, fmt.Errorf("empty media id")
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	var out FavResourceResponse
+	err := c.getCollectionJSON(ctx, "/x/v3/fav/resource/list", map[string]string{
+		"media_id": mediaID,
+		"pn":       strconv.Itoa(page),
+		"ps":       strconv.Itoa(pageSize),
+		"order":    "mtime",
+		"platform": "web",
+	}, &out, func() (int, string) { return out.Code, out.Message })
+	return out, err
+}
+
+// ListSeasonArchives lists one page of a creator's season (合集).
+func (c *Client) ListSeasonArchives(ctx context.Context, mid string, seasonID string, page int, pageSize int) (SeasonArchivesResponse, error) {
+	if strings.TrimSpace(mid) == "" || strings.TrimSpace(seasonID) == "" {
+		return SeasonArchivesResponse{}, fmt.Errorf("empty mid or season id")
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 30
+	}
+	var out SeasonArchivesResponse
+	err := c.getCollectionJSON(ctx, "/x/polymer/web-space/seasons_archives_list", map[string]string{
+		"mid":          strings.TrimSpace(mid),
+		"season_id":    strings.TrimSpace(seasonID),
+		"page_num":     strconv.Itoa(page),
+		"page_size":    strconv.Itoa(pageSize),
+		"sort_reverse": "false",
+	}, &out, func() (int, string) { return out.Code, out.Message })
+	return out, err
+}
+
+// GetSeries returns the metadata of a channel series (系列).
+func (c *Client) GetSeries(ctx context.Context, seriesID string) (SeriesResponse, error) {
+	if strings.TrimSpace(seriesID) == "" {
+		return SeriesResponse{}, fmt.Errorf("empty series id")
+	}
+	var out SeriesResponse
+	err := c.getCollectionJSON(ctx, "/x/series/series", map[string]string{
+		"series_id": strings.TrimSpace(seriesID),
+	}, &out, func() (int, string) { return out.Code, out.Message })
+	return out, err
+}
+
+// ListSeriesArchives lists one page of a channel series in ascending order.
+func (c *Client) ListSeriesArchives(ctx context.Context, mid string, seriesID string, page int, pageSize int) (SeriesArchivesResponse, error) {
+	if strings.TrimSpace(mid) == "" || strings.TrimSpace(seriesID) == "" {
+		return SeriesArchivesResponse{}, fmt.Errorf("empty mid or series id")
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 30
+	}
+	var out SeriesArchivesResponse
+	err := c.getCollectionJSON(ctx, "/x/series/archives", map[string]string{
+		"mid":       strings.TrimSpace(mid),
+		"series_id": strings.TrimSpace(seriesID),
+		"pn":        strconv.Itoa(page),
+		"ps":        strconv.Itoa(pageSize),
+		"sort":      "asc",
+	}, &out, func() (int, string) { return out.Code, out.Message })
+	return out, err
+}
+
+// GetWatchLater returns the watch-later list (稍后再看) of the logged-in
+// account; it requires the SESSDATA cookie.
+func (c *Client) GetWatchLater(ctx context.Context) (WatchLaterResponse, error) {
+	var out WatchLaterResponse
+	err := c.getCollectionJSON(ctx, "/x/v2/history/toview", nil, &out, func() (int, string) { return out.Code, out.Message })
+	return out, err
+}
@@ -0,0 +1,154 @@
+package bilibili
+
+import (
+	"context"
+	"encoding/json"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseCollectionRef(t *testing.T) {
+	cases := []struct {
+		in   string
+		want CollectionRef
+	}{
+		{"fav:123", CollectionRef{Kind: CollectionFavorite, ID: "123"}},
+		{"ml456", CollectionRef{Kind: CollectionFavorite, ID: "456"}},
+		{"season:11:22", CollectionRef{Kind: CollectionSeason, Mid: "11", ID: "22"}},
+		{"series:11:33", CollectionRef{Kind: CollectionSeries, Mid: "11", ID: "33"}},
+		{"watchlater", CollectionRef{Kind: CollectionWatchLater}},
+		{"https://space.bilibili.com/11/favlist?fid=789&ftype=create", CollectionRef{Kind: CollectionFavorite, ID: "789"}},
+		{"https://space.bilibili.com/11/channel/collectiondetail?sid=22", CollectionRef{Kind: CollectionSeason, Mid: "11", ID: "22"}},
+		{"https://space.bilibili.com/11/channel/seriesdetail?sid=33", CollectionRef{Kind: CollectionSeries, Mid: "11", ID: "33"}},
+		{"https://space.bilibili.com/11/lists/22?type=season", CollectionRef{Kind: CollectionSeason, Mid: "11", ID: "22"}},
+		{"https://www.bilibili.com/list/watchlater?bvid=BV1xx", CollectionRef{Kind: CollectionWatchLater}},
+		{"https://www.bilibili.com/list/ml456", CollectionRef{Kind: CollectionFavorite, ID: "456"}},
+	}
+	for _, tc := range cases {
+		got, err := ParseCollectionRef(tc.in)
+		if err != nil {
+			t.Fatalf("ParseCollectionRef(%q): %v", tc.in, err)
+		}
+		if got != tc.want {
+			t.Fatalf("ParseCollectionRef(%q)=%+v want %+v", tc.in, got, tc.want)
+		}
+	}
+	for _, in := range []string{"", "season:22", "fav:abc", "https://www.bilibili.com/video/BV1xx"} {
+		if _, err := ParseCollectionRef(in); err == nil {
+			t.Fatalf("expected error for %q", in)
+		}
+	}
+	if got := (CollectionRef{Kind: CollectionFavorite, ID: "123"}).CollectionID(); got != "fav_123" {
+		t.Fatalf("unexpected collection id: %s", got)
+	}
+}
+
+type fakeCollectionClient struct {
+	fakeClient
+	favPages int
+}
+
+func (f *fakeCollectionClient) ListFavResources(ctx context.Context, mediaID string, page int, pageSize int) (FavResourceResponse, error) {
+	f.favPages++
+	var out FavResourceResponse
+	out.Data.Info.ID = 123
+	out.Data.Info.Title = "my favs"
+	out.Data.Info.MediaCount = 3
+	out.Data.Info.Upper = collectionUpper{Mid: 11, Name: "up"}
+	switch page {
+	case 1:
+		out.Data.Medias = append(out.Data.Medias, favMedia{ID: 1, Type: 2, BVID: "BV1aa", Title: "a", FavTime: 100})
+		out.Data.Medias = append(out.Data.Medias, out.Data.Medias[0])
+		out.Data.Medias[1].ID, out.Data.Medias[1].BVID, out.Data.Medias[1].Attr = 2, "BV1gone", 9
+		out.Data.HasMore = true
+	case 2:
+		out.Data.Medias = append(out.Data.Medias, favMedia{ID: 3, Type: 2, BVID: "BV1bb", Title: "b", FavTime: 200})
+	}
+	return out, nil
+}
+
+func (f *fakeCollectionClient) ListSeasonArchives(ctx context.Context, mid string, seasonID string, page int, pageSize int) (SeasonArchivesResponse, error) {
+	var out SeasonArchivesResponse
+	out.Data.Meta.Name = "season"
+	out.Data.Meta.Total = 1
+	out.Data.Archives = []collectionArchive{{AID: 4, BVID: "BV1cc", Title: "c"}}
+	out.Data.Page.Total = 1
+	return out, nil
+}
+
+func (f *fakeCollectionClient) GetSeries(ctx context.Context, seriesID string) (SeriesResponse, error) {
+	return SeriesResponse{}, nil
+}
+
+func (f *fakeCollectionClient) ListSeriesArchives(ctx context.Context, mid string, seriesID string, page int, pageSize int) (SeriesArchivesResponse, error) {
+	return SeriesArchivesResponse{}, nil
+}
+
+func (f *fakeCollectionClient) GetWatchLater(ctx context.Context) (WatchLaterResponse, error) {
+	return WatchLaterResponse{}, nil
+}
+
+func TestCrawlerCollectionMode(t *testing.T) {
+	oldwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	tmp := t.TempDir()
+	if err := os.Chdir(tmp); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(oldwd) })
+
+	config.AppConfig = config.Config{
+		Platform:       "bilibili",
+		StoreBackend:   "file",
+		SaveDataOption: "json",
+		DataDir:        "data",
+	}
+
+	fc := &fakeCollectionClient{}
+	c := NewCrawlerWithClient(fc)
+	req := crawler.Request{Platform: "bilibili", Mode: crawler.ModeCollection, Inputs: []string{"fav:123", "season:11:22", "bad"}, Concurrency: 1}
+	res, err := c.Run(context.Background(), req)
+	if err != nil {
+		t.Fatalf("collection run: %v", err)
+	}
+	if fc.favPages != 2 {
+		t.Fatalf("expected 2 fav pages, got %d", fc.favPages)
+	}
+	if res.Succeeded != 3 || res.Failed != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	base := filepath.Join("data", "bilibili", "collections", "fav_123")
+	b, err := os.ReadFile(filepath.Join(base, "collection.json"))
+	if err != nil {
+		t.Fatalf("read collection.json: %v", err)
+	}
+	var meta Collection
+	if err := json.Unmarshal(b, &meta); err != nil || meta.Title != "my favs" || meta.OwnerMid != 11 {
+		t.Fatalf("unexpected meta: %+v err=%v", meta, err)
+	}
+	items, err := os.ReadFile(filepath.Join(base, "items.jsonl"))
+	if err != nil {
+		t.Fatalf("read items: %v", err)
+	}
+	if n := strings.Count(strings.TrimSpace(string(items)), "\n") + 1; n != 2 {
+		t.Fatalf("expected 2 items, got %d: %s", n, items)
+	}
+	if strings.Contains(string(items), "BV1GONE") {
+		t.Fatalf("removed video should be skipped: %s", items)
+	}
+
+	note, err := os.ReadFile(filepath.Join("data", "bilibili", "notes", "BV1AA", "note.json"))
+	if err != nil {
+		t.Fatalf("read note: %v", err)
+	}
+	if !strings.Contains(string(note), `"collection_id":"fav_123"`) && !strings.Contains(string(note), `"collection_id": "fav_123"`) {
+		t.Fatalf("expected source in note: %s", note)
+	}
+}
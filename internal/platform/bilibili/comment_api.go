@@ -12,7 +12,9 @@ import (
 
 type replyItem struct {
 	RPID    int64 `json:"rpid"`
+	Root    int64 `json:"root"`
 	Parent  int64 `json:"parent"`
+	RCount  int64 `json:"rcount"`
 	CTime   int64 `json:"ctime"`
 	Like    int   `json:"like"`
 	Content struct {
@@ -155,6 +157,7 @@ func fetchAllVideoComments(ctx context.Context, client commentClient, oid int64,
 				LikeCount:       r.Like,
 				UserID:          strings.TrimSpace(r.Member.Mid),
 				UserNickname:    strings.TrimSpace(r.Member.Uname),
+				SubCommentCount: r.RCount,
 			})
 			if len(out) >= max {
 				break
@@ -208,14 +211,22 @@ func fetchAllVideoSubComments(ctx context.Context, client commentClient, oid int
 			break
 		}
 		for _, r := range resp.Data.Replies {
+			// parent is the comment replied to; it equals root for direct
+			// replies to the top-level comment.
+			replyTo := rootID
+			if r.Parent > 0 {
+				replyTo = strconv.FormatInt(r.Parent, 10)
+			}
 			out = append(out, Comment{
-				CommentID:       strconv.FormatInt(r.RPID, 10),
-				ParentCommentID: rootID,
-				Content:         r.Content.Message,
-				CreateTime:      r.CTime,
-				LikeCount:       r.Like,
-				UserID:          strings.TrimSpace(r.Member.Mid),
-				UserNickname:    strings.TrimSpace(r.Member.Uname),
+				CommentID:        strconv.FormatInt(r.RPID, 10),
+				ParentCommentID:  rootID,
+				RootCommentID:    rootID,
+				ReplyToCommentID: replyTo,
+				Content:          r.Content.Message,
+				CreateTime:       r.CTime,
+				LikeCount:        r.Like,
+				UserID:           strings.TrimSpace(r.Member.Mid),
+				UserNickname:     strings.TrimSpace(r.Member.Uname),
 			})
 			if len(out) >= max {
 				break
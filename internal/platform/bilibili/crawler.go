@@ -10,6 +10,8 @@ import (
 	"media-crawler-go/internal/logger"
 	"media-crawler-go/internal/proxy"
 	"media-crawler-go/internal/store"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -63,6 +65,10 @@ func (c *Crawler) Run(ctx context.Context, req crawler.Request) (crawler.Result,
 		res, err = c.runSearch(ctx, req)
 	case crawler.ModeCreator:
 		res, err = c.runCreator(ctx, req)
+	case crawler.ModeLive:
+		res, err = c.runLive(ctx, req)
+	case crawler.ModeCollection:
+		res, err = c.runCollection(ctx, req)
 	default:
 		res, err = c.runDetail(ctx, req)
 	}
@@ -101,6 +107,12 @@ func (c *Crawler) runDetail(ctx context.Context, req crawler.Request) (crawler.R
 }
 
 func (c *Crawler) fetchAndSaveVideo(ctx context.Context, bvid string, aid int64, noteID string) error {
+	return c.fetchAndSaveVideoFrom(ctx, bvid, aid, noteID, nil)
+}
+
+// fetchAndSaveVideoFrom is fetchAndSaveVideo for videos reached through a
+// collection; a non-nil source is stored as the note's "source".
+func (c *Crawler) fetchAndSaveVideoFrom(ctx context.Context, bvid string, aid int64, noteID string, source *VideoSource) error {
 	res, err := c.client.GetView(ctx, bvid, aid)
 	if err != nil {
 		logger.Error("fetch view failed", "note_id", noteID, "err", err)
@@ -111,11 +123,20 @@ func (c *Crawler) fetchAndSaveVideo(ctx context.Context, bvid string, aid int64,
 		logger.Error("decode view data failed", "note_id", noteID, "err", err)
 		return err
 	}
-	if err := store.SaveNoteDetail(noteID, data); err != nil {
+	if m, ok := data.(map[string]any); ok && source != nil {
+		m["source"] = source
+	}
+	if err := store.SaveNoteDetailContext(ctx, noteID, data); err != nil {
 		logger.Error("save note failed", "note_id", noteID, "err", err)
 		return err
 	}
-	logger.Info("note saved", "note_id", noteID)
+	pages := ExtractPagesFromViewData(data)
+	logger.Info("note saved", "note_id", noteID, "parts", len(pages))
+	c.savePages(noteID, pages)
+
+	if config.AppConfig.EnableGetDanmaku {
+		c.fetchAndSaveDanmaku(ctx, aid, noteID, data, pages)
+	}
 
 	if !config.AppConfig.EnableGetComments {
 		if config.AppConfig.EnableGetMedias {
@@ -129,6 +150,8 @@ func (c *Crawler) fetchAndSaveVideo(ctx context.Context, bvid string, aid int64,
 		return nil
 	}
 
+	// Replies hang off the archive (aid), so every part of a multi-part video
+	// shares one comment area and it is fetched once rather than per cid.
 	oid := aid
 	if oid <= 0 {
 		oid = extractAIDFromViewData(data)
@@ -148,12 +171,15 @@ func (c *Crawler) fetchAndSaveVideo(ctx context.Context, bvid string, aid int64,
 	)
 	if err != nil {
 		logger.Error("fetch bilibili comments failed", "note_id", noteID, "oid", oid, "err", err)
+		recordCommentCoverage(noteID, data, 0)
 		return nil
 	}
+	defer recordCommentCoverage(noteID, data, len(comments))
 	if len(comments) == 0 {
 		return nil
 	}
 
+	unified := toUnifiedComments(noteID, comments)
 	switch config.AppConfig.SaveDataOption {
 	case "csv":
 		items := make([]any, 0, len(comments))
@@ -161,17 +187,7 @@ func (c *Crawler) fetchAndSaveVideo(ctx context.Context, bvid string, aid int64,
 		for i := range comments {
 			comments[i].NoteID = noteID
 			items = append(items, &comments[i])
-			globalItems = append(globalItems, &store.UnifiedComment{
-				Platform:        "bilibili",
-				NoteID:          noteID,
-				CommentID:       comments[i].CommentID,
-				ParentCommentID: comments[i].ParentCommentID,
-				Content:         comments[i].Content,
-				CreateTime:      comments[i].CreateTime,
-				LikeCount:       int64(comments[i].LikeCount),
-				UserID:          comments[i].UserID,
-				UserNickname:    comments[i].UserNickname,
-			})
+			globalItems = append(globalItems, unified[i])
 		}
 		if _, err := store.AppendUniqueCommentsCSV(
 			noteID,
@@ -194,17 +210,7 @@ func (c *Crawler) fetchAndSaveVideo(ctx context.Context, bvid string, aid int64,
 		globalItems := make([]any, 0, len(comments))
 		for i := range comments {
 			comments[i].NoteID = noteID
-			globalItems = append(globalItems, &store.UnifiedComment{
-				Platform:        "bilibili",
-				NoteID:          noteID,
-				CommentID:       comments[i].CommentID,
-				ParentCommentID: comments[i].ParentCommentID,
-				Content:         comments[i].Content,
-				CreateTime:      comments[i].CreateTime,
-				LikeCount:       int64(comments[i].LikeCount),
-				UserID:          comments[i].UserID,
-				UserNickname:    comments[i].UserNickname,
-			})
+			globalItems = append(globalItems, unified[i])
 		}
 		if _, err := store.AppendUniqueGlobalCommentsBook(
 			globalItems,
@@ -226,17 +232,7 @@ func (c *Crawler) fetchAndSaveVideo(ctx context.Context, bvid string, aid int64,
 		for i := range comments {
 			comments[i].NoteID = noteID
 			items = append(items, &comments[i])
-			globalItems = append(globalItems, &store.UnifiedComment{
-				Platform:        "bilibili",
-				NoteID:          noteID,
-				CommentID:       comments[i].CommentID,
-				ParentCommentID: comments[i].ParentCommentID,
-				Content:         comments[i].Content,
-				CreateTime:      comments[i].CreateTime,
-				LikeCount:       int64(comments[i].LikeCount),
-				UserID:          comments[i].UserID,
-				UserNickname:    comments[i].UserNickname,
-			})
+			globalItems = append(globalItems, unified[i])
 		}
 		if _, err := store.AppendUniqueCommentsXLSX(
 			noteID,
@@ -261,17 +257,7 @@ func (c *Crawler) fetchAndSaveVideo(ctx context.Context, bvid string, aid int64,
 		for i := range comments {
 			comments[i].NoteID = noteID
 			items = append(items, comments[i])
-			globalItems = append(globalItems, &store.UnifiedComment{
-				Platform:        "bilibili",
-				NoteID:          noteID,
-				CommentID:       comments[i].CommentID,
-				ParentCommentID: comments[i].ParentCommentID,
-				Content:         comments[i].Content,
-				CreateTime:      comments[i].CreateTime,
-				LikeCount:       int64(comments[i].LikeCount),
-				UserID:          comments[i].UserID,
-				UserNickname:    comments[i].UserNickname,
-			})
+			globalItems = append(globalItems, unified[i])
 		}
 		if _, err := store.AppendUniqueCommentsJSONL(
 			noteID,
@@ -294,6 +280,63 @@ func (c *Crawler) fetchAndSaveVideo(ctx context.Context, bvid string, aid int64,
 	return nil
 }
 
+// recordCommentCoverage compares the comments stored for a video with the
+// reply count (stat.reply) of its view data.
+func recordCommentCoverage(noteID string, viewData any, fetched int) {
+	reported := int64(-1)
+	if m, ok := viewData.(map[string]any); ok {
+		if v := dataGet(m, "stat", "reply"); v != nil {
+			reported = toInt64(v)
+		}
+	}
+	cov, err := store.RecordCommentCoverage("bilibili", noteID, noteID, reported, fetched)
+	if err != nil {
+		logger.Error("save comment coverage failed", "note_id", noteID, "err", err)
+		return
+	}
+	logger.Info("comment coverage", "note_id", noteID, "fetched", cov.FetchedCount, "reported", cov.ReportedCount, "ratio", cov.Ratio)
+}
+
+func (c *Crawler) fetchAndSaveDanmaku(ctx context.Context, aid int64, noteID string, viewData any, pages []VideoPage) {
+	dc, ok := c.client.(danmakuClient)
+	if !ok {
+		return
+	}
+	if aid <= 0 {
+		aid = extractAIDFromViewData(viewData)
+	}
+	if len(pages) == 0 {
+		logger.Warn("skip bilibili danmaku due to missing cid", "note_id", noteID)
+		return
+	}
+	for _, p := range pages {
+		items, err := fetchVideoDanmaku(ctx, dc, aid, p, config.AppConfig.BiliDanmakuSource, config.AppConfig.CrawlerMaxSleepSec)
+		if err != nil {
+			logger.Error("fetch bilibili danmaku failed", "note_id", noteID, "cid", p.CID, "err", err)
+		}
+		if len(items) == 0 {
+			continue
+		}
+		anyItems := make([]any, 0, len(items))
+		for i := range items {
+			items[i].NoteID = noteID
+			anyItems = append(anyItems, &items[i])
+		}
+		n, err := store.AppendUniqueNoteDanmaku(
+			noteID,
+			anyItems,
+			func(item any) (string, error) { return item.(*Danmaku).Key(), nil },
+			(&Danmaku{}).CSVHeader(),
+			func(item any) ([]string, error) { return item.(*Danmaku).ToCSV(), nil },
+		)
+		if err != nil {
+			logger.Error("save bilibili danmaku failed", "note_id", noteID, "cid", p.CID, "err", err)
+			continue
+		}
+		logger.Info("danmaku saved", "note_id", noteID, "cid", p.CID, "page", p.Page, "fetched", len(items), "new", n)
+	}
+}
+
 type mediaClient interface {
 	GetPlayURL(context.Context, int64, int64, int) (PlayURLResponse, error)
 }
@@ -304,17 +347,29 @@ func (c *Crawler) downloadMedias(ctx context.Context, bvid string, aid int64, no
 	if aid2 <= 0 {
 		aid2 = extractAIDFromViewData(viewData)
 	}
-	cid := ExtractCIDFromViewData(viewData)
-	if mc, ok := c.client.(mediaClient); ok && aid2 > 0 && cid > 0 {
+	pages := ExtractPagesFromViewData(viewData)
+	mediaDir := store.NoteMediaDir(noteID)
+	merge := config.AppConfig.BiliMergeDash
+	var prefixes []string
+	if mc, ok := c.client.(mediaClient); ok && aid2 > 0 {
 		qn := config.AppConfig.BiliQn
 		if qn <= 0 {
 			qn = 80
 		}
-		play, err := mc.GetPlayURL(ctx, aid2, cid, qn)
-		if err == nil {
-			purls, pnames := ExtractBilibiliPlayURLs(noteID, play.Data)
+		for _, p := range pages {
+			prefix := PartFilePrefix(noteID, p, len(pages))
+			if merge && fileExists(filepath.Join(mediaDir, prefix+".mp4")) {
+				continue
+			}
+			play, err := mc.GetPlayURL(ctx, aid2, p.CID, qn)
+			if err != nil {
+				logger.Warn("get bilibili play url failed", "note_id", noteID, "cid", p.CID, "page", p.Page, "err", err)
+				continue
+			}
+			purls, pnames := ExtractBilibiliPlayURLs(prefix, play.Data)
 			urls = append(urls, purls...)
 			filenames = append(filenames, pnames...)
+			prefixes = append(prefixes, prefix)
 		}
 	}
 	if len(urls) == 0 {
@@ -327,8 +382,54 @@ func (c *Crawler) downloadMedias(ctx context.Context, bvid string, aid int64, no
 	if ck := strings.TrimSpace(config.AppConfig.Cookies); ck != "" {
 		headers["Cookie"] = ck
 	}
-	d := downloader.NewDownloader(store.NoteMediaDir(noteID))
+	d := downloader.NewDownloader(mediaDir)
 	_ = d.BatchDownloadWithHeaders(urls, filenames, headers)
+	if merge {
+		for _, prefix := range prefixes {
+			mergeDashStreams(mediaDir, prefix)
+		}
+	}
+}
+
+// mergeDashStreams remuxes the separate DASH video and audio downloads of one
+// part into <prefix>.mp4. Progressive (durl) downloads have no audio file and
+// are left alone.
+func mergeDashStreams(dir string, prefix string) {
+	videoPath := filepath.Join(dir, prefix+"_video.mp4")
+	audioPath := filepath.Join(dir, prefix+"_audio.m4a")
+	if !fileExists(videoPath) || !fileExists(audioPath) {
+		return
+	}
+	outPath := filepath.Join(dir, prefix+".mp4")
+	opts := downloader.MuxOptions{KeepOriginals: config.AppConfig.BiliKeepDashOriginals}
+	if err := downloader.MuxFragmentedMP4(outPath, []string{videoPath, audioPath}, opts); err != nil {
+		logger.Warn("merge bilibili dash streams failed", "file", prefix, "err", err)
+	}
+}
+
+func fileExists(path string) bool {
+	st, err := os.Stat(path)
+	return err == nil && !st.IsDir()
+}
+
+func (c *Crawler) savePages(noteID string, pages []VideoPage) {
+	if len(pages) == 0 {
+		return
+	}
+	items := make([]any, 0, len(pages))
+	for i := range pages {
+		pages[i].NoteID = noteID
+		items = append(items, &pages[i])
+	}
+	if _, err := store.AppendUniqueNoteParts(
+		noteID,
+		items,
+		func(item any) (string, error) { return strconv.FormatInt(item.(*VideoPage).CID, 10), nil },
+		(&VideoPage{}).CSVHeader(),
+		func(item any) ([]string, error) { return item.(*VideoPage).ToCSV(), nil },
+	); err != nil {
+		logger.Error("save bilibili parts failed", "note_id", noteID, "err", err)
+	}
 }
 
 func (c *Crawler) runSearch(ctx context.Context, req crawler.Request) (crawler.Result, error) {
@@ -377,6 +478,7 @@ func (c *Crawler) runSearch(ctx context.Context, req crawler.Request) (crawler.R
 	for _, kw := range keywords {
 		page := startPage
 		for out.Succeeded+out.Failed < maxNotes {
+			crawler.ProgressFrom(ctx).SetStage(kw, page)
 			res, err := c.client.SearchVideo(ctx, kw, page, searchType)
 			if err != nil {
 				return out, err
@@ -468,11 +570,15 @@ func (c *Crawler) runCreator(ctx context.Context, req crawler.Request) (crawler.
 				logger.Error("crawl dynamics failed", "mid", mid, "err", err)
 			}
 		}
+		if config.AppConfig.EnableGetCreatorRelations {
+			c.crawlRelations(ctx, mid, upInfoName(infoData))
+		}
 
 		page := 1
 		pageSize := 30
 		seen := map[string]struct{}{}
 		for out.Succeeded+out.Failed < maxNotes {
+			crawler.ProgressFrom(ctx).SetStage(mid, page)
 			res, err := c.client.ListUpVideos(ctx, mid, page, pageSize)
 			if err != nil {
 				return out, err
@@ -3,12 +3,14 @@ package bilibili
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"media-crawler-go/internal/config"
 	"media-crawler-go/internal/crawler"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -42,6 +44,11 @@ func (f fakeClientWithMedia) ListUpVideos(ctx context.Context, mid string, page
 	return UpVideosResponse{Code: 0, Data: b}, nil
 }
 
+func (f fakeClientWithMedia) GetSpaceDynamics(ctx context.Context, hostMid string, offset string) (SpaceDynamicsResponse, error) {
+	b, _ := json.Marshal(map[string]any{})
+	return SpaceDynamicsResponse{Code: 0, Data: b}, nil
+}
+
 func (f fakeClientWithMedia) GetPlayURL(ctx context.Context, aid int64, cid int64, qn int) (PlayURLResponse, error) {
 	payload := map[string]any{
 		"durl": []any{
@@ -92,3 +99,89 @@ func TestCrawlerMediasSaved(t *testing.T) {
 	}
 }
 
+
+type fakeClientWithParts struct {
+	fakeClientWithMedia
+}
+
+func (f fakeClientWithParts) GetView(ctx context.Context, bvid string, aid int64) (ViewResponse, error) {
+	payload := map[string]any{
+		"aid":  170001,
+		"cid":  333,
+		"bvid": bvid,
+		"pic":  f.base + "/cover.jpg",
+		"pages": []any{
+			map[string]any{"cid": 333, "page": 1, "part": "Intro", "duration": 120},
+			map[string]any{"cid": 444, "page": 2, "part": "Lecture", "duration": 3600},
+		},
+	}
+	b, _ := json.Marshal(payload)
+	return ViewResponse{Code: 0, Data: b}, nil
+}
+
+func (f fakeClientWithParts) GetPlayURL(ctx context.Context, aid int64, cid int64, qn int) (PlayURLResponse, error) {
+	payload := map[string]any{
+		"durl": []any{
+			map[string]any{"url": fmt.Sprintf("%s/v%d.mp4", f.base, cid)},
+		},
+	}
+	b, _ := json.Marshal(payload)
+	return PlayURLResponse{Code: 0, Data: b}, nil
+}
+
+func TestCrawlerMultiPartMediasSaved(t *testing.T) {
+	oldwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	tmp := t.TempDir()
+	if err := os.Chdir(tmp); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(oldwd) })
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cover.jpg", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("c")) })
+	mux.HandleFunc("/v333.mp4", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("p1")) })
+	mux.HandleFunc("/v444.mp4", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("p2")) })
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	config.AppConfig = config.Config{
+		Platform:          "bilibili",
+		StoreBackend:      "file",
+		SaveDataOption:    "json",
+		DataDir:           "data",
+		EnableGetComments: false,
+		EnableGetMedias:   true,
+	}
+
+	c := NewCrawlerWithClient(fakeClientWithParts{fakeClientWithMedia{base: srv.URL}})
+	req := crawler.Request{Platform: "bilibili", Mode: crawler.ModeDetail, Inputs: []string{"BV1Q5411W7bH"}, Concurrency: 1}
+	if _, err := c.Run(context.Background(), req); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	noteDir := filepath.Join("data", "bilibili", "notes", "BV1Q5411W7BH")
+	for _, f := range []string{"BV1Q5411W7BH_cover_0.jpg", "BV1Q5411W7BH_p1_video.mp4", "BV1Q5411W7BH_p2_video.mp4"} {
+		if _, err := os.Stat(filepath.Join(noteDir, "media", f)); err != nil {
+			t.Fatalf("expected media saved: %s: %v", f, err)
+		}
+	}
+
+	b, err := os.ReadFile(filepath.Join(noteDir, "parts.jsonl"))
+	if err != nil {
+		t.Fatalf("read parts.jsonl: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 parts, got %d: %s", len(lines), string(b))
+	}
+	var p2 VideoPage
+	if err := json.Unmarshal([]byte(lines[1]), &p2); err != nil {
+		t.Fatalf("decode part: %v", err)
+	}
+	if p2.CID != 444 || p2.Page != 2 || p2.Part != "Lecture" || p2.Duration != 3600 || p2.NoteID != "BV1Q5411W7BH" {
+		t.Fatalf("unexpected part: %+v", p2)
+	}
+}
@@ -45,6 +45,11 @@ func (f fakeClient) ListUpVideos(ctx context.Context, mid string, page int, page
 	return UpVideosResponse{Code: 0, Data: b}, nil
 }
 
+func (f fakeClient) GetSpaceDynamics(ctx context.Context, hostMid string, offset string) (SpaceDynamicsResponse, error) {
+	b, _ := json.Marshal(map[string]any{})
+	return SpaceDynamicsResponse{Code: 0, Data: b}, nil
+}
+
 type fakeClientWithComments struct{}
 
 func (f fakeClientWithComments) GetView(ctx context.Context, bvid string, aid int64) (ViewResponse, error) {
@@ -80,6 +85,11 @@ func (f fakeClientWithComments) ListUpVideos(ctx context.Context, mid string, pa
 	return UpVideosResponse{Code: 0, Data: b}, nil
 }
 
+func (f fakeClientWithComments) GetSpaceDynamics(ctx context.Context, hostMid string, offset string) (SpaceDynamicsResponse, error) {
+	b, _ := json.Marshal(map[string]any{})
+	return SpaceDynamicsResponse{Code: 0, Data: b}, nil
+}
+
 func (f fakeClientWithComments) GetVideoComments(ctx context.Context, oid int64, page int, pageSize int, sort int) (replyMainResp, error) {
 	return replyMainResp{
 		Code: 0,
@@ -0,0 +1,235 @@
+package bilibili
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type Danmaku struct {
+	NoteID     string `json:"note_id"`
+	CID        int64  `json:"cid"`
+	DanmakuID  string `json:"danmaku_id"`
+	ProgressMs int64  `json:"progress_ms"`
+	Mode       int    `json:"mode"`
+	FontSize   int    `json:"font_size"`
+	Color      uint32 `json:"color"`
+	SendTime   int64  `json:"send_time"`
+	UserHash   string `json:"user_hash"`
+	Content    string `json:"content"`
+	Pool       int    `json:"pool"`
+}
+
+func (d *Danmaku) CSVHeader() []string {
+	return []string{"note_id", "cid", "danmaku_id", "progress_ms", "mode", "font_size", "color", "send_time", "user_hash", "content", "pool"}
+}
+
+func (d *Danmaku) ToCSV() []string {
+	return []string{
+		d.NoteID,
+		strconv.FormatInt(d.CID, 10),
+		d.DanmakuID,
+		strconv.FormatInt(d.ProgressMs, 10),
+		strconv.Itoa(d.Mode),
+		strconv.Itoa(d.FontSize),
+		fmt.Sprintf("#%06X", d.Color),
+		strconv.FormatInt(d.SendTime, 10),
+		d.UserHash,
+		d.Content,
+		strconv.Itoa(d.Pool),
+	}
+}
+
+// Key falls back to a content fingerprint for legacy XML entries without a dmid.
+func (d *Danmaku) Key() string {
+	if d.DanmakuID != "" {
+		return d.DanmakuID
+	}
+	return fmt.Sprintf("%d:%d:%d:%s:%s", d.CID, d.ProgressMs, d.SendTime, d.UserHash, d.Content)
+}
+
+type danmakuXML struct {
+	Items []struct {
+		P    string `xml:"p,attr"`
+		Text string `xml:",chardata"`
+	} `xml:"d"`
+}
+
+// ParseDanmakuXML parses the list.so payload. The endpoint answers with a raw
+// deflate stream, so undecoded bodies are inflated before unmarshalling.
+func ParseDanmakuXML(cid int64, body []byte) ([]Danmaku, error) {
+	raw, err := inflateDanmakuXML(body)
+	if err != nil {
+		return nil, err
+	}
+	var doc danmakuXML
+	if err := xml.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	out := make([]Danmaku, 0, len(doc.Items))
+	for _, it := range doc.Items {
+		parts := strings.Split(it.P, ",")
+		if len(parts) < 5 {
+			continue
+		}
+		sec, _ := strconv.ParseFloat(parts[0], 64)
+		mode, _ := strconv.Atoi(parts[1])
+		size, _ := strconv.Atoi(parts[2])
+		color, _ := strconv.ParseUint(parts[3], 10, 32)
+		ctime, _ := strconv.ParseInt(parts[4], 10, 64)
+		d := Danmaku{
+			CID:        cid,
+			ProgressMs: int64(sec * 1000),
+			Mode:       mode,
+			FontSize:   size,
+			Color:      uint32(color),
+			SendTime:   ctime,
+			Content:    it.Text,
+		}
+		if len(parts) > 5 {
+			d.Pool, _ = strconv.Atoi(parts[5])
+		}
+		if len(parts) > 6 {
+			d.UserHash = parts[6]
+		}
+		if len(parts) > 7 {
+			d.DanmakuID = parts[7]
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+func inflateDanmakuXML(body []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil, errors.New("empty danmaku xml")
+	}
+	if trimmed[0] == '<' {
+		return trimmed, nil
+	}
+	if b, err := io.ReadAll(flate.NewReader(bytes.NewReader(body))); err == nil {
+		return b, nil
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("decode danmaku xml: %w", err)
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// ParseDanmakuSegment decodes a DmSegMobileReply message from the seg.so
+// endpoint. Only the DanmakuElem fields we store are read; unknown fields are
+// skipped according to their wire type.
+func ParseDanmakuSegment(cid int64, body []byte) ([]Danmaku, error) {
+	var out []Danmaku
+	err := walkProto(body, func(field int, wire int, v uint64, b []byte) error {
+		if field != 1 || wire != 2 {
+			return nil
+		}
+		d, err := parseDanmakuElem(b)
+		if err != nil {
+			return err
+		}
+		d.CID = cid
+		out = append(out, d)
+		return nil
+	})
+	return out, err
+}
+
+func parseDanmakuElem(b []byte) (Danmaku, error) {
+	var d Danmaku
+	var id int64
+	err := walkProto(b, func(field int, wire int, v uint64, s []byte) error {
+		switch field {
+		case 1:
+			id = int64(v)
+		case 2:
+			d.ProgressMs = int64(int32(v))
+		case 3:
+			d.Mode = int(int32(v))
+		case 4:
+			d.FontSize = int(int32(v))
+		case 5:
+			d.Color = uint32(v)
+		case 6:
+			d.UserHash = string(s)
+		case 7:
+			d.Content = string(s)
+		case 8:
+			d.SendTime = int64(v)
+		case 11:
+			d.Pool = int(int32(v))
+		case 12:
+			d.DanmakuID = string(s)
+		}
+		return nil
+	})
+	if err != nil {
+		return Danmaku{}, err
+	}
+	if d.DanmakuID == "" && id != 0 {
+		d.DanmakuID = strconv.FormatInt(id, 10)
+	}
+	return d, nil
+}
+
+var errProtoTruncated = errors.New("protobuf: truncated message")
+
+// walkProto iterates the top-level fields of a protobuf message. Varint and
+// fixed fields are passed as v, length-delimited fields as b.
+func walkProto(buf []byte, fn func(field int, wire int, v uint64, b []byte) error) error {
+	for len(buf) > 0 {
+		key, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return errProtoTruncated
+		}
+		buf = buf[n:]
+		field := int(key >> 3)
+		wire := int(key & 7)
+		var v uint64
+		var b []byte
+		switch wire {
+		case 0:
+			v, n = binary.Uvarint(buf)
+			if n <= 0 {
+				return errProtoTruncated
+			}
+			buf = buf[n:]
+		case 1:
+			if len(buf) < 8 {
+				return errProtoTruncated
+			}
+			v = binary.LittleEndian.Uint64(buf)
+			buf = buf[8:]
+		case 2:
+			l, n := binary.Uvarint(buf)
+			if n <= 0 || uint64(len(buf)-n) < l {
+				return errProtoTruncated
+			}
+			b = buf[n : n+int(l)]
+			buf = buf[n+int(l):]
+		case 5:
+			if len(buf) < 4 {
+				return errProtoTruncated
+			}
+			v = uint64(binary.LittleEndian.Uint32(buf))
+			buf = buf[4:]
+		default:
+			return fmt.Errorf("protobuf: unsupported wire type %d", wire)
+		}
+		if err := fn(field, wire, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package bilibili
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"media-crawler-go/internal/crawler"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Each seg.so segment covers six minutes of the video timeline.
+const danmakuSegmentSec = 360
+
+func (c *Client) GetDanmakuXML(ctx context.Context, cid int64) ([]byte, error) {
+	if err := c.ensureProxy(ctx); err != nil {
+		return nil, err
+	}
+	if cid <= 0 {
+		return nil, fmt.Errorf("invalid cid")
+	}
+	resp, err := c.httpClient.R().
+		SetContext(ctx).
+		SetHeader("accept", "*/*").
+		SetQueryParam("oid", strconv.FormatInt(cid, 10)).
+		Get("/x/v1/dm/list.so")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, crawler.NewHTTPStatusError("bilibili", "/x/v1/dm/list.so", resp.StatusCode(), resp.String())
+	}
+	return resp.Body(), nil
+}
+
+func (c *Client) GetDanmakuSegment(ctx context.Context, aid int64, cid int64, segmentIndex int) ([]byte, error) {
+	if err := c.ensureProxy(ctx); err != nil {
+		return nil, err
+	}
+	if cid <= 0 {
+		return nil, fmt.Errorf("invalid cid")
+	}
+	if segmentIndex <= 0 {
+		segmentIndex = 1
+	}
+	params := map[string]string{
+		"type":          "1",
+		"oid":           strconv.FormatInt(cid, 10),
+		"segment_index": strconv.Itoa(segmentIndex),
+	}
+	if aid > 0 {
+		params["pid"] = strconv.FormatInt(aid, 10)
+	}
+	resp, err := c.httpClient.R().
+		SetContext(ctx).
+		SetHeader("accept", "*/*").
+		SetQueryParams(params).
+		Get("/x/v2/dm/web/seg.so")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, crawler.NewHTTPStatusError("bilibili", "/x/v2/dm/web/seg.so", resp.StatusCode(), resp.String())
+	}
+	body := resp.Body()
+	if strings.Contains(resp.Header().Get("Content-Type"), "json") || bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")) {
+		var e struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(body, &e); err == nil && e.Code != 0 {
+			return nil, fmt.Errorf("bilibili api error: code=%d message=%s", e.Code, strings.TrimSpace(e.Message))
+		}
+	}
+	return body, nil
+}
+
+type danmakuClient interface {
+	GetDanmakuXML(context.Context, int64) ([]byte, error)
+	GetDanmakuSegment(context.Context, int64, int64, int) ([]byte, error)
+}
+
+// fetchVideoDanmaku collects danmaku for one cid. The protobuf segments are
+// preferred because list.so caps the number of entries it returns; when the
+// segment endpoint fails (or source is "xml") the XML list is used instead.
+func fetchVideoDanmaku(ctx context.Context, client danmakuClient, aid int64, page VideoPage, source string, sleepSec int) ([]Danmaku, error) {
+	if client == nil || page.CID <= 0 {
+		return nil, nil
+	}
+	if strings.EqualFold(strings.TrimSpace(source), "xml") {
+		return fetchVideoDanmakuXML(ctx, client, page.CID)
+	}
+	out, err := fetchVideoDanmakuSegments(ctx, client, aid, page, sleepSec)
+	if err == nil {
+		return out, nil
+	}
+	if ctx.Err() != nil {
+		return out, ctx.Err()
+	}
+	xmlItems, xmlErr := fetchVideoDanmakuXML(ctx, client, page.CID)
+	if xmlErr != nil {
+		return out, fmt.Errorf("danmaku segments: %w; xml fallback: %v", err, xmlErr)
+	}
+	return xmlItems, nil
+}
+
+func fetchVideoDanmakuXML(ctx context.Context, client danmakuClient, cid int64) ([]Danmaku, error) {
+	body, err := client.GetDanmakuXML(ctx, cid)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDanmakuXML(cid, body)
+}
+
+func fetchVideoDanmakuSegments(ctx context.Context, client danmakuClient, aid int64, page VideoPage, sleepSec int) ([]Danmaku, error) {
+	segments := 1
+	if page.Duration > 0 {
+		segments = int((page.Duration + danmakuSegmentSec - 1) / danmakuSegmentSec)
+	}
+	out := make([]Danmaku, 0, 256)
+	for idx := 1; idx <= segments; idx++ {
+		body, err := client.GetDanmakuSegment(ctx, aid, page.CID, idx)
+		if err != nil {
+			return out, err
+		}
+		items, err := ParseDanmakuSegment(page.CID, body)
+		if err != nil {
+			return out, err
+		}
+		out = append(out, items...)
+		if idx < segments && sleepSec > 0 {
+			select {
+			case <-ctx.Done():
+				return out, ctx.Err()
+			case <-time.After(time.Duration(sleepSec) * time.Second):
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,179 @@
+package bilibili
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func pbVarint(field int, v uint64) []byte {
+	b := binary.AppendUvarint(nil, uint64(field<<3))
+	return binary.AppendUvarint(b, v)
+}
+
+func pbBytes(field int, v []byte) []byte {
+	b := binary.AppendUvarint(nil, uint64(field<<3|2))
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func pbDanmakuElem(id int64, progress int32, content string) []byte {
+	var elem []byte
+	elem = append(elem, pbVarint(1, uint64(id))...)
+	elem = append(elem, pbVarint(2, uint64(progress))...)
+	elem = append(elem, pbVarint(3, 1)...)
+	elem = append(elem, pbVarint(4, 25)...)
+	elem = append(elem, pbVarint(5, 0xFFFFFF)...)
+	elem = append(elem, pbBytes(6, []byte("a1b2c3"))...)
+	elem = append(elem, pbBytes(7, []byte(content))...)
+	elem = append(elem, pbVarint(8, 1700000000)...)
+	elem = append(elem, pbVarint(9, 10)...)
+	elem = append(elem, pbBytes(12, []byte(strconv.FormatInt(id, 10)))...)
+	return elem
+}
+
+func TestParseDanmakuSegment(t *testing.T) {
+	var body []byte
+	body = append(body, pbBytes(1, pbDanmakuElem(1234567890123, 15500, "前方高能"))...)
+	body = append(body, pbBytes(1, pbDanmakuElem(42, 61000, "hi"))...)
+	body = append(body, pbBytes(2, []byte("ignored"))...)
+
+	items, err := ParseDanmakuSegment(333, body)
+	if err != nil {
+		t.Fatalf("ParseDanmakuSegment: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(items))
+	}
+	d := items[0]
+	if d.CID != 333 || d.ProgressMs != 15500 || d.Mode != 1 || d.FontSize != 25 || d.Color != 0xFFFFFF {
+		t.Fatalf("unexpected item: %+v", d)
+	}
+	if d.UserHash != "a1b2c3" || d.Content != "前方高能" || d.SendTime != 1700000000 || d.DanmakuID != "1234567890123" {
+		t.Fatalf("unexpected item: %+v", d)
+	}
+
+	if _, err := ParseDanmakuSegment(333, body[:len(body)-3]); err == nil {
+		t.Fatalf("expected error for truncated payload")
+	}
+}
+
+func TestParseDanmakuXML(t *testing.T) {
+	raw := []byte(`<?xml version="1.0" encoding="UTF-8"?><i><chatid>333</chatid>` +
+		`<d p="12.345,1,25,16777215,1700000000,0,a1b2c3,98765,10">hello</d>` +
+		`<d p="3.5,5,25,255,1700000001,0,d4e5f6">legacy</d></i>`)
+
+	var buf bytes.Buffer
+	fw, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+	_, _ = fw.Write(raw)
+	_ = fw.Close()
+
+	for name, body := range map[string][]byte{"plain": raw, "deflate": buf.Bytes()} {
+		items, err := ParseDanmakuXML(333, body)
+		if err != nil {
+			t.Fatalf("%s: ParseDanmakuXML: %v", name, err)
+		}
+		if len(items) != 2 {
+			t.Fatalf("%s: expected 2 items, got %d", name, len(items))
+		}
+		if items[0].ProgressMs != 12345 || items[0].DanmakuID != "98765" || items[0].UserHash != "a1b2c3" || items[0].Content != "hello" {
+			t.Fatalf("%s: unexpected item: %+v", name, items[0])
+		}
+		if items[1].Mode != 5 || items[1].Color != 255 || items[1].DanmakuID != "" || items[1].Key() == "" {
+			t.Fatalf("%s: unexpected item: %+v", name, items[1])
+		}
+	}
+}
+
+type fakeClientWithDanmaku struct {
+	fakeClient
+	segments map[int64]int
+}
+
+func (f *fakeClientWithDanmaku) GetView(ctx context.Context, bvid string, aid int64) (ViewResponse, error) {
+	payload := map[string]any{
+		"aid":  170001,
+		"bvid": bvid,
+		"cid":  333,
+		"pages": []any{
+			map[string]any{"cid": 333, "page": 1, "part": "P1", "duration": 400},
+			map[string]any{"cid": 444, "page": 2, "part": "P2", "duration": 30},
+		},
+	}
+	b, _ := json.Marshal(payload)
+	return ViewResponse{Code: 0, Data: b}, nil
+}
+
+func (f *fakeClientWithDanmaku) GetDanmakuXML(ctx context.Context, cid int64) ([]byte, error) {
+	return []byte(`<i></i>`), nil
+}
+
+func (f *fakeClientWithDanmaku) GetDanmakuSegment(ctx context.Context, aid int64, cid int64, segmentIndex int) ([]byte, error) {
+	if f.segments == nil {
+		f.segments = map[int64]int{}
+	}
+	f.segments[cid]++
+	id := cid*10 + int64(segmentIndex)
+	return pbBytes(1, pbDanmakuElem(id, int32(segmentIndex)*1000, "x")), nil
+}
+
+func TestCrawlerDanmakuSaved(t *testing.T) {
+	oldwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	tmp := t.TempDir()
+	if err := os.Chdir(tmp); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(oldwd) })
+
+	config.AppConfig = config.Config{
+		Platform:          "bilibili",
+		StoreBackend:      "file",
+		SaveDataOption:    "json",
+		DataDir:           "data",
+		EnableGetComments: false,
+		EnableGetDanmaku:  true,
+	}
+
+	fc := &fakeClientWithDanmaku{}
+	c := NewCrawlerWithClient(fc)
+	req := crawler.Request{Platform: "bilibili", Mode: crawler.ModeDetail, Inputs: []string{"BV1Q5411W7bH"}, Concurrency: 1}
+	if _, err := c.Run(context.Background(), req); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if fc.segments[333] != 2 || fc.segments[444] != 1 {
+		t.Fatalf("unexpected segment requests: %v", fc.segments)
+	}
+
+	f, err := os.Open(filepath.Join("data", "bilibili", "notes", "BV1Q5411W7BH", "danmaku.jsonl"))
+	if err != nil {
+		t.Fatalf("open danmaku.jsonl: %v", err)
+	}
+	defer f.Close()
+	var lines int
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var d Danmaku
+		if err := json.Unmarshal(sc.Bytes(), &d); err != nil {
+			t.Fatalf("decode line: %v", err)
+		}
+		if d.NoteID != "BV1Q5411W7BH" || d.CID == 0 {
+			t.Fatalf("unexpected danmaku: %+v", d)
+		}
+		lines++
+	}
+	if lines != 3 {
+		t.Fatalf("expected 3 danmaku lines, got %d", lines)
+	}
+}
@@ -0,0 +1,537 @@
+package bilibili
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"media-crawler-go/internal/logger"
+	"media-crawler-go/internal/store"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// Live event types recorded from the danmaku stream.
+const (
+	LiveEventDanmaku   = "danmaku"
+	LiveEventGift      = "gift"
+	LiveEventSuperChat = "super_chat"
+)
+
+const (
+	liveHeartbeatInterval = 30 * time.Second
+	liveFlushInterval     = 2 * time.Second
+	liveFlushBatch        = 100
+	liveMaxReconnects     = 5
+)
+
+type LiveRoom struct {
+	SnapshotAt     int64  `json:"snapshot_at"`
+	RoomID         int64  `json:"room_id"`
+	ShortID        int64  `json:"short_id"`
+	UID            int64  `json:"uid"`
+	LiveStatus     int    `json:"live_status"`
+	Title          string `json:"title"`
+	Online         int64  `json:"online"`
+	Attention      int64  `json:"attention"`
+	AreaID         int64  `json:"area_id"`
+	AreaName       string `json:"area_name"`
+	ParentAreaName string `json:"parent_area_name"`
+	LiveTime       string `json:"live_time"`
+	Cover          string `json:"cover"`
+}
+
+func (r *LiveRoom) CSVHeader() []string {
+	return []string{"snapshot_at", "room_id", "short_id", "uid", "live_status", "title", "online", "attention", "area_id", "area_name", "parent_area_name", "live_time", "cover"}
+}
+
+func (r *LiveRoom) ToCSV() []string {
+	return []string{
+		strconv.FormatInt(r.SnapshotAt, 10),
+		strconv.FormatInt(r.RoomID, 10),
+		strconv.FormatInt(r.ShortID, 10),
+		strconv.FormatInt(r.UID, 10),
+		strconv.Itoa(r.LiveStatus),
+		r.Title,
+		strconv.FormatInt(r.Online, 10),
+		strconv.FormatInt(r.Attention, 10),
+		strconv.FormatInt(r.AreaID, 10),
+		r.AreaName,
+		r.ParentAreaName,
+		r.LiveTime,
+		r.Cover,
+	}
+}
+
+func (r *LiveRoom) Key() string {
+	return fmt.Sprintf("%d:%d", r.RoomID, r.SnapshotAt)
+}
+
+func liveRoomFromInfo(info LiveRoomInfoResponse, snapshotAt int64) LiveRoom {
+	d := info.Data
+	return LiveRoom{
+		SnapshotAt:     snapshotAt,
+		RoomID:         d.RoomID,
+		ShortID:        d.ShortID,
+		UID:            d.UID,
+		LiveStatus:     d.LiveStatus,
+		Title:          d.Title,
+		Online:         d.Online,
+		Attention:      d.Attention,
+		AreaID:         d.AreaID,
+		AreaName:       d.AreaName,
+		ParentAreaName: d.ParentAreaName,
+		LiveTime:       d.LiveTime,
+		Cover:          d.UserCover,
+	}
+}
+
+// LiveEvent is a danmaku, gift or super chat received in a live room.
+// PriceCNY is the super chat price, or the value of paid (gold) gifts at
+// 1000 gold = 1 CNY; free (silver) gifts are worth 0.
+type LiveEvent struct {
+	RoomID      int64   `json:"room_id"`
+	EventID     string  `json:"event_id"`
+	Type        string  `json:"type"`
+	UID         int64   `json:"uid"`
+	Uname       string  `json:"uname"`
+	Content     string  `json:"content"`
+	GiftName    string  `json:"gift_name"`
+	GiftNum     int64   `json:"gift_num"`
+	PriceCNY    float64 `json:"price_cny"`
+	TimestampMs int64   `json:"timestamp_ms"`
+}
+
+func (e *LiveEvent) CSVHeader() []string {
+	return []string{"room_id", "event_id", "type", "uid", "uname", "content", "gift_name", "gift_num", "price_cny", "timestamp_ms"}
+}
+
+func (e *LiveEvent) ToCSV() []string {
+	return []string{
+		strconv.FormatInt(e.RoomID, 10),
+		e.EventID,
+		e.Type,
+		strconv.FormatInt(e.UID, 10),
+		e.Uname,
+		e.Content,
+		e.GiftName,
+		strconv.FormatInt(e.GiftNum, 10),
+		strconv.FormatFloat(e.PriceCNY, 'f', -1, 64),
+		strconv.FormatInt(e.TimestampMs, 10),
+	}
+}
+
+// Key falls back to a fingerprint for messages without a server-side id.
+func (e *LiveEvent) Key() string {
+	if e.EventID != "" {
+		return e.Type + ":" + e.EventID
+	}
+	return fmt.Sprintf("%s:%d:%d:%s:%s", e.Type, e.UID, e.TimestampMs, e.GiftName, e.Content)
+}
+
+// ParseLiveMessage converts one op=5 JSON message into a live event. Other
+// commands (entries, rank updates, ...) are ignored.
+func ParseLiveMessage(roomID int64, body []byte) (LiveEvent, bool) {
+	var msg struct {
+		Cmd  string          `json:"cmd"`
+		Info []any           `json:"info"`
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return LiveEvent{}, false
+	}
+	// Commands may carry a version suffix, e.g. "DANMU_MSG:4:0:2:2:2:0".
+	cmd, _, _ := strings.Cut(msg.Cmd, ":")
+	switch cmd {
+	case "DANMU_MSG":
+		return parseLiveDanmaku(roomID, msg.Info)
+	case "SEND_GIFT":
+		var d struct {
+			UID       any    `json:"uid"`
+			Uname     string `json:"uname"`
+			GiftName  string `json:"giftName"`
+			Num       int64  `json:"num"`
+			TotalCoin int64  `json:"total_coin"`
+			CoinType  string `json:"coin_type"`
+			Timestamp int64  `json:"timestamp"`
+			TID       any    `json:"tid"`
+		}
+		if err := json.Unmarshal(msg.Data, &d); err != nil {
+			return LiveEvent{}, false
+		}
+		e := LiveEvent{
+			RoomID:      roomID,
+			EventID:     liveString(d.TID),
+			Type:        LiveEventGift,
+			UID:         liveInt(d.UID),
+			Uname:       d.Uname,
+			GiftName:    d.GiftName,
+			GiftNum:     d.Num,
+			TimestampMs: d.Timestamp * 1000,
+		}
+		if d.CoinType == "gold" {
+			e.PriceCNY = float64(d.TotalCoin) / 1000
+		}
+		return e, true
+	case "SUPER_CHAT_MESSAGE":
+		var d struct {
+			ID        any     `json:"id"`
+			UID       any     `json:"uid"`
+			Message   string  `json:"message"`
+			Price     float64 `json:"price"`
+			StartTime int64   `json:"start_time"`
+			UserInfo  struct {
+				Uname string `json:"uname"`
+			} `json:"user_info"`
+		}
+		if err := json.Unmarshal(msg.Data, &d); err != nil {
+			return LiveEvent{}, false
+		}
+		return LiveEvent{
+			RoomID:      roomID,
+			EventID:     liveString(d.ID),
+			Type:        LiveEventSuperChat,
+			UID:         liveInt(d.UID),
+			Uname:       d.UserInfo.Uname,
+			Content:     d.Message,
+			PriceCNY:    d.Price,
+			TimestampMs: d.StartTime * 1000,
+		}, true
+	default:
+		return LiveEvent{}, false
+	}
+}
+
+// parseLiveDanmaku reads the positional DANMU_MSG info array:
+// info[0] metadata (info[0][4] send time in ms, info[0][15].extra with the
+// id_str), info[1] text and info[2] [uid, uname, ...].
+func parseLiveDanmaku(roomID int64, info []any) (LiveEvent, bool) {
+	if len(info) < 3 {
+		return LiveEvent{}, false
+	}
+	text, _ := info[1].(string)
+	if text == "" {
+		return LiveEvent{}, false
+	}
+	e := LiveEvent{RoomID: roomID, Type: LiveEventDanmaku, Content: text}
+	if meta, ok := info[0].([]any); ok {
+		if len(meta) > 4 {
+			e.TimestampMs = liveInt(meta[4])
+		}
+		if len(meta) > 15 {
+			if m, ok := meta[15].(map[string]any); ok {
+				if extra, ok := m["extra"].(string); ok {
+					var x struct {
+						IDStr string `json:"id_str"`
+					}
+					if json.Unmarshal([]byte(extra), &x) == nil {
+						e.EventID = x.IDStr
+					}
+				}
+			}
+		}
+	}
+	if user, ok := info[2].([]any); ok {
+		if len(user) > 0 {
+			e.UID = liveInt(user[0])
+		}
+		if len(user) > 1 {
+			e.Uname, _ = user[1].(string)
+		}
+	}
+	return e, true
+}
+
+func liveInt(v any) int64 {
+	switch vv := v.(type) {
+	case float64:
+		return int64(vv)
+	case string:
+		n, _ := strconv.ParseInt(vv, 10, 64)
+		return n
+	default:
+		return 0
+	}
+}
+
+func liveString(v any) string {
+	switch vv := v.(type) {
+	case float64:
+		return strconv.FormatInt(int64(vv), 10)
+	case string:
+		return vv
+	default:
+		return ""
+	}
+}
+
+type liveClient interface {
+	GetLiveRoomInfo(context.Context, string) (LiveRoomInfoResponse, error)
+	GetLiveDanmakuServers(context.Context, int64) (string, []string, error)
+}
+
+// runLive snapshots every room of BILI_LIVE_ROOM_ID_LIST and records the
+// danmaku stream of the rooms that are live, all rooms in parallel, for
+// BILI_LIVE_RECORD_SEC seconds (0 records until the context is cancelled).
+func (c *Crawler) runLive(ctx context.Context, req crawler.Request) (crawler.Result, error) {
+	lc, ok := c.client.(liveClient)
+	if !ok {
+		return crawler.Result{}, fmt.Errorf("bilibili client does not support live")
+	}
+	inputs := req.Inputs
+	if len(inputs) == 0 {
+		inputs = config.AppConfig.BiliLiveRoomIdList
+	}
+	if len(inputs) == 0 {
+		return crawler.Result{}, fmt.Errorf("empty inputs (BILI_LIVE_ROOM_ID_LIST)")
+	}
+	duration := time.Duration(config.AppConfig.BiliLiveRecordSec) * time.Second
+	logger.Info("bilibili live start", "rooms", len(inputs), "record_sec", config.AppConfig.BiliLiveRecordSec)
+
+	out := crawler.NewResult(req)
+	r := crawler.ForEachLimit(ctx, inputs, len(inputs), func(ctx context.Context, input string) error {
+		return c.recordLiveRoom(ctx, lc, input, duration)
+	})
+	out.Processed += r.Processed
+	out.Succeeded += r.Succeeded
+	out.Failed += r.Failed
+	out.FailureKinds = crawler.MergeFailureKinds(out.FailureKinds, r.FailureKinds)
+	out.FinishedAt = time.Now().Unix()
+	return out, ctx.Err()
+}
+
+func (c *Crawler) recordLiveRoom(ctx context.Context, lc liveClient, input string, duration time.Duration) error {
+	id, err := ParseLiveRoomID(input)
+	if err != nil {
+		logger.Warn("skip invalid live room", "value", input, "err", err)
+		return err
+	}
+	info, err := lc.GetLiveRoomInfo(ctx, id)
+	if err != nil {
+		logger.Error("bilibili live room info failed", "room_id", id, "err", err)
+		return err
+	}
+	room := liveRoomFromInfo(info, time.Now().Unix())
+	roomKey := strconv.FormatInt(room.RoomID, 10)
+	if room.RoomID == 0 {
+		roomKey = id
+	}
+	if _, err := store.AppendLiveRoomSnapshots(
+		roomKey,
+		[]any{&room},
+		func(item any) (string, error) { return item.(*LiveRoom).Key(), nil },
+		(&LiveRoom{}).CSVHeader(),
+		func(item any) ([]string, error) { return item.(*LiveRoom).ToCSV(), nil },
+	); err != nil {
+		logger.Error("save live room snapshot failed", "room_id", roomKey, "err", err)
+	}
+	if room.LiveStatus != 1 {
+		logger.Info("bilibili live room is not live, skip recording", "room_id", roomKey, "live_status", room.LiveStatus)
+		return nil
+	}
+
+	token, servers, err := lc.GetLiveDanmakuServers(ctx, room.RoomID)
+	if err != nil {
+		logger.Error("bilibili live danmaku info failed", "room_id", roomKey, "err", err)
+		return err
+	}
+	if duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, duration)
+		defer cancel()
+	}
+	logger.Info("bilibili live recording", "room_id", roomKey, "title", room.Title, "servers", len(servers))
+
+	saved := 0
+	sink := func(events []LiveEvent) {
+		items := make([]any, 0, len(events))
+		for i := range events {
+			items = append(items, &events[i])
+		}
+		n, err := store.AppendUniqueLiveEvents(
+			roomKey,
+			items,
+			func(item any) (string, error) { return item.(*LiveEvent).Key(), nil },
+			(&LiveEvent{}).CSVHeader(),
+			func(item any) ([]string, error) { return item.(*LiveEvent).ToCSV(), nil },
+		)
+		if err != nil {
+			logger.Error("save live events failed", "room_id", roomKey, "err", err)
+			return
+		}
+		saved += n
+	}
+	auth := liveAuth{UID: cookieInt(config.AppConfig.Cookies, "DedeUserID"), RoomID: room.RoomID, Protover: liveProtoBrotli, Platform: "web", Type: 2, Key: token, Buvid: cookieValue(config.AppConfig.Cookies, "buvid3")}
+	err = recordLiveDanmaku(ctx, servers, auth, sink)
+	logger.Info("bilibili live recording stopped", "room_id", roomKey, "events", saved, "err", err)
+	return err
+}
+
+type liveAuth struct {
+	UID      int64  `json:"uid"`
+	RoomID   int64  `json:"roomid"`
+	Protover int    `json:"protover"`
+	Platform string `json:"platform"`
+	Type     int    `json:"type"`
+	Key      string `json:"key"`
+	Buvid    string `json:"buvid,omitempty"`
+}
+
+// recordLiveDanmaku streams the events of a room into sink until ctx is done,
+// which is the normal way to stop and returns nil. Dropped connections are
+// re-established (rotating through servers) up to liveMaxReconnects times in
+// a row.
+func recordLiveDanmaku(ctx context.Context, servers []string, auth liveAuth, sink func([]LiveEvent)) error {
+	if len(servers) == 0 {
+		return fmt.Errorf("no live danmaku server")
+	}
+	failures := 0
+	for attempt := 0; ; attempt++ {
+		server := servers[attempt%len(servers)]
+		received, err := liveSession(ctx, server, auth, sink)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if received {
+			failures = 0
+		}
+		failures++
+		if failures > liveMaxReconnects {
+			return fmt.Errorf("live danmaku connection lost: %w", err)
+		}
+		logger.Warn("bilibili live connection dropped, reconnecting", "room_id", auth.RoomID, "server", server, "err", err)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Duration(failures) * time.Second):
+		}
+	}
+}
+
+// liveSession runs one connection: auth, heartbeats and the read loop.
+// received reports whether the session got past authentication.
+func liveSession(ctx context.Context, server string, auth liveAuth, sink func([]LiveEvent)) (received bool, err error) {
+	wsCfg, err := websocket.NewConfig(server, "https://live.bilibili.com")
+	if err != nil {
+		return false, err
+	}
+	wsCfg.Header = http.Header{"User-Agent": []string{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"}}
+	conn, err := wsCfg.DialContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	defer func() {
+		close(done)
+		_ = conn.Close()
+		wg.Wait()
+	}()
+	// Closing the connection unblocks the read loop on cancellation.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	body, err := json.Marshal(auth)
+	if err != nil {
+		return false, err
+	}
+	if err := websocket.Message.Send(conn, encodeLivePacket(liveOpAuth, liveProtoHeartbeat, body)); err != nil {
+		return false, err
+	}
+
+	var pending []LiveEvent
+	lastFlush := time.Now()
+	flush := func() {
+		if len(pending) > 0 {
+			sink(pending)
+			pending = nil
+		}
+		lastFlush = time.Now()
+	}
+	defer flush()
+
+	for {
+		var frame []byte
+		if err := websocket.Message.Receive(conn, &frame); err != nil {
+			return received, err
+		}
+		packets, err := decodeLivePackets(frame)
+		if err != nil {
+			logger.Warn("bilibili live packet decode failed", "room_id", auth.RoomID, "err", err)
+		}
+		for _, p := range packets {
+			switch p.Op {
+			case liveOpAuthReply:
+				var reply struct {
+					Code int `json:"code"`
+				}
+				_ = json.Unmarshal(p.Body, &reply)
+				if reply.Code != 0 {
+					return received, fmt.Errorf("live danmaku auth rejected: code=%d", reply.Code)
+				}
+				if !received {
+					received = true
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						liveHeartbeat(conn, done)
+					}()
+				}
+			case liveOpHeartbeatReply:
+				logger.Debug("bilibili live popularity", "room_id", auth.RoomID, "popularity", livePopularity(p.Body))
+			case liveOpMessage:
+				if e, ok := ParseLiveMessage(auth.RoomID, p.Body); ok {
+					pending = append(pending, e)
+				}
+			}
+		}
+		if len(pending) >= liveFlushBatch || time.Since(lastFlush) >= liveFlushInterval {
+			flush()
+		}
+	}
+}
+
+func liveHeartbeat(conn *websocket.Conn, done <-chan struct{}) {
+	packet := encodeLivePacket(liveOpHeartbeat, liveProtoHeartbeat, []byte("[object Object]"))
+	ticker := time.NewTicker(liveHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		if err := websocket.Message.Send(conn, packet); err != nil {
+			logger.Debug("bilibili live heartbeat failed", "err", err)
+			return
+		}
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func cookieValue(cookies string, name string) string {
+	for _, part := range strings.Split(cookies, ";") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok && k == name {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+func cookieInt(cookies string, name string) int64 {
+	n, _ := strconv.ParseInt(cookieValue(cookies, name), 10, 64)
+	return n
+}
@@ -0,0 +1,117 @@
+package bilibili
+
+import (
+	"context"
+	"fmt"
+	"media-crawler-go/internal/crawler"
+	"net/http"
+	"strings"
+)
+
+const liveAPIBase = "https://api.live.bilibili.com"
+
+type LiveRoomInfoResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		UID            int64  `json:"uid"`
+		RoomID         int64  `json:"room_id"`
+		ShortID        int64  `json:"short_id"`
+		Attention      int64  `json:"attention"`
+		Online         int64  `json:"online"`
+		LiveStatus     int    `json:"live_status"`
+		Title          string `json:"title"`
+		Description    string `json:"description"`
+		AreaID         int64  `json:"area_id"`
+		AreaName       string `json:"area_name"`
+		ParentAreaID   int64  `json:"parent_area_id"`
+		ParentAreaName string `json:"parent_area_name"`
+		LiveTime       string `json:"live_time"`
+		UserCover      string `json:"user_cover"`
+	} `json:"data"`
+}
+
+type LiveDanmuInfoResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Token    string `json:"token"`
+		HostList []struct {
+			Host    string `json:"host"`
+			Port    int    `json:"port"`
+			WssPort int    `json:"wss_port"`
+			WsPort  int    `json:"ws_port"`
+		} `json:"host_list"`
+	} `json:"data"`
+}
+
+// GetLiveRoomInfo returns the current state of a live room. Short room ids
+// are resolved: Data.RoomID is always the real room id.
+func (c *Client) GetLiveRoomInfo(ctx context.Context, roomID string) (LiveRoomInfoResponse, error) {
+	if err := c.ensureProxy(ctx); err != nil {
+		return LiveRoomInfoResponse{}, err
+	}
+	roomID = strings.TrimSpace(roomID)
+	if roomID == "" {
+		return LiveRoomInfoResponse{}, fmt.Errorf("empty room id")
+	}
+	var out LiveRoomInfoResponse
+	resp, err := c.httpClient.R().
+		SetContext(ctx).
+		SetHeader("referer", "https://live.bilibili.com/").
+		SetQueryParam("room_id", roomID).
+		SetResult(&out).
+		Get(liveAPIBase + "/room/v1/Room/get_info")
+	if err != nil {
+		return LiveRoomInfoResponse{}, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return LiveRoomInfoResponse{}, crawler.NewHTTPStatusError("bilibili", "/room/v1/Room/get_info", resp.StatusCode(), resp.String())
+	}
+	if out.Code != 0 {
+		return LiveRoomInfoResponse{}, fmt.Errorf("bilibili api error: code=%d message=%s", out.Code, out.Message)
+	}
+	return out, nil
+}
+
+// GetLiveDanmakuServers returns the auth token and the WebSocket URLs of the
+// danmaku servers of a (real) room id.
+func (c *Client) GetLiveDanmakuServers(ctx context.Context, roomID int64) (string, []string, error) {
+	if err := c.ensureProxy(ctx); err != nil {
+		return "", nil, err
+	}
+	var out LiveDanmuInfoResponse
+	resp, err := c.httpClient.R().
+		SetContext(ctx).
+		SetHeader("referer", fmt.Sprintf("https://live.bilibili.com/%d", roomID)).
+		SetQueryParams(map[string]string{
+			"id":   fmt.Sprintf("%d", roomID),
+			"type": "0",
+		}).
+		SetResult(&out).
+		Get(liveAPIBase + "/xlive/web-room/v1/index/getDanmuInfo")
+	if err != nil {
+		return "", nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return "", nil, crawler.NewHTTPStatusError("bilibili", "/xlive/web-room/v1/index/getDanmuInfo", resp.StatusCode(), resp.String())
+	}
+	if out.Code != 0 {
+		return "", nil, fmt.Errorf("bilibili api error: code=%d message=%s", out.Code, out.Message)
+	}
+	urls := make([]string, 0, len(out.Data.HostList)+1)
+	for _, h := range out.Data.HostList {
+		if strings.TrimSpace(h.Host) == "" {
+			continue
+		}
+		port := h.WssPort
+		if port <= 0 {
+			port = 443
+		}
+		urls = append(urls, fmt.Sprintf("wss://%s:%d/sub", h.Host, port))
+	}
+	if len(urls) == 0 {
+		urls = append(urls, "wss://broadcastlv.chat.bilibili.com:443/sub")
+	}
+	return out.Data.Token, urls, nil
+}
@@ -0,0 +1,114 @@
+package bilibili
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Live danmaku packets start with a 16-byte big-endian header:
+// packet length (4), header length (2), protocol version (2), operation (4)
+// and sequence (4).
+const liveHeaderLen = 16
+
+// Protocol versions: plain JSON, heartbeat (popularity counter), and zlib or
+// brotli compressed batches of packets.
+const (
+	liveProtoJSON      = 0
+	liveProtoHeartbeat = 1
+	liveProtoZlib      = 2
+	liveProtoBrotli    = 3
+)
+
+// Operations used by the web client.
+const (
+	liveOpHeartbeat      = 2
+	liveOpHeartbeatReply = 3
+	liveOpMessage        = 5
+	liveOpAuth           = 7
+	liveOpAuthReply      = 8
+)
+
+type livePacket struct {
+	Proto uint16
+	Op    uint32
+	Body  []byte
+}
+
+func encodeLivePacket(op uint32, proto uint16, body []byte) []byte {
+	buf := make([]byte, liveHeaderLen+len(body))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(buf)))
+	binary.BigEndian.PutUint16(buf[4:6], liveHeaderLen)
+	binary.BigEndian.PutUint16(buf[6:8], proto)
+	binary.BigEndian.PutUint32(buf[8:12], op)
+	binary.BigEndian.PutUint32(buf[12:16], 1)
+	copy(buf[liveHeaderLen:], body)
+	return buf
+}
+
+// decodeLivePackets splits a WebSocket frame into packets, inflating
+// compressed batches (which hold further packets) recursively.
+func decodeLivePackets(data []byte) ([]livePacket, error) {
+	var out []livePacket
+	for len(data) > 0 {
+		if len(data) < liveHeaderLen {
+			return out, fmt.Errorf("short live packet header: %d bytes", len(data))
+		}
+		total := int(binary.BigEndian.Uint32(data[0:4]))
+		headerLen := int(binary.BigEndian.Uint16(data[4:6]))
+		if total < headerLen || headerLen < liveHeaderLen || total > len(data) {
+			return out, fmt.Errorf("invalid live packet length: total=%d header=%d available=%d", total, headerLen, len(data))
+		}
+		p := livePacket{
+			Proto: binary.BigEndian.Uint16(data[6:8]),
+			Op:    binary.BigEndian.Uint32(data[8:12]),
+			Body:  data[headerLen:total],
+		}
+		data = data[total:]
+
+		if p.Op == liveOpMessage && (p.Proto == liveProtoZlib || p.Proto == liveProtoBrotli) {
+			raw, err := inflateLiveBody(p.Proto, p.Body)
+			if err != nil {
+				return out, err
+			}
+			inner, err := decodeLivePackets(raw)
+			out = append(out, inner...)
+			if err != nil {
+				return out, err
+			}
+			continue
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func inflateLiveBody(proto uint16, body []byte) ([]byte, error) {
+	var r io.Reader
+	switch proto {
+	case liveProtoZlib:
+		zr, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	case liveProtoBrotli:
+		r = brotli.NewReader(bytes.NewReader(body))
+	default:
+		return body, nil
+	}
+	return io.ReadAll(r)
+}
+
+// livePopularity reads the popularity counter of a heartbeat reply.
+func livePopularity(body []byte) int64 {
+	if len(body) < 4 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint32(body[:4]))
+}
@@ -0,0 +1,295 @@
+package bilibili
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	testDanmakuMsg   = `{"cmd":"DANMU_MSG:4:0:2:2:2:0","info":[[0,1,25,16777215,1700000000123,0,0,"abc",0,0,0,"",0,{},{},{"extra":"{\"id_str\":\"dm-1\"}"}],"hello",[42,"alice",0,0,0,10000,1,""]]}`
+	testGiftMsg      = `{"cmd":"SEND_GIFT","data":{"uid":43,"uname":"bob","giftName":"小心心","num":2,"total_coin":2000,"coin_type":"gold","timestamp":1700000001,"tid":"gift-1"}}`
+	testSuperChatMsg = `{"cmd":"SUPER_CHAT_MESSAGE","data":{"id":7,"uid":44,"message":"great stream","price":30,"start_time":1700000002,"user_info":{"uname":"carol"}}}`
+	testIgnoredMsg   = `{"cmd":"INTERACT_WORD","data":{"uid":45}}`
+)
+
+func compressLiveBatch(t *testing.T, proto uint16, packets ...[]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	switch proto {
+	case liveProtoZlib:
+		w := zlib.NewWriter(&buf)
+		_, _ = w.Write(bytes.Join(packets, nil))
+		_ = w.Close()
+	case liveProtoBrotli:
+		w := brotli.NewWriter(&buf)
+		_, _ = w.Write(bytes.Join(packets, nil))
+		_ = w.Close()
+	}
+	return encodeLivePacket(liveOpMessage, proto, buf.Bytes())
+}
+
+func TestDecodeLivePackets(t *testing.T) {
+	msg1 := encodeLivePacket(liveOpMessage, liveProtoJSON, []byte(testDanmakuMsg))
+	msg2 := encodeLivePacket(liveOpMessage, liveProtoJSON, []byte(testGiftMsg))
+	popularity := make([]byte, 4)
+	binary.BigEndian.PutUint32(popularity, 1234)
+	heartbeat := encodeLivePacket(liveOpHeartbeatReply, liveProtoHeartbeat, popularity)
+
+	for _, proto := range []uint16{liveProtoZlib, liveProtoBrotli} {
+		frame := append(compressLiveBatch(t, proto, msg1, msg2), heartbeat...)
+		packets, err := decodeLivePackets(frame)
+		if err != nil {
+			t.Fatalf("proto %d: %v", proto, err)
+		}
+		if len(packets) != 3 {
+			t.Fatalf("proto %d: expected 3 packets, got %d", proto, len(packets))
+		}
+		if string(packets[0].Body) != testDanmakuMsg || string(packets[1].Body) != testGiftMsg {
+			t.Fatalf("proto %d: unexpected bodies: %q / %q", proto, packets[0].Body, packets[1].Body)
+		}
+		if packets[2].Op != liveOpHeartbeatReply || livePopularity(packets[2].Body) != 1234 {
+			t.Fatalf("proto %d: unexpected heartbeat reply: %+v", proto, packets[2])
+		}
+	}
+
+	if _, err := decodeLivePackets(msg1[:10]); err == nil {
+		t.Fatalf("expected an error for a truncated header")
+	}
+}
+
+func TestParseLiveMessage(t *testing.T) {
+	e, ok := ParseLiveMessage(100, []byte(testDanmakuMsg))
+	if !ok || e.Type != LiveEventDanmaku || e.Content != "hello" || e.UID != 42 || e.Uname != "alice" || e.TimestampMs != 1700000000123 || e.EventID != "dm-1" {
+		t.Fatalf("unexpected danmaku: %+v", e)
+	}
+	e, ok = ParseLiveMessage(100, []byte(testGiftMsg))
+	if !ok || e.Type != LiveEventGift || e.GiftName != "小心心" || e.GiftNum != 2 || e.PriceCNY != 2 || e.UID != 43 || e.TimestampMs != 1700000001000 {
+		t.Fatalf("unexpected gift: %+v", e)
+	}
+	e, ok = ParseLiveMessage(100, []byte(testSuperChatMsg))
+	if !ok || e.Type != LiveEventSuperChat || e.Content != "great stream" || e.PriceCNY != 30 || e.Uname != "carol" || e.EventID != "7" {
+		t.Fatalf("unexpected super chat: %+v", e)
+	}
+	if _, ok := ParseLiveMessage(100, []byte(testIgnoredMsg)); ok {
+		t.Fatalf("expected INTERACT_WORD to be ignored")
+	}
+}
+
+func TestParseLiveRoomID(t *testing.T) {
+	for in, want := range map[string]string{
+		"21452505": "21452505",
+		"https://live.bilibili.com/21452505?spm_id_from=333": "21452505",
+		"https://live.bilibili.com/blanc/6":                  "",
+		"https://www.bilibili.com/video/BV1Q5411W7bH":        "",
+	} {
+		got, err := ParseLiveRoomID(in)
+		if got != want || (want == "" && err == nil) {
+			t.Fatalf("ParseLiveRoomID(%q)=%q,%v want %q", in, got, err, want)
+		}
+	}
+}
+
+type fakeLiveClient struct {
+	fakeClient
+	liveStatus int
+	server     string
+}
+
+func (f fakeLiveClient) GetLiveRoomInfo(ctx context.Context, roomID string) (LiveRoomInfoResponse, error) {
+	var out LiveRoomInfoResponse
+	out.Data.RoomID = 1000
+	out.Data.ShortID = 6
+	out.Data.LiveStatus = f.liveStatus
+	out.Data.Title = "test stream"
+	out.Data.AreaName = "单机游戏"
+	return out, nil
+}
+
+func (f fakeLiveClient) GetLiveDanmakuServers(ctx context.Context, roomID int64) (string, []string, error) {
+	return "token-1", []string{f.server}, nil
+}
+
+// newFakeDanmakuServer accepts the auth packet, replies, pushes one zlib and
+// one brotli batch and then keeps the connection open until the client
+// closes it.
+func newFakeDanmakuServer(t *testing.T, auths chan<- liveAuth) *httptest.Server {
+	return httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		var frame []byte
+		if err := websocket.Message.Receive(conn, &frame); err != nil {
+			return
+		}
+		packets, _ := decodeLivePackets(frame)
+		if len(packets) == 1 && packets[0].Op == liveOpAuth {
+			var a liveAuth
+			_ = json.Unmarshal(packets[0].Body, &a)
+			auths <- a
+		}
+		_ = websocket.Message.Send(conn, encodeLivePacket(liveOpAuthReply, liveProtoHeartbeat, []byte(`{"code":0}`)))
+		_ = websocket.Message.Send(conn, compressLiveBatch(t, liveProtoZlib,
+			encodeLivePacket(liveOpMessage, liveProtoJSON, []byte(testDanmakuMsg)),
+			encodeLivePacket(liveOpMessage, liveProtoJSON, []byte(testIgnoredMsg)),
+		))
+		_ = websocket.Message.Send(conn, compressLiveBatch(t, liveProtoBrotli,
+			encodeLivePacket(liveOpMessage, liveProtoJSON, []byte(testGiftMsg)),
+			encodeLivePacket(liveOpMessage, liveProtoJSON, []byte(testSuperChatMsg)),
+			encodeLivePacket(liveOpMessage, liveProtoJSON, []byte(testDanmakuMsg)),
+		))
+		for {
+			if err := websocket.Message.Receive(conn, &frame); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+func setupLiveTest(t *testing.T, recordSec int) string {
+	t.Helper()
+	oldwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	tmp := t.TempDir()
+	if err := os.Chdir(tmp); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	oldCfg := config.AppConfig
+	t.Cleanup(func() {
+		_ = os.Chdir(oldwd)
+		config.AppConfig = oldCfg
+	})
+	config.AppConfig = config.Config{
+		Platform:          "bilibili",
+		StoreBackend:      "file",
+		SaveDataOption:    "json",
+		DataDir:           "data",
+		Cookies:           "buvid3=abc; DedeUserID=99",
+		BiliLiveRecordSec: recordSec,
+	}
+	return filepath.Join("data", "bilibili", "live", "1000")
+}
+
+func readJSONLines(t *testing.T, path string) []map[string]any {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer f.Close()
+	var out []map[string]any
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var m map[string]any
+		if err := json.Unmarshal(sc.Bytes(), &m); err != nil {
+			t.Fatalf("decode line: %v", err)
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func TestCrawlerLiveRecordsUntilCancelled(t *testing.T) {
+	dir := setupLiveTest(t, 0)
+	auths := make(chan liveAuth, 1)
+	srv := newFakeDanmakuServer(t, auths)
+	defer srv.Close()
+
+	c := NewCrawlerWithClient(fakeLiveClient{liveStatus: 1, server: "ws" + strings.TrimPrefix(srv.URL, "http")})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	type runResult struct {
+		res crawler.Result
+		err error
+	}
+	done := make(chan runResult, 1)
+	go func() {
+		res, err := c.Run(ctx, crawler.Request{Platform: "bilibili", Mode: crawler.ModeLive, Inputs: []string{"https://live.bilibili.com/6"}})
+		done <- runResult{res, err}
+	}()
+
+	select {
+	case a := <-auths:
+		if a.RoomID != 1000 || a.Key != "token-1" || a.UID != 99 || a.Buvid != "abc" || a.Protover != liveProtoBrotli {
+			t.Fatalf("unexpected auth packet: %+v", a)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("recorder did not connect")
+	}
+	time.Sleep(300 * time.Millisecond)
+	cancel()
+
+	var rr runResult
+	select {
+	case rr = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("recorder did not stop after cancel")
+	}
+	if !errors.Is(rr.err, context.Canceled) || rr.res.Succeeded != 1 || rr.res.Failed != 0 {
+		t.Fatalf("unexpected result: %+v err=%v", rr.res, rr.err)
+	}
+
+	rooms := readJSONLines(t, filepath.Join(dir, "room.jsonl"))
+	if len(rooms) != 1 || rooms[0]["title"] != "test stream" || rooms[0]["short_id"] != float64(6) {
+		t.Fatalf("unexpected room snapshots: %+v", rooms)
+	}
+	events := readJSONLines(t, filepath.Join(dir, "events.jsonl"))
+	if len(events) != 3 {
+		t.Fatalf("expected 3 deduped events, got %d: %+v", len(events), events)
+	}
+	types := map[string]bool{}
+	for _, e := range events {
+		types[e["type"].(string)] = true
+	}
+	if !types[LiveEventDanmaku] || !types[LiveEventGift] || !types[LiveEventSuperChat] {
+		t.Fatalf("missing event types: %+v", events)
+	}
+}
+
+func TestCrawlerLiveStopsAfterDuration(t *testing.T) {
+	dir := setupLiveTest(t, 1)
+	srv := newFakeDanmakuServer(t, make(chan liveAuth, 1))
+	defer srv.Close()
+
+	c := NewCrawlerWithClient(fakeLiveClient{liveStatus: 1, server: "ws" + strings.TrimPrefix(srv.URL, "http")})
+	start := time.Now()
+	res, err := c.Run(context.Background(), crawler.Request{Platform: "bilibili", Mode: crawler.ModeLive, Inputs: []string{"6"}})
+	if err != nil || res.Succeeded != 1 {
+		t.Fatalf("unexpected result: %+v err=%v", res, err)
+	}
+	if d := time.Since(start); d > 4*time.Second {
+		t.Fatalf("recording did not stop after the configured duration: %v", d)
+	}
+	if events := readJSONLines(t, filepath.Join(dir, "events.jsonl")); len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+}
+
+func TestCrawlerLiveOfflineRoomSnapshotOnly(t *testing.T) {
+	dir := setupLiveTest(t, 60)
+	c := NewCrawlerWithClient(fakeLiveClient{liveStatus: 0, server: "ws://127.0.0.1:1/sub"})
+	res, err := c.Run(context.Background(), crawler.Request{Platform: "bilibili", Mode: crawler.ModeLive, Inputs: []string{"6"}})
+	if err != nil || res.Succeeded != 1 {
+		t.Fatalf("unexpected result: %+v err=%v", res, err)
+	}
+	if rooms := readJSONLines(t, filepath.Join(dir, "room.jsonl")); len(rooms) != 1 || rooms[0]["live_status"] != float64(0) {
+		t.Fatalf("unexpected room snapshots: %+v", rooms)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "events.jsonl")); !os.IsNotExist(err) {
+		t.Fatalf("expected no events for an offline room, stat err=%v", err)
+	}
+}
@@ -45,6 +45,69 @@ func ExtractCIDFromViewData(viewData any) int64 {
 	return 0
 }
 
+type VideoPage struct {
+	NoteID   string `json:"note_id"`
+	CID      int64  `json:"cid"`
+	Page     int    `json:"page"`
+	Part     string `json:"part"`
+	Duration int64  `json:"duration"`
+}
+
+func (p *VideoPage) CSVHeader() []string {
+	return []string{"note_id", "cid", "page", "part", "duration"}
+}
+
+func (p *VideoPage) ToCSV() []string {
+	return []string{
+		p.NoteID,
+		strconv.FormatInt(p.CID, 10),
+		strconv.Itoa(p.Page),
+		p.Part,
+		strconv.FormatInt(p.Duration, 10),
+	}
+}
+
+// PartFilePrefix keeps single-part filenames unchanged and adds a _p<N>
+// suffix when a video has several parts.
+func PartFilePrefix(noteID string, page VideoPage, total int) string {
+	if total <= 1 {
+		return noteID
+	}
+	return fmt.Sprintf("%s_p%d", noteID, page.Page)
+}
+
+func ExtractPagesFromViewData(viewData any) []VideoPage {
+	m, ok := viewData.(map[string]any)
+	if !ok || m == nil {
+		return nil
+	}
+	var out []VideoPage
+	if pages, ok := m["pages"].([]any); ok {
+		for i, it := range pages {
+			p, ok := it.(map[string]any)
+			if !ok {
+				continue
+			}
+			cid := toInt64(p["cid"])
+			if cid <= 0 {
+				continue
+			}
+			idx := int(toInt64(p["page"]))
+			if idx <= 0 {
+				idx = i + 1
+			}
+			part, _ := p["part"].(string)
+			out = append(out, VideoPage{CID: cid, Page: idx, Part: strings.TrimSpace(part), Duration: toInt64(p["duration"])})
+		}
+	}
+	if len(out) == 0 {
+		if cid := toInt64(m["cid"]); cid > 0 {
+			out = append(out, VideoPage{CID: cid, Page: 1, Duration: toInt64(m["duration"])})
+		}
+	}
+	return out
+}
+
 func ExtractBilibiliPlayURLs(noteID string, playData json.RawMessage) (urls []string, filenames []string) {
 	noteID = strings.TrimSpace(noteID)
 	if noteID == "" || len(playData) == 0 {
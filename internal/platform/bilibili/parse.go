@@ -110,3 +110,25 @@ func ParseCreatorID(input string) (mid string, err error) {
 	}
 	return "", fmt.Errorf("cannot parse bilibili mid from: %s", input)
 }
+
+// ParseLiveRoomID accepts a numeric (short or real) room id or a
+// live.bilibili.com/<room_id> URL.
+func ParseLiveRoomID(input string) (string, error) {
+	s := strings.TrimSpace(input)
+	if s == "" {
+		return "", fmt.Errorf("empty input")
+	}
+	if u, err := url.Parse(s); err == nil && u != nil && u.Host != "" {
+		if strings.Contains(u.Host, "live.bilibili.com") {
+			first := strings.Split(strings.Trim(u.Path, "/"), "/")[0]
+			if _, err := strconv.ParseInt(first, 10, 64); err == nil {
+				return first, nil
+			}
+		}
+		return "", fmt.Errorf("cannot parse bilibili live room id from: %s", input)
+	}
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return s, nil
+	}
+	return "", fmt.Errorf("cannot parse bilibili live room id from: %s", input)
+}
@@ -0,0 +1,141 @@
+package bilibili
+
+import (
+	"context"
+	"fmt"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"media-crawler-go/internal/logger"
+	"media-crawler-go/internal/store"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type RelationResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		List []struct {
+			Mid   int64  `json:"mid"`
+			Uname string `json:"uname"`
+		} `json:"list"`
+		Total int `json:"total"`
+	} `json:"data"`
+}
+
+// GetRelations lists one page of a user's followings ("followings") or fans
+// ("fans"). For other users' fans the API only serves the first 5 pages.
+func (c *Client) GetRelations(ctx context.Context, mid string, relation string, page int, pageSize int) (RelationResponse, error) {
+	if err := c.ensureProxy(ctx); err != nil {
+		return RelationResponse{}, err
+	}
+	mid = strings.TrimSpace(mid)
+	if mid == "" {
+		return RelationResponse{}, fmt.Errorf("empty mid")
+	}
+	if relation != "followings" && relation != "fans" {
+		return RelationResponse{}, fmt.Errorf("unsupported relation: %s", relation)
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 50
+	}
+	path := "/x/relation/" + relation
+	var out RelationResponse
+	resp, err := c.httpClient.R().
+		SetContext(ctx).
+		SetQueryParams(map[string]string{
+			"vmid":  mid,
+			"pn":    strconv.Itoa(page),
+			"ps":    strconv.Itoa(pageSize),
+			"order": "desc",
+		}).
+		SetResult(&out).
+		Get(path)
+	if err != nil {
+		return RelationResponse{}, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return RelationResponse{}, crawler.NewHTTPStatusError("bilibili", path, resp.StatusCode(), resp.String())
+	}
+	if out.Code != 0 {
+		return RelationResponse{}, fmt.Errorf("bilibili api error: code=%d message=%s", out.Code, out.Message)
+	}
+	return out, nil
+}
+
+type relationClient interface {
+	GetRelations(context.Context, string, string, int, int) (RelationResponse, error)
+}
+
+// fetchRelationEdges pages through one relation list of mid and converts it
+// to follow edges, stopping after max entries. Entries collected before an
+// error (e.g. the fans page limit) are returned with it.
+func fetchRelationEdges(ctx context.Context, client relationClient, mid string, name string, relation string, max int, sleepSec int) ([]store.CreatorEdge, error) {
+	if max <= 0 {
+		return nil, nil
+	}
+	const pageSize = 50
+	out := make([]store.CreatorEdge, 0, 64)
+	for page := 1; len(out) < max; page++ {
+		res, err := client.GetRelations(ctx, mid, relation, page, pageSize)
+		if err != nil {
+			return out, err
+		}
+		for _, u := range res.Data.List {
+			if u.Mid == 0 {
+				continue
+			}
+			id := strconv.FormatInt(u.Mid, 10)
+			e := store.CreatorEdge{FromID: mid, FromName: name, ToID: id, ToName: u.Uname, Type: store.EdgeTypeFollowing}
+			if relation == "fans" {
+				e = store.CreatorEdge{FromID: id, FromName: u.Uname, ToID: mid, ToName: name, Type: store.EdgeTypeFollower}
+			}
+			out = append(out, e)
+			if len(out) >= max {
+				break
+			}
+		}
+		if len(res.Data.List) < pageSize || (res.Data.Total > 0 && page*pageSize >= res.Data.Total) {
+			break
+		}
+		if sleepSec > 0 {
+			select {
+			case <-ctx.Done():
+				return out, ctx.Err()
+			case <-time.After(time.Duration(sleepSec) * time.Second):
+			}
+		}
+	}
+	return out, nil
+}
+
+// crawlRelations stores the follow edges of a creator's followings and fans
+// (up to CRAWLER_MAX_RELATIONS_COUNT each).
+func (c *Crawler) crawlRelations(ctx context.Context, mid string, name string) {
+	rc, ok := c.client.(relationClient)
+	if !ok {
+		return
+	}
+	for _, relation := range []string{"followings", "fans"} {
+		edges, err := fetchRelationEdges(ctx, rc, mid, name, relation, config.AppConfig.CrawlerMaxRelationsCount, config.AppConfig.CrawlerMaxSleepSec)
+		if err != nil {
+			logger.Warn("bilibili relation list incomplete", "mid", mid, "relation", relation, "collected", len(edges), "err", err)
+		}
+		if _, err := store.AppendCreatorEdges(edges); err != nil {
+			logger.Error("save creator edges failed", "mid", mid, "relation", relation, "err", err)
+			continue
+		}
+		logger.Info("bilibili relations saved", "mid", mid, "relation", relation, "edges", len(edges))
+	}
+}
+
+func upInfoName(infoData any) string {
+	m, _ := infoData.(map[string]any)
+	name, _ := m["name"].(string)
+	return strings.TrimSpace(name)
+}
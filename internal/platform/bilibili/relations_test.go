@@ -0,0 +1,63 @@
+package bilibili
+
+import (
+	"context"
+	"fmt"
+	"media-crawler-go/internal/store"
+	"testing"
+)
+
+type fakeRelationClient struct {
+	total int
+	calls []string
+}
+
+func (f *fakeRelationClient) GetRelations(ctx context.Context, mid string, relation string, page int, pageSize int) (RelationResponse, error) {
+	f.calls = append(f.calls, fmt.Sprintf("%s:%d", relation, page))
+	if relation == "fans" && page > 1 {
+		return RelationResponse{}, fmt.Errorf("bilibili api error: code=22007 message=access limited")
+	}
+	var out RelationResponse
+	out.Data.Total = f.total
+	for i := (page - 1) * pageSize; i < page*pageSize && i < f.total; i++ {
+		out.Data.List = append(out.Data.List, struct {
+			Mid   int64  `json:"mid"`
+			Uname string `json:"uname"`
+		}{Mid: int64(1000 + i), Uname: fmt.Sprintf("user%d", i)})
+	}
+	return out, nil
+}
+
+func TestFetchRelationEdges(t *testing.T) {
+	fc := &fakeRelationClient{total: 120}
+	edges, err := fetchRelationEdges(context.Background(), fc, "42", "up", "followings", 70, 0)
+	if err != nil {
+		t.Fatalf("followings: %v", err)
+	}
+	if len(edges) != 70 || len(fc.calls) != 2 {
+		t.Fatalf("expected 70 edges over 2 pages, got %d edges, calls %v", len(edges), fc.calls)
+	}
+	e := edges[0]
+	if e.FromID != "42" || e.FromName != "up" || e.ToID != "1000" || e.ToName != "user0" || e.Type != store.EdgeTypeFollowing {
+		t.Fatalf("unexpected following edge: %+v", e)
+	}
+
+	fc = &fakeRelationClient{total: 120}
+	edges, err = fetchRelationEdges(context.Background(), fc, "42", "up", "fans", 200, 0)
+	if err == nil {
+		t.Fatalf("expected the fans page limit error")
+	}
+	if len(edges) != 50 {
+		t.Fatalf("expected the first page to be kept, got %d", len(edges))
+	}
+	e = edges[0]
+	if e.FromID != "1000" || e.ToID != "42" || e.ToName != "up" || e.Type != store.EdgeTypeFollower {
+		t.Fatalf("unexpected follower edge: %+v", e)
+	}
+
+	fc = &fakeRelationClient{total: 30}
+	edges, err = fetchRelationEdges(context.Background(), fc, "42", "up", "followings", 200, 0)
+	if err != nil || len(edges) != 30 || len(fc.calls) != 1 {
+		t.Fatalf("expected a short last page to stop paging: edges=%d calls=%v err=%v", len(edges), fc.calls, err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"media-crawler-go/internal/config"
 	"media-crawler-go/internal/crawler"
+	"media-crawler-go/internal/metrics"
 	"media-crawler-go/internal/proxy"
 	"net/http"
 	"net/url"
@@ -43,6 +44,7 @@ func NewClient(signer *Signer, userAgent string) *Client {
 		Timeout:   time.Duration(timeoutSec) * time.Second,
 	}
 	rc := resty.NewWithClient(hc)
+	metrics.InstrumentResty(rc, "douyin")
 	rc.SetBaseURL("https://www.douyin.com")
 	rc.SetHeaders(map[string]string{
 		"accept":          "application/json, text/plain, */*",
@@ -1,6 +1,9 @@
 package douyin
 
-import "fmt"
+import (
+	"fmt"
+	"media-crawler-go/internal/store"
+)
 
 type CommentUser struct {
 	UID      string `json:"uid"`
@@ -15,6 +18,15 @@ type Comment struct {
 	DiggCount  int64       `json:"digg_count"`
 	User       CommentUser `json:"user"`
 
+	// Reply fields of sub comments: reply_id is the top-level comment and
+	// reply_to_reply_id the sub comment replied to ("0" when replying to the
+	// top-level comment).
+	ReplyID           string `json:"reply_id"`
+	ReplyToReplyID    string `json:"reply_to_reply_id"`
+	ReplyToUserID     string `json:"reply_to_userid"`
+	ReplyToUsername   string `json:"reply_to_username"`
+	ReplyCommentTotal int64  `json:"reply_comment_total"`
+
 	NoteID          string `json:"-"`
 	ParentCommentID string `json:"-"`
 }
@@ -46,3 +58,33 @@ func (c *Comment) ToCSV() []string {
 		c.User.Nickname,
 	}
 }
+
+func toUnifiedComments(awemeID string, comments []Comment) []*store.UnifiedComment {
+	out := make([]*store.UnifiedComment, 0, len(comments))
+	for _, c := range comments {
+		u := &store.UnifiedComment{
+			Platform:            "douyin",
+			NoteID:              awemeID,
+			CommentID:           c.CID,
+			ParentCommentID:     c.ParentCommentID,
+			Content:             c.Text,
+			CreateTime:          c.CreateTime,
+			LikeCount:           c.DiggCount,
+			UserID:              c.User.UID,
+			UserSecUID:          c.User.SecUID,
+			UserNickname:        c.User.Nickname,
+			ReplyToUserID:       c.ReplyToUserID,
+			ReplyToUserNickname: c.ReplyToUsername,
+			SubCommentCount:     c.ReplyCommentTotal,
+		}
+		if c.ReplyID != "" && c.ReplyID != "0" {
+			u.RootCommentID = c.ReplyID
+		}
+		if c.ReplyToReplyID != "" && c.ReplyToReplyID != "0" {
+			u.ReplyToCommentID = c.ReplyToReplyID
+		}
+		out = append(out, u)
+	}
+	store.LinkCommentReplies(out)
+	return out
+}
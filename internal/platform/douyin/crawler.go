@@ -12,6 +12,7 @@ import (
 	"media-crawler-go/internal/proxy"
 	"media-crawler-go/internal/sms"
 	"media-crawler-go/internal/store"
+	"media-crawler-go/internal/webhook"
 	"os/exec"
 	"strings"
 	"time"
@@ -91,8 +92,12 @@ func (c *DouyinCrawler) Run(ctx context.Context, req crawler.Request) (crawler.R
 		res, runErr = c.runCreatorMode(ctx, req, msToken)
 	case crawler.ModeSearch:
 		res, runErr = c.runSearchMode(ctx, req, msToken)
+	case crawler.ModeMusic:
+		res, runErr = c.runMusicMode(ctx, req, msToken)
+	case crawler.ModeMix:
+		res, runErr = c.runMixMode(ctx, req, msToken)
 	default:
-		return crawler.Result{}, fmt.Errorf("douyin mode not implemented: %s (supported: search/detail/creator)", req.Mode)
+		return crawler.Result{}, fmt.Errorf("douyin mode not implemented: %s (supported: search/detail/creator/music/mix)", req.Mode)
 	}
 	res.StartedAt = out.StartedAt
 	return res, runErr
@@ -177,7 +182,7 @@ func (c *DouyinCrawler) close() {
 		_ = c.cdpBrowser.Close()
 	}
 	if c.cdpCmd != nil && c.cdpCmd.Process != nil && config.AppConfig.AutoCloseBrowser {
-		_ = c.cdpCmd.Process.Kill()
+		browser.StopBrowserProcess(c.cdpCmd, 5*time.Second)
 	}
 	if c.pw != nil {
 		_ = c.pw.Stop()
@@ -219,6 +224,12 @@ func (c *DouyinCrawler) login(ctx context.Context) error {
 	if timeoutSec <= 0 {
 		timeoutSec = 120
 	}
+	webhook.Emit(webhook.Event{
+		Type:     webhook.LoginRequired,
+		Platform: "douyin",
+		TaskID:   crawler.TaskIDFrom(ctx),
+		Data:     map[string]any{"login_type": loginType, "timeout_sec": timeoutSec},
+	})
 	deadline := time.Now().Add(time.Duration(timeoutSec) * time.Second)
 	for time.Now().Before(deadline) {
 		_ = c.client.UpdateCookies(c.browser)
@@ -226,10 +237,14 @@ func (c *DouyinCrawler) login(ctx context.Context) error {
 			_ = c.tryAutoFillSMSCode("douyin", config.AppConfig.LoginPhone)
 		}
 		if c.isLoggedIn() {
-			time.Sleep(3 * time.Second)
+			if !crawler.Sleep(ctx, 3*time.Second) {
+				return ctx.Err()
+			}
 			return nil
 		}
-		time.Sleep(1 * time.Second)
+		if !crawler.Sleep(ctx, 1*time.Second) {
+			return ctx.Err()
+		}
 	}
 	return fmt.Errorf("login timed out after %ds", timeoutSec)
 }
@@ -459,6 +474,9 @@ func (c *DouyinCrawler) runCreatorMode(ctx context.Context, req crawler.Request,
 		} else {
 			logger.Error("fetch creator profile failed", "creator_id", secUserID, "err", err)
 		}
+		if config.AppConfig.EnableGetCreatorRelations {
+			c.crawlRelations(ctx, secUserID, profile, msToken)
+		}
 
 		maxCursor := ""
 		hasMore := 1
@@ -497,6 +515,185 @@ func (c *DouyinCrawler) runCreatorMode(ctx context.Context, req crawler.Request,
 	return out, nil
 }
 
+func (c *DouyinCrawler) runMusicMode(ctx context.Context, req crawler.Request, msToken string) (crawler.Result, error) {
+	inputs := req.Inputs
+	if len(inputs) == 0 {
+		inputs = config.AppConfig.DouyinMusicIdList
+	}
+	logger.Info("running music mode", "inputs", len(inputs))
+	if len(inputs) == 0 {
+		return crawler.Result{}, fmt.Errorf("empty inputs (DY_MUSIC_ID_LIST)")
+	}
+
+	limit := req.MaxNotes
+	if limit == 0 {
+		limit = config.AppConfig.CrawlerMaxNotesCount
+	}
+	out := crawler.NewResult(req)
+
+	for _, input := range inputs {
+		musicID := ExtractMusicID(input)
+		if musicID == "" {
+			logger.Warn("skip invalid music id/url", "value", input)
+			continue
+		}
+		var cursor int64
+		hasMore := 1
+		processed := 0
+		for hasMore == 1 && (limit <= 0 || processed < limit) {
+			resp, err := c.client.GetMusicAwemeList(ctx, musicID, cursor, msToken)
+			if err != nil {
+				return out, err
+			}
+			hasMore = resp.HasMore
+			if resp.Cursor == cursor {
+				hasMore = 0
+			}
+			cursor = resp.Cursor
+
+			var ids []string
+			for _, aweme := range resp.AwemeList {
+				id, _ := aweme["aweme_id"].(string)
+				if id == "" {
+					continue
+				}
+				ids = append(ids, id)
+				processed++
+				if limit > 0 && processed >= limit {
+					break
+				}
+			}
+			if len(ids) == 0 {
+				break
+			}
+			r := c.processAwemeIDs(ctx, ids, msToken, req.Concurrency)
+			out.Succeeded += r.Succeeded
+			out.Failed += r.Failed
+			out.Processed += r.Processed
+			out.FailureKinds = crawler.MergeFailureKinds(out.FailureKinds, r.FailureKinds)
+			if config.AppConfig.CrawlerMaxSleepSec > 0 {
+				time.Sleep(time.Duration(config.AppConfig.CrawlerMaxSleepSec) * time.Second)
+			}
+		}
+		logger.Info("music done", "music_id", musicID, "posts", processed)
+	}
+	out.FinishedAt = time.Now().Unix()
+	return out, nil
+}
+
+// runMixMode crawls whole collections (合集): the mix metadata and episode
+// list are saved under collections/<mix_id>/, then every episode runs through
+// the detail pipeline one at a time in episode order.
+func (c *DouyinCrawler) runMixMode(ctx context.Context, req crawler.Request, msToken string) (crawler.Result, error) {
+	inputs := req.Inputs
+	if len(inputs) == 0 {
+		inputs = config.AppConfig.DouyinMixIdList
+	}
+	logger.Info("running mix mode", "inputs", len(inputs))
+	if len(inputs) == 0 {
+		return crawler.Result{}, fmt.Errorf("empty inputs (DY_MIX_ID_LIST)")
+	}
+
+	limit := req.MaxNotes
+	if limit == 0 {
+		limit = config.AppConfig.CrawlerMaxNotesCount
+	}
+	out := crawler.NewResult(req)
+
+	for _, input := range inputs {
+		mixID := ExtractMixID(input)
+		if mixID == "" {
+			logger.Warn("skip invalid mix id/url", "value", input)
+			continue
+		}
+		mix, hasMeta := Mix{}, false
+		detail, err := c.client.GetMixDetail(ctx, mixID, msToken)
+		if err != nil {
+			logger.Warn("fetch mix detail failed", "mix_id", mixID, "err", err)
+		} else {
+			mix, hasMeta = ParseMixDetail(detail)
+		}
+
+		awemes, pageErr := pageMixAwemes(ctx, limit, func(cursor int64) (mixAwemeResp, error) {
+			return c.client.GetMixAwemeList(ctx, mixID, cursor, msToken)
+		})
+		if pageErr != nil {
+			if len(awemes) == 0 {
+				return out, pageErr
+			}
+			logger.Warn("fetch mix episodes failed, keeping collected pages", "mix_id", mixID, "episodes", len(awemes), "err", pageErr)
+		}
+		if !hasMeta && len(awemes) > 0 {
+			mix, hasMeta = mixFromAweme(awemes[0])
+		}
+		if hasMeta {
+			if err := store.SaveCollectionMeta(mixID, &mix); err != nil {
+				logger.Error("save mix metadata failed", "mix_id", mixID, "err", err)
+			}
+		}
+
+		episodes := SortMixEpisodes(mixID, awemes)
+		if limit > 0 && len(episodes) > limit {
+			episodes = episodes[:limit]
+		}
+		items := make([]any, 0, len(episodes))
+		ids := make([]string, 0, len(episodes))
+		for i := range episodes {
+			items = append(items, &episodes[i])
+			ids = append(ids, episodes[i].AwemeID)
+		}
+		if _, err := store.AppendUniqueCollectionItems(
+			mixID,
+			items,
+			func(item any) (string, error) { return item.(*MixEpisode).Key(), nil },
+			(&MixEpisode{}).CSVHeader(),
+			func(item any) ([]string, error) { return item.(*MixEpisode).ToCSV(), nil },
+		); err != nil {
+			logger.Error("save mix episodes failed", "mix_id", mixID, "err", err)
+		}
+
+		r := c.processAwemeIDs(ctx, ids, msToken, 1)
+		out.Succeeded += r.Succeeded
+		out.Failed += r.Failed
+		out.Processed += r.Processed
+		out.FailureKinds = crawler.MergeFailureKinds(out.FailureKinds, r.FailureKinds)
+		logger.Info("mix done", "mix_id", mixID, "title", mix.Title, "episodes", len(ids))
+		if pageErr != nil {
+			return out, pageErr
+		}
+	}
+	out.FinishedAt = time.Now().Unix()
+	return out, nil
+}
+
+// pageMixAwemes follows the mix episode cursor until the list ends or limit
+// episodes are collected. On a failed page the episodes collected so far are
+// returned along with the error.
+func pageMixAwemes(ctx context.Context, limit int, fetch func(cursor int64) (mixAwemeResp, error)) ([]map[string]any, error) {
+	var awemes []map[string]any
+	var cursor int64
+	hasMore := 1
+	for hasMore == 1 && (limit <= 0 || len(awemes) < limit) {
+		resp, err := fetch(cursor)
+		if err != nil {
+			return awemes, err
+		}
+		if len(resp.AwemeList) == 0 {
+			break
+		}
+		awemes = append(awemes, resp.AwemeList...)
+		hasMore = resp.HasMore
+		if resp.Cursor == cursor {
+			hasMore = 0
+		}
+		cursor = resp.Cursor
+		if hasMore == 1 && !crawler.Sleep(ctx, time.Duration(config.AppConfig.CrawlerMaxSleepSec)*time.Second) {
+			return awemes, ctx.Err()
+		}
+	}
+	return awemes, nil
+}
+
 func (c *DouyinCrawler) runSearchMode(ctx context.Context, req crawler.Request, msToken string) (crawler.Result, error) {
 	keywords := req.Keywords
 	if len(keywords) == 0 {
@@ -533,6 +730,7 @@ func (c *DouyinCrawler) runSearchMode(ctx context.Context, req crawler.Request,
 				continue
 			}
 			offset := page*limitCount - limitCount
+			crawler.ProgressFrom(ctx).SetStage(keyword, page)
 			resp, err := c.client.SearchInfoByKeyword(ctx, keyword, offset, limitCount, searchID, msToken)
 			if err != nil {
 				return crawler.Result{}, err
@@ -594,11 +792,11 @@ func (c *DouyinCrawler) processOneAweme(ctx context.Context, awemeID string, msT
 		var rec VideoDetail
 		b, _ := json.Marshal(detail)
 		_ = json.Unmarshal(b, &rec)
-		if err := store.SaveNoteDetail(awemeID, &rec); err != nil {
+		if err := store.SaveNoteDetailContext(ctx, awemeID, &rec); err != nil {
 			return err
 		}
 	} else {
-		if err := store.SaveNoteDetail(awemeID, detail); err != nil {
+		if err := store.SaveNoteDetailContext(ctx, awemeID, detail); err != nil {
 			return err
 		}
 	}
@@ -613,27 +811,19 @@ func (c *DouyinCrawler) processOneAweme(ctx context.Context, awemeID string, msT
 			msToken,
 			config.AppConfig.EnableGetSubComments,
 		)
+		fetched := 0
 		if err != nil {
 			logger.Error("fetch comments failed", "aweme_id", awemeID, "err", err)
 		} else {
+			fetched = len(comments)
+			unified := toUnifiedComments(awemeID, comments)
 			if config.AppConfig.SaveDataOption == "csv" {
 				items := make([]any, 0, len(comments))
 				globalItems := make([]any, 0, len(comments))
 				for i := range comments {
 					comments[i].NoteID = awemeID
 					items = append(items, &comments[i])
-					globalItems = append(globalItems, &store.UnifiedComment{
-						Platform:        "douyin",
-						NoteID:          awemeID,
-						CommentID:       comments[i].CID,
-						ParentCommentID: comments[i].ParentCommentID,
-						Content:         comments[i].Text,
-						CreateTime:      comments[i].CreateTime,
-						LikeCount:       comments[i].DiggCount,
-						UserID:          comments[i].User.UID,
-						UserSecUID:      comments[i].User.SecUID,
-						UserNickname:    comments[i].User.Nickname,
-					})
+					globalItems = append(globalItems, unified[i])
 				}
 				_, err := store.AppendUniqueCommentsCSV(
 					awemeID,
@@ -658,18 +848,7 @@ func (c *DouyinCrawler) processOneAweme(ctx context.Context, awemeID string, msT
 				globalItems := make([]any, 0, len(comments))
 				for i := range comments {
 					comments[i].NoteID = awemeID
-					globalItems = append(globalItems, &store.UnifiedComment{
-						Platform:        "douyin",
-						NoteID:          awemeID,
-						CommentID:       comments[i].CID,
-						ParentCommentID: comments[i].ParentCommentID,
-						Content:         comments[i].Text,
-						CreateTime:      comments[i].CreateTime,
-						LikeCount:       comments[i].DiggCount,
-						UserID:          comments[i].User.UID,
-						UserSecUID:      comments[i].User.SecUID,
-						UserNickname:    comments[i].User.Nickname,
-					})
+					globalItems = append(globalItems, unified[i])
 				}
 				_, err := store.AppendUniqueGlobalCommentsBook(
 					globalItems,
@@ -693,18 +872,7 @@ func (c *DouyinCrawler) processOneAweme(ctx context.Context, awemeID string, msT
 				for i := range comments {
 					comments[i].NoteID = awemeID
 					items = append(items, &comments[i])
-					globalItems = append(globalItems, &store.UnifiedComment{
-						Platform:        "douyin",
-						NoteID:          awemeID,
-						CommentID:       comments[i].CID,
-						ParentCommentID: comments[i].ParentCommentID,
-						Content:         comments[i].Text,
-						CreateTime:      comments[i].CreateTime,
-						LikeCount:       comments[i].DiggCount,
-						UserID:          comments[i].User.UID,
-						UserSecUID:      comments[i].User.SecUID,
-						UserNickname:    comments[i].User.Nickname,
-					})
+					globalItems = append(globalItems, unified[i])
 				}
 				_, err := store.AppendUniqueCommentsXLSX(
 					awemeID,
@@ -730,18 +898,7 @@ func (c *DouyinCrawler) processOneAweme(ctx context.Context, awemeID string, msT
 				globalItems := make([]any, 0, len(comments))
 				for i := range comments {
 					items = append(items, comments[i])
-					globalItems = append(globalItems, &store.UnifiedComment{
-						Platform:        "douyin",
-						NoteID:          awemeID,
-						CommentID:       comments[i].CID,
-						ParentCommentID: comments[i].ParentCommentID,
-						Content:         comments[i].Text,
-						CreateTime:      comments[i].CreateTime,
-						LikeCount:       comments[i].DiggCount,
-						UserID:          comments[i].User.UID,
-						UserSecUID:      comments[i].User.SecUID,
-						UserNickname:    comments[i].User.Nickname,
-					})
+					globalItems = append(globalItems, unified[i])
 				}
 				_, err := store.AppendUniqueCommentsJSONL(
 					awemeID,
@@ -760,44 +917,38 @@ func (c *DouyinCrawler) processOneAweme(ctx context.Context, awemeID string, msT
 				}
 			}
 		}
-	}
 
-	if config.AppConfig.EnableGetMedias {
-		headers := map[string]string{
-			"User-Agent": c.client.UserAgent(),
-			"Referer":    fmt.Sprintf("https://www.douyin.com/video/%s", awemeID),
+		var rec VideoDetail
+		b, _ := json.Marshal(detail)
+		reported := int64(-1)
+		if json.Unmarshal(b, &rec) == nil && rec.AwemeID != "" {
+			reported = rec.Statistics.CommentCount
 		}
-		if ck := c.client.CookieHeader(); ck != "" {
-			headers["Cookie"] = ck
+		if cov, err := store.RecordCommentCoverage("douyin", awemeID, awemeID, reported, fetched); err != nil {
+			logger.Error("save comment coverage failed", "aweme_id", awemeID, "err", err)
+		} else {
+			logger.Info("comment coverage", "aweme_id", awemeID, "fetched", cov.FetchedCount, "reported", cov.ReportedCount, "ratio", cov.Ratio)
 		}
+	}
 
+	if config.AppConfig.EnableGetMedias {
 		var rec VideoDetail
 		b, _ := json.Marshal(detail)
 		_ = json.Unmarshal(b, &rec)
 
-		var urls []string
-		var filenames []string
-
-		if len(rec.Video.PlayAddr.URLList) > 0 {
-			urls = append(urls, rec.Video.PlayAddr.URLList[0])
-			filenames = append(filenames, fmt.Sprintf("%s_video.mp4", awemeID))
+		referer := fmt.Sprintf("https://www.douyin.com/video/%s", awemeID)
+		if rec.IsImagePost() {
+			referer = fmt.Sprintf("https://www.douyin.com/note/%s", awemeID)
 		}
-
-		coverList := rec.Video.OriginCover.URLList
-		if len(coverList) == 0 {
-			coverList = rec.Video.Cover.URLList
+		headers := map[string]string{
+			"User-Agent": c.client.UserAgent(),
+			"Referer":    referer,
 		}
-		for i, u := range coverList {
-			if u == "" {
-				continue
-			}
-			urls = append(urls, u)
-			filenames = append(filenames, fmt.Sprintf("%s_cover_%d.jpg", awemeID, i))
-			if i >= 2 {
-				break
-			}
+		if ck := c.client.CookieHeader(); ck != "" {
+			headers["Cookie"] = ck
 		}
 
+		urls, filenames := ExtractAwemeMediaURLs(awemeID, &rec)
 		if len(urls) > 0 {
 			noteDownloader := downloader.NewDownloader(store.NoteMediaDir(awemeID))
 			noteDownloader.BatchDownloadWithHeaders(urls, filenames, headers)
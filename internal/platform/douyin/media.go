@@ -0,0 +1,64 @@
+package douyin
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ExtractAwemeMediaURLs lists the files to download for one aweme: the video
+// and up to 3 covers for video posts, every image plus the background music
+// for image posts.
+func ExtractAwemeMediaURLs(awemeID string, rec *VideoDetail) (urls []string, filenames []string) {
+	if rec == nil || strings.TrimSpace(awemeID) == "" {
+		return nil, nil
+	}
+	if rec.IsImagePost() {
+		for i, u := range rec.ImageURLs() {
+			urls = append(urls, u)
+			filenames = append(filenames, fmt.Sprintf("%s_image_%d%s", awemeID, i, imageExt(u)))
+		}
+		if u := rec.MusicURL(); u != "" {
+			urls = append(urls, u)
+			filenames = append(filenames, fmt.Sprintf("%s_music.mp3", awemeID))
+		}
+		return urls, filenames
+	}
+
+	if len(rec.Video.PlayAddr.URLList) > 0 {
+		urls = append(urls, rec.Video.PlayAddr.URLList[0])
+		filenames = append(filenames, fmt.Sprintf("%s_video.mp4", awemeID))
+	}
+
+	coverList := rec.Video.OriginCover.URLList
+	if len(coverList) == 0 {
+		coverList = rec.Video.Cover.URLList
+	}
+	for i, u := range coverList {
+		if u == "" {
+			continue
+		}
+		urls = append(urls, u)
+		filenames = append(filenames, fmt.Sprintf("%s_cover_%d.jpg", awemeID, i))
+		if i >= 2 {
+			break
+		}
+	}
+	return urls, filenames
+}
+
+// imageExt keeps the format of the CDN URL (douyin serves webp/jpeg/heic
+// variants, e.g. "...~tplv-dy-aweme-images:q75.webp"), defaulting to .jpg.
+func imageExt(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ".jpg"
+	}
+	switch ext := strings.ToLower(path.Ext(u.Path)); ext {
+	case ".webp", ".jpeg", ".jpg", ".png", ".heic", ".gif":
+		return ext
+	default:
+		return ".jpg"
+	}
+}
@@ -0,0 +1,57 @@
+package douyin
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestExtractAwemeMediaURLsImagePost(t *testing.T) {
+	raw := `{
+		"aweme_id": "7363550223532576043",
+		"aweme_type": 68,
+		"video": {"play_addr": {"url_list": ["https://example.com/bgm-as-video.mp4"]}},
+		"images": [
+			{"url_list": ["https://p3.douyinpic.com/a~tplv-dy-aweme-images:q75.webp?x=1"], "download_url_list": ["https://p3.douyinpic.com/a-wm.jpeg"]},
+			{"url_list": [], "download_url_list": ["https://p3.douyinpic.com/b-wm.jpeg"]},
+			{"url_list": ["https://p3.douyinpic.com/c"]}
+		],
+		"music": {"id_str": "7315704709279550259", "title": "bgm", "play_url": {"url_list": ["", "https://sf3.douyinvod.com/music.mp3"]}}
+	}`
+	var rec VideoDetail
+	if err := json.Unmarshal([]byte(raw), &rec); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !rec.IsImagePost() {
+		t.Fatalf("expected image post")
+	}
+	urls, names := ExtractAwemeMediaURLs(rec.AwemeID, &rec)
+	want := []string{
+		"7363550223532576043_image_0.webp",
+		"7363550223532576043_image_1.jpeg",
+		"7363550223532576043_image_2.jpg",
+		"7363550223532576043_music.mp3",
+	}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected filenames: %v", names)
+	}
+	if urls[1] != "https://p3.douyinpic.com/b-wm.jpeg" || urls[3] != "https://sf3.douyinvod.com/music.mp3" {
+		t.Fatalf("unexpected urls: %v", urls)
+	}
+	row := rec.ToCSV()
+	if len(row) != len(rec.CSVHeader()) || row[1] != "68" || row[len(row)-3] != "3" || row[len(row)-2] != "7315704709279550259" {
+		t.Fatalf("unexpected csv row: %v", row)
+	}
+}
+
+func TestExtractAwemeMediaURLsVideo(t *testing.T) {
+	var rec VideoDetail
+	rec.AwemeType = 0
+	rec.Video.PlayAddr.URLList = []string{"https://example.com/v.mp4"}
+	rec.Video.Cover.URLList = []string{"https://example.com/c0", "https://example.com/c1", "https://example.com/c2", "https://example.com/c3"}
+	rec.Music.PlayURL.URLList = []string{"https://example.com/m.mp3"}
+	_, names := ExtractAwemeMediaURLs("1", &rec)
+	if strings.Join(names, ",") != "1_video.mp4,1_cover_0.jpg,1_cover_1.jpg,1_cover_2.jpg" {
+		t.Fatalf("unexpected filenames: %v", names)
+	}
+}
@@ -0,0 +1,152 @@
+package douyin
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// mixInfo is the aweme_mix_info / mix_info payload shared by the mix detail
+// endpoint and every episode of a collection.
+type mixInfo struct {
+	MixID      string `json:"mix_id"`
+	MixName    string `json:"mix_name"`
+	Desc       string `json:"desc"`
+	CreateTime int64  `json:"create_time"`
+	UpdateTime int64  `json:"update_time"`
+	Statis     struct {
+		CurrentEpisode   int   `json:"current_episode"`
+		UpdatedToEpisode int   `json:"updated_to_episode"`
+		PlayVV           int64 `json:"play_vv"`
+		CollectVV        int64 `json:"collect_vv"`
+	} `json:"statis"`
+	Author struct {
+		UID      string `json:"uid"`
+		SecUID   string `json:"sec_uid"`
+		Nickname string `json:"nickname"`
+	} `json:"author"`
+}
+
+// Mix is the saved metadata of a collection (合集).
+type Mix struct {
+	MixID          string `json:"mix_id"`
+	Title          string `json:"title"`
+	Desc           string `json:"desc"`
+	EpisodeCount   int    `json:"episode_count"`
+	PlayCount      int64  `json:"play_count"`
+	CollectCount   int64  `json:"collect_count"`
+	AuthorID       string `json:"author_id"`
+	AuthorSecUID   string `json:"author_sec_uid"`
+	AuthorNickname string `json:"author_nickname"`
+	CreateTime     int64  `json:"create_time"`
+	UpdateTime     int64  `json:"update_time"`
+}
+
+// MixEpisode is one aweme of a collection at its position in the collection.
+type MixEpisode struct {
+	MixID   string `json:"mix_id"`
+	Episode int    `json:"episode"`
+	AwemeID string `json:"aweme_id"`
+	Desc    string `json:"desc"`
+}
+
+func (e *MixEpisode) CSVHeader() []string {
+	return []string{"mix_id", "episode", "aweme_id", "desc"}
+}
+
+func (e *MixEpisode) ToCSV() []string {
+	return []string{e.MixID, strconv.Itoa(e.Episode), e.AwemeID, e.Desc}
+}
+
+func (e *MixEpisode) Key() string {
+	return fmt.Sprintf("%s:%s", e.MixID, e.AwemeID)
+}
+
+func decodeMixInfo(v any) (mixInfo, bool) {
+	var info mixInfo
+	if v == nil {
+		return info, false
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return info, false
+	}
+	if err := json.Unmarshal(b, &info); err != nil {
+		return info, false
+	}
+	return info, info.MixID != ""
+}
+
+func (info mixInfo) toMix() Mix {
+	return Mix{
+		MixID:          info.MixID,
+		Title:          info.MixName,
+		Desc:           info.Desc,
+		EpisodeCount:   info.Statis.UpdatedToEpisode,
+		PlayCount:      info.Statis.PlayVV,
+		CollectCount:   info.Statis.CollectVV,
+		AuthorID:       info.Author.UID,
+		AuthorSecUID:   info.Author.SecUID,
+		AuthorNickname: info.Author.Nickname,
+		CreateTime:     info.CreateTime,
+		UpdateTime:     info.UpdateTime,
+	}
+}
+
+// ParseMixDetail reads the collection metadata from the mix detail response.
+func ParseMixDetail(resp map[string]any) (Mix, bool) {
+	info, ok := decodeMixInfo(resp["mix_info"])
+	if !ok {
+		return Mix{}, false
+	}
+	return info.toMix(), true
+}
+
+// mixFromAweme falls back to the mix_info embedded in an episode when the
+// mix detail endpoint is unavailable.
+func mixFromAweme(aweme map[string]any) (Mix, bool) {
+	info, ok := decodeMixInfo(aweme["mix_info"])
+	if !ok {
+		return Mix{}, false
+	}
+	return info.toMix(), true
+}
+
+// SortMixEpisodes orders the awemes of a collection by their episode number
+// (mix_info.statis.current_episode). Awemes without one keep their listing
+// position, numbered after the last known episode.
+func SortMixEpisodes(mixID string, awemes []map[string]any) []MixEpisode {
+	out := make([]MixEpisode, 0, len(awemes))
+	seen := make(map[string]struct{}, len(awemes))
+	maxEpisode := 0
+	var unnumbered []MixEpisode
+	for _, aweme := range awemes {
+		id, _ := aweme["aweme_id"].(string)
+		if id == "" {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		desc, _ := aweme["desc"].(string)
+		ep := MixEpisode{MixID: mixID, AwemeID: id, Desc: desc}
+		if info, ok := decodeMixInfo(aweme["mix_info"]); ok {
+			ep.Episode = info.Statis.CurrentEpisode
+		}
+		if ep.Episode <= 0 {
+			unnumbered = append(unnumbered, ep)
+			continue
+		}
+		if ep.Episode > maxEpisode {
+			maxEpisode = ep.Episode
+		}
+		out = append(out, ep)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Episode < out[j].Episode })
+	for i := range unnumbered {
+		unnumbered[i].Episode = maxEpisode + i + 1
+	}
+	return append(out, unnumbered...)
+}
@@ -0,0 +1,83 @@
+package douyin
+
+import (
+	"context"
+	"fmt"
+	"media-crawler-go/internal/crawler"
+	"strconv"
+)
+
+type mixAwemeResp struct {
+	AwemeList []map[string]any `json:"aweme_list"`
+	HasMore   int              `json:"has_more"`
+	Cursor    int64            `json:"cursor"`
+}
+
+// GetMixDetail returns the metadata of a collection (合集), i.e. the header of
+// https://www.douyin.com/collection/<id>.
+func (c *Client) GetMixDetail(ctx context.Context, mixID string, msToken string) (map[string]any, error) {
+	if err := c.ensureProxy(ctx); err != nil {
+		return nil, err
+	}
+	if mixID == "" {
+		return nil, fmt.Errorf("mix_id is empty")
+	}
+	params := defaultParams(msToken, "")
+	params.Set("mix_id", mixID)
+
+	aBogus, err := c.signer.SignDetail(params, c.userAgent)
+	if err != nil {
+		return nil, err
+	}
+	params.Set("a_bogus", aBogus)
+
+	var out map[string]any
+	r, err := c.httpClient.R().
+		SetContext(ctx).
+		SetHeader("Referer", fmt.Sprintf("https://www.douyin.com/collection/%s", mixID)).
+		SetQueryString(params.Encode()).
+		SetResult(&out).
+		Get("/aweme/v1/web/mix/detail/")
+	if err != nil {
+		return nil, err
+	}
+	if r.IsError() {
+		return nil, crawler.NewHTTPStatusError("douyin", "/aweme/v1/web/mix/detail/", r.StatusCode(), r.String())
+	}
+	return out, nil
+}
+
+// GetMixAwemeList pages through the episodes of a collection.
+func (c *Client) GetMixAwemeList(ctx context.Context, mixID string, cursor int64, msToken string) (mixAwemeResp, error) {
+	if err := c.ensureProxy(ctx); err != nil {
+		return mixAwemeResp{}, err
+	}
+	if mixID == "" {
+		return mixAwemeResp{}, fmt.Errorf("mix_id is empty")
+	}
+	params := defaultParams(msToken, "")
+	params.Set("mix_id", mixID)
+	params.Set("cursor", strconv.FormatInt(cursor, 10))
+	params.Set("count", "20")
+
+	aBogus, err := c.signer.SignDetail(params, c.userAgent)
+	if err != nil {
+		return mixAwemeResp{}, err
+	}
+	params.Set("a_bogus", aBogus)
+
+	var out mixAwemeResp
+	r, err := c.httpClient.R().
+		SetContext(ctx).
+		SetHeader("Referer", fmt.Sprintf("https://www.douyin.com/collection/%s", mixID)).
+		SetQueryString(params.Encode()).
+		SetResult(&out).
+		Get("/aweme/v1/web/mix/aweme/")
+	if err != nil {
+		return out, err
+	}
+	if r.IsError() {
+		return out, crawler.NewHTTPStatusError("douyin", "/aweme/v1/web/mix/aweme/", r.StatusCode(), r.String())
+	}
+	return out, nil
+}
@@ -0,0 +1,77 @@
+package douyin
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseMixDetail(t *testing.T) {
+	resp := map[string]any{
+		"mix_info": map[string]any{
+			"mix_id":   "7348687990509553679",
+			"mix_name": "合集",
+			"statis":   map[string]any{"current_episode": 0, "updated_to_episode": 12, "play_vv": 3456},
+			"author":   map[string]any{"uid": "1", "sec_uid": "MS4wLjABAAAA", "nickname": "author"},
+		},
+	}
+	mix, ok := ParseMixDetail(resp)
+	if !ok {
+		t.Fatalf("expected mix_info")
+	}
+	if mix.Title != "合集" || mix.EpisodeCount != 12 || mix.PlayCount != 3456 || mix.AuthorNickname != "author" || mix.AuthorSecUID != "MS4wLjABAAAA" {
+		t.Fatalf("unexpected mix: %+v", mix)
+	}
+	if _, ok := ParseMixDetail(map[string]any{"status_code": 0}); ok {
+		t.Fatalf("expected no mix without mix_info")
+	}
+}
+
+func TestSortMixEpisodes(t *testing.T) {
+	ep := func(id string, n int) map[string]any {
+		return map[string]any{
+			"aweme_id": id,
+			"desc":     "ep " + id,
+			"mix_info": map[string]any{"mix_id": "m", "statis": map[string]any{"current_episode": n}},
+		}
+	}
+	awemes := []map[string]any{
+		ep("c", 3),
+		ep("a", 1),
+		{"aweme_id": "x"},
+		ep("b", 2),
+		ep("a", 1),
+		{"desc": "no id"},
+	}
+	got := SortMixEpisodes("m", awemes)
+	want := []struct {
+		id string
+		n  int
+	}{{"a", 1}, {"b", 2}, {"c", 3}, {"x", 4}}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected episodes: %+v", got)
+	}
+	for i, w := range want {
+		if got[i].AwemeID != w.id || got[i].Episode != w.n || got[i].MixID != "m" {
+			t.Fatalf("episode %d: got %+v want %s/%d", i, got[i], w.id, w.n)
+		}
+	}
+}
+
+func TestPageMixAwemesKeepsPartialOnError(t *testing.T) {
+	boom := errors.New("boom")
+	calls := 0
+	awemes, err := pageMixAwemes(context.Background(), 0, func(cursor int64) (mixAwemeResp, error) {
+		calls++
+		if cursor == 0 {
+			return mixAwemeResp{AwemeList: []map[string]any{{"aweme_id": "1"}, {"aweme_id": "2"}}, HasMore: 1, Cursor: 2}, nil
+		}
+		return mixAwemeResp{}, boom
+	})
+	if !errors.Is(err, boom) || calls != 2 {
+		t.Fatalf("err=%v calls=%d", err, calls)
+	}
+	if len(awemes) != 2 || awemes[1]["aweme_id"] != "2" {
+		t.Fatalf("partial episodes lost: %v", awemes)
+	}
+}
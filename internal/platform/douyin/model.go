@@ -2,8 +2,12 @@ package douyin
 
 import "fmt"
 
+// AwemeTypeImagePost is the aweme_type of image carousel posts (图文).
+const AwemeTypeImagePost = 68
+
 type VideoDetail struct {
 	AwemeID    string `json:"aweme_id"`
+	AwemeType  int    `json:"aweme_type"`
 	Desc       string `json:"desc"`
 	CreateTime int64  `json:"create_time"`
 	Author     struct {
@@ -29,11 +33,64 @@ type VideoDetail struct {
 			URLList []string `json:"url_list"`
 		} `json:"origin_cover"`
 	} `json:"video"`
+	Images []AwemeImage `json:"images"`
+	Music  AwemeMusic   `json:"music"`
+}
+
+type AwemeImage struct {
+	URLList         []string `json:"url_list"`
+	DownloadURLList []string `json:"download_url_list"`
+	Width           int      `json:"width"`
+	Height          int      `json:"height"`
+}
+
+type AwemeMusic struct {
+	IDStr   string `json:"id_str"`
+	Title   string `json:"title"`
+	Author  string `json:"author"`
+	PlayURL struct {
+		URLList []string `json:"url_list"`
+	} `json:"play_url"`
+	Duration int `json:"duration"`
+}
+
+// IsImagePost reports whether the aweme is an image carousel; for those
+// video.play_addr only carries the background music.
+func (v *VideoDetail) IsImagePost() bool {
+	return v.AwemeType == AwemeTypeImagePost || len(v.Images) > 0
+}
+
+// ImageURLs returns one URL per carousel image, preferring the watermark-free
+// url_list over download_url_list.
+func (v *VideoDetail) ImageURLs() []string {
+	out := make([]string, 0, len(v.Images))
+	for _, img := range v.Images {
+		if u := firstNonEmpty(img.URLList); u != "" {
+			out = append(out, u)
+		} else if u := firstNonEmpty(img.DownloadURLList); u != "" {
+			out = append(out, u)
+		}
+	}
+	return out
+}
+
+func (v *VideoDetail) MusicURL() string {
+	return firstNonEmpty(v.Music.PlayURL.URLList)
+}
+
+func firstNonEmpty(list []string) string {
+	for _, s := range list {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
 }
 
 func (v *VideoDetail) CSVHeader() []string {
 	return []string{
 		"aweme_id",
+		"aweme_type",
 		"desc",
 		"create_time",
 		"author_uid",
@@ -44,12 +101,16 @@ func (v *VideoDetail) CSVHeader() []string {
 		"collect_count",
 		"share_count",
 		"play_count",
+		"image_count",
+		"music_id",
+		"music_title",
 	}
 }
 
 func (v *VideoDetail) ToCSV() []string {
 	return []string{
 		v.AwemeID,
+		fmt.Sprintf("%d", v.AwemeType),
 		v.Desc,
 		fmt.Sprintf("%d", v.CreateTime),
 		v.Author.UID,
@@ -60,5 +121,8 @@ func (v *VideoDetail) ToCSV() []string {
 		fmt.Sprintf("%d", v.Statistics.CollectCount),
 		fmt.Sprintf("%d", v.Statistics.ShareCount),
 		fmt.Sprintf("%d", v.Statistics.PlayCount),
+		fmt.Sprintf("%d", len(v.Images)),
+		v.Music.IDStr,
+		v.Music.Title,
 	}
 }
@@ -0,0 +1,50 @@
+package douyin
+
+import (
+	"context"
+	"fmt"
+	"media-crawler-go/internal/crawler"
+	"strconv"
+)
+
+type musicAwemeResp struct {
+	AwemeList []map[string]any `json:"aweme_list"`
+	HasMore   int              `json:"has_more"`
+	Cursor    int64            `json:"cursor"`
+}
+
+// GetMusicAwemeList pages through the posts that use a music track, i.e. the
+// list behind https://www.douyin.com/music/<id>.
+func (c *Client) GetMusicAwemeList(ctx context.Context, musicID string, cursor int64, msToken string) (musicAwemeResp, error) {
+	if err := c.ensureProxy(ctx); err != nil {
+		return musicAwemeResp{}, err
+	}
+	if musicID == "" {
+		return musicAwemeResp{}, fmt.Errorf("music_id is empty")
+	}
+	params := defaultParams(msToken, "")
+	params.Set("music_id", musicID)
+	params.Set("cursor", strconv.FormatInt(cursor, 10))
+	params.Set("count", "20")
+
+	aBogus, err := c.signer.SignDetail(params, c.userAgent)
+	if err != nil {
+		return musicAwemeResp{}, err
+	}
+	params.Set("a_bogus", aBogus)
+
+	var out musicAwemeResp
+	r, err := c.httpClient.R().
+		SetContext(ctx).
+		SetHeader("Referer", fmt.Sprintf("https://www.douyin.com/music/%s", musicID)).
+		SetQueryString(params.Encode()).
+		SetResult(&out).
+		Get("/aweme/v1/web/music/aweme/")
+	if err != nil {
+		return out, err
+	}
+	if r.IsError() {
+		return out, crawler.NewHTTPStatusError("douyin", "/aweme/v1/web/music/aweme/", r.StatusCode(), r.String())
+	}
+	return out, nil
+}
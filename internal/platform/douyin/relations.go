@@ -0,0 +1,150 @@
+package douyin
+
+import (
+	"context"
+	"fmt"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"media-crawler-go/internal/logger"
+	"media-crawler-go/internal/store"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Relation lists of a user: "following" are the accounts the user follows,
+// "follower" the accounts following the user.
+const (
+	relationFollowing = "following"
+	relationFollower  = "follower"
+)
+
+type relationListResp struct {
+	Followers  []map[string]any `json:"followers"`
+	Followings []map[string]any `json:"followings"`
+	HasMore    bool             `json:"has_more"`
+	MinTime    int64            `json:"min_time"`
+	Total      int64            `json:"total"`
+}
+
+func (r relationListResp) users() []map[string]any {
+	if len(r.Followings) > 0 {
+		return r.Followings
+	}
+	return r.Followers
+}
+
+// GetRelationList pages a user's following/follower list. maxTime is the
+// min_time of the previous page (0 for the first page). Both lists are only
+// served when the user has not hidden them.
+func (c *Client) GetRelationList(ctx context.Context, uid string, secUserID string, relation string, maxTime int64, msToken string) (relationListResp, error) {
+	if err := c.ensureProxy(ctx); err != nil {
+		return relationListResp{}, err
+	}
+	if secUserID == "" {
+		return relationListResp{}, fmt.Errorf("sec_user_id is empty")
+	}
+	params := defaultParams(msToken, "")
+	params.Set("user_id", uid)
+	params.Set("sec_user_id", secUserID)
+	params.Set("offset", "0")
+	params.Set("min_time", "0")
+	params.Set("max_time", strconv.FormatInt(maxTime, 10))
+	params.Set("count", "20")
+	params.Set("source_type", "1")
+	params.Set("gps_access", "0")
+	params.Set("address_book_access", "0")
+
+	aBogus, err := c.signer.SignDetail(params, c.userAgent)
+	if err != nil {
+		return relationListResp{}, err
+	}
+	params.Set("a_bogus", aBogus)
+
+	path := fmt.Sprintf("/aweme/v1/web/user/%s/list/", relation)
+	var out relationListResp
+	r, err := c.httpClient.R().
+		SetContext(ctx).
+		SetHeader("Referer", fmt.Sprintf("https://www.douyin.com/user/%s", url.PathEscape(secUserID))).
+		SetQueryString(params.Encode()).
+		SetResult(&out).
+		Get(path)
+	if err != nil {
+		return out, err
+	}
+	if r.IsError() {
+		return out, crawler.NewHTTPStatusError("douyin", path, r.StatusCode(), r.String())
+	}
+	return out, nil
+}
+
+type relationClient interface {
+	GetRelationList(context.Context, string, string, string, int64, string) (relationListResp, error)
+}
+
+// fetchRelationEdges pages through one relation list of secUserID and converts
+// it to follow edges keyed by sec_uid, stopping after max entries.
+func fetchRelationEdges(ctx context.Context, client relationClient, uid string, secUserID string, name string, relation string, max int, msToken string) ([]store.CreatorEdge, error) {
+	if max <= 0 {
+		return nil, nil
+	}
+	out := make([]store.CreatorEdge, 0, 64)
+	seen := map[string]struct{}{}
+	var maxTime int64
+	for len(out) < max {
+		resp, err := client.GetRelationList(ctx, uid, secUserID, relation, maxTime, msToken)
+		if err != nil {
+			return out, err
+		}
+		added := 0
+		for _, u := range resp.users() {
+			id, _ := u["sec_uid"].(string)
+			id = strings.TrimSpace(id)
+			if id == "" || id == secUserID {
+				continue
+			}
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+			added++
+			other, _ := u["nickname"].(string)
+			e := store.CreatorEdge{FromID: secUserID, FromName: name, ToID: id, ToName: other, Type: store.EdgeTypeFollowing}
+			if relation == relationFollower {
+				e = store.CreatorEdge{FromID: id, FromName: other, ToID: secUserID, ToName: name, Type: store.EdgeTypeFollower}
+			}
+			out = append(out, e)
+			if len(out) >= max {
+				break
+			}
+		}
+		if !resp.HasMore || added == 0 || resp.MinTime <= 0 || resp.MinTime == maxTime {
+			break
+		}
+		maxTime = resp.MinTime
+	}
+	return out, nil
+}
+
+// crawlRelations stores the follow edges of a creator's followings and
+// followers (up to CRAWLER_MAX_RELATIONS_COUNT each).
+func (c *DouyinCrawler) crawlRelations(ctx context.Context, secUserID string, profile map[string]any, msToken string) {
+	user, _ := profile["user"].(map[string]any)
+	uid, _ := user["uid"].(string)
+	name, _ := user["nickname"].(string)
+	for _, relation := range []string{relationFollowing, relationFollower} {
+		edges, err := fetchRelationEdges(ctx, c.client, uid, secUserID, name, relation, config.AppConfig.CrawlerMaxRelationsCount, msToken)
+		if err != nil {
+			logger.Warn("douyin relation list incomplete", "creator_id", secUserID, "relation", relation, "collected", len(edges), "err", err)
+		}
+		if _, err := store.AppendCreatorEdges(edges); err != nil {
+			logger.Error("save creator edges failed", "creator_id", secUserID, "relation", relation, "err", err)
+			continue
+		}
+		logger.Info("douyin relations saved", "creator_id", secUserID, "relation", relation, "edges", len(edges))
+		if config.AppConfig.CrawlerMaxSleepSec > 0 {
+			time.Sleep(time.Duration(config.AppConfig.CrawlerMaxSleepSec) * time.Second)
+		}
+	}
+}
@@ -11,8 +11,10 @@ import (
 )
 
 var (
-	reVideoID = regexp.MustCompile(`/video/(\d+)`)
+	reVideoID = regexp.MustCompile(`/(?:video|note)/(\d+)`)
 	reUserID  = regexp.MustCompile(`/user/([^/?]+)`)
+	reMusicID = regexp.MustCompile(`/music/(\d+)`)
+	reMixID   = regexp.MustCompile(`/(?:collection|mix/detail)/(\d+)`)
 )
 
 func ExtractAwemeID(input string) string {
@@ -38,6 +40,22 @@ func ExtractAwemeID(input string) string {
 	return ""
 }
 
+// ExtractMusicID accepts a numeric music id or a /music/<id> page URL.
+func ExtractMusicID(input string) string {
+	s := strings.TrimSpace(input)
+	if isDigits(s) {
+		return s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return ""
+	}
+	if m := reMusicID.FindStringSubmatch(u.Path); len(m) == 2 {
+		return m[1]
+	}
+	return ""
+}
+
 func ExtractSecUserID(input string) string {
 	s := strings.TrimSpace(input)
 	if s == "" {
@@ -114,3 +132,23 @@ func isDigits(s string) bool {
 	}
 	return s != ""
 }
+
+// ExtractMixID accepts a numeric collection id, a /collection/<id> page URL
+// or a /share/mix/detail/<id> share link.
+func ExtractMixID(input string) string {
+	s := strings.TrimSpace(input)
+	if isDigits(s) {
+		return s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return ""
+	}
+	if id := u.Query().Get("mix_id"); isDigits(id) {
+		return id
+	}
+	if m := reMixID.FindStringSubmatch(u.Path); len(m) == 2 {
+		return m[1]
+	}
+	return ""
+}
@@ -10,6 +10,7 @@ func TestExtractAwemeID(t *testing.T) {
 		{"7525082444551310602", "7525082444551310602"},
 		{"https://www.douyin.com/video/7525082444551310602", "7525082444551310602"},
 		{"https://www.douyin.com/user/xxx?modal_id=7471165520058862848", "7471165520058862848"},
+		{"https://www.douyin.com/note/7363550223532576043", "7363550223532576043"},
 		{"https://v.douyin.com/iF12345ABC/", ""},
 		{"", ""},
 	}
@@ -37,3 +38,40 @@ func TestExtractSecUserID(t *testing.T) {
 		}
 	}
 }
+
+func TestExtractMusicID(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"7315704709279550259", "7315704709279550259"},
+		{"https://www.douyin.com/music/7315704709279550259?previous_page=app_code_link", "7315704709279550259"},
+		{"https://www.douyin.com/video/7525082444551310602", ""},
+		{"", ""},
+	}
+	for _, c := range cases {
+		got := ExtractMusicID(c.in)
+		if got != c.want {
+			t.Fatalf("ExtractMusicID(%q)=%q want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestExtractMixID(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"7348687990509553679", "7348687990509553679"},
+		{"https://www.douyin.com/collection/7348687990509553679", "7348687990509553679"},
+		{"https://www.iesdouyin.com/share/mix/detail/7348687990509553679/?schema_type=24", "7348687990509553679"},
+		{"https://www.douyin.com/video/7525082444551310602?mix_id=7348687990509553679", "7348687990509553679"},
+		{"https://www.douyin.com/music/7315704709279550259", ""},
+	}
+	for _, c := range cases {
+		got := ExtractMixID(c.in)
+		if got != c.want {
+			t.Fatalf("ExtractMixID(%q)=%q want %q", c.in, got, c.want)
+		}
+	}
+}
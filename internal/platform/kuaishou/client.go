@@ -4,6 +4,7 @@ import (
 	"context"
 	"media-crawler-go/internal/config"
 	"media-crawler-go/internal/crawler"
+	"media-crawler-go/internal/metrics"
 	"media-crawler-go/internal/proxy"
 	"net/http"
 	"time"
@@ -43,6 +44,7 @@ func NewClient() *Client {
 		Timeout:   timeout,
 	}
 	httpClient := resty.NewWithClient(hc)
+	metrics.InstrumentResty(httpClient, "kuaishou")
 	headers := map[string]string{
 		"accept":          "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
 		"accept-language": "zh-CN,zh;q=0.9,en;q=0.8",
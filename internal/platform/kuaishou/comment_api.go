@@ -326,22 +326,34 @@ func mapToAPIComment(m map[string]any, noteID string, parentID string) (Comment,
 		userName = ""
 	}
 
+	// parentID is the root comment for sub comments; replyToCommentId names
+	// the comment actually replied to.
+	rootID := strings.TrimSpace(parentID)
+	replyTo := strings.TrimSpace(fmt.Sprintf("%v", m["replyToCommentId"]))
+	if replyTo == "<nil>" {
+		replyTo = ""
+	}
+	replyToName := strings.TrimSpace(fmt.Sprintf("%v", m["replyToUserName"]))
+	if replyToName == "<nil>" {
+		replyToName = ""
+	}
 	if strings.TrimSpace(parentID) == "" {
-		parentID = strings.TrimSpace(fmt.Sprintf("%v", m["replyToCommentId"]))
-		if parentID == "<nil>" {
-			parentID = ""
-		}
+		parentID = replyTo
 	}
 
 	return Comment{
-		NoteID:          noteID,
-		CommentID:       id,
-		ParentCommentID: parentID,
-		Content:         stripHTML(content),
-		CreateTime:      ts,
-		LikeCount:       like,
-		UserID:          userID,
-		UserNickname:    userName,
+		NoteID:              noteID,
+		CommentID:           id,
+		ParentCommentID:     parentID,
+		RootCommentID:       rootID,
+		ReplyToCommentID:    replyTo,
+		ReplyToUserNickname: replyToName,
+		Content:             stripHTML(content),
+		CreateTime:          ts,
+		LikeCount:           like,
+		UserID:              userID,
+		UserNickname:        userName,
+		SubCommentCount:     toInt64(m["subCommentCount"]),
 	}, true
 }
 
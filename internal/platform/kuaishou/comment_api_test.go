@@ -46,4 +46,7 @@ func TestFetchAllPhotoCommentsAPIOnce_PaginatesAndSubcomments(t *testing.T) {
 	if out[1].ParentCommentID != "c1" {
 		t.Fatalf("sub ParentCommentID=%q", out[1].ParentCommentID)
 	}
+	if out[0].SubCommentCount != 1 || out[1].RootCommentID != "c1" {
+		t.Fatalf("unexpected thread fields: %+v", out)
+	}
 }
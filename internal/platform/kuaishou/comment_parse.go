@@ -205,6 +205,9 @@ func mapToComment(m map[string]any, noteID string) (Comment, bool) {
 		return Comment{}, false
 	}
 	parent := firstString(m, "replyToCommentId", "parentCommentId", "rootCommentId")
+	root := firstString(m, "rootCommentId")
+	replyTo := firstString(m, "replyToCommentId")
+	replyToName := firstString(m, "replyToUserName")
 	createTime := firstInt64(m, "timestamp", "createTime", "createdAt", "createdTime")
 	likeCount := firstInt64(m, "likeCount", "likedCount", "diggCount")
 
@@ -219,14 +222,18 @@ func mapToComment(m map[string]any, noteID string) (Comment, bool) {
 	}
 
 	return Comment{
-		NoteID:          noteID,
-		CommentID:       id,
-		ParentCommentID: parent,
-		Content:         stripHTML(content),
-		CreateTime:      createTime,
-		LikeCount:       likeCount,
-		UserID:          userID,
-		UserNickname:    userName,
+		NoteID:              noteID,
+		CommentID:           id,
+		ParentCommentID:     parent,
+		RootCommentID:       root,
+		ReplyToCommentID:    replyTo,
+		ReplyToUserNickname: replyToName,
+		Content:             stripHTML(content),
+		CreateTime:          createTime,
+		LikeCount:           likeCount,
+		UserID:              userID,
+		UserNickname:        userName,
+		SubCommentCount:     firstInt64(m, "subCommentCount"),
 	}, true
 }
 
@@ -74,7 +74,7 @@ func (c *Crawler) runDetail(ctx context.Context, req crawler.Request) (crawler.R
 	}
 	r := crawler.ForEachLimit(ctx, req.Inputs, limit, func(ctx context.Context, input string) error {
 		u := normalizeKSDetailURL(input)
-		return c.fetchAndSaveDetail(ctx, req.Platform, u)
+		return c.fetchAndSaveDetail(ctx, req.Platform, u, nil)
 	})
 	out.Processed = r.Processed
 	out.Succeeded = r.Succeeded
@@ -121,75 +121,156 @@ func (c *Crawler) runSearch(ctx context.Context, req crawler.Request) (crawler.R
 			continue
 		}
 		logger.Info("kuaishou searching keyword", "keyword", keyword)
-		page := startPage
-		for {
-			if maxNotes > 0 && out.Processed >= maxNotes {
-				break
-			}
-			select {
-			case <-ctx.Done():
+		searchKey, endpoint := searchGraphQLTarget(keyword)
+		if pc, ok := c.client.(jsonPostClient); ok && searchKey != "" {
+			listed, err := c.searchGraphQL(ctx, pc, endpoint, req.Platform, searchKey, startPage, maxNotes, concurrency, &out, seen)
+			if ctx.Err() != nil {
 				out.FinishedAt = time.Now().Unix()
 				return out, ctx.Err()
-			default:
-			}
-
-			searchURL := ""
-			singlePage := false
-			if strings.HasPrefix(keyword, "http://") || strings.HasPrefix(keyword, "https://") {
-				searchURL = keyword
-				singlePage = true
-			} else {
-				searchURL = fmt.Sprintf("https://www.kuaishou.com/search/video?searchKey=%s&page=%d", url.QueryEscape(keyword), page)
-			}
-			res, err := c.client.FetchHTML(ctx, searchURL)
-			if err != nil {
-				logger.Error("kuaishou search fetch failed", "url", searchURL, "err", err)
-				break
 			}
-			baseURL := "https://www.kuaishou.com"
-			if pu, err := url.Parse(res.URL); err == nil && pu.Scheme != "" && pu.Host != "" {
-				baseURL = pu.Scheme + "://" + pu.Host
-			}
-			candidates := ExtractDetailURLsFromHTML(res.Body, baseURL, 500)
-			if len(candidates) == 0 {
-				break
+			if err == nil || listed > 0 {
+				continue
 			}
+			logger.Warn("kuaishou graphql search failed, falling back to html", "keyword", keyword, "err", err)
+		}
+		if err := c.searchHTML(ctx, req.Platform, keyword, startPage, maxNotes, concurrency, &out, seen); err != nil {
+			out.FinishedAt = time.Now().Unix()
+			return out, err
+		}
+	}
 
-			tasks := make([]string, 0, len(candidates))
-			for _, u := range candidates {
+	out.FinishedAt = time.Now().Unix()
+	return out, nil
+}
+
+// searchGraphQLTarget returns the search keyword and GraphQL endpoint for a
+// search input. A search page URL yields its searchKey and the endpoint on
+// the page's host, so mirrors and replays are searched through GraphQL too;
+// URLs without a searchKey return an empty keyword and are scraped as HTML.
+func searchGraphQLTarget(input string) (string, string) {
+	if !strings.HasPrefix(input, "http://") && !strings.HasPrefix(input, "https://") {
+		return input, ksGraphQLEndpoint
+	}
+	pu, err := url.Parse(input)
+	if err != nil {
+		return "", ""
+	}
+	return strings.TrimSpace(pu.Query().Get("searchKey")), graphQLEndpointFor(input)
+}
+
+// searchGraphQL pages through visionSearchPhoto for keyword and processes
+// the listed photos. It returns how many photos were listed so the caller can
+// fall back to HTML scraping when the endpoint is unavailable.
+func (c *Crawler) searchGraphQL(ctx context.Context, pc jsonPostClient, endpoint string, platform string, keyword string, startPage int, maxNotes int, concurrency int, out *crawler.Result, seen map[string]struct{}) (int, error) {
+	listed := 0
+	pcursor := ""
+	sessionID := ""
+	for page := 1; maxNotes <= 0 || out.Processed < maxNotes; page++ {
+		if err := ctx.Err(); err != nil {
+			return listed, err
+		}
+		crawler.ProgressFrom(ctx).SetStage(keyword, page)
+		res, err := visionSearchPhoto(ctx, pc, endpoint, keyword, pcursor, sessionID)
+		if err != nil {
+			return listed, err
+		}
+		listed += len(res.Photos)
+		if res.SearchSessionID != "" {
+			sessionID = res.SearchSessionID
+		}
+		if page >= startPage {
+			tasks := make([]Photo, 0, len(res.Photos))
+			for _, p := range res.Photos {
 				if maxNotes > 0 && out.Processed+len(tasks) >= maxNotes {
 					break
 				}
-				if _, ok := seen[u]; ok {
+				if _, ok := seen[p.DetailURL()]; ok {
 					continue
 				}
-				seen[u] = struct{}{}
-				tasks = append(tasks, u)
-			}
-			if len(tasks) == 0 {
-				break
+				seen[p.DetailURL()] = struct{}{}
+				tasks = append(tasks, p)
 			}
+			c.processPhotos(ctx, platform, tasks, concurrency, out)
+		}
+		if !res.HasMore(pcursor) {
+			break
+		}
+		pcursor = res.Pcursor
+		if config.AppConfig.CrawlerMaxSleepSec > 0 {
+			crawler.Sleep(ctx, time.Duration(config.AppConfig.CrawlerMaxSleepSec)*time.Second)
+		}
+	}
+	return listed, nil
+}
 
-			r := crawler.ForEachLimit(ctx, tasks, concurrency, func(ctx context.Context, u string) error {
-				return c.fetchAndSaveDetail(ctx, req.Platform, u)
-			})
-			out.Succeeded += r.Succeeded
-			out.Failed += r.Failed
-			out.Processed += r.Processed
-			out.FailureKinds = crawler.MergeFailureKinds(out.FailureKinds, r.FailureKinds)
-
-			page++
-			if config.AppConfig.CrawlerMaxSleepSec > 0 {
-				crawler.Sleep(ctx, time.Duration(config.AppConfig.CrawlerMaxSleepSec)*time.Second)
-			}
-			if singlePage {
+// searchHTML scrapes detail links from the web search page (or from the page
+// at keyword when it is a URL).
+func (c *Crawler) searchHTML(ctx context.Context, platform string, keyword string, startPage int, maxNotes int, concurrency int, out *crawler.Result, seen map[string]struct{}) error {
+	page := startPage
+	for {
+		if maxNotes > 0 && out.Processed >= maxNotes {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		searchURL := ""
+		singlePage := false
+		if strings.HasPrefix(keyword, "http://") || strings.HasPrefix(keyword, "https://") {
+			searchURL = keyword
+			singlePage = true
+		} else {
+			searchURL = fmt.Sprintf("https://www.kuaishou.com/search/video?searchKey=%s&page=%d", url.QueryEscape(keyword), page)
+		}
+		crawler.ProgressFrom(ctx).SetStage(keyword, page)
+		res, err := c.client.FetchHTML(ctx, searchURL)
+		if err != nil {
+			logger.Error("kuaishou search fetch failed", "url", searchURL, "err", err)
+			return nil
+		}
+		baseURL := "https://www.kuaishou.com"
+		if pu, err := url.Parse(res.URL); err == nil && pu.Scheme != "" && pu.Host != "" {
+			baseURL = pu.Scheme + "://" + pu.Host
+		}
+		candidates := ExtractDetailURLsFromHTML(res.Body, baseURL, 500)
+		if len(candidates) == 0 {
+			return nil
+		}
+
+		tasks := make([]string, 0, len(candidates))
+		for _, u := range candidates {
+			if maxNotes > 0 && out.Processed+len(tasks) >= maxNotes {
 				break
 			}
+			if _, ok := seen[u]; ok {
+				continue
+			}
+			seen[u] = struct{}{}
+			tasks = append(tasks, u)
+		}
+		if len(tasks) == 0 {
+			return nil
 		}
-	}
 
-	out.FinishedAt = time.Now().Unix()
-	return out, nil
+		r := crawler.ForEachLimit(ctx, tasks, concurrency, func(ctx context.Context, u string) error {
+			return c.fetchAndSaveDetail(ctx, platform, u, nil)
+		})
+		out.Succeeded += r.Succeeded
+		out.Failed += r.Failed
+		out.Processed += r.Processed
+		out.FailureKinds = crawler.MergeFailureKinds(out.FailureKinds, r.FailureKinds)
+
+		page++
+		if config.AppConfig.CrawlerMaxSleepSec > 0 {
+			crawler.Sleep(ctx, time.Duration(config.AppConfig.CrawlerMaxSleepSec)*time.Second)
+		}
+		if singlePage {
+			return nil
+		}
+	}
 }
 
 func (c *Crawler) runCreator(ctx context.Context, req crawler.Request) (crawler.Result, error) {
@@ -220,31 +301,77 @@ func (c *Crawler) runCreator(ctx context.Context, req crawler.Request) (crawler.
 		}
 
 		creatorURL := normalizeKSCreatorURL(input)
-		creatorID, _ := ParseKSCreatorID(input)
+		creatorID, parseErr := ParseKSCreatorID(input)
 		if creatorID == "" {
 			creatorID = stableID("ks_creator", input)
 		}
 		logger.Info("kuaishou fetch creator", "creator_id", creatorID, "url", creatorURL)
 
+		var photos []Photo
+		if pc, ok := c.client.(jsonPostClient); ok && parseErr == nil {
+			limit := 0
+			if maxNotes > 0 {
+				limit = maxNotes - out.Processed
+			}
+			var err error
+			photos, err = c.listCreatorPhotos(ctx, pc, graphQLEndpointFor(creatorURL), creatorID, limit)
+			if err != nil {
+				logger.Warn("kuaishou graphql profile list failed, falling back to html", "creator_id", creatorID, "err", err)
+			}
+		}
+
 		res, err := c.client.FetchHTML(ctx, creatorURL)
 		if err != nil {
 			logger.Error("kuaishou creator fetch failed", "url", creatorURL, "err", err)
-			continue
+			if len(photos) == 0 {
+				continue
+			}
+		}
+		record := map[string]any{
+			"url":         creatorURL,
+			"user_id":     creatorID,
+			"photo_count": len(photos),
+		}
+		if err == nil {
+			record["url"] = res.URL
+			record["status_code"] = res.StatusCode
+			record["content_type"] = res.ContentType
+			record["body"] = res.Body
+			record["original_len"] = res.OriginalLen
+			record["truncated"] = res.Truncated
+			record["fetched_at"] = res.FetchedAt
+			record["risk_hint"] = crawler.DetectRiskHint(res.Body)
 		}
-		riskHint := crawler.DetectRiskHint(res.Body)
-		if err := store.SaveCreator(creatorID, map[string]any{
-			"url":          res.URL,
-			"status_code":  res.StatusCode,
-			"content_type": res.ContentType,
-			"body":         res.Body,
-			"original_len": res.OriginalLen,
-			"truncated":    res.Truncated,
-			"fetched_at":   res.FetchedAt,
-			"risk_hint":    riskHint,
-		}); err != nil {
+		if len(photos) > 0 {
+			record["author"] = map[string]any{
+				"id":         photos[0].AuthorID,
+				"name":       photos[0].AuthorName,
+				"header_url": photos[0].AuthorAvatar,
+			}
+		}
+		if err := store.SaveCreator(creatorID, record); err != nil {
 			logger.Error("kuaishou save creator failed", "creator_id", creatorID, "err", err)
 		}
 
+		if len(photos) > 0 {
+			tasks := make([]Photo, 0, len(photos))
+			for _, p := range photos {
+				if maxNotes > 0 && out.Processed+len(tasks) >= maxNotes {
+					break
+				}
+				if _, ok := seen[p.DetailURL()]; ok {
+					continue
+				}
+				seen[p.DetailURL()] = struct{}{}
+				tasks = append(tasks, p)
+			}
+			c.processPhotos(ctx, req.Platform, tasks, concurrency, &out)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
 		baseURL := "https://www.kuaishou.com"
 		if pu, err := url.Parse(res.URL); err == nil && pu.Scheme != "" && pu.Host != "" {
 			baseURL = pu.Scheme + "://" + pu.Host
@@ -266,7 +393,7 @@ func (c *Crawler) runCreator(ctx context.Context, req crawler.Request) (crawler.
 		}
 
 		r := crawler.ForEachLimit(ctx, tasks, concurrency, func(ctx context.Context, u string) error {
-			return c.fetchAndSaveDetail(ctx, req.Platform, u)
+			return c.fetchAndSaveDetail(ctx, req.Platform, u, nil)
 		})
 		out.Succeeded += r.Succeeded
 		out.Failed += r.Failed
@@ -278,8 +405,55 @@ func (c *Crawler) runCreator(ctx context.Context, req crawler.Request) (crawler.
 	return out, nil
 }
 
-func (c *Crawler) fetchAndSaveDetail(ctx context.Context, platform string, url string) error {
+// listCreatorPhotos pages through visionProfilePhotoList until limit photos
+// were listed (<= 0 lists the whole profile).
+func (c *Crawler) listCreatorPhotos(ctx context.Context, pc jsonPostClient, endpoint string, userID string, limit int) ([]Photo, error) {
+	var out []Photo
+	pcursor := ""
+	for limit <= 0 || len(out) < limit {
+		if err := ctx.Err(); err != nil {
+			return out, err
+		}
+		res, err := visionProfilePhotoList(ctx, pc, endpoint, userID, pcursor)
+		if err != nil {
+			return out, err
+		}
+		out = append(out, res.Photos...)
+		if !res.HasMore(pcursor) {
+			break
+		}
+		pcursor = res.Pcursor
+		if config.AppConfig.CrawlerMaxSleepSec > 0 {
+			crawler.Sleep(ctx, time.Duration(config.AppConfig.CrawlerMaxSleepSec)*time.Second)
+		}
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (c *Crawler) processPhotos(ctx context.Context, platform string, photos []Photo, concurrency int, out *crawler.Result) {
+	if len(photos) == 0 {
+		return
+	}
+	r := crawler.ForEachLimit(ctx, photos, concurrency, func(ctx context.Context, p Photo) error {
+		return c.fetchAndSaveDetail(ctx, platform, p.DetailURL(), &p)
+	})
+	out.Succeeded += r.Succeeded
+	out.Failed += r.Failed
+	out.Processed += r.Processed
+	out.FailureKinds = crawler.MergeFailureKinds(out.FailureKinds, r.FailureKinds)
+}
+
+// fetchAndSaveDetail fetches the detail page at url and saves it as a note.
+// photo, when listed by a GraphQL endpoint, is stored alongside and provides
+// the note id.
+func (c *Crawler) fetchAndSaveDetail(ctx context.Context, platform string, url string, photo *Photo) error {
 	ksid, noteID, _ := ParseKSID(url)
+	if photo != nil && photo.PhotoID != "" {
+		ksid, noteID = photo.PhotoID, sanitizeID(photo.PhotoID)
+	}
 	logger.Info("kuaishou fetch html", "url", url, "note_id", noteID)
 	res, err := c.client.FetchHTML(ctx, url)
 	if err != nil {
@@ -299,13 +473,16 @@ func (c *Crawler) fetchAndSaveDetail(ctx context.Context, platform string, url s
 		"parsed_note_id": noteID,
 		"risk_hint":      riskHint,
 	}
+	if photo != nil {
+		record["photo"] = photo
+	}
 	if noteID == "" {
 		noteID = ksid
 	}
 	if noteID == "" {
 		noteID = stableID("ks", url)
 	}
-	if err := store.SaveNoteDetail(noteID, record); err != nil {
+	if err := store.SaveNoteDetailContext(ctx, noteID, record); err != nil {
 		logger.Error("kuaishou save note failed", "note_id", noteID, "err", err)
 		return err
 	}
@@ -317,23 +494,14 @@ func (c *Crawler) fetchAndSaveDetail(ctx context.Context, platform string, url s
 	if config.AppConfig.EnableGetComments {
 		comments := fetchCommentsPreferAPI(ctx, c.client, res.Body, noteID, ksid, config.AppConfig.CrawlerMaxComments, config.AppConfig.EnableGetSubComments)
 		if len(comments) > 0 {
+			unified := toUnifiedComments(noteID, comments)
 			switch config.AppConfig.SaveDataOption {
 			case "csv":
 				items := make([]any, 0, len(comments))
 				globalItems := make([]any, 0, len(comments))
 				for i := range comments {
 					items = append(items, &comments[i])
-					globalItems = append(globalItems, &store.UnifiedComment{
-						Platform:        "kuaishou",
-						NoteID:          noteID,
-						CommentID:       comments[i].CommentID,
-						ParentCommentID: comments[i].ParentCommentID,
-						Content:         comments[i].Content,
-						CreateTime:      comments[i].CreateTime,
-						LikeCount:       comments[i].LikeCount,
-						UserID:          comments[i].UserID,
-						UserNickname:    comments[i].UserNickname,
-					})
+					globalItems = append(globalItems, unified[i])
 				}
 				if _, err := store.AppendUniqueCommentsCSV(
 					noteID,
@@ -355,17 +523,7 @@ func (c *Crawler) fetchAndSaveDetail(ctx context.Context, platform string, url s
 			case "xlsx_book":
 				globalItems := make([]any, 0, len(comments))
 				for i := range comments {
-					globalItems = append(globalItems, &store.UnifiedComment{
-						Platform:        "kuaishou",
-						NoteID:          noteID,
-						CommentID:       comments[i].CommentID,
-						ParentCommentID: comments[i].ParentCommentID,
-						Content:         comments[i].Content,
-						CreateTime:      comments[i].CreateTime,
-						LikeCount:       comments[i].LikeCount,
-						UserID:          comments[i].UserID,
-						UserNickname:    comments[i].UserNickname,
-					})
+					globalItems = append(globalItems, unified[i])
 				}
 				if _, err := store.AppendUniqueGlobalCommentsBook(
 					globalItems,
@@ -386,17 +544,7 @@ func (c *Crawler) fetchAndSaveDetail(ctx context.Context, platform string, url s
 				globalItems := make([]any, 0, len(comments))
 				for i := range comments {
 					items = append(items, &comments[i])
-					globalItems = append(globalItems, &store.UnifiedComment{
-						Platform:        "kuaishou",
-						NoteID:          noteID,
-						CommentID:       comments[i].CommentID,
-						ParentCommentID: comments[i].ParentCommentID,
-						Content:         comments[i].Content,
-						CreateTime:      comments[i].CreateTime,
-						LikeCount:       comments[i].LikeCount,
-						UserID:          comments[i].UserID,
-						UserNickname:    comments[i].UserNickname,
-					})
+					globalItems = append(globalItems, unified[i])
 				}
 				if _, err := store.AppendUniqueCommentsXLSX(
 					noteID,
@@ -420,17 +568,7 @@ func (c *Crawler) fetchAndSaveDetail(ctx context.Context, platform string, url s
 				globalItems := make([]any, 0, len(comments))
 				for i := range comments {
 					items = append(items, comments[i])
-					globalItems = append(globalItems, &store.UnifiedComment{
-						Platform:        "kuaishou",
-						NoteID:          noteID,
-						CommentID:       comments[i].CommentID,
-						ParentCommentID: comments[i].ParentCommentID,
-						Content:         comments[i].Content,
-						CreateTime:      comments[i].CreateTime,
-						LikeCount:       comments[i].LikeCount,
-						UserID:          comments[i].UserID,
-						UserNickname:    comments[i].UserNickname,
-					})
+					globalItems = append(globalItems, unified[i])
 				}
 				if _, err := store.AppendUniqueCommentsJSONL(
 					noteID,
@@ -447,6 +585,15 @@ func (c *Crawler) fetchAndSaveDetail(ctx context.Context, platform string, url s
 				}
 			}
 		}
+		reported := int64(-1)
+		if photo != nil {
+			reported = photo.CommentCount
+		}
+		if cov, err := store.RecordCommentCoverage("kuaishou", noteID, url, reported, len(comments)); err != nil {
+			logger.Error("kuaishou save comment coverage failed", "note_id", noteID, "err", err)
+		} else {
+			logger.Info("kuaishou comment coverage", "note_id", noteID, "fetched", cov.FetchedCount, "reported", cov.ReportedCount, "ratio", cov.Ratio)
+		}
 	}
 	return nil
 }
@@ -470,6 +617,16 @@ func normalizeKSCreatorURL(input string) string {
 	return "https://www.kuaishou.com/profile/" + url.PathEscape(s)
 }
 
+// graphQLEndpointFor returns the GraphQL endpoint on the host of a non-kuaishou
+// page URL (mirrors/replays), and the public endpoint otherwise.
+func graphQLEndpointFor(pageURL string) string {
+	pu, err := url.Parse(strings.TrimSpace(pageURL))
+	if err != nil || pu.Scheme == "" || pu.Host == "" || strings.HasSuffix(pu.Hostname(), "kuaishou.com") {
+		return ksGraphQLEndpoint
+	}
+	return pu.Scheme + "://" + pu.Host + "/graphql"
+}
+
 func stableID(prefix string, raw string) string {
 	h := sha1.Sum([]byte(prefix + ":" + strings.TrimSpace(raw)))
 	return prefix + "_" + hex.EncodeToString(h[:])
@@ -0,0 +1,92 @@
+package kuaishou
+
+import (
+	"media-crawler-go/internal/crawler"
+	"strconv"
+	"strings"
+)
+
+// Photo is a short video listed by the vision GraphQL endpoints
+// (visionSearchPhoto / visionProfilePhotoList).
+type Photo struct {
+	PhotoID       string `json:"photo_id"`
+	Caption       string `json:"caption"`
+	LikeCount     int64  `json:"like_count"`
+	RealLikeCount int64  `json:"real_like_count"`
+	ViewCount     int64  `json:"view_count"`
+	CommentCount  int64  `json:"comment_count"`
+	Duration      int64  `json:"duration"`
+	CoverURL      string `json:"cover_url"`
+	VideoURL      string `json:"video_url"`
+	Timestamp     int64  `json:"timestamp"`
+	AuthorID      string `json:"author_id"`
+	AuthorName    string `json:"author_name"`
+	AuthorAvatar  string `json:"author_avatar"`
+}
+
+func (p Photo) CSVHeader() []string {
+	return []string{"photo_id", "caption", "like_count", "real_like_count", "view_count", "comment_count", "duration", "cover_url", "video_url", "timestamp", "author_id", "author_name", "author_avatar"}
+}
+
+func (p Photo) ToCSV() []string {
+	return []string{
+		p.PhotoID,
+		p.Caption,
+		strconv.FormatInt(p.LikeCount, 10),
+		strconv.FormatInt(p.RealLikeCount, 10),
+		strconv.FormatInt(p.ViewCount, 10),
+		strconv.FormatInt(p.CommentCount, 10),
+		strconv.FormatInt(p.Duration, 10),
+		p.CoverURL,
+		p.VideoURL,
+		strconv.FormatInt(p.Timestamp, 10),
+		p.AuthorID,
+		p.AuthorName,
+		p.AuthorAvatar,
+	}
+}
+
+// DetailURL is the web page of the photo, used for the detail fetch.
+func (p Photo) DetailURL() string {
+	return "https://www.kuaishou.com/short-video/" + p.PhotoID
+}
+
+// feedToPhoto converts one entry of a vision feed list ({photo, author}).
+func feedToPhoto(feed map[string]any) (Photo, bool) {
+	photo, _ := feed["photo"].(map[string]any)
+	if photo == nil {
+		return Photo{}, false
+	}
+	id := firstString(photo, "id", "photoId")
+	if id == "" {
+		return Photo{}, false
+	}
+	out := Photo{
+		PhotoID:       id,
+		Caption:       strings.TrimSpace(firstString(photo, "caption", "originCaption")),
+		LikeCount:     parseKSCount(photo["likeCount"]),
+		RealLikeCount: parseKSCount(photo["realLikeCount"]),
+		ViewCount:     parseKSCount(photo["viewCount"]),
+		CommentCount:  parseKSCount(photo["commentCount"]),
+		Duration:      toInt64(photo["duration"]),
+		CoverURL:      firstString(photo, "coverUrl"),
+		VideoURL:      firstString(photo, "photoUrl", "photoH265Url"),
+		Timestamp:     toInt64(photo["timestamp"]),
+	}
+	if author, _ := feed["author"].(map[string]any); author != nil {
+		out.AuthorID = firstString(author, "id")
+		out.AuthorName = firstString(author, "name")
+		out.AuthorAvatar = firstString(author, "headerUrl")
+	}
+	return out, true
+}
+
+// parseKSCount reads a count that is either numeric or a display string such
+// as "1.2万" / "3w" / "1,024".
+func parseKSCount(v any) int64 {
+	s, ok := v.(string)
+	if !ok {
+		return toInt64(v)
+	}
+	return crawler.ParseCount(s)
+}
@@ -0,0 +1,175 @@
+package kuaishou
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const ksGraphQLEndpoint = "https://www.kuaishou.com/graphql"
+
+// pcursorNoMore is the pcursor the vision endpoints return on the last page.
+const pcursorNoMore = "no_more"
+
+const visionSearchPhotoQuery = `fragment photoContent on PhotoEntity {
+  id
+  duration
+  caption
+  originCaption
+  likeCount
+  viewCount
+  commentCount
+  realLikeCount
+  coverUrl
+  photoUrl
+  photoH265Url
+  timestamp
+}
+
+fragment feedContent on Feed {
+  type
+  author {
+    id
+    name
+    headerUrl
+  }
+  photo {
+    ...photoContent
+  }
+}
+
+query visionSearchPhoto($keyword: String, $pcursor: String, $searchSessionId: String, $page: String, $webPageArea: String) {
+  visionSearchPhoto(keyword: $keyword, pcursor: $pcursor, searchSessionId: $searchSessionId, page: $page, webPageArea: $webPageArea) {
+    result
+    llsid
+    searchSessionId
+    pcursor
+    feeds {
+      ...feedContent
+    }
+  }
+}`
+
+const visionProfilePhotoListQuery = `fragment photoContent on PhotoEntity {
+  id
+  duration
+  caption
+  originCaption
+  likeCount
+  viewCount
+  commentCount
+  realLikeCount
+  coverUrl
+  photoUrl
+  photoH265Url
+  timestamp
+}
+
+fragment feedContent on Feed {
+  type
+  author {
+    id
+    name
+    headerUrl
+  }
+  photo {
+    ...photoContent
+  }
+}
+
+query visionProfilePhotoList($pcursor: String, $userId: String, $page: String, $webPageArea: String) {
+  visionProfilePhotoList(pcursor: $pcursor, userId: $userId, page: $page, webPageArea: $webPageArea) {
+    result
+    llsid
+    pcursor
+    feeds {
+      ...feedContent
+    }
+  }
+}`
+
+// photoPage is one pcursor page of a vision photo list.
+type photoPage struct {
+	Photos          []Photo
+	Pcursor         string
+	SearchSessionID string
+}
+
+// HasMore reports whether another page can be requested after prev.
+func (p photoPage) HasMore(prev string) bool {
+	return len(p.Photos) > 0 && p.Pcursor != "" && p.Pcursor != pcursorNoMore && p.Pcursor != prev
+}
+
+// visionSearchPhoto requests one page of keyword search results.
+func visionSearchPhoto(ctx context.Context, c jsonPostClient, endpoint string, keyword string, pcursor string, searchSessionID string) (photoPage, error) {
+	vars := map[string]any{
+		"keyword": keyword,
+		"pcursor": pcursor,
+		"page":    "search",
+	}
+	if searchSessionID != "" {
+		vars["searchSessionId"] = searchSessionID
+	}
+	return postVisionPhotoList(ctx, c, endpoint, "visionSearchPhoto", visionSearchPhotoQuery, vars)
+}
+
+// visionProfilePhotoList requests one page of a creator's public photos.
+func visionProfilePhotoList(ctx context.Context, c jsonPostClient, endpoint string, userID string, pcursor string) (photoPage, error) {
+	return postVisionPhotoList(ctx, c, endpoint, "visionProfilePhotoList", visionProfilePhotoListQuery, map[string]any{
+		"userId":  userID,
+		"pcursor": pcursor,
+		"page":    "profile",
+	})
+}
+
+func postVisionPhotoList(ctx context.Context, c jsonPostClient, endpoint string, op string, query string, vars map[string]any) (photoPage, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if endpoint == "" {
+		endpoint = ksGraphQLEndpoint
+	}
+	res, err := c.PostJSON(ctx, endpoint, map[string]any{
+		"operationName": op,
+		"variables":     vars,
+		"query":         query,
+	})
+	if err != nil {
+		return photoPage{}, err
+	}
+	var resp ksGraphQLResponse
+	if err := json.Unmarshal([]byte(res.Body), &resp); err != nil {
+		return photoPage{}, err
+	}
+	return parseVisionPhotoList(resp.Data, op)
+}
+
+// parseVisionPhotoList reads data.<op> of a vision photo list response.
+// result != 1 without feeds means the request was rejected (e.g. a captcha
+// challenge) and is reported as an error.
+func parseVisionPhotoList(data map[string]any, op string) (photoPage, error) {
+	list, _ := data[op].(map[string]any)
+	if list == nil {
+		return photoPage{}, fmt.Errorf("missing %s", op)
+	}
+	feeds, _ := list["feeds"].([]any)
+	if result := toInt64(list["result"]); result != 1 && len(feeds) == 0 {
+		return photoPage{}, fmt.Errorf("%s result=%d", op, result)
+	}
+	out := photoPage{
+		Pcursor:         strings.TrimSpace(firstString(list, "pcursor")),
+		SearchSessionID: strings.TrimSpace(firstString(list, "searchSessionId")),
+		Photos:          make([]Photo, 0, len(feeds)),
+	}
+	for _, f := range feeds {
+		m, _ := f.(map[string]any)
+		if m == nil {
+			continue
+		}
+		if p, ok := feedToPhoto(m); ok {
+			out.Photos = append(out.Photos, p)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,167 @@
+package kuaishou
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type fakeVisionClient struct {
+	fakeFetchClientWithComments
+	pages     map[string][]string
+	calls     []map[string]any
+	endpoints []string
+}
+
+func (f *fakeVisionClient) PostJSON(ctx context.Context, u string, payload any) (FetchResult, error) {
+	body := payload.(map[string]any)
+	op, _ := body["operationName"].(string)
+	vars, _ := body["variables"].(map[string]any)
+	f.calls = append(f.calls, vars)
+	f.endpoints = append(f.endpoints, u)
+	pages := f.pages[op]
+	if len(pages) == 0 {
+		return FetchResult{}, fmt.Errorf("unexpected operation %q", op)
+	}
+	idx := 0
+	if pc, _ := vars["pcursor"].(string); pc != "" {
+		fmt.Sscanf(pc, "p%d", &idx)
+	}
+	return FetchResult{URL: u, StatusCode: 200, Body: pages[idx]}, nil
+}
+
+func visionPage(op string, pcursor string, ids ...string) string {
+	feeds := make([]any, 0, len(ids))
+	for _, id := range ids {
+		feeds = append(feeds, map[string]any{
+			"type":   1,
+			"author": map[string]any{"id": "3xauthor", "name": "author", "headerUrl": "https://example.com/a.jpg"},
+			"photo": map[string]any{
+				"id":            id,
+				"caption":       "caption " + id,
+				"likeCount":     "1.2万",
+				"viewCount":     "356",
+				"duration":      15000,
+				"coverUrl":      "https://example.com/" + id + ".jpg",
+				"photoUrl":      "https://example.com/" + id + ".mp4",
+				"timestamp":     1700000000000,
+				"realLikeCount": 12034,
+			},
+		})
+	}
+	b, _ := json.Marshal(map[string]any{"data": map[string]any{op: map[string]any{
+		"result":          1,
+		"pcursor":         pcursor,
+		"searchSessionId": "sess",
+		"feeds":           feeds,
+	}}})
+	return string(b)
+}
+
+func TestParseVisionPhotoList(t *testing.T) {
+	var resp ksGraphQLResponse
+	if err := json.Unmarshal([]byte(visionPage("visionSearchPhoto", "no_more", "3xphoto1")), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	page, err := parseVisionPhotoList(resp.Data, "visionSearchPhoto")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if page.HasMore("") || page.SearchSessionID != "sess" || len(page.Photos) != 1 {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+	p := page.Photos[0]
+	if p.PhotoID != "3xphoto1" || p.LikeCount != 12000 || p.ViewCount != 356 || p.RealLikeCount != 12034 || p.Duration != 15000 || p.AuthorName != "author" || p.VideoURL == "" {
+		t.Fatalf("unexpected photo: %+v", p)
+	}
+
+	if _, err := parseVisionPhotoList(map[string]any{"visionSearchPhoto": map[string]any{"result": 2}}, "visionSearchPhoto"); err == nil {
+		t.Fatalf("expected error for rejected request")
+	}
+}
+
+func TestKuaishouCrawlerGraphQLSearchAndCreator(t *testing.T) {
+	oldwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	tmp := t.TempDir()
+	if err := os.Chdir(tmp); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(oldwd) })
+
+	config.AppConfig = config.Config{
+		Platform:       "kuaishou",
+		StoreBackend:   "file",
+		SaveDataOption: "json",
+		DataDir:        "data",
+	}
+
+	fc := &fakeVisionClient{pages: map[string][]string{
+		"visionSearchPhoto": {
+			visionPage("visionSearchPhoto", "p1", "3xphoto1", "3xphoto2"),
+			visionPage("visionSearchPhoto", "no_more", "3xphoto2", "3xphoto3"),
+		},
+		"visionProfilePhotoList": {
+			visionPage("visionProfilePhotoList", "p1", "3xphoto4"),
+			visionPage("visionProfilePhotoList", "no_more", "3xphoto5", "3xphoto6"),
+		},
+	}}
+	c := NewCrawlerWithClient(fc)
+
+	res, err := c.Run(context.Background(), crawler.Request{Platform: "kuaishou", Mode: crawler.ModeSearch, Keywords: []string{"cats"}, MaxNotes: 10, Concurrency: 1})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if res.Succeeded != 3 {
+		t.Fatalf("search succeeded=%d", res.Succeeded)
+	}
+	if fc.calls[1]["searchSessionId"] != "sess" || fc.calls[1]["pcursor"] != "p1" {
+		t.Fatalf("expected second page to carry pcursor and session: %v", fc.calls[1])
+	}
+
+	if fc.endpoints[0] != ksGraphQLEndpoint {
+		t.Fatalf("expected public endpoint for keyword search, got %q", fc.endpoints[0])
+	}
+
+	calls := len(fc.calls)
+	if _, err := c.Run(context.Background(), crawler.Request{Platform: "kuaishou", Mode: crawler.ModeSearch, Keywords: []string{"http://mirror.test/search/video?searchKey=dogs"}, MaxNotes: 1, Concurrency: 1}); err != nil {
+		t.Fatalf("mirror search: %v", err)
+	}
+	if len(fc.calls) != calls+1 || fc.calls[calls]["keyword"] != "dogs" || fc.endpoints[calls] != "http://mirror.test/graphql" {
+		t.Fatalf("expected mirror search via mirror graphql: %v %v", fc.calls[calls:], fc.endpoints[calls:])
+	}
+
+	res, err = c.Run(context.Background(), crawler.Request{Platform: "kuaishou", Mode: crawler.ModeCreator, Inputs: []string{"https://www.kuaishou.com/profile/3xauthor"}, MaxNotes: 2, Concurrency: 1})
+	if err != nil {
+		t.Fatalf("creator: %v", err)
+	}
+	if res.Succeeded != 2 {
+		t.Fatalf("creator succeeded=%d", res.Succeeded)
+	}
+
+	for _, id := range []string{"3xphoto1", "3xphoto2", "3xphoto3", "3xphoto4", "3xphoto5"} {
+		b, err := os.ReadFile(filepath.Join("data", "kuaishou", "notes", id, "note.json"))
+		if err != nil {
+			t.Fatalf("note %s not saved: %v", id, err)
+		}
+		var note struct {
+			Photo Photo `json:"photo"`
+		}
+		if err := json.Unmarshal(b, &note); err != nil {
+			t.Fatalf("decode note %s: %v", id, err)
+		}
+		if note.Photo.PhotoID != id || note.Photo.Caption != "caption "+id {
+			t.Fatalf("unexpected photo record for %s: %+v", id, note.Photo)
+		}
+	}
+	if _, err := os.Stat(filepath.Join("data", "kuaishou", "notes", "3xphoto6")); err == nil {
+		t.Fatalf("expected creator listing to stop at MaxNotes")
+	}
+}
@@ -1,11 +1,15 @@
 package platform
 
 import (
+	"context"
 	"fmt"
 	"media-crawler-go/internal/crawler"
+	"media-crawler-go/internal/metrics"
+	"media-crawler-go/internal/webhook"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
 type Factory func() crawler.Runner
@@ -42,7 +46,56 @@ func New(name string) (crawler.Runner, error) {
 	if f == nil {
 		return nil, fmt.Errorf("unknown platform: %s (available: %s)", name, strings.Join(Names(), ", "))
 	}
-	return f(), nil
+	return instrumentedRunner{Runner: f(), platform: n}, nil
+}
+
+// instrumentedRunner records every run in metrics.TaskDuration and sends
+// the task lifecycle webhooks.
+type instrumentedRunner struct {
+	crawler.Runner
+	platform string
+}
+
+func (r instrumentedRunner) Run(ctx context.Context, req crawler.Request) (crawler.Result, error) {
+	platform := r.platform
+	if req.Platform != "" {
+		platform = normalize(req.Platform)
+	}
+	taskID := crawler.TaskIDFrom(ctx)
+	webhook.Emit(webhook.Event{
+		Type:     webhook.TaskStarted,
+		Platform: platform,
+		TaskID:   taskID,
+		Data:     map[string]any{"mode": string(req.Mode), "keywords": req.Keywords, "inputs": len(req.Inputs)},
+	})
+
+	start := time.Now()
+	res, err := r.Runner.Run(ctx, req)
+	result, event := "ok", webhook.TaskFinished
+	switch {
+	case err != nil && crawler.KindOf(err) == crawler.ErrorKindCanceled:
+		result, event = "canceled", webhook.TaskCanceled
+	case err != nil:
+		result, event = "error", webhook.TaskFailed
+	}
+	elapsed := time.Since(start)
+	metrics.TaskDuration.Observe(elapsed.Seconds(), platform, string(req.Mode), result)
+
+	data := map[string]any{
+		"mode":          string(req.Mode),
+		"keywords":      req.Keywords,
+		"processed":     res.Processed,
+		"succeeded":     res.Succeeded,
+		"failed":        res.Failed,
+		"failure_kinds": res.FailureKinds,
+		"duration_sec":  int64(elapsed.Seconds()),
+	}
+	if err != nil {
+		data["error"] = err.Error()
+		data["error_kind"] = string(crawler.KindOf(err))
+	}
+	webhook.Emit(webhook.Event{Type: event, Platform: platform, TaskID: taskID, Data: data})
+	return res, err
 }
 
 func Exists(name string) bool {
@@ -1,16 +1,21 @@
 package tieba
 
-import "strconv"
+import (
+	"media-crawler-go/internal/store"
+	"strconv"
+)
 
 type Comment struct {
 	NoteID          string
 	CommentID       string
 	ParentCommentID string
+	RootCommentID   string
 	Content         string
 	CreateTime      int64
 	LikeCount       int64
 	UserID          string
 	UserNickname    string
+	SubCommentCount int64
 }
 
 func (c Comment) CSVHeader() []string {
@@ -30,3 +35,24 @@ func (c Comment) ToCSV() []string {
 	}
 }
 
+// Floor replies only name the user replied to in their "回复 name :" prefix.
+func toUnifiedComments(noteID string, comments []Comment) []*store.UnifiedComment {
+	out := make([]*store.UnifiedComment, 0, len(comments))
+	for _, c := range comments {
+		out = append(out, &store.UnifiedComment{
+			Platform:        "tieba",
+			NoteID:          noteID,
+			CommentID:       c.CommentID,
+			ParentCommentID: c.ParentCommentID,
+			Content:         c.Content,
+			CreateTime:      c.CreateTime,
+			LikeCount:       c.LikeCount,
+			UserID:          c.UserID,
+			UserNickname:    c.UserNickname,
+			RootCommentID:   c.RootCommentID,
+			SubCommentCount: c.SubCommentCount,
+		})
+	}
+	store.LinkCommentReplies(out)
+	return out
+}
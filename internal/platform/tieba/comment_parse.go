@@ -19,8 +19,23 @@ type parentCommentMeta struct {
 var (
 	reDataFieldDiv = regexp.MustCompile(`data-field='([^']+)'`)
 	reHTMLTags     = regexp.MustCompile(`<[^>]+>`)
+	reReplyCount   = regexp.MustCompile(`<span[^>]*class="red"[^>]*>\s*(\d+)\s*</span>\s*回复贴`)
 )
 
+// parseReportedReplyCount returns the "N 回复贴" count of a thread page, or -1
+// when the page does not show it.
+func parseReportedReplyCount(pageContent string) int64 {
+	m := reReplyCount.FindStringSubmatch(pageContent)
+	if len(m) != 2 {
+		return -1
+	}
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
 func parseParentCommentsFromHTML(pageContent string, noteID string) []parentCommentMeta {
 	if strings.TrimSpace(pageContent) == "" {
 		return nil
@@ -78,6 +93,7 @@ func parseParentCommentsFromHTML(pageContent string, noteID string) []parentComm
 				LikeCount:       0,
 				UserID:          userID,
 				UserNickname:    userName,
+				SubCommentCount: int64(commentNum),
 			},
 			ForumID:  forumID,
 			SubCount: commentNum,
@@ -124,6 +140,7 @@ func parseSubCommentsFromHTML(pageContent string, noteID string, parentCommentID
 				NoteID:          noteID,
 				CommentID:       spid,
 				ParentCommentID: parentCommentID,
+				RootCommentID:   parentCommentID,
 				Content:         content,
 				CreateTime:      0,
 				LikeCount:       0,
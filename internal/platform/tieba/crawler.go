@@ -114,6 +114,7 @@ func (c *Crawler) runSearch(ctx context.Context, req crawler.Request) (crawler.R
 		page := startPage
 		for out.Succeeded+out.Failed < maxNotes {
 			searchURL := buildSearchURL(kw, page)
+			crawler.ProgressFrom(ctx).SetStage(kw, page)
 			res, err := c.client.FetchHTML(ctx, searchURL)
 			if err != nil {
 				return out, err
@@ -252,7 +253,7 @@ func (c *Crawler) fetchAndSaveThread(ctx context.Context, platform string, input
 	if noteID == "" {
 		noteID = fmt.Sprintf("tieba_%d", time.Now().UnixNano())
 	}
-	if err := store.SaveNoteDetail(noteID, record); err != nil {
+	if err := store.SaveNoteDetailContext(ctx, noteID, record); err != nil {
 		logger.Error("tieba save note failed", "note_id", noteID, "err", err)
 		return err
 	}
@@ -274,23 +275,14 @@ func (c *Crawler) fetchAndSaveThread(ctx context.Context, platform string, input
 		if err != nil {
 			logger.Error("tieba fetch comments failed", "note_id", noteID, "thread_id", threadID, "err", err)
 		} else if len(comments) > 0 {
+			unified := toUnifiedComments(noteID, comments)
 			switch config.AppConfig.SaveDataOption {
 			case "csv":
 				items := make([]any, 0, len(comments))
 				globalItems := make([]any, 0, len(comments))
 				for i := range comments {
 					items = append(items, &comments[i])
-					globalItems = append(globalItems, &store.UnifiedComment{
-						Platform:        "tieba",
-						NoteID:          noteID,
-						CommentID:       comments[i].CommentID,
-						ParentCommentID: comments[i].ParentCommentID,
-						Content:         comments[i].Content,
-						CreateTime:      comments[i].CreateTime,
-						LikeCount:       comments[i].LikeCount,
-						UserID:          comments[i].UserID,
-						UserNickname:    comments[i].UserNickname,
-					})
+					globalItems = append(globalItems, unified[i])
 				}
 				if _, err := store.AppendUniqueCommentsCSV(
 					noteID,
@@ -312,17 +304,7 @@ func (c *Crawler) fetchAndSaveThread(ctx context.Context, platform string, input
 			case "xlsx_book":
 				globalItems := make([]any, 0, len(comments))
 				for i := range comments {
-					globalItems = append(globalItems, &store.UnifiedComment{
-						Platform:        "tieba",
-						NoteID:          noteID,
-						CommentID:       comments[i].CommentID,
-						ParentCommentID: comments[i].ParentCommentID,
-						Content:         comments[i].Content,
-						CreateTime:      comments[i].CreateTime,
-						LikeCount:       comments[i].LikeCount,
-						UserID:          comments[i].UserID,
-						UserNickname:    comments[i].UserNickname,
-					})
+					globalItems = append(globalItems, unified[i])
 				}
 				if _, err := store.AppendUniqueGlobalCommentsBook(
 					globalItems,
@@ -343,17 +325,7 @@ func (c *Crawler) fetchAndSaveThread(ctx context.Context, platform string, input
 				globalItems := make([]any, 0, len(comments))
 				for i := range comments {
 					items = append(items, &comments[i])
-					globalItems = append(globalItems, &store.UnifiedComment{
-						Platform:        "tieba",
-						NoteID:          noteID,
-						CommentID:       comments[i].CommentID,
-						ParentCommentID: comments[i].ParentCommentID,
-						Content:         comments[i].Content,
-						CreateTime:      comments[i].CreateTime,
-						LikeCount:       comments[i].LikeCount,
-						UserID:          comments[i].UserID,
-						UserNickname:    comments[i].UserNickname,
-					})
+					globalItems = append(globalItems, unified[i])
 				}
 				if _, err := store.AppendUniqueCommentsXLSX(
 					noteID,
@@ -377,17 +349,7 @@ func (c *Crawler) fetchAndSaveThread(ctx context.Context, platform string, input
 				globalItems := make([]any, 0, len(comments))
 				for i := range comments {
 					items = append(items, comments[i])
-					globalItems = append(globalItems, &store.UnifiedComment{
-						Platform:        "tieba",
-						NoteID:          noteID,
-						CommentID:       comments[i].CommentID,
-						ParentCommentID: comments[i].ParentCommentID,
-						Content:         comments[i].Content,
-						CreateTime:      comments[i].CreateTime,
-						LikeCount:       comments[i].LikeCount,
-						UserID:          comments[i].UserID,
-						UserNickname:    comments[i].UserNickname,
-					})
+					globalItems = append(globalItems, unified[i])
 				}
 				if _, err := store.AppendUniqueCommentsJSONL(
 					noteID,
@@ -404,6 +366,12 @@ func (c *Crawler) fetchAndSaveThread(ctx context.Context, platform string, input
 				}
 			}
 		}
+		reported := parseReportedReplyCount(res.Body)
+		if cov, err := store.RecordCommentCoverage("tieba", noteID, pageURL, reported, len(comments)); err != nil {
+			logger.Error("tieba save comment coverage failed", "note_id", noteID, "err", err)
+		} else {
+			logger.Info("tieba comment coverage", "note_id", noteID, "fetched", cov.FetchedCount, "reported", cov.ReportedCount, "ratio", cov.Ratio)
+		}
 	}
 	return nil
 }
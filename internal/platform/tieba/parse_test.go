@@ -26,3 +26,13 @@ func TestParseThreadID(t *testing.T) {
 		}
 	}
 }
+
+func TestParseReportedReplyCount(t *testing.T) {
+	page := `<li class="l_reply_num" style="margin-left:8px" ><span class="red" style="margin-right:3px">128</span>回复贴，共<span class="red">3</span>页</li>`
+	if got := parseReportedReplyCount(page); got != 128 {
+		t.Fatalf("count=%d", got)
+	}
+	if got := parseReportedReplyCount("<html></html>"); got != -1 {
+		t.Fatalf("missing count=%d", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"media-crawler-go/internal/config"
 	"media-crawler-go/internal/crawler"
+	"media-crawler-go/internal/metrics"
 	"media-crawler-go/internal/proxy"
 	"net/http"
 	"strings"
@@ -34,6 +35,7 @@ func NewClient() *Client {
 		Timeout:   time.Duration(timeoutSec) * time.Second,
 	}
 	rc := resty.NewWithClient(hc)
+	metrics.InstrumentResty(rc, "weibo")
 	rc.SetBaseURL("https://m.weibo.cn")
 	rc.SetHeaders(map[string]string{
 		"accept":          "application/json, text/plain, */*",
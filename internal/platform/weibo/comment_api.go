@@ -33,6 +33,9 @@ type hotflowComment struct {
 	Source      string          `json:"source"`
 	User        hotflowUser     `json:"user"`
 	Comments    []hotflowComment `json:"comments"`
+	// ReplyComment is the comment a sub comment replies to when it is not
+	// the root.
+	ReplyComment *hotflowComment `json:"reply_comment"`
 }
 
 type hotflowUser struct {
@@ -138,16 +141,34 @@ func fetchAllNoteComments(ctx context.Context, client commentClient, noteID stri
 }
 
 func toComment(noteID string, it hotflowComment) Comment {
-	return Comment{
+	c := Comment{
 		NoteID:          noteID,
-		CommentID:       strings.TrimSpace(fmt.Sprintf("%v", it.ID)),
-		ParentCommentID: strings.TrimSpace(fmt.Sprintf("%v", it.RootID)),
+		CommentID:       anyString(it.ID),
+		ParentCommentID: anyString(it.RootID),
 		Content:         stripHTML(it.Text),
 		CreateTime:      parseWeiboTime(it.CreatedAt),
 		LikeCount:       it.LikeCount,
-		UserID:          strings.TrimSpace(fmt.Sprintf("%v", it.User.ID)),
+		UserID:          anyString(it.User.ID),
 		UserNickname:    strings.TrimSpace(it.User.ScreenName),
+		SubCommentCount: it.TotalNumber,
 	}
+	// rootid is the comment's own id for top-level comments.
+	if c.ParentCommentID != c.CommentID {
+		c.RootCommentID = c.ParentCommentID
+	}
+	if rc := it.ReplyComment; rc != nil {
+		c.ReplyToCommentID = anyString(rc.ID)
+		c.ReplyToUserID = anyString(rc.User.ID)
+		c.ReplyToUserNickname = strings.TrimSpace(rc.User.ScreenName)
+	}
+	return c
+}
+
+func anyString(v any) string {
+	if v == nil {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprintf("%v", v))
 }
 
 var reHTMLTags = regexp.MustCompile(`<[^>]+>`)
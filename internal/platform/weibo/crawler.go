@@ -63,6 +63,8 @@ func (c *Crawler) Run(ctx context.Context, req crawler.Request) (crawler.Result,
 		res, err = c.runSearch(ctx, req)
 	case crawler.ModeCreator:
 		res, err = c.runCreator(ctx, req)
+	case crawler.ModeTrending:
+		res, err = c.runTrending(ctx, req)
 	default:
 		res, err = c.runDetail(ctx, req)
 	}
@@ -111,12 +113,16 @@ func (c *Crawler) fetchAndSaveStatus(ctx context.Context, id string, noteID stri
 		logger.Error("decode status data failed", "note_id", noteID, "err", err)
 		return err
 	}
-	if err := store.SaveNoteDetail(noteID, data); err != nil {
+	if err := store.SaveNoteDetailContext(ctx, noteID, data); err != nil {
 		logger.Error("save note failed", "note_id", noteID, "err", err)
 		return err
 	}
 	logger.Info("note saved", "note_id", noteID)
 
+	if config.AppConfig.EnableGetReposts {
+		c.fetchAndSaveReposts(ctx, noteID, data)
+	}
+
 	if !config.AppConfig.EnableGetComments {
 		if config.AppConfig.EnableGetMedias {
 			c.downloadMedias(noteID, data)
@@ -133,29 +139,22 @@ func (c *Crawler) fetchAndSaveStatus(ctx context.Context, id string, noteID stri
 	)
 	if err != nil {
 		logger.Error("fetch weibo comments failed", "note_id", noteID, "err", err)
+		recordCommentCoverage(noteID, data, 0)
 		return nil
 	}
+	defer recordCommentCoverage(noteID, data, len(comments))
 	if len(comments) == 0 {
 		return nil
 	}
 
+	unified := toUnifiedComments(noteID, comments)
 	switch config.AppConfig.SaveDataOption {
 	case "csv":
 		items := make([]any, 0, len(comments))
 		globalItems := make([]any, 0, len(comments))
 		for i := range comments {
 			items = append(items, &comments[i])
-			globalItems = append(globalItems, &store.UnifiedComment{
-				Platform:        "weibo",
-				NoteID:          noteID,
-				CommentID:       comments[i].CommentID,
-				ParentCommentID: comments[i].ParentCommentID,
-				Content:         comments[i].Content,
-				CreateTime:      comments[i].CreateTime,
-				LikeCount:       comments[i].LikeCount,
-				UserID:          comments[i].UserID,
-				UserNickname:    comments[i].UserNickname,
-			})
+			globalItems = append(globalItems, unified[i])
 		}
 		if _, err := store.AppendUniqueCommentsCSV(
 			noteID,
@@ -177,17 +176,7 @@ func (c *Crawler) fetchAndSaveStatus(ctx context.Context, id string, noteID stri
 	case "xlsx_book":
 		globalItems := make([]any, 0, len(comments))
 		for i := range comments {
-			globalItems = append(globalItems, &store.UnifiedComment{
-				Platform:        "weibo",
-				NoteID:          noteID,
-				CommentID:       comments[i].CommentID,
-				ParentCommentID: comments[i].ParentCommentID,
-				Content:         comments[i].Content,
-				CreateTime:      comments[i].CreateTime,
-				LikeCount:       comments[i].LikeCount,
-				UserID:          comments[i].UserID,
-				UserNickname:    comments[i].UserNickname,
-			})
+			globalItems = append(globalItems, unified[i])
 		}
 		if _, err := store.AppendUniqueGlobalCommentsBook(
 			globalItems,
@@ -208,17 +197,7 @@ func (c *Crawler) fetchAndSaveStatus(ctx context.Context, id string, noteID stri
 		globalItems := make([]any, 0, len(comments))
 		for i := range comments {
 			items = append(items, &comments[i])
-			globalItems = append(globalItems, &store.UnifiedComment{
-				Platform:        "weibo",
-				NoteID:          noteID,
-				CommentID:       comments[i].CommentID,
-				ParentCommentID: comments[i].ParentCommentID,
-				Content:         comments[i].Content,
-				CreateTime:      comments[i].CreateTime,
-				LikeCount:       comments[i].LikeCount,
-				UserID:          comments[i].UserID,
-				UserNickname:    comments[i].UserNickname,
-			})
+			globalItems = append(globalItems, unified[i])
 		}
 		if _, err := store.AppendUniqueCommentsXLSX(
 			noteID,
@@ -242,17 +221,7 @@ func (c *Crawler) fetchAndSaveStatus(ctx context.Context, id string, noteID stri
 		globalItems := make([]any, 0, len(comments))
 		for i := range comments {
 			items = append(items, comments[i])
-			globalItems = append(globalItems, &store.UnifiedComment{
-				Platform:        "weibo",
-				NoteID:          noteID,
-				CommentID:       comments[i].CommentID,
-				ParentCommentID: comments[i].ParentCommentID,
-				Content:         comments[i].Content,
-				CreateTime:      comments[i].CreateTime,
-				LikeCount:       comments[i].LikeCount,
-				UserID:          comments[i].UserID,
-				UserNickname:    comments[i].UserNickname,
-			})
+			globalItems = append(globalItems, unified[i])
 		}
 		if _, err := store.AppendUniqueCommentsJSONL(
 			noteID,
@@ -275,6 +244,87 @@ func (c *Crawler) fetchAndSaveStatus(ctx context.Context, id string, noteID stri
 	return nil
 }
 
+// recordCommentCoverage compares the comments stored for a status with its
+// comments_count.
+func recordCommentCoverage(noteID string, data any, fetched int) {
+	reported := int64(-1)
+	if m, ok := data.(map[string]any); ok {
+		switch v := m["comments_count"].(type) {
+		case float64:
+			reported = int64(v)
+		case string:
+			reported = crawler.ParseCount(v)
+		}
+	}
+	cov, err := store.RecordCommentCoverage("weibo", noteID, noteID, reported, fetched)
+	if err != nil {
+		logger.Error("save comment coverage failed", "note_id", noteID, "err", err)
+		return
+	}
+	logger.Info("comment coverage", "note_id", noteID, "fetched", cov.FetchedCount, "reported", cov.ReportedCount, "ratio", cov.Ratio)
+}
+
+func (c *Crawler) fetchAndSaveReposts(ctx context.Context, noteID string, data any) {
+	rc, ok := c.client.(repostClient)
+	if !ok {
+		return
+	}
+	reposts, pids, err := fetchAllReposts(
+		ctx,
+		rc,
+		noteID,
+		config.AppConfig.WBMaxReposts,
+		config.AppConfig.WBMaxRepostPages,
+		config.AppConfig.CrawlerMaxSleepSec,
+	)
+	if err != nil {
+		logger.Error("fetch weibo reposts failed", "note_id", noteID, "err", err)
+	}
+	if len(reposts) == 0 {
+		return
+	}
+	tree := resolveRepostTree(repostRootFromStatus(noteID, data), reposts, pids)
+
+	items := make([]any, 0, len(reposts))
+	for i := range reposts {
+		items = append(items, &reposts[i])
+	}
+	if _, err := store.AppendUniqueNoteReposts(
+		noteID,
+		items,
+		func(item any) (string, error) { return item.(*Repost).RepostID, nil },
+		(&Repost{}).CSVHeader(),
+		func(item any) ([]string, error) { return item.(*Repost).ToCSV(), nil },
+	); err != nil {
+		logger.Error("save weibo reposts failed", "note_id", noteID, "err", err)
+	}
+	if err := store.SaveNoteRepostTree(noteID, tree); err != nil {
+		logger.Error("save weibo repost tree failed", "note_id", noteID, "err", err)
+	}
+	logger.Info("weibo reposts saved", "note_id", noteID, "count", len(reposts))
+}
+
+func repostRootFromStatus(noteID string, data any) RepostNode {
+	root := RepostNode{ID: noteID}
+	m, ok := data.(map[string]any)
+	if !ok {
+		return root
+	}
+	if text, ok := m["text"].(string); ok {
+		root.Content = stripHTML(text)
+	}
+	if createdAt, ok := m["created_at"].(string); ok {
+		root.CreateTime = parseWeiboTime(createdAt)
+	}
+	if user, ok := m["user"].(map[string]any); ok {
+		root.UserID = anyID(user["id"])
+		if name, ok := user["screen_name"].(string); ok {
+			root.UserNickname = strings.TrimSpace(name)
+		}
+	}
+	return root
+}
+
 func (c *Crawler) downloadMedias(noteID string, data any) {
 	urls, filenames := ExtractWeiboMediaURLs(noteID, data)
 	if len(urls) == 0 {
@@ -327,6 +377,7 @@ func (c *Crawler) runSearch(ctx context.Context, req crawler.Request) (crawler.R
 	for _, kw := range keywords {
 		page := startPage
 		for out.Succeeded+out.Failed < maxNotes {
+			crawler.ProgressFrom(ctx).SetStage(kw, page)
 			res, err := c.client.SearchByKeyword(ctx, kw, page, searchType)
 			if err != nil {
 				return out, err
@@ -401,6 +452,13 @@ func (c *Crawler) runCreator(ctx context.Context, req crawler.Request) (crawler.
 		if err := store.SaveCreatorProfile(creatorID, profile); err != nil {
 			return out, err
 		}
+		if config.AppConfig.EnableGetCreatorRelations {
+			name := ""
+			if ui, ok := profile.(map[string]any); ok {
+				name, _ = ui["screen_name"].(string)
+			}
+			c.crawlRelations(ctx, creatorID, name)
+		}
 
 		containerID := "107603" + creatorID
 		sinceID := "0"
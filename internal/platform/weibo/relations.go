@@ -0,0 +1,163 @@
+package weibo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"media-crawler-go/internal/logger"
+	"media-crawler-go/internal/store"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Relation containers of m.weibo.cn: "followers" lists the accounts a user
+// follows (关注), "fans" the accounts following the user (粉丝).
+const (
+	relationFollowings = "followers"
+	relationFans       = "fans"
+)
+
+// GetRelationPage returns one page of a user's follow or fans container. The
+// endpoint answers ok=0 past the last page, which is reported as an empty
+// page.
+func (c *Client) GetRelationPage(ctx context.Context, uid string, relation string, page int) (map[string]any, error) {
+	if err := c.ensureProxy(ctx); err != nil {
+		return nil, err
+	}
+	uid = strings.TrimSpace(uid)
+	if uid == "" {
+		return nil, fmt.Errorf("empty creator id")
+	}
+	if page <= 0 {
+		page = 1
+	}
+	var out GetIndexResponse
+	resp, err := c.httpClient.R().
+		SetContext(ctx).
+		SetQueryParams(map[string]string{
+			"containerid": fmt.Sprintf("231051_-_%s_-_%s", relation, uid),
+			"page":        strconv.Itoa(page),
+			"since_id":    strconv.Itoa(page),
+		}).
+		SetResult(&out).
+		Get("/api/container/getIndex")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, crawler.NewHTTPStatusError("weibo", "/api/container/getIndex", resp.StatusCode(), resp.String())
+	}
+	if out.Ok != 1 {
+		return map[string]any{}, nil
+	}
+	var data map[string]any
+	if err := json.Unmarshal(out.Data, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+type relationClient interface {
+	GetRelationPage(context.Context, string, string, int) (map[string]any, error)
+}
+
+type relationUser struct {
+	ID   string
+	Name string
+}
+
+// extractRelationUsers collects the users of a relation container page
+// (cards[].card_group[].user).
+func extractRelationUsers(data map[string]any) []relationUser {
+	cards, _ := data["cards"].([]any)
+	var out []relationUser
+	for _, c := range cards {
+		card, _ := c.(map[string]any)
+		group, _ := card["card_group"].([]any)
+		for _, g := range group {
+			m, _ := g.(map[string]any)
+			user, _ := m["user"].(map[string]any)
+			if user == nil {
+				continue
+			}
+			id := anyID(user["id"])
+			if id == "" {
+				continue
+			}
+			name, _ := user["screen_name"].(string)
+			out = append(out, relationUser{ID: id, Name: strings.TrimSpace(name)})
+		}
+	}
+	return out
+}
+
+// fetchRelationEdges pages through one relation container of uid and converts
+// it to follow edges, stopping after max entries or maxPages pages.
+func fetchRelationEdges(ctx context.Context, client relationClient, uid string, name string, relation string, max int, maxPages int, sleepSec int) ([]store.CreatorEdge, error) {
+	if max <= 0 {
+		return nil, nil
+	}
+	if maxPages <= 0 {
+		maxPages = 50
+	}
+	out := make([]store.CreatorEdge, 0, 64)
+	seen := map[string]struct{}{}
+	for page := 1; page <= maxPages && len(out) < max; page++ {
+		data, err := client.GetRelationPage(ctx, uid, relation, page)
+		if err != nil {
+			return out, err
+		}
+		users := extractRelationUsers(data)
+		added := 0
+		for _, u := range users {
+			if _, ok := seen[u.ID]; ok || u.ID == uid {
+				continue
+			}
+			seen[u.ID] = struct{}{}
+			added++
+			e := store.CreatorEdge{FromID: uid, FromName: name, ToID: u.ID, ToName: u.Name, Type: store.EdgeTypeFollowing}
+			if relation == relationFans {
+				e = store.CreatorEdge{FromID: u.ID, FromName: u.Name, ToID: uid, ToName: name, Type: store.EdgeTypeFollower}
+			}
+			out = append(out, e)
+			if len(out) >= max {
+				break
+			}
+		}
+		if added == 0 {
+			break
+		}
+		if sleepSec > 0 {
+			select {
+			case <-ctx.Done():
+				return out, ctx.Err()
+			case <-time.After(time.Duration(sleepSec) * time.Second):
+			}
+		}
+	}
+	return out, nil
+}
+
+// crawlRelations stores the follow edges of a creator's followings and fans
+// (up to CRAWLER_MAX_RELATIONS_COUNT each).
+func (c *Crawler) crawlRelations(ctx context.Context, uid string, name string) {
+	rc, ok := c.client.(relationClient)
+	if !ok {
+		return
+	}
+	for _, relation := range []string{relationFollowings, relationFans} {
+		edges, err := fetchRelationEdges(ctx, rc, uid, name, relation, config.AppConfig.CrawlerMaxRelationsCount, 0, config.AppConfig.CrawlerMaxSleepSec)
+		if err != nil {
+			logger.Warn("weibo relation list incomplete", "creator_id", uid, "relation", relation, "collected", len(edges), "err", err)
+		}
+		if _, err := store.AppendCreatorEdges(edges); err != nil {
+			logger.Error("save creator edges failed", "creator_id", uid, "relation", relation, "err", err)
+			continue
+		}
+		logger.Info("weibo relations saved", "creator_id", uid, "relation", relation, "edges", len(edges))
+	}
+}
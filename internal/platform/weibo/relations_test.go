@@ -0,0 +1,46 @@
+package weibo
+
+import (
+	"context"
+	"media-crawler-go/internal/store"
+	"testing"
+)
+
+type fakeRelationClient struct {
+	pages []int
+}
+
+func (f *fakeRelationClient) GetRelationPage(ctx context.Context, uid string, relation string, page int) (map[string]any, error) {
+	f.pages = append(f.pages, page)
+	if page > 2 {
+		return map[string]any{}, nil
+	}
+	users := []any{
+		map[string]any{"user": map[string]any{"id": float64(page*10 + 1), "screen_name": "a"}},
+		map[string]any{"user": map[string]any{"id": float64(page*10 + 2), "screen_name": "b"}},
+		map[string]any{"title": "not a user"},
+	}
+	return map[string]any{"cards": []any{map[string]any{"card_group": users}}}, nil
+}
+
+func TestFetchRelationEdges(t *testing.T) {
+	fc := &fakeRelationClient{}
+	edges, err := fetchRelationEdges(context.Background(), fc, "7", "me", relationFollowings, 10, 0, 0)
+	if err != nil {
+		t.Fatalf("followings: %v", err)
+	}
+	if len(edges) != 4 || len(fc.pages) != 3 {
+		t.Fatalf("expected 4 edges and paging to stop on an empty page, got %d edges, pages %v", len(edges), fc.pages)
+	}
+	if e := edges[0]; e.FromID != "7" || e.ToID != "11" || e.ToName != "a" || e.Type != store.EdgeTypeFollowing {
+		t.Fatalf("unexpected following edge: %+v", e)
+	}
+
+	edges, err = fetchRelationEdges(context.Background(), &fakeRelationClient{}, "7", "me", relationFans, 3, 0, 0)
+	if err != nil || len(edges) != 3 {
+		t.Fatalf("expected max to cap fans at 3: edges=%d err=%v", len(edges), err)
+	}
+	if e := edges[2]; e.FromID != "21" || e.ToID != "7" || e.ToName != "me" || e.Type != store.EdgeTypeFollower {
+		t.Fatalf("unexpected follower edge: %+v", e)
+	}
+}
@@ -0,0 +1,157 @@
+package weibo
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Repost struct {
+	NoteID       string `json:"note_id"`
+	RepostID     string `json:"repost_id"`
+	ParentID     string `json:"parent_id"`
+	Depth        int    `json:"depth"`
+	Content      string `json:"content"`
+	CreateTime   int64  `json:"create_time"`
+	RepostCount  int64  `json:"repost_count"`
+	LikeCount    int64  `json:"like_count"`
+	UserID       string `json:"user_id"`
+	UserNickname string `json:"user_nickname"`
+}
+
+func (r *Repost) CSVHeader() []string {
+	return []string{"note_id", "repost_id", "parent_id", "depth", "content", "create_time", "repost_count", "like_count", "user_id", "user_nickname"}
+}
+
+func (r *Repost) ToCSV() []string {
+	return []string{
+		r.NoteID,
+		r.RepostID,
+		r.ParentID,
+		strconv.Itoa(r.Depth),
+		r.Content,
+		strconv.FormatInt(r.CreateTime, 10),
+		strconv.FormatInt(r.RepostCount, 10),
+		strconv.FormatInt(r.LikeCount, 10),
+		r.UserID,
+		r.UserNickname,
+	}
+}
+
+// RepostNode is one status in the repost tree; the root node is the crawled
+// status itself (depth 0).
+type RepostNode struct {
+	ID           string        `json:"id"`
+	UserID       string        `json:"user_id,omitempty"`
+	UserNickname string        `json:"user_nickname,omitempty"`
+	Content      string        `json:"content,omitempty"`
+	CreateTime   int64         `json:"create_time,omitempty"`
+	Depth        int           `json:"depth"`
+	Children     []*RepostNode `json:"children,omitempty"`
+}
+
+// repostChainNick returns the screen name of the user whose repost was
+// reposted, i.e. the first "//@nick:" segment of a repost text.
+func repostChainNick(content string) string {
+	idx := strings.Index(content, "//@")
+	if idx < 0 {
+		return ""
+	}
+	rest := content[idx+3:]
+	end := strings.IndexAny(rest, ":： ")
+	if end < 0 {
+		return ""
+	}
+	return strings.TrimSpace(rest[:end])
+}
+
+// resolveRepostTree fills ParentID and Depth for the reposts of one root
+// status and returns the tree. The repost timeline lists every repost of the
+// root, so parents are recovered from the "pid" hint when the API provides it
+// and otherwise from the "//@nick:" chain in the text, matched against the
+// latest earlier repost by that user. Anything unresolved hangs off the root.
+func resolveRepostTree(root RepostNode, reposts []Repost, pids map[string]string) *RepostNode {
+	sort.SliceStable(reposts, func(i, j int) bool { return reposts[i].CreateTime < reposts[j].CreateTime })
+
+	known := make(map[string]int, len(reposts))
+	for i := range reposts {
+		known[reposts[i].RepostID] = i
+	}
+	byNick := map[string][]int{}
+	for i := range reposts {
+		if n := reposts[i].UserNickname; n != "" {
+			byNick[n] = append(byNick[n], i)
+		}
+	}
+
+	for i := range reposts {
+		r := &reposts[i]
+		r.NoteID = root.ID
+		r.ParentID = root.ID
+		if pid := pids[r.RepostID]; pid != "" && pid != r.RepostID {
+			if _, ok := known[pid]; ok {
+				r.ParentID = pid
+				continue
+			}
+		}
+		nick := repostChainNick(r.Content)
+		if nick == "" || nick == root.UserNickname {
+			continue
+		}
+		for _, j := range byNick[nick] {
+			if j == i || reposts[j].CreateTime > r.CreateTime {
+				continue
+			}
+			r.ParentID = reposts[j].RepostID
+		}
+	}
+
+	nodes := make(map[string]*RepostNode, len(reposts)+1)
+	rootNode := root
+	rootNode.Depth = 0
+	rootNode.Children = nil
+	nodes[root.ID] = &rootNode
+	for i := range reposts {
+		r := reposts[i]
+		nodes[r.RepostID] = &RepostNode{
+			ID:           r.RepostID,
+			UserID:       r.UserID,
+			UserNickname: r.UserNickname,
+			Content:      r.Content,
+			CreateTime:   r.CreateTime,
+		}
+	}
+	for i := range reposts {
+		reposts[i].Depth = repostDepth(reposts, known, i, root.ID)
+		n := nodes[reposts[i].RepostID]
+		n.Depth = reposts[i].Depth
+		parent := nodes[reposts[i].ParentID]
+		if parent == nil || reposts[i].Depth == 1 {
+			parent = &rootNode
+		}
+		parent.Children = append(parent.Children, n)
+	}
+	return &rootNode
+}
+
+// repostDepth walks the parent chain; a cycle (possible with ambiguous
+// nicknames) is cut by re-attaching the repost to the root.
+func repostDepth(reposts []Repost, known map[string]int, i int, rootID string) int {
+	depth := 1
+	seen := map[int]struct{}{i: {}}
+	cur := reposts[i].ParentID
+	for cur != rootID {
+		j, ok := known[cur]
+		if !ok {
+			break
+		}
+		if _, loop := seen[j]; loop {
+			reposts[i].ParentID = rootID
+			return 1
+		}
+		seen[j] = struct{}{}
+		depth++
+		cur = reposts[j].ParentID
+	}
+	return depth
+}
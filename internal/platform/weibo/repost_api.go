@@ -0,0 +1,165 @@
+package weibo
+
+import (
+	"context"
+	"fmt"
+	"media-crawler-go/internal/crawler"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type repostTimelineResp struct {
+	Ok   int                `json:"ok"`
+	Msg  string             `json:"msg"`
+	Data repostTimelineData `json:"data"`
+}
+
+type repostTimelineData struct {
+	Data        []repostStatus `json:"data"`
+	TotalNumber int64          `json:"total_number"`
+	Max         int            `json:"max"`
+}
+
+type repostStatus struct {
+	ID             any         `json:"id"`
+	PID            any         `json:"pid"`
+	Text           string      `json:"text"`
+	RawText        string      `json:"raw_text"`
+	CreatedAt      string      `json:"created_at"`
+	RepostsCount   int64       `json:"reposts_count"`
+	AttitudesCount int64       `json:"attitudes_count"`
+	User           hotflowUser `json:"user"`
+}
+
+// GetRepostTimeline returns one page of reposts of a status. The endpoint
+// answers ok=0 once the timeline is exhausted (or the status has no reposts),
+// which is reported as an empty page.
+func (c *Client) GetRepostTimeline(ctx context.Context, noteID string, page int) (repostTimelineData, error) {
+	if err := c.ensureProxy(ctx); err != nil {
+		return repostTimelineData{}, err
+	}
+	noteID = strings.TrimSpace(noteID)
+	if noteID == "" {
+		return repostTimelineData{}, fmt.Errorf("empty note id")
+	}
+	if page <= 0 {
+		page = 1
+	}
+	var out repostTimelineResp
+	r, err := c.httpClient.R().
+		SetContext(ctx).
+		SetHeader("referer", "https://m.weibo.cn/detail/"+noteID).
+		SetQueryParams(map[string]string{
+			"id":   noteID,
+			"page": strconv.Itoa(page),
+		}).
+		SetResult(&out).
+		Get("/api/statuses/repostTimeline")
+	if err != nil {
+		return repostTimelineData{}, err
+	}
+	if r.StatusCode() != http.StatusOK {
+		return repostTimelineData{}, crawler.NewHTTPStatusError("weibo", "/api/statuses/repostTimeline", r.StatusCode(), r.String())
+	}
+	if out.Ok != 1 {
+		return repostTimelineData{}, nil
+	}
+	return out.Data, nil
+}
+
+type repostClient interface {
+	GetRepostTimeline(context.Context, string, int) (repostTimelineData, error)
+}
+
+// fetchAllReposts pages through the repost timeline of noteID until max
+// reposts or maxPages pages were collected (<= 0 means the WB_MAX_REPOSTS
+// and WB_MAX_REPOST_PAGES defaults, 200 and 20). The returned pids map holds
+// the parent hint of each repost when the API provides one.
+func fetchAllReposts(ctx context.Context, client repostClient, noteID string, max int, maxPages int, sleepSec int) ([]Repost, map[string]string, error) {
+	noteID = strings.TrimSpace(noteID)
+	if client == nil || noteID == "" {
+		return nil, nil, nil
+	}
+	if max <= 0 {
+		max = 200
+	}
+	if maxPages <= 0 {
+		maxPages = 20
+	}
+	if sleepSec < 0 {
+		sleepSec = 0
+	}
+
+	out := make([]Repost, 0, 64)
+	pids := map[string]string{}
+	seen := map[string]struct{}{}
+	for page := 1; page <= maxPages && len(out) < max; page++ {
+		data, err := client.GetRepostTimeline(ctx, noteID, page)
+		if err != nil {
+			return out, pids, err
+		}
+		if len(data.Data) == 0 {
+			break
+		}
+		for _, it := range data.Data {
+			r := toRepost(noteID, it)
+			if r.RepostID == "" || r.RepostID == noteID {
+				continue
+			}
+			if _, ok := seen[r.RepostID]; ok {
+				continue
+			}
+			seen[r.RepostID] = struct{}{}
+			if pid := anyID(it.PID); pid != "" && pid != "0" {
+				pids[r.RepostID] = pid
+			}
+			out = append(out, r)
+			if len(out) >= max {
+				break
+			}
+		}
+		if data.Max > 0 && page >= data.Max {
+			break
+		}
+		if sleepSec > 0 {
+			select {
+			case <-ctx.Done():
+				return out, pids, ctx.Err()
+			case <-time.After(time.Duration(sleepSec) * time.Second):
+			}
+		}
+	}
+	return out, pids, nil
+}
+
+func toRepost(noteID string, it repostStatus) Repost {
+	content := strings.TrimSpace(it.RawText)
+	if content == "" {
+		content = stripHTML(it.Text)
+	}
+	return Repost{
+		NoteID:       noteID,
+		RepostID:     anyID(it.ID),
+		Content:      content,
+		CreateTime:   parseWeiboTime(it.CreatedAt),
+		RepostCount:  it.RepostsCount,
+		LikeCount:    it.AttitudesCount,
+		UserID:       anyID(it.User.ID),
+		UserNickname: strings.TrimSpace(it.User.ScreenName),
+	}
+}
+
+func anyID(v any) string {
+	switch vv := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatInt(int64(vv), 10)
+	case string:
+		return strings.TrimSpace(vv)
+	default:
+		return strings.TrimSpace(fmt.Sprintf("%v", vv))
+	}
+}
@@ -0,0 +1,171 @@
+package weibo
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveRepostTree(t *testing.T) {
+	root := RepostNode{ID: "100", UserNickname: "author"}
+	reposts := []Repost{
+		{RepostID: "104", Content: "pid hint", CreateTime: 40, UserNickname: "dave"},
+		{RepostID: "101", Content: "first", CreateTime: 10, UserNickname: "alice"},
+		{RepostID: "102", Content: "lol//@alice:first", CreateTime: 20, UserNickname: "bob"},
+		{RepostID: "103", Content: "deep//@bob:lol//@alice:first", CreateTime: 30, UserNickname: "carol"},
+		{RepostID: "105", Content: "to author//@author:orig", CreateTime: 50, UserNickname: "erin"},
+		{RepostID: "106", Content: "unknown//@nobody:x", CreateTime: 60, UserNickname: "frank"},
+	}
+	tree := resolveRepostTree(root, reposts, map[string]string{"104": "102"})
+
+	want := map[string]struct {
+		parent string
+		depth  int
+	}{
+		"101": {"100", 1},
+		"102": {"101", 2},
+		"103": {"102", 3},
+		"104": {"102", 3},
+		"105": {"100", 1},
+		"106": {"100", 1},
+	}
+	for _, r := range reposts {
+		w := want[r.RepostID]
+		if r.ParentID != w.parent || r.Depth != w.depth || r.NoteID != "100" {
+			t.Fatalf("repost %s: parent=%s depth=%d note=%s, want parent=%s depth=%d", r.RepostID, r.ParentID, r.Depth, r.NoteID, w.parent, w.depth)
+		}
+	}
+	if tree.ID != "100" || len(tree.Children) != 3 {
+		t.Fatalf("unexpected root children: %+v", tree.Children)
+	}
+	alice := tree.Children[0]
+	if alice.ID != "101" || len(alice.Children) != 1 || len(alice.Children[0].Children) != 2 {
+		t.Fatalf("unexpected subtree: %+v", alice)
+	}
+}
+
+func TestResolveRepostTreeBreaksCycles(t *testing.T) {
+	reposts := []Repost{
+		{RepostID: "1", CreateTime: 10, UserNickname: "a"},
+		{RepostID: "2", CreateTime: 10, UserNickname: "b"},
+	}
+	tree := resolveRepostTree(RepostNode{ID: "root"}, reposts, map[string]string{"1": "2", "2": "1"})
+	if tree == nil || len(tree.Children) == 0 {
+		t.Fatalf("expected at least one repost attached to root")
+	}
+	for _, r := range reposts {
+		if r.Depth < 1 || r.Depth > 2 {
+			t.Fatalf("unexpected depth for %s: %d", r.RepostID, r.Depth)
+		}
+	}
+}
+
+type fakeClientWithReposts struct {
+	fakeClientWithComments
+	pages []int
+}
+
+func (f *fakeClientWithReposts) GetRepostTimeline(ctx context.Context, noteID string, page int) (repostTimelineData, error) {
+	f.pages = append(f.pages, page)
+	switch page {
+	case 1:
+		return repostTimelineData{Max: 3, Data: []repostStatus{
+			{ID: float64(11), Text: "<span>first</span>", CreatedAt: "Mon Jan 02 15:04:05 +0800 2006", User: hotflowUser{ID: float64(1), ScreenName: "alice"}},
+			{ID: "12", RawText: "again//@alice:first", CreatedAt: "Mon Jan 02 15:05:05 +0800 2006", User: hotflowUser{ID: "2", ScreenName: "bob"}},
+		}}, nil
+	case 2:
+		return repostTimelineData{Max: 3, Data: []repostStatus{
+			{ID: "12", RawText: "duplicate", User: hotflowUser{ID: "2", ScreenName: "bob"}},
+			{ID: "13", RawText: "third", CreatedAt: "Mon Jan 02 15:06:05 +0800 2006", User: hotflowUser{ID: "3", ScreenName: "carol"}},
+		}}, nil
+	default:
+		return repostTimelineData{Max: 3, Data: []repostStatus{
+			{ID: "14", RawText: "over the limit", User: hotflowUser{ID: "4", ScreenName: "dave"}},
+		}}, nil
+	}
+}
+
+func TestFetchAllRepostsDefaults(t *testing.T) {
+	fc := &fakeClientWithReposts{}
+	reposts, _, err := fetchAllReposts(context.Background(), fc, "4KjD8oZ4D", 0, 0, 0)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(reposts) != 4 || len(fc.pages) != 3 {
+		t.Fatalf("zero limits should use the defaults, got %d reposts from pages %v", len(reposts), fc.pages)
+	}
+}
+
+func TestCrawlerRepostsSaved(t *testing.T) {
+	oldwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	tmp := t.TempDir()
+	if err := os.Chdir(tmp); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(oldwd) })
+
+	config.AppConfig = config.Config{
+		Platform:         "weibo",
+		StoreBackend:     "file",
+		SaveDataOption:   "json",
+		DataDir:          "data",
+		EnableGetReposts: true,
+		WBMaxReposts:     3,
+		WBMaxRepostPages: 5,
+	}
+
+	fc := &fakeClientWithReposts{}
+	c := NewCrawlerWithClient(fc)
+	req := crawler.Request{Platform: "weibo", Mode: crawler.ModeDetail, Inputs: []string{"4KjD8oZ4D"}, Concurrency: 1}
+	if _, err := c.Run(context.Background(), req); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(fc.pages) != 2 {
+		t.Fatalf("expected paging to stop at the max-count limit, got pages %v", fc.pages)
+	}
+
+	dir := filepath.Join("data", "weibo", "notes", "4KjD8oZ4D")
+	f, err := os.Open(filepath.Join(dir, "reposts.jsonl"))
+	if err != nil {
+		t.Fatalf("open reposts.jsonl: %v", err)
+	}
+	defer f.Close()
+	got := map[string]Repost{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var r Repost
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			t.Fatalf("decode line: %v", err)
+		}
+		got[r.RepostID] = r
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 reposts, got %d", len(got))
+	}
+	if got["11"].Content != "first" || got["11"].UserID != "1" || got["11"].Depth != 1 {
+		t.Fatalf("unexpected repost 11: %+v", got["11"])
+	}
+	if got["12"].ParentID != "11" || got["12"].Depth != 2 {
+		t.Fatalf("unexpected repost 12: %+v", got["12"])
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "repost_tree.json"))
+	if err != nil {
+		t.Fatalf("read repost_tree.json: %v", err)
+	}
+	var tree RepostNode
+	if err := json.Unmarshal(b, &tree); err != nil {
+		t.Fatalf("decode tree: %v", err)
+	}
+	if len(tree.Children) != 2 || tree.Children[0].ID != "11" || len(tree.Children[0].Children) != 1 {
+		t.Fatalf("unexpected tree: %+v", tree)
+	}
+}
@@ -0,0 +1,270 @@
+package weibo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"media-crawler-go/internal/logger"
+	"media-crawler-go/internal/store"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// hotSearchContainerID is the m.weibo.cn container of the realtime hot search
+// list (微博热搜).
+const hotSearchContainerID = "106003type=25&t=3&disable_hot=1&filter_type=realtimehot"
+
+type TrendingItem struct {
+	SnapshotAt int64  `json:"snapshot_at"`
+	Rank       int    `json:"rank"`
+	Keyword    string `json:"keyword"`
+	HeatValue  int64  `json:"heat_value"`
+	Label      string `json:"label"`
+	Category   string `json:"category"`
+	URL        string `json:"url"`
+}
+
+func (t *TrendingItem) CSVHeader() []string {
+	return []string{"snapshot_at", "rank", "keyword", "heat_value", "label", "category", "url"}
+}
+
+func (t *TrendingItem) ToCSV() []string {
+	return []string{
+		strconv.FormatInt(t.SnapshotAt, 10),
+		strconv.Itoa(t.Rank),
+		t.Keyword,
+		strconv.FormatInt(t.HeatValue, 10),
+		t.Label,
+		t.Category,
+		t.URL,
+	}
+}
+
+func (t *TrendingItem) Key() string {
+	return fmt.Sprintf("%d:%d:%s", t.SnapshotAt, t.Rank, t.Keyword)
+}
+
+func (c *Client) HotSearch(ctx context.Context) (GetIndexResponse, error) {
+	return c.GetIndex(ctx, map[string]string{
+		"containerid": hotSearchContainerID,
+	})
+}
+
+type trendingClient interface {
+	HotSearch(context.Context) (GetIndexResponse, error)
+}
+
+var reHeatDigits = regexp.MustCompile(`\d+`)
+
+// hotSearchLabels maps the badge icon file name of an entry to its label for
+// payloads that do not carry the label text (icon_desc / label_name).
+var hotSearchLabels = []struct{ icon, label string }{
+	{"boil", "沸"},
+	{"fei", "沸"},
+	{"bao", "爆"},
+	{"new", "新"},
+	{"hot", "热"},
+	{"recom", "荐"},
+	{"jian", "荐"},
+	{"shang", "商"},
+}
+
+// ParseHotSearch extracts the ranked hot search entries from the getIndex
+// payload. Pinned entries (置顶, without a numeric rank) and ads are skipped.
+func ParseHotSearch(data map[string]any, snapshotAt int64) []TrendingItem {
+	cards, _ := data["cards"].([]any)
+	var out []TrendingItem
+	for _, c := range cards {
+		card, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+		group, _ := card["card_group"].([]any)
+		for _, g := range group {
+			m, ok := g.(map[string]any)
+			if !ok {
+				continue
+			}
+			keyword := strings.TrimSpace(fmt.Sprintf("%v", m["desc"]))
+			if keyword == "" || keyword == "<nil>" {
+				continue
+			}
+			if promo, _ := m["promotion"].(map[string]any); promo != nil {
+				continue
+			}
+			rank := hotSearchRank(m["pic"])
+			if rank <= 0 {
+				continue
+			}
+			item := TrendingItem{
+				SnapshotAt: snapshotAt,
+				Rank:       rank,
+				Keyword:    keyword,
+				Label:      hotSearchLabel(m),
+			}
+			if scheme, ok := m["scheme"].(string); ok {
+				item.URL = strings.TrimSpace(scheme)
+			}
+			item.HeatValue, item.Category = parseHeat(m["desc_extr"])
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// hotSearchRank reads the rank from the rank badge image
+// (".../search_point_<n>.png"); pinned entries use a different image.
+func hotSearchRank(pic any) int {
+	s, _ := pic.(string)
+	idx := strings.LastIndex(s, "_")
+	if idx < 0 {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(s[idx+1:], ".png"))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func hotSearchLabel(m map[string]any) string {
+	for _, k := range []string{"label_name", "icon_desc"} {
+		if v, ok := m[k].(string); ok && strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	icon, _ := m["icon"].(string)
+	icon = strings.ToLower(icon[strings.LastIndex(icon, "/")+1:])
+	if icon == "" {
+		return ""
+	}
+	for _, l := range hotSearchLabels {
+		if strings.Contains(icon, l.icon) {
+			return l.label
+		}
+	}
+	return ""
+}
+
+// parseHeat splits desc_extr, which is either a number or "<category> <heat>"
+// (e.g. "剧集 356411").
+func parseHeat(v any) (int64, string) {
+	switch vv := v.(type) {
+	case float64:
+		return int64(vv), ""
+	case string:
+		s := strings.TrimSpace(vv)
+		loc := reHeatDigits.FindStringIndex(s)
+		if loc == nil {
+			return 0, s
+		}
+		n, _ := strconv.ParseInt(s[loc[0]:loc[1]], 10, 64)
+		return n, strings.TrimSpace(s[:loc[0]])
+	default:
+		return 0, ""
+	}
+}
+
+// runTrending snapshots the hot search list WB_TRENDING_POLL_COUNT times
+// (0 polls until the context is cancelled), WB_TRENDING_POLL_INTERVAL_SEC
+// apart. With WB_TRENDING_SEARCH_TOP_N > 0, the top-N keywords of each
+// snapshot that were not searched yet are fed into runSearch.
+func (c *Crawler) runTrending(ctx context.Context, req crawler.Request) (crawler.Result, error) {
+	tc, ok := c.client.(trendingClient)
+	if !ok {
+		return crawler.Result{}, fmt.Errorf("weibo client does not support trending")
+	}
+	interval := config.AppConfig.WBTrendingPollIntervalSec
+	polls := config.AppConfig.WBTrendingPollCount
+	if interval <= 0 {
+		polls = 1
+	}
+	topN := config.AppConfig.WBTrendingSearchTopN
+
+	out := crawler.NewResult(req)
+	searched := map[string]struct{}{}
+	logger.Info("weibo trending start", "polls", polls, "interval_sec", interval, "search_top_n", topN)
+	for i := 0; polls <= 0 || i < polls; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				out.FinishedAt = time.Now().Unix()
+				return out, ctx.Err()
+			case <-time.After(time.Duration(interval) * time.Second):
+			}
+		}
+		items, err := c.snapshotTrending(ctx, tc)
+		out.Processed++
+		if err != nil {
+			logger.Error("weibo hot search snapshot failed", "err", err)
+			out.Failed++
+			out.FailureKinds = crawler.MergeFailureKinds(out.FailureKinds, map[string]int{string(crawler.KindOf(err)): 1})
+			if ctx.Err() != nil {
+				out.FinishedAt = time.Now().Unix()
+				return out, ctx.Err()
+			}
+			continue
+		}
+		out.Succeeded++
+		logger.Info("weibo hot search snapshot saved", "items", len(items))
+
+		for _, it := range items {
+			if topN <= 0 || it.Rank > topN {
+				continue
+			}
+			if _, ok := searched[it.Keyword]; ok {
+				continue
+			}
+			searched[it.Keyword] = struct{}{}
+			sreq := req
+			sreq.Mode = crawler.ModeSearch
+			sreq.Keywords = []string{it.Keyword}
+			res, err := c.runSearch(ctx, sreq)
+			out.Processed += res.Processed
+			out.Succeeded += res.Succeeded
+			out.Failed += res.Failed
+			out.FailureKinds = crawler.MergeFailureKinds(out.FailureKinds, res.FailureKinds)
+			if err != nil {
+				logger.Warn("weibo trending search failed", "keyword", it.Keyword, "err", err)
+				if ctx.Err() != nil {
+					out.FinishedAt = time.Now().Unix()
+					return out, ctx.Err()
+				}
+			}
+		}
+	}
+	out.FinishedAt = time.Now().Unix()
+	return out, nil
+}
+
+func (c *Crawler) snapshotTrending(ctx context.Context, tc trendingClient) ([]TrendingItem, error) {
+	res, err := tc.HotSearch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]any
+	if err := json.Unmarshal(res.Data, &data); err != nil {
+		return nil, err
+	}
+	items := ParseHotSearch(data, time.Now().Unix())
+	if len(items) == 0 {
+		return nil, fmt.Errorf("empty hot search list")
+	}
+	rows := make([]any, 0, len(items))
+	for i := range items {
+		rows = append(rows, &items[i])
+	}
+	if _, err := store.AppendUniqueTrending(
+		rows,
+		func(item any) (string, error) { return item.(*TrendingItem).Key(), nil },
+		(&TrendingItem{}).CSVHeader(),
+		func(item any) ([]string, error) { return item.(*TrendingItem).ToCSV(), nil },
+	); err != nil {
+		return items, err
+	}
+	return items, nil
+}
@@ -0,0 +1,128 @@
+package weibo
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func hotSearchPayload() map[string]any {
+	return map[string]any{
+		"cards": []any{
+			map[string]any{
+				"card_group": []any{
+					map[string]any{"desc": "置顶话题", "pic": "https://simg.s.weibo.com/20180205110043_img_search_stick.png"},
+					map[string]any{"desc": "热搜一", "desc_extr": float64(3120456), "pic": "https://simg.s.weibo.com/20170303_img_search_1.png", "icon": "https://simg.s.weibo.com/moter/flags/hot_boil.png", "scheme": "sinaweibo://searchall?q=1"},
+					map[string]any{"desc": "热搜二", "desc_extr": "剧集 356411", "pic": "https://simg.s.weibo.com/20170303_img_search_2.png", "icon_desc": "新"},
+					map[string]any{"desc": "广告", "pic": "https://simg.s.weibo.com/20170303_img_search_3.png", "promotion": map[string]any{"id": 1}},
+					map[string]any{"desc": "热搜三", "desc_extr": "99", "pic": "https://simg.s.weibo.com/20170303_img_search_4.png"},
+				},
+			},
+		},
+	}
+}
+
+func TestParseHotSearch(t *testing.T) {
+	items := ParseHotSearch(hotSearchPayload(), 1700000000)
+	if len(items) != 3 {
+		t.Fatalf("expected 3 items, got %d: %+v", len(items), items)
+	}
+	first := items[0]
+	if first.Rank != 1 || first.Keyword != "热搜一" || first.HeatValue != 3120456 || first.Label != "沸" || first.SnapshotAt != 1700000000 || first.URL == "" {
+		t.Fatalf("unexpected first item: %+v", first)
+	}
+	second := items[1]
+	if second.Rank != 2 || second.HeatValue != 356411 || second.Category != "剧集" || second.Label != "新" {
+		t.Fatalf("unexpected second item: %+v", second)
+	}
+	if items[2].Rank != 4 || items[2].HeatValue != 99 || items[2].Label != "" {
+		t.Fatalf("unexpected third item: %+v", items[2])
+	}
+}
+
+type fakeClientWithTrending struct {
+	fakeClientWithComments
+	snapshots int
+	searched  []string
+}
+
+func (f *fakeClientWithTrending) HotSearch(ctx context.Context) (GetIndexResponse, error) {
+	f.snapshots++
+	b, _ := json.Marshal(hotSearchPayload())
+	return GetIndexResponse{Ok: 1, Data: b}, nil
+}
+
+func (f *fakeClientWithTrending) SearchByKeyword(ctx context.Context, keyword string, page int, searchType string) (GetIndexResponse, error) {
+	f.searched = append(f.searched, keyword)
+	var cards []any
+	if page == 1 {
+		cards = []any{map[string]any{"card_type": 9, "mblog": map[string]any{"id": "post-" + keyword}}}
+	}
+	b, _ := json.Marshal(map[string]any{"cards": cards})
+	return GetIndexResponse{Ok: 1, Data: b}, nil
+}
+
+func TestCrawlerTrendingSnapshotsAndSearch(t *testing.T) {
+	oldwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	tmp := t.TempDir()
+	if err := os.Chdir(tmp); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(oldwd) })
+
+	config.AppConfig = config.Config{
+		Platform:             "weibo",
+		StoreBackend:         "file",
+		SaveDataOption:       "json",
+		DataDir:              "data",
+		WBTrendingSearchTopN: 2,
+	}
+
+	fc := &fakeClientWithTrending{}
+	c := NewCrawlerWithClient(fc)
+	req := crawler.Request{Platform: "weibo", Mode: crawler.ModeTrending, MaxNotes: 5, Concurrency: 1}
+	res, err := c.Run(context.Background(), req)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if fc.snapshots != 1 {
+		t.Fatalf("expected a single snapshot without polling, got %d", fc.snapshots)
+	}
+	if res.Succeeded != 3 {
+		t.Fatalf("expected 1 snapshot + 2 posts, got %+v", res)
+	}
+	if _, err := os.Stat(filepath.Join("data", "weibo", "notes", "post-热搜二", "note.json")); err != nil {
+		t.Fatalf("expected trend post saved: %v", err)
+	}
+	for _, kw := range fc.searched {
+		if kw == "热搜三" {
+			t.Fatalf("rank 4 keyword should not be searched with top_n=2")
+		}
+	}
+
+	f, err := os.Open(filepath.Join("data", "weibo", "trending", "hot_search.jsonl"))
+	if err != nil {
+		t.Fatalf("open hot_search.jsonl: %v", err)
+	}
+	defer f.Close()
+	var lines int
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var it TrendingItem
+		if err := json.Unmarshal(sc.Bytes(), &it); err != nil {
+			t.Fatalf("decode line: %v", err)
+		}
+		lines++
+	}
+	if lines != 3 {
+		t.Fatalf("expected 3 trending rows, got %d", lines)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"media-crawler-go/internal/config"
 	"media-crawler-go/internal/crawler"
 	"media-crawler-go/internal/logger"
+	"media-crawler-go/internal/metrics"
 	"media-crawler-go/internal/proxy"
 	"math/rand"
 	"net/http"
@@ -46,6 +47,7 @@ func NewClient(signer *Signer) *Client {
 	}
 
 	client := resty.NewWithClient(httpClient)
+	metrics.InstrumentResty(client, "xhs")
 	client.SetBaseURL("https://edith.xiaohongshu.com")
 
 	// Default headers
@@ -124,6 +126,7 @@ func (c *Client) Post(ctx context.Context, uri string, data interface{}, result
 		if err := c.ensureProxy(ctx); err != nil {
 			lastErr = err
 			if attempt < retryCount-1 {
+				metrics.HTTPRetries.Inc("xhs")
 				delay := backoffDelay(attempt, baseDelay, maxDelay)
 				logger.Warn("xhs request retry (proxy)", "uri", uri, "attempt", attempt+1, "max_attempts", retryCount, "err", err, "sleep_ms", delay.Milliseconds())
 				if !crawler.Sleep(ctx, delay) {
@@ -163,6 +166,72 @@ func (c *Client) Post(ctx context.Context, uri string, data interface{}, result
 			return lastErr
 		}
 		if attempt < retryCount-1 {
+			metrics.HTTPRetries.Inc("xhs")
+			delay := backoffDelay(attempt, baseDelay, maxDelay)
+			logger.Warn("xhs request retry", "uri", uri, "attempt", attempt+1, "max_attempts", retryCount, "kind", string(crawler.KindOf(lastErr)), "err", lastErr, "sleep_ms", delay.Milliseconds())
+			if !crawler.Sleep(ctx, delay) {
+				return ctx.Err()
+			}
+		}
+	}
+
+	return lastErr
+}
+
+// Get is the signed GET counterpart of Post, with the same proxy handling
+// and retries.
+func (c *Client) Get(ctx context.Context, uri string, params map[string]string, result interface{}) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	retryCount, baseDelay, maxDelay := retryParams()
+	var lastErr error
+
+	for attempt := 0; attempt < retryCount; attempt++ {
+		if err := c.ensureProxy(ctx); err != nil {
+			lastErr = err
+			if attempt < retryCount-1 {
+				metrics.HTTPRetries.Inc("xhs")
+				delay := backoffDelay(attempt, baseDelay, maxDelay)
+				logger.Warn("xhs request retry (proxy)", "uri", uri, "attempt", attempt+1, "max_attempts", retryCount, "err", err, "sleep_ms", delay.Milliseconds())
+				if !crawler.Sleep(ctx, delay) {
+					return ctx.Err()
+				}
+				continue
+			}
+			return err
+		}
+
+		headers, err := c.preHeaders(uri, params, "GET")
+		if err != nil {
+			return err
+		}
+
+		resp, err := c.HttpClient.R().
+			SetContext(ctx).
+			SetHeaders(headers).
+			SetQueryParams(params).
+			SetResult(result).
+			Get(uri)
+
+		if err == nil && !resp.IsError() {
+			return nil
+		}
+
+		if err != nil {
+			lastErr = err
+		} else {
+			lastErr = crawler.NewHTTPStatusError("xhs", uri, resp.StatusCode(), resp.String())
+		}
+
+		if shouldInvalidateProxy(resp) && c.ProxyPool != nil {
+			c.ProxyPool.InvalidateCurrent()
+		}
+		if !shouldRetry(resp, err) {
+			return lastErr
+		}
+		if attempt < retryCount-1 {
+			metrics.HTTPRetries.Inc("xhs")
 			delay := backoffDelay(attempt, baseDelay, maxDelay)
 			logger.Warn("xhs request retry", "uri", uri, "attempt", attempt+1, "max_attempts", retryCount, "kind", string(crawler.KindOf(lastErr)), "err", lastErr, "sleep_ms", delay.Milliseconds())
 			if !crawler.Sleep(ctx, delay) {
@@ -218,6 +287,43 @@ func (c *Client) GetNoteByKeyword(ctx context.Context, keyword string, page int)
 	return &resp.Data, nil
 }
 
+// GetHomefeed returns one page of a homefeed channel (category), e.g.
+// "homefeed_recommend" or "homefeed.fashion_v3". cursorScore is empty and
+// noteIndex 0 for the first page.
+func (c *Client) GetHomefeed(ctx context.Context, category, cursorScore string, noteIndex int) (*HomefeedResult, error) {
+	uri := "/api/sns/web/v1/homefeed"
+	data := map[string]interface{}{
+		"cursor_score":         cursorScore,
+		"num":                  31,
+		"refresh_type":         1,
+		"note_index":           noteIndex,
+		"unread_begin_note_id": "",
+		"unread_end_note_id":   "",
+		"unread_note_count":    0,
+		"category":             category,
+		"search_key":           "",
+		"need_num":             10,
+		"image_formats":        []string{"jpg", "webp", "avif"},
+		"need_filter_image":    false,
+	}
+
+	type Response struct {
+		Success bool           `json:"success"`
+		Code    int            `json:"code"`
+		Msg     string         `json:"msg"`
+		Data    HomefeedResult `json:"data"`
+	}
+
+	var resp Response
+	if err := c.Post(ctx, uri, data, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("api error: %s", resp.Msg)
+	}
+	return &resp.Data, nil
+}
+
 func (c *Client) GetNotesByCreator(ctx context.Context, userId, cursor string) (*CreatorNotesResult, error) {
 	if ctx == nil {
 		ctx = context.Background()
@@ -245,6 +351,7 @@ func (c *Client) GetNotesByCreator(ctx context.Context, userId, cursor string) (
 		if err := c.ensureProxy(ctx); err != nil {
 			lastErr = err
 			if attempt < retryCount-1 {
+				metrics.HTTPRetries.Inc("xhs")
 				delay := backoffDelay(attempt, baseDelay, maxDelay)
 				logger.Warn("xhs request retry (proxy)", "uri", uri, "attempt", attempt+1, "max_attempts", retryCount, "err", err, "sleep_ms", delay.Milliseconds())
 				if !crawler.Sleep(ctx, delay) {
@@ -286,6 +393,7 @@ func (c *Client) GetNotesByCreator(ctx context.Context, userId, cursor string) (
 			return nil, lastErr
 		}
 		if attempt < retryCount-1 {
+			metrics.HTTPRetries.Inc("xhs")
 			delay := backoffDelay(attempt, baseDelay, maxDelay)
 			logger.Warn("xhs request retry", "uri", uri, "attempt", attempt+1, "max_attempts", retryCount, "kind", string(crawler.KindOf(lastErr)), "err", lastErr, "sleep_ms", delay.Milliseconds())
 			if !crawler.Sleep(ctx, delay) {
@@ -296,6 +404,64 @@ func (c *Client) GetNotesByCreator(ctx context.Context, userId, cursor string) (
 	return nil, lastErr
 }
 
+// GetTopicInfo returns the metadata of a topic page (话题): name, view count
+// and note count.
+func (c *Client) GetTopicInfo(ctx context.Context, pageId string) (*TopicInfo, error) {
+	uri := "/api/sns/web/v1/page/info"
+	params := map[string]string{"page_id": pageId}
+
+	type Response struct {
+		Success bool      `json:"success"`
+		Code    int       `json:"code"`
+		Msg     string    `json:"msg"`
+		Data    TopicInfo `json:"data"`
+	}
+
+	var resp Response
+	if err := c.Get(ctx, uri, params, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("api error: %s", resp.Msg)
+	}
+	if resp.Data.PageId == "" {
+		resp.Data.PageId = pageId
+	}
+	return &resp.Data, nil
+}
+
+// GetTopicNotes returns one page of a topic's note feed; sort is "hot" or
+// "new" and cursor is empty for the first page.
+func (c *Client) GetTopicNotes(ctx context.Context, pageId, sort, cursor string) (*TopicNotesResult, error) {
+	uri := "/api/sns/web/v1/page/notes"
+	if sort == "" {
+		sort = "hot"
+	}
+	params := map[string]string{
+		"page_id":       pageId,
+		"sort":          sort,
+		"cursor":        cursor,
+		"page_size":     "20",
+		"image_formats": "jpg,webp,avif",
+	}
+
+	type Response struct {
+		Success bool             `json:"success"`
+		Code    int              `json:"code"`
+		Msg     string           `json:"msg"`
+		Data    TopicNotesResult `json:"data"`
+	}
+
+	var resp Response
+	if err := c.Get(ctx, uri, params, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("api error: %s", resp.Msg)
+	}
+	return &resp.Data, nil
+}
+
 func (c *Client) GetNoteById(ctx context.Context, noteId, xsecSource, xsecToken string) (*Note, error) {
 	if xsecSource == "" {
 		xsecSource = "pc_search"
@@ -367,6 +533,7 @@ func (c *Client) GetNoteComments(ctx context.Context, noteId, xsecToken, cursor
 		if err := c.ensureProxy(ctx); err != nil {
 			lastErr = err
 			if attempt < retryCount-1 {
+				metrics.HTTPRetries.Inc("xhs")
 				delay := backoffDelay(attempt, baseDelay, maxDelay)
 				logger.Warn("xhs request retry (proxy)", "uri", uri, "attempt", attempt+1, "max_attempts", retryCount, "err", err, "sleep_ms", delay.Milliseconds())
 				if !crawler.Sleep(ctx, delay) {
@@ -408,6 +575,7 @@ func (c *Client) GetNoteComments(ctx context.Context, noteId, xsecToken, cursor
 			return nil, lastErr
 		}
 		if attempt < retryCount-1 {
+			metrics.HTTPRetries.Inc("xhs")
 			delay := backoffDelay(attempt, baseDelay, maxDelay)
 			logger.Warn("xhs request retry", "uri", uri, "attempt", attempt+1, "max_attempts", retryCount, "kind", string(crawler.KindOf(lastErr)), "err", lastErr, "sleep_ms", delay.Milliseconds())
 			if !crawler.Sleep(ctx, delay) {
@@ -451,6 +619,7 @@ func (c *Client) GetNoteSubComments(ctx context.Context, noteId, rootCommentId,
 		if err := c.ensureProxy(ctx); err != nil {
 			lastErr = err
 			if attempt < retryCount-1 {
+				metrics.HTTPRetries.Inc("xhs")
 				delay := backoffDelay(attempt, baseDelay, maxDelay)
 				logger.Warn("xhs request retry (proxy)", "uri", uri, "attempt", attempt+1, "max_attempts", retryCount, "err", err, "sleep_ms", delay.Milliseconds())
 				if !crawler.Sleep(ctx, delay) {
@@ -492,6 +661,7 @@ func (c *Client) GetNoteSubComments(ctx context.Context, noteId, rootCommentId,
 			return nil, lastErr
 		}
 		if attempt < retryCount-1 {
+			metrics.HTTPRetries.Inc("xhs")
 			delay := backoffDelay(attempt, baseDelay, maxDelay)
 			logger.Warn("xhs request retry", "uri", uri, "attempt", attempt+1, "max_attempts", retryCount, "kind", string(crawler.KindOf(lastErr)), "err", lastErr, "sleep_ms", delay.Milliseconds())
 			if !crawler.Sleep(ctx, delay) {
@@ -11,7 +11,9 @@ import (
 	"media-crawler-go/internal/proxy"
 	"media-crawler-go/internal/sms"
 	"media-crawler-go/internal/store"
+	"media-crawler-go/internal/webhook"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 
@@ -90,6 +92,10 @@ func (c *XhsCrawler) Run(ctx context.Context, req crawler.Request) (crawler.Resu
 		res, err = c.runDetailMode(ctx, req)
 	case crawler.ModeCreator:
 		res, err = c.runCreatorMode(ctx, req)
+	case crawler.ModeTopic:
+		res, err = c.runTopicMode(ctx, req)
+	case crawler.ModeFeed:
+		res, err = c.runFeedMode(ctx, req)
 	default:
 		return crawler.Result{}, fmt.Errorf("unknown mode: %s", req.Mode)
 	}
@@ -147,6 +153,12 @@ func (c *XhsCrawler) login(ctx context.Context) error {
 	if timeoutSec <= 0 {
 		timeoutSec = 120
 	}
+	webhook.Emit(webhook.Event{
+		Type:     webhook.LoginRequired,
+		Platform: "xhs",
+		TaskID:   crawler.TaskIDFrom(ctx),
+		Data:     map[string]any{"login_type": loginType, "timeout_sec": timeoutSec},
+	})
 	deadline := time.Now().Add(time.Duration(timeoutSec) * time.Second)
 
 	for time.Now().Before(deadline) {
@@ -154,14 +166,18 @@ func (c *XhsCrawler) login(ctx context.Context) error {
 			_ = c.tryAutoFillSMSCode("xhs", config.AppConfig.LoginPhone)
 		}
 		if err := c.client.UpdateCookies(c.browser); err == nil && c.client.Pong() {
-			time.Sleep(5 * time.Second)
+			if !crawler.Sleep(ctx, 5*time.Second) {
+				return ctx.Err()
+			}
 			return nil
 		}
 		content, err := c.page.Content()
 		if err == nil && strings.Contains(content, "请通过验证") {
 			logger.Warn("captcha detected; verify manually in browser window")
 		}
-		time.Sleep(1 * time.Second)
+		if !crawler.Sleep(ctx, 1*time.Second) {
+			return ctx.Err()
+		}
 	}
 	return fmt.Errorf("login timed out after %ds", timeoutSec)
 }
@@ -341,6 +357,7 @@ func (c *XhsCrawler) runSearchMode(ctx context.Context, req crawler.Request) (cr
 			default:
 			}
 
+			crawler.ProgressFrom(ctx).SetStage(keyword, page)
 			res, err := c.client.GetNoteByKeyword(ctx, keyword, page)
 			if err != nil {
 				logger.Error("search failed", "page", page, "err", err)
@@ -581,7 +598,7 @@ func (c *XhsCrawler) processNote(ctx context.Context, noteId, xsecSource, xsecTo
 		return err
 	}
 
-	if err := store.SaveNoteDetail(noteId, &noteDetail); err != nil {
+	if err := store.SaveNoteDetailContext(ctx, noteId, &noteDetail); err != nil {
 		logger.Error("save note failed", "note_id", noteId, "err", err)
 		return err
 	}
@@ -637,10 +654,13 @@ func (c *XhsCrawler) processNote(ctx context.Context, noteId, xsecSource, xsecTo
 
 		logger.Info("fetching comments", "note_id", noteId)
 		comments, err := c.fetchAllComments(ctx, noteId, token)
+		fetched := 0
 		if err != nil {
 			logger.Error("get comments failed", "note_id", noteId, "err", err)
 		} else {
+			fetched = len(comments)
 			logger.Info("comments fetched", "note_id", noteId, "comments", len(comments))
+			unified := toUnifiedComments(noteId, comments)
 			if config.AppConfig.SaveDataOption == "csv" {
 				items := make([]any, 0, len(comments))
 				globalItems := make([]any, 0, len(comments))
@@ -648,15 +668,7 @@ func (c *XhsCrawler) processNote(ctx context.Context, noteId, xsecSource, xsecTo
 					comment := comments[i]
 					comment.NoteId = noteId
 					items = append(items, &comment)
-					globalItems = append(globalItems, &store.UnifiedComment{
-						Platform:     "xhs",
-						NoteID:       noteId,
-						CommentID:    comment.Id,
-						Content:      comment.Content,
-						CreateTime:   comment.CreateTime,
-						UserID:       comment.User.UserId,
-						UserNickname: comment.User.Nickname,
-					})
+					globalItems = append(globalItems, unified[i])
 				}
 				_, err := store.AppendUniqueCommentsCSV(
 					noteId,
@@ -680,17 +692,7 @@ func (c *XhsCrawler) processNote(ctx context.Context, noteId, xsecSource, xsecTo
 			} else if config.AppConfig.SaveDataOption == "xlsx_book" {
 				globalItems := make([]any, 0, len(comments))
 				for i := range comments {
-					comment := comments[i]
-					comment.NoteId = noteId
-					globalItems = append(globalItems, &store.UnifiedComment{
-						Platform:     "xhs",
-						NoteID:       noteId,
-						CommentID:    comment.Id,
-						Content:      comment.Content,
-						CreateTime:   comment.CreateTime,
-						UserID:       comment.User.UserId,
-						UserNickname: comment.User.Nickname,
-					})
+					globalItems = append(globalItems, unified[i])
 				}
 				_, err := store.AppendUniqueGlobalCommentsBook(
 					globalItems,
@@ -715,15 +717,7 @@ func (c *XhsCrawler) processNote(ctx context.Context, noteId, xsecSource, xsecTo
 					comment := comments[i]
 					comment.NoteId = noteId
 					items = append(items, &comment)
-					globalItems = append(globalItems, &store.UnifiedComment{
-						Platform:     "xhs",
-						NoteID:       noteId,
-						CommentID:    comment.Id,
-						Content:      comment.Content,
-						CreateTime:   comment.CreateTime,
-						UserID:       comment.User.UserId,
-						UserNickname: comment.User.Nickname,
-					})
+					globalItems = append(globalItems, unified[i])
 				}
 				_, err := store.AppendUniqueCommentsXLSX(
 					noteId,
@@ -750,15 +744,7 @@ func (c *XhsCrawler) processNote(ctx context.Context, noteId, xsecSource, xsecTo
 				for i := range comments {
 					comments[i].NoteId = noteId
 					items = append(items, comments[i])
-					globalItems = append(globalItems, &store.UnifiedComment{
-						Platform:     "xhs",
-						NoteID:       noteId,
-						CommentID:    comments[i].Id,
-						Content:      comments[i].Content,
-						CreateTime:   comments[i].CreateTime,
-						UserID:       comments[i].User.UserId,
-						UserNickname: comments[i].User.Nickname,
-					})
+					globalItems = append(globalItems, unified[i])
 				}
 				_, err := store.AppendUniqueCommentsJSONL(
 					noteId,
@@ -777,6 +763,16 @@ func (c *XhsCrawler) processNote(ctx context.Context, noteId, xsecSource, xsecTo
 				}
 			}
 		}
+
+		reported := int64(-1)
+		if s := noteDetail.InteractInfo.CommentCount; s != "" {
+			reported = crawler.ParseCount(s)
+		}
+		if cov, err := store.RecordCommentCoverage("xhs", noteId, noteId, reported, fetched); err != nil {
+			logger.Error("save comment coverage failed", "note_id", noteId, "err", err)
+		} else {
+			logger.Info("comment coverage", "note_id", noteId, "fetched", cov.FetchedCount, "reported", cov.ReportedCount, "ratio", cov.Ratio)
+		}
 	}
 	return nil
 }
@@ -849,6 +845,7 @@ func (c *XhsCrawler) fetchSubComments(ctx context.Context, noteId, xsecToken str
 
 		if len(root.SubComments) > 0 {
 			for _, sub := range root.SubComments {
+				sub.RootCommentId = root.Id
 				out = append(out, sub)
 				if remaining >= 0 && len(out) >= remaining {
 					break
@@ -885,6 +882,7 @@ func (c *XhsCrawler) fetchSubComments(ctx context.Context, noteId, xsecToken str
 				break
 			}
 			for _, sub := range res.Comments {
+				sub.RootCommentId = root.Id
 				out = append(out, sub)
 				if remaining >= 0 && len(out) >= remaining {
 					return out
@@ -901,6 +899,32 @@ func (c *XhsCrawler) fetchSubComments(ctx context.Context, noteId, xsecToken str
 	return out
 }
 
+func toUnifiedComments(noteId string, comments []Comment) []*store.UnifiedComment {
+	out := make([]*store.UnifiedComment, 0, len(comments))
+	for _, comment := range comments {
+		u := &store.UnifiedComment{
+			Platform:        "xhs",
+			NoteID:          noteId,
+			CommentID:       comment.Id,
+			ParentCommentID: comment.RootCommentId,
+			Content:         comment.Content,
+			CreateTime:      comment.CreateTime,
+			UserID:          comment.User.UserId,
+			UserNickname:    comment.User.Nickname,
+			RootCommentID:   comment.RootCommentId,
+		}
+		u.SubCommentCount, _ = strconv.ParseInt(comment.SubCommentCount, 10, 64)
+		if t := comment.TargetComment; t != nil {
+			u.ReplyToCommentID = t.Id
+			u.ReplyToUserID = t.UserInfo.UserId
+			u.ReplyToUserNickname = t.UserInfo.Nickname
+		}
+		out = append(out, u)
+	}
+	store.LinkCommentReplies(out)
+	return out
+}
+
 func extractNoteId(url string) string {
 	// Simple regex or split
 	// https://www.xiaohongshu.com/explore/64a...
@@ -1031,7 +1055,7 @@ func (c *XhsCrawler) close() {
 		c.cdpBrowser.Close()
 	}
 	if c.cdpCmd != nil && c.cdpCmd.Process != nil && config.AppConfig.AutoCloseBrowser {
-		_ = c.cdpCmd.Process.Kill()
+		browser.StopBrowserProcess(c.cdpCmd, 5*time.Second)
 	}
 	if c.pw != nil {
 		c.pw.Stop()
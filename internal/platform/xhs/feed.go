@@ -0,0 +1,265 @@
+package xhs
+
+import (
+	"context"
+	"fmt"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"media-crawler-go/internal/logger"
+	"media-crawler-go/internal/store"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultFeedSampleSize bounds a channel sample when CRAWLER_MAX_NOTES_COUNT
+// is unlimited; the homefeed never runs out.
+const defaultFeedSampleSize = 100
+
+// feedChannels maps channel names (English key or the web tab label) to the
+// homefeed category.
+var feedChannels = []struct {
+	name     string
+	label    string
+	category string
+}{
+	{"recommend", "推荐", "homefeed_recommend"},
+	{"fashion", "穿搭", "homefeed.fashion_v3"},
+	{"food", "美食", "homefeed.food_v3"},
+	{"cosmetics", "彩妆", "homefeed.cosmetics_v3"},
+	{"movie", "影视", "homefeed.movie_and_tv_v3"},
+	{"career", "职场", "homefeed.career_v3"},
+	{"love", "情感", "homefeed.love_v3"},
+	{"household", "家居", "homefeed.household_product_v3"},
+	{"gaming", "游戏", "homefeed.gaming_v3"},
+	{"travel", "旅行", "homefeed.travel_v3"},
+	{"fitness", "健身", "homefeed.fitness_v3"},
+}
+
+// ResolveFeedChannel returns the channel name and homefeed category of a
+// channel key ("fashion"), tab label ("穿搭") or raw category
+// ("homefeed.fashion_v3"). Unknown raw "homefeed." categories are passed
+// through.
+func ResolveFeedChannel(input string) (string, string, bool) {
+	s := strings.TrimSpace(input)
+	lower := strings.ToLower(s)
+	for _, ch := range feedChannels {
+		if lower == ch.name || s == ch.label || lower == ch.category {
+			return ch.name, ch.category, true
+		}
+	}
+	if strings.HasPrefix(lower, "homefeed.") && len(lower) > len("homefeed.") {
+		name := strings.TrimSuffix(strings.TrimPrefix(lower, "homefeed."), "_v3")
+		return name, lower, true
+	}
+	return "", "", false
+}
+
+// FeedSample is one note seen in a channel at a sampling time; Rank is its
+// 1-based position in the feed as served.
+type FeedSample struct {
+	SampledAt  int64  `json:"sampled_at"`
+	Channel    string `json:"channel"`
+	Category   string `json:"category"`
+	Rank       int    `json:"rank"`
+	NoteId     string `json:"note_id"`
+	Title      string `json:"title"`
+	Type       string `json:"type"`
+	UserId     string `json:"user_id"`
+	Nickname   string `json:"nickname"`
+	LikedCount string `json:"liked_count"`
+}
+
+func (f *FeedSample) CSVHeader() []string {
+	return []string{"sampled_at", "channel", "category", "rank", "note_id", "title", "type", "user_id", "nickname", "liked_count"}
+}
+
+func (f *FeedSample) ToCSV() []string {
+	return []string{
+		strconv.FormatInt(f.SampledAt, 10),
+		f.Channel,
+		f.Category,
+		strconv.Itoa(f.Rank),
+		f.NoteId,
+		f.Title,
+		f.Type,
+		f.UserId,
+		f.Nickname,
+		f.LikedCount,
+	}
+}
+
+func (f *FeedSample) Key() string {
+	return fmt.Sprintf("%d:%s:%d:%s", f.SampledAt, f.Channel, f.Rank, f.NoteId)
+}
+
+// buildFeedSamples turns one homefeed page into samples ranked after
+// lastRank. Non-note cards (ads, hot queries) and notes already in the sample
+// are skipped.
+func buildFeedSamples(items []SearchItem, channel, category string, sampledAt int64, lastRank int, seen map[string]struct{}) ([]FeedSample, []SearchItem) {
+	var samples []FeedSample
+	var notes []SearchItem
+	for _, item := range items {
+		if item.ModelType != "" && item.ModelType != "note" {
+			continue
+		}
+		if item.Id == "" {
+			item.Id = item.NoteCard.NoteId
+		}
+		if item.Id == "" {
+			continue
+		}
+		if _, ok := seen[item.Id]; ok {
+			continue
+		}
+		seen[item.Id] = struct{}{}
+		lastRank++
+		samples = append(samples, FeedSample{
+			SampledAt:  sampledAt,
+			Channel:    channel,
+			Category:   category,
+			Rank:       lastRank,
+			NoteId:     item.Id,
+			Title:      item.NoteCard.Title,
+			Type:       item.NoteCard.Type,
+			UserId:     item.NoteCard.User.UserId,
+			Nickname:   item.NoteCard.User.Nickname,
+			LikedCount: item.NoteCard.InteractInfo.LikedCount,
+		})
+		notes = append(notes, item)
+	}
+	return samples, notes
+}
+
+// runFeedMode samples the homefeed channels of XHS_FEED_CHANNEL_LIST: every
+// note is recorded with its channel and rank in feed/samples.*, and notes
+// not processed earlier in the run go through processNote.
+func (c *XhsCrawler) runFeedMode(ctx context.Context, req crawler.Request) (crawler.Result, error) {
+	inputs := req.Inputs
+	if len(inputs) == 0 {
+		inputs = config.AppConfig.XhsFeedChannelList
+	}
+	logger.Info("running feed mode", "channels", len(inputs))
+	if len(inputs) == 0 {
+		return crawler.Result{}, fmt.Errorf("empty inputs (XHS_FEED_CHANNEL_LIST)")
+	}
+
+	sampleSize := req.MaxNotes
+	if sampleSize == 0 {
+		sampleSize = config.AppConfig.CrawlerMaxNotesCount
+	}
+	if sampleSize <= 0 {
+		sampleSize = defaultFeedSampleSize
+	}
+	concurrency := req.Concurrency
+	if concurrency <= 0 {
+		concurrency = config.AppConfig.MaxConcurrencyNum
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	out := crawler.NewResult(req)
+	processedNotes := make(map[string]struct{})
+	for _, input := range inputs {
+		select {
+		case <-ctx.Done():
+			out.FinishedAt = time.Now().Unix()
+			return out, ctx.Err()
+		default:
+		}
+		channel, category, ok := ResolveFeedChannel(input)
+		if !ok {
+			logger.Warn("unknown feed channel", "value", input)
+			out.Processed++
+			out.Failed++
+			out.FailureKinds = crawler.MergeFailureKinds(out.FailureKinds, map[string]int{string(crawler.ErrorKindInvalidInput): 1})
+			continue
+		}
+		logger.Info("sampling feed channel", "channel", channel, "category", category)
+
+		sampledAt := time.Now().Unix()
+		seen := make(map[string]struct{})
+		rank := 0
+		cursor := ""
+		noteIndex := 0
+		stalePages := 0
+		for rank < sampleSize {
+			select {
+			case <-ctx.Done():
+				out.FinishedAt = time.Now().Unix()
+				return out, ctx.Err()
+			default:
+			}
+			res, err := c.client.GetHomefeed(ctx, category, cursor, noteIndex)
+			if err != nil {
+				logger.Error("get homefeed failed", "channel", channel, "err", err)
+				break
+			}
+			if len(res.Items) == 0 {
+				break
+			}
+			noteIndex += len(res.Items)
+
+			samples, notes := buildFeedSamples(res.Items, channel, category, sampledAt, rank, seen)
+			if len(samples) == 0 {
+				// The feed keeps serving pages; stop once it only repeats.
+				if stalePages++; stalePages >= 3 {
+					break
+				}
+			} else {
+				stalePages = 0
+			}
+			if extra := rank + len(samples) - sampleSize; extra > 0 {
+				samples, notes = samples[:len(samples)-extra], notes[:len(notes)-extra]
+			}
+			rank += len(samples)
+			logger.Info("feed page", "channel", channel, "notes", len(samples), "rank", rank)
+
+			rows := make([]any, 0, len(samples))
+			for i := range samples {
+				rows = append(rows, &samples[i])
+			}
+			if _, err := store.AppendUniqueFeedSamples(
+				rows,
+				func(item any) (string, error) { return item.(*FeedSample).Key(), nil },
+				(&FeedSample{}).CSVHeader(),
+				func(item any) ([]string, error) { return item.(*FeedSample).ToCSV(), nil },
+			); err != nil {
+				logger.Error("save feed samples failed", "channel", channel, "err", err)
+			}
+
+			tasks := make([]SearchItem, 0, len(notes))
+			for _, note := range notes {
+				if _, ok := processedNotes[note.Id]; ok {
+					continue
+				}
+				processedNotes[note.Id] = struct{}{}
+				tasks = append(tasks, note)
+			}
+			r := crawler.ForEachLimit(ctx, tasks, concurrency, func(ctx context.Context, item SearchItem) error {
+				logger.Info("note", "nickname", item.NoteCard.User.Nickname, "title", item.NoteCard.Title, "note_id", item.Id)
+				xsecSource := item.XsecSource
+				if xsecSource == "" {
+					xsecSource = "pc_feed"
+				}
+				return c.processNote(ctx, item.Id, xsecSource, item.XsecToken)
+			})
+			out.Succeeded += r.Succeeded
+			out.Failed += r.Failed
+			out.Processed += r.Processed
+			out.FailureKinds = crawler.MergeFailureKinds(out.FailureKinds, r.FailureKinds)
+
+			if res.CursorScore == "" {
+				break
+			}
+			cursor = res.CursorScore
+			if config.AppConfig.CrawlerMaxSleepSec > 0 {
+				crawler.Sleep(ctx, time.Duration(config.AppConfig.CrawlerMaxSleepSec)*time.Second)
+			}
+		}
+	}
+
+	out.FinishedAt = time.Now().Unix()
+	return out, nil
+}
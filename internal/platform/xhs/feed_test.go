@@ -0,0 +1,54 @@
+package xhs
+
+import "testing"
+
+func TestResolveFeedChannel(t *testing.T) {
+	tests := []struct {
+		in       string
+		name     string
+		category string
+		ok       bool
+	}{
+		{in: "recommend", name: "recommend", category: "homefeed_recommend", ok: true},
+		{in: "穿搭", name: "fashion", category: "homefeed.fashion_v3", ok: true},
+		{in: " Food ", name: "food", category: "homefeed.food_v3", ok: true},
+		{in: "homefeed.pets_v3", name: "pets", category: "homefeed.pets_v3", ok: true},
+		{in: "unknown", ok: false},
+		{in: "homefeed.", ok: false},
+	}
+	for _, tt := range tests {
+		name, category, ok := ResolveFeedChannel(tt.in)
+		if ok != tt.ok || name != tt.name || category != tt.category {
+			t.Fatalf("ResolveFeedChannel(%q) = %q, %q, %v", tt.in, name, category, ok)
+		}
+	}
+}
+
+func TestBuildFeedSamples(t *testing.T) {
+	items := []SearchItem{
+		{Id: "a", ModelType: "note", XsecToken: "ta", NoteCard: Note{Title: "A", User: User{UserId: "u1", Nickname: "n1"}}},
+		{Id: "ad", ModelType: "ads"},
+		{ModelType: "note", NoteCard: Note{NoteId: "b", Title: "B"}},
+		{Id: "c", ModelType: "note"},
+	}
+	seen := map[string]struct{}{"c": {}}
+	samples, notes := buildFeedSamples(items, "fashion", "homefeed.fashion_v3", 100, 5, seen)
+	if len(samples) != 2 || len(notes) != 2 {
+		t.Fatalf("expected 2 samples, got %d/%d", len(samples), len(notes))
+	}
+	if samples[0].NoteId != "a" || samples[0].Rank != 6 || samples[0].Nickname != "n1" || samples[0].Channel != "fashion" {
+		t.Fatalf("unexpected first sample: %+v", samples[0])
+	}
+	if samples[1].NoteId != "b" || samples[1].Rank != 7 || notes[1].Id != "b" {
+		t.Fatalf("unexpected second sample: %+v", samples[1])
+	}
+	if notes[0].XsecToken != "ta" {
+		t.Fatalf("xsec token not kept: %+v", notes[0])
+	}
+	if _, ok := seen["b"]; !ok {
+		t.Fatalf("seen not updated")
+	}
+	if (&samples[0]).Key() != "100:fashion:6:a" {
+		t.Fatalf("unexpected key: %s", (&samples[0]).Key())
+	}
+}
@@ -88,11 +88,23 @@ type Comment struct {
 	SubComments       []Comment `json:"sub_comments"`
 	SubCommentCursor  string    `json:"sub_comment_cursor"`
 	SubCommentHasMore bool      `json:"sub_comment_has_more"`
+	SubCommentCount   string    `json:"sub_comment_count"`
+	// TargetComment is the comment a sub comment replies to.
+	TargetComment *TargetComment `json:"target_comment,omitempty"`
+
+	// RootCommentId is set on sub comments to the top-level comment they
+	// were fetched under.
+	RootCommentId string `json:"root_comment_id,omitempty"`
 
 	// Extra for CSV
 	NoteId string `json:"-"`
 }
 
+type TargetComment struct {
+	Id       string `json:"id"`
+	UserInfo User   `json:"user_info"`
+}
+
 func (c *Comment) CSVHeader() []string {
 	return []string{"NoteId", "CommentId", "Content", "UserId", "Nickname", "LikeCount", "CreateTime"}
 }
@@ -126,6 +138,39 @@ type CreatorNotesResult struct {
 	Notes   []Note `json:"notes"`
 }
 
+type HomefeedResult struct {
+	CursorScore string       `json:"cursor_score"`
+	Items       []SearchItem `json:"items"`
+}
+
+type TopicInfo struct {
+	PageId  string `json:"page_id"`
+	Name    string `json:"name"`
+	Desc    string `json:"desc"`
+	Image   string `json:"image"`
+	ViewNum int64  `json:"view_num"`
+	NoteNum int64  `json:"note_num"`
+}
+
+type TopicNotesResult struct {
+	HasMore bool        `json:"has_more"`
+	Cursor  string      `json:"cursor"`
+	Notes   []TopicNote `json:"notes"`
+}
+
+// TopicNote is a note card of a topic feed; older responses use "id" and
+// newer ones "note_id".
+type TopicNote struct {
+	Id           string   `json:"id"`
+	NoteId       string   `json:"note_id"`
+	Title        string   `json:"title"`
+	Type         string   `json:"type"`
+	User         User     `json:"user"`
+	InteractInfo Interact `json:"interact_info"`
+	XsecToken    string   `json:"xsec_token"`
+	XsecSource   string   `json:"xsec_source"`
+}
+
 type SearchItem struct {
 	Id         string `json:"id"`
 	XsecSource string `json:"xsec_source"`
@@ -0,0 +1,236 @@
+package xhs
+
+import (
+	"context"
+	"fmt"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"media-crawler-go/internal/logger"
+	"media-crawler-go/internal/store"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var reTopicID = regexp.MustCompile(`^[0-9a-fA-F]{24}$`)
+
+// ExtractTopicID accepts a topic page id or a
+// https://www.xiaohongshu.com/page/topics/<page_id> URL (the page_id query
+// parameter is honoured as well).
+func ExtractTopicID(input string) string {
+	s := strings.TrimSpace(input)
+	if reTopicID.MatchString(s) {
+		return s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	if id := u.Query().Get("page_id"); reTopicID.MatchString(id) {
+		return id
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i] == "topics" && reTopicID.MatchString(parts[i+1]) {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
+
+// NormalizeTopicSort maps XHS_TOPIC_SORT to the feed sort: "hot" (default)
+// or "new".
+func NormalizeTopicSort(s string) string {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "new", "time":
+		return "new"
+	default:
+		return "hot"
+	}
+}
+
+func topicCollectionID(pageID string) string {
+	return "topic_" + pageID
+}
+
+// TopicRecord is the topic metadata saved to
+// collections/topic_<page_id>/collection.json.
+type TopicRecord struct {
+	TopicInfo
+	CollectionID string `json:"collection_id"`
+	Sort         string `json:"sort"`
+	CrawledAt    int64  `json:"crawled_at"`
+}
+
+// TopicItem is one row of a topic's ordered note list.
+type TopicItem struct {
+	CollectionID string `json:"collection_id"`
+	Index        int    `json:"index"`
+	NoteId       string `json:"note_id"`
+	Title        string `json:"title"`
+	Type         string `json:"type"`
+	UserId       string `json:"user_id"`
+	Nickname     string `json:"nickname"`
+	LikedCount   string `json:"liked_count"`
+}
+
+func (t *TopicItem) CSVHeader() []string {
+	return []string{"collection_id", "index", "note_id", "title", "type", "user_id", "nickname", "liked_count"}
+}
+
+func (t *TopicItem) ToCSV() []string {
+	return []string{
+		t.CollectionID,
+		strconv.Itoa(t.Index),
+		t.NoteId,
+		t.Title,
+		t.Type,
+		t.UserId,
+		t.Nickname,
+		t.LikedCount,
+	}
+}
+
+func (t *TopicItem) Key() string {
+	return t.NoteId
+}
+
+func (c *XhsCrawler) runTopicMode(ctx context.Context, req crawler.Request) (crawler.Result, error) {
+	inputs := req.Inputs
+	if len(inputs) == 0 {
+		inputs = config.AppConfig.XhsTopicIdList
+	}
+	logger.Info("running topic mode", "topics", len(inputs))
+	if len(inputs) == 0 {
+		return crawler.Result{}, fmt.Errorf("empty inputs (XHS_TOPIC_ID_LIST)")
+	}
+
+	maxNotes := req.MaxNotes
+	if maxNotes == 0 {
+		maxNotes = config.AppConfig.CrawlerMaxNotesCount
+	}
+	concurrency := req.Concurrency
+	if concurrency <= 0 {
+		concurrency = config.AppConfig.MaxConcurrencyNum
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sort := NormalizeTopicSort(config.AppConfig.XhsTopicSort)
+
+	out := crawler.NewResult(req)
+	for _, input := range inputs {
+		select {
+		case <-ctx.Done():
+			out.FinishedAt = time.Now().Unix()
+			return out, ctx.Err()
+		default:
+		}
+		pageID := ExtractTopicID(input)
+		if pageID == "" {
+			logger.Warn("invalid topic id/url", "value", input)
+			out.Processed++
+			out.Failed++
+			out.FailureKinds = crawler.MergeFailureKinds(out.FailureKinds, map[string]int{string(crawler.ErrorKindInvalidInput): 1})
+			continue
+		}
+		collectionID := topicCollectionID(pageID)
+		logger.Info("processing topic", "page_id", pageID, "sort", sort)
+
+		record := TopicRecord{TopicInfo: TopicInfo{PageId: pageID}, CollectionID: collectionID, Sort: sort, CrawledAt: time.Now().Unix()}
+		if info, err := c.client.GetTopicInfo(ctx, pageID); err != nil {
+			logger.Warn("get topic info failed", "page_id", pageID, "err", err)
+		} else {
+			record.TopicInfo = *info
+		}
+		if err := store.SaveCollectionMeta(collectionID, &record); err != nil {
+			logger.Error("save topic meta failed", "page_id", pageID, "err", err)
+		}
+
+		processed := 0
+		seen := make(map[string]struct{})
+		cursor := ""
+		for {
+			select {
+			case <-ctx.Done():
+				out.FinishedAt = time.Now().Unix()
+				return out, ctx.Err()
+			default:
+			}
+			res, err := c.client.GetTopicNotes(ctx, pageID, sort, cursor)
+			if err != nil {
+				logger.Error("get topic notes failed", "page_id", pageID, "err", err)
+				break
+			}
+
+			logger.Info("topic notes", "page_id", pageID, "notes", len(res.Notes))
+			tasks := make([]TopicNote, 0, len(res.Notes))
+			items := make([]any, 0, len(res.Notes))
+			for _, note := range res.Notes {
+				if maxNotes > 0 && processed >= maxNotes {
+					break
+				}
+				if note.NoteId == "" {
+					note.NoteId = note.Id
+				}
+				if note.NoteId == "" {
+					continue
+				}
+				if _, ok := seen[note.NoteId]; ok {
+					continue
+				}
+				seen[note.NoteId] = struct{}{}
+				processed++
+				tasks = append(tasks, note)
+				items = append(items, &TopicItem{
+					CollectionID: collectionID,
+					Index:        processed,
+					NoteId:       note.NoteId,
+					Title:        note.Title,
+					Type:         note.Type,
+					UserId:       note.User.UserId,
+					Nickname:     note.User.Nickname,
+					LikedCount:   note.InteractInfo.LikedCount,
+				})
+			}
+			if _, err := store.AppendUniqueCollectionItems(
+				collectionID,
+				items,
+				func(item any) (string, error) { return item.(*TopicItem).Key(), nil },
+				(&TopicItem{}).CSVHeader(),
+				func(item any) ([]string, error) { return item.(*TopicItem).ToCSV(), nil },
+			); err != nil {
+				logger.Error("save topic items failed", "page_id", pageID, "err", err)
+			}
+
+			r := crawler.ForEachLimit(ctx, tasks, concurrency, func(ctx context.Context, note TopicNote) error {
+				logger.Info("note", "nickname", note.User.Nickname, "title", note.Title, "note_id", note.NoteId)
+				xsecSource := note.XsecSource
+				if xsecSource == "" {
+					xsecSource = "pc_feed"
+				}
+				return c.processNote(ctx, note.NoteId, xsecSource, note.XsecToken)
+			})
+			out.Succeeded += r.Succeeded
+			out.Failed += r.Failed
+			out.Processed += r.Processed
+			out.FailureKinds = crawler.MergeFailureKinds(out.FailureKinds, r.FailureKinds)
+
+			if maxNotes > 0 && processed >= maxNotes {
+				break
+			}
+			if !res.HasMore || res.Cursor == "" {
+				break
+			}
+			cursor = res.Cursor
+			if config.AppConfig.CrawlerMaxSleepSec > 0 {
+				crawler.Sleep(ctx, time.Duration(config.AppConfig.CrawlerMaxSleepSec)*time.Second)
+			}
+		}
+	}
+
+	out.FinishedAt = time.Now().Unix()
+	return out, nil
+}
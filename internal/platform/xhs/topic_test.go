@@ -0,0 +1,102 @@
+package xhs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+func TestExtractTopicID(t *testing.T) {
+	const id = "5c1b4f0bc4ec8300015b1e4b"
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: id, want: id},
+		{in: " " + id + " ", want: id},
+		{in: "https://www.xiaohongshu.com/page/topics/" + id + "?fullscreen=true", want: id},
+		{in: "https://www.xiaohongshu.com/page/topics/x?page_id=" + id, want: id},
+		{in: "https://www.xiaohongshu.com/explore/64a123", want: ""},
+		{in: "topic", want: ""},
+	}
+	for _, tt := range tests {
+		if got := ExtractTopicID(tt.in); got != tt.want {
+			t.Fatalf("ExtractTopicID(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeTopicSort(t *testing.T) {
+	for in, want := range map[string]string{"": "hot", "hot": "hot", "NEW": "new", "time": "new", "x": "hot"} {
+		if got := NormalizeTopicSort(in); got != want {
+			t.Fatalf("NormalizeTopicSort(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// fakeSignPage answers the signer's page evaluations.
+type fakeSignPage struct {
+	playwright.Page
+}
+
+func (fakeSignPage) Evaluate(expression string, arg ...interface{}) (interface{}, error) {
+	return "sig", nil
+}
+
+func TestGetTopicNotesPaging(t *testing.T) {
+	const pageID = "5c1b4f0bc4ec8300015b1e4b"
+	pages := map[string]map[string]any{
+		"":   {"success": true, "data": map[string]any{"has_more": true, "cursor": "c1", "notes": []map[string]any{{"id": "n1", "title": "one"}}}},
+		"c1": {"success": true, "data": map[string]any{"has_more": false, "cursor": "", "notes": []map[string]any{{"note_id": "n2", "title": "two"}}}},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/api/sns/web/v1/page/notes" || q.Get("page_id") != pageID || q.Get("sort") != "new" || q.Get("page_size") != "20" || r.Header.Get("X-S") == "" {
+			t.Errorf("unexpected request %s %v", r.URL, r.Header)
+		}
+		page, ok := pages[q.Get("cursor")]
+		if !ok {
+			page = map[string]any{"success": false, "msg": "bad cursor"}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+	defer srv.Close()
+
+	client := NewClient(NewSigner(fakeSignPage{}))
+	client.HttpClient.SetBaseURL(srv.URL)
+
+	var ids []string
+	cursor := ""
+	for i := 0; i < 5; i++ {
+		res, err := client.GetTopicNotes(context.Background(), pageID, "new", cursor)
+		if err != nil {
+			t.Fatalf("page %d: %v", i, err)
+		}
+		for _, n := range res.Notes {
+			ids = append(ids, n.Id+n.NoteId)
+		}
+		if !res.HasMore || res.Cursor == "" {
+			break
+		}
+		cursor = res.Cursor
+	}
+	if strings.Join(ids, ",") != "n1,n2" {
+		t.Fatalf("notes = %v", ids)
+	}
+	if _, err := client.GetTopicNotes(context.Background(), pageID, "new", "stale"); err == nil || !strings.Contains(err.Error(), "bad cursor") {
+		t.Fatalf("expected api error, got %v", err)
+	}
+}
+
+func TestTopicItemCSVHeader(t *testing.T) {
+	item := &TopicItem{CollectionID: "topic_x", Index: 1, NoteId: "n1"}
+	if h := item.CSVHeader(); strings.Join(h, ",") != "collection_id,index,note_id,title,type,user_id,nickname,liked_count" || len(h) != len(item.ToCSV()) {
+		t.Fatalf("header %v", h)
+	}
+}
@@ -1,16 +1,23 @@
 package zhihu
 
-import "strconv"
+import (
+	"media-crawler-go/internal/store"
+	"strconv"
+)
 
 type Comment struct {
-	NoteID          string
-	CommentID       string
-	ParentCommentID string
-	Content         string
-	CreateTime      int64
-	LikeCount       int64
-	UserID          string
-	UserNickname    string
+	NoteID              string
+	CommentID           string
+	ParentCommentID     string
+	RootCommentID       string
+	ReplyToUserID       string
+	ReplyToUserNickname string
+	Content             string
+	CreateTime          int64
+	LikeCount           int64
+	UserID              string
+	UserNickname        string
+	SubCommentCount     int64
 }
 
 func (c Comment) CSVHeader() []string {
@@ -30,3 +37,27 @@ func (c Comment) ToCSV() []string {
 	}
 }
 
+// ParentCommentID is the comment replied to on zhihu.
+func toUnifiedComments(noteID string, comments []Comment) []*store.UnifiedComment {
+	out := make([]*store.UnifiedComment, 0, len(comments))
+	for _, c := range comments {
+		out = append(out, &store.UnifiedComment{
+			Platform:            "zhihu",
+			NoteID:              noteID,
+			CommentID:           c.CommentID,
+			ParentCommentID:     c.ParentCommentID,
+			Content:             c.Content,
+			CreateTime:          c.CreateTime,
+			LikeCount:           c.LikeCount,
+			UserID:              c.UserID,
+			UserNickname:        c.UserNickname,
+			RootCommentID:       c.RootCommentID,
+			ReplyToCommentID:    c.ParentCommentID,
+			ReplyToUserID:       c.ReplyToUserID,
+			ReplyToUserNickname: c.ReplyToUserNickname,
+			SubCommentCount:     c.SubCommentCount,
+		})
+	}
+	store.LinkCommentReplies(out)
+	return out
+}
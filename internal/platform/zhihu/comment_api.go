@@ -177,6 +177,9 @@ func fetchAllChildCommentsAPI(ctx context.Context, c jsonFetchClient, noteID str
 				if out[i].ParentCommentID == "" {
 					out[i].ParentCommentID = parentCommentID
 				}
+				if out[i].RootCommentID == "" {
+					out[i].RootCommentID = parentCommentID
+				}
 			}
 			return out, nil
 		}
@@ -203,6 +206,20 @@ func parseZhihuAPIComment(m map[string]any, noteID string) (Comment, bool) {
 		parentID = toStringID(pickAny(m, "reply_to_comment_id", "replyToCommentId", "replyToCommentID"))
 	}
 
+	rootID := toStringID(pickAny(m, "reply_root_comment_id", "replyRootCommentId"))
+	replyToID, replyToName := "", ""
+	if ra, ok := pickAny(m, "reply_to_author", "replyToAuthor").(map[string]any); ok && ra != nil {
+		// v4 wraps the replied-to user in a "member" object.
+		if member, ok := ra["member"].(map[string]any); ok && member != nil {
+			ra = member
+		}
+		replyToID = toStringID(pickAny(ra, "id", "member_id", "url_token", "urlToken"))
+		replyToName = strings.TrimSpace(fmt.Sprintf("%v", pickAny(ra, "name", "nickname")))
+		if replyToName == "<nil>" {
+			replyToName = ""
+		}
+	}
+
 	content := stripHTML(fmt.Sprintf("%v", pickAny(m, "content", "text", "body")))
 	createTime := toInt64(pickAny(m, "created_time", "createdTime", "created"))
 	likeCount := toInt64(pickAny(m, "like_count", "likeCount", "vote_count", "voteCount"))
@@ -218,14 +235,18 @@ func parseZhihuAPIComment(m map[string]any, noteID string) (Comment, bool) {
 	}
 
 	return Comment{
-		NoteID:          noteID,
-		CommentID:       id,
-		ParentCommentID: parentID,
-		Content:         content,
-		CreateTime:      createTime,
-		LikeCount:       likeCount,
-		UserID:          userID,
-		UserNickname:    userName,
+		NoteID:              noteID,
+		CommentID:           id,
+		ParentCommentID:     parentID,
+		RootCommentID:       rootID,
+		ReplyToUserID:       replyToID,
+		ReplyToUserNickname: replyToName,
+		Content:             content,
+		CreateTime:          createTime,
+		LikeCount:           likeCount,
+		UserID:              userID,
+		UserNickname:        userName,
+		SubCommentCount:     toInt64(pickAny(m, "child_comment_count", "childCommentCount")),
 	}, true
 }
 
@@ -105,6 +105,36 @@ func parseCommentsFromHTML(pageContent string, noteID string, max int, enableSub
 	return out
 }
 
+// parseReportedCommentCount returns the commentCount of the answer (or,
+// without an answer id, the question) in the page's initial data, or -1 when
+// the page does not carry it.
+func parseReportedCommentCount(pageContent string, qid string, aid string) int64 {
+	js := extractInitialDataJSON(pageContent)
+	if js == "" {
+		return -1
+	}
+	var root map[string]any
+	if err := json.Unmarshal([]byte(js), &root); err != nil {
+		return -1
+	}
+	kind, id := "answers", aid
+	if id == "" {
+		kind, id = "questions", qid
+	}
+	if id == "" {
+		return -1
+	}
+	initState, _ := root["initialState"].(map[string]any)
+	entities, _ := initState["entities"].(map[string]any)
+	items, _ := entities[kind].(map[string]any)
+	entity, _ := items[id].(map[string]any)
+	v, ok := entity["commentCount"]
+	if !ok || v == nil {
+		return -1
+	}
+	return toInt64(v)
+}
+
 func extractInitialDataJSON(pageContent string) string {
 	m := reInitialData.FindStringSubmatch(pageContent)
 	if len(m) != 2 {
@@ -141,6 +141,7 @@ func (c *Crawler) runSearch(ctx context.Context, req crawler.Request) (crawler.R
 			} else {
 				searchURL = fmt.Sprintf("https://www.zhihu.com/search?type=content&q=%s&page=%d", url.QueryEscape(keyword), page)
 			}
+			crawler.ProgressFrom(ctx).SetStage(keyword, page)
 			res, err := c.client.FetchHTML(ctx, searchURL)
 			if err != nil {
 				logger.Error("zhihu search fetch failed", "url", searchURL, "err", err)
@@ -253,6 +254,9 @@ func (c *Crawler) runCreator(ctx context.Context, req crawler.Request) (crawler.
 		if pu, err := url.Parse(res.URL); err == nil && pu.Scheme != "" && pu.Host != "" {
 			baseURL = pu.Scheme + "://" + pu.Host
 		}
+		if config.AppConfig.EnableGetCreatorRelations {
+			c.crawlRelations(ctx, baseURL, creatorURLToken(creatorURL))
+		}
 		candidates := ExtractDetailURLsFromHTML(res.Body, baseURL, 500)
 		tasks := make([]string, 0, len(candidates))
 		for _, u := range candidates {
@@ -314,7 +318,7 @@ func (c *Crawler) fetchAndSaveDetail(ctx context.Context, platform string, url s
 	if noteID == "" {
 		noteID = stableID("zhihu", url)
 	}
-	if err := store.SaveNoteDetail(noteID, record); err != nil {
+	if err := store.SaveNoteDetailContext(ctx, noteID, record); err != nil {
 		logger.Error("zhihu save note failed", "note_id", noteID, "err", err)
 		return err
 	}
@@ -326,23 +330,14 @@ func (c *Crawler) fetchAndSaveDetail(ctx context.Context, platform string, url s
 	if config.AppConfig.EnableGetComments {
 		comments := fetchCommentsPreferAPI(ctx, c.client, res.Body, noteID, aid, config.AppConfig.CrawlerMaxComments, config.AppConfig.EnableGetSubComments)
 		if len(comments) > 0 {
+			unified := toUnifiedComments(noteID, comments)
 			switch config.AppConfig.SaveDataOption {
 			case "csv":
 				items := make([]any, 0, len(comments))
 				globalItems := make([]any, 0, len(comments))
 				for i := range comments {
 					items = append(items, &comments[i])
-					globalItems = append(globalItems, &store.UnifiedComment{
-						Platform:        "zhihu",
-						NoteID:          noteID,
-						CommentID:       comments[i].CommentID,
-						ParentCommentID: comments[i].ParentCommentID,
-						Content:         comments[i].Content,
-						CreateTime:      comments[i].CreateTime,
-						LikeCount:       comments[i].LikeCount,
-						UserID:          comments[i].UserID,
-						UserNickname:    comments[i].UserNickname,
-					})
+					globalItems = append(globalItems, unified[i])
 				}
 				if _, err := store.AppendUniqueCommentsCSV(
 					noteID,
@@ -364,17 +359,7 @@ func (c *Crawler) fetchAndSaveDetail(ctx context.Context, platform string, url s
 			case "xlsx_book":
 				globalItems := make([]any, 0, len(comments))
 				for i := range comments {
-					globalItems = append(globalItems, &store.UnifiedComment{
-						Platform:        "zhihu",
-						NoteID:          noteID,
-						CommentID:       comments[i].CommentID,
-						ParentCommentID: comments[i].ParentCommentID,
-						Content:         comments[i].Content,
-						CreateTime:      comments[i].CreateTime,
-						LikeCount:       comments[i].LikeCount,
-						UserID:          comments[i].UserID,
-						UserNickname:    comments[i].UserNickname,
-					})
+					globalItems = append(globalItems, unified[i])
 				}
 				if _, err := store.AppendUniqueGlobalCommentsBook(
 					globalItems,
@@ -395,17 +380,7 @@ func (c *Crawler) fetchAndSaveDetail(ctx context.Context, platform string, url s
 				globalItems := make([]any, 0, len(comments))
 				for i := range comments {
 					items = append(items, &comments[i])
-					globalItems = append(globalItems, &store.UnifiedComment{
-						Platform:        "zhihu",
-						NoteID:          noteID,
-						CommentID:       comments[i].CommentID,
-						ParentCommentID: comments[i].ParentCommentID,
-						Content:         comments[i].Content,
-						CreateTime:      comments[i].CreateTime,
-						LikeCount:       comments[i].LikeCount,
-						UserID:          comments[i].UserID,
-						UserNickname:    comments[i].UserNickname,
-					})
+					globalItems = append(globalItems, unified[i])
 				}
 				if _, err := store.AppendUniqueCommentsXLSX(
 					noteID,
@@ -429,17 +404,7 @@ func (c *Crawler) fetchAndSaveDetail(ctx context.Context, platform string, url s
 				globalItems := make([]any, 0, len(comments))
 				for i := range comments {
 					items = append(items, comments[i])
-					globalItems = append(globalItems, &store.UnifiedComment{
-						Platform:        "zhihu",
-						NoteID:          noteID,
-						CommentID:       comments[i].CommentID,
-						ParentCommentID: comments[i].ParentCommentID,
-						Content:         comments[i].Content,
-						CreateTime:      comments[i].CreateTime,
-						LikeCount:       comments[i].LikeCount,
-						UserID:          comments[i].UserID,
-						UserNickname:    comments[i].UserNickname,
-					})
+					globalItems = append(globalItems, unified[i])
 				}
 				if _, err := store.AppendUniqueCommentsJSONL(
 					noteID,
@@ -456,6 +421,12 @@ func (c *Crawler) fetchAndSaveDetail(ctx context.Context, platform string, url s
 				}
 			}
 		}
+		reported := parseReportedCommentCount(res.Body, qid, aid)
+		if cov, err := store.RecordCommentCoverage("zhihu", noteID, url, reported, len(comments)); err != nil {
+			logger.Error("zhihu save comment coverage failed", "note_id", noteID, "err", err)
+		} else {
+			logger.Info("zhihu comment coverage", "note_id", noteID, "fetched", cov.FetchedCount, "reported", cov.ReportedCount, "ratio", cov.Ratio)
+		}
 	}
 	return nil
 }
@@ -47,3 +47,19 @@ func TestExtractDetailURLsFromHTML(t *testing.T) {
 		t.Fatalf("third=%s", got[2])
 	}
 }
+
+func TestParseReportedCommentCount(t *testing.T) {
+	page := `<html><script id="js-initialData" type="text/json">{"initialState":{"entities":{"answers":{"456":{"commentCount":37}},"questions":{"123":{"commentCount":"5"}}}}}</script></html>`
+	if got := parseReportedCommentCount(page, "123", "456"); got != 37 {
+		t.Fatalf("answer count=%d", got)
+	}
+	if got := parseReportedCommentCount(page, "123", ""); got != 5 {
+		t.Fatalf("question count=%d", got)
+	}
+	if got := parseReportedCommentCount(page, "123", "999"); got != -1 {
+		t.Fatalf("missing answer count=%d", got)
+	}
+	if got := parseReportedCommentCount("<html></html>", "123", "456"); got != -1 {
+		t.Fatalf("no initial data count=%d", got)
+	}
+}
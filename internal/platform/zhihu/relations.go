@@ -0,0 +1,109 @@
+package zhihu
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/logger"
+	"media-crawler-go/internal/store"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Member relation lists of /api/v4/members/<url_token>: "followees" are the
+// accounts the member follows, "followers" the accounts following the member.
+const (
+	relationFollowees = "followees"
+	relationFollowers = "followers"
+)
+
+// creatorURLToken returns the url_token of a /people/<token> creator URL.
+func creatorURLToken(creatorURL string) string {
+	u, err := url.Parse(strings.TrimSpace(creatorURL))
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i] == "people" || parts[i] == "org" {
+			token, _ := url.PathUnescape(parts[i+1])
+			return strings.TrimSpace(token)
+		}
+	}
+	return ""
+}
+
+// fetchRelationEdges pages through a member's followers/followees list via
+// offset/limit until paging.is_end or max entries.
+func fetchRelationEdges(ctx context.Context, c jsonFetchClient, baseURL string, token string, name string, relation string, max int, sleepSec int) ([]store.CreatorEdge, error) {
+	token = strings.TrimSpace(token)
+	if token == "" || max <= 0 {
+		return nil, nil
+	}
+	const limit = 20
+	out := make([]store.CreatorEdge, 0, 64)
+	seen := map[string]struct{}{}
+	for offset := 0; len(out) < max; offset += limit {
+		endpoint := fmt.Sprintf("%s/api/v4/members/%s/%s?offset=%d&limit=%d", strings.TrimRight(baseURL, "/"), url.PathEscape(token), relation, offset, limit)
+		res, err := c.FetchJSON(ctx, endpoint)
+		if err != nil {
+			return out, err
+		}
+		var resp zhihuAPIListResponse
+		if err := json.Unmarshal([]byte(res.Body), &resp); err != nil {
+			return out, err
+		}
+		for _, m := range resp.Data {
+			id := strings.TrimSpace(fmt.Sprintf("%v", pickAny(m, "url_token", "id")))
+			if id == "" || id == "<nil>" || id == token {
+				continue
+			}
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+			other, _ := m["name"].(string)
+			e := store.CreatorEdge{FromID: token, FromName: name, ToID: id, ToName: other, Type: store.EdgeTypeFollowing}
+			if relation == relationFollowers {
+				e = store.CreatorEdge{FromID: id, FromName: other, ToID: token, ToName: name, Type: store.EdgeTypeFollower}
+			}
+			out = append(out, e)
+			if len(out) >= max {
+				break
+			}
+		}
+		if resp.Paging.IsEnd || len(resp.Data) == 0 {
+			break
+		}
+		if sleepSec > 0 {
+			select {
+			case <-ctx.Done():
+				return out, ctx.Err()
+			case <-time.After(time.Duration(sleepSec) * time.Second):
+			}
+		}
+	}
+	return out, nil
+}
+
+// crawlRelations stores the follow edges of a creator's followees and
+// followers (up to CRAWLER_MAX_RELATIONS_COUNT each).
+func (c *Crawler) crawlRelations(ctx context.Context, baseURL string, token string) {
+	jf, ok := c.client.(jsonFetchClient)
+	if !ok || strings.TrimSpace(token) == "" {
+		return
+	}
+	for _, relation := range []string{relationFollowees, relationFollowers} {
+		edges, err := fetchRelationEdges(ctx, jf, baseURL, token, "", relation, config.AppConfig.CrawlerMaxRelationsCount, config.AppConfig.CrawlerMaxSleepSec)
+		if err != nil {
+			logger.Warn("zhihu relation list incomplete", "creator", token, "relation", relation, "collected", len(edges), "err", err)
+		}
+		if _, err := store.AppendCreatorEdges(edges); err != nil {
+			logger.Error("save creator edges failed", "creator", token, "relation", relation, "err", err)
+			continue
+		}
+		logger.Info("zhihu relations saved", "creator", token, "relation", relation, "edges", len(edges))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"math/rand"
+	"media-crawler-go/internal/metrics"
 	"sync"
 	"time"
 )
@@ -18,6 +19,9 @@ type Pool struct {
 	mu      sync.Mutex
 	proxies []Proxy
 	current *Proxy
+	// switchReason labels the next proxy taken from the pool in
+	// metrics.ProxySwitches.
+	switchReason string
 }
 
 func NewPool(provider Provider, count int) *Pool {
@@ -25,9 +29,10 @@ func NewPool(provider Provider, count int) *Pool {
 		count = 2
 	}
 	return &Pool{
-		provider: provider,
-		count:    count,
-		buffer:   30 * time.Second,
+		provider:     provider,
+		count:        count,
+		buffer:       30 * time.Second,
+		switchReason: "initial",
 	}
 }
 
@@ -47,6 +52,9 @@ func (p *Pool) GetOrRefresh(ctx context.Context) (Proxy, error) {
 	if p.current != nil && !p.current.IsExpired(p.buffer) {
 		return *p.current, nil
 	}
+	if p.current != nil {
+		p.switchReason = "expired"
+	}
 
 	if len(p.proxies) == 0 {
 		proxies, err := p.provider.GetProxies(ctx, p.count)
@@ -66,6 +74,7 @@ func (p *Pool) GetOrRefresh(ctx context.Context) (Proxy, error) {
 	p.proxies = p.proxies[:len(p.proxies)-1]
 
 	p.current = &next
+	metrics.ProxySwitches.Inc(p.switchReason)
 	return next, nil
 }
 
@@ -81,5 +90,8 @@ func (p *Pool) Current() (Proxy, bool) {
 func (p *Pool) InvalidateCurrent() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	if p.current != nil {
+		p.switchReason = "invalidated"
+	}
 	p.current = nil
 }
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CollectionDir is the directory of a platform collection (douyin 合集, ...)
+// holding its metadata and ordered item list; the items themselves are saved
+// as regular notes.
+func CollectionDir(collectionID string) string {
+	return filepath.Join(PlatformDir(), "collections", collectionID)
+}
+
+// SaveCollectionMeta writes collection.json, replacing the metadata of a
+// previous run.
+func SaveCollectionMeta(collectionID string, meta any) error {
+	collectionID = strings.TrimSpace(collectionID)
+	if collectionID == "" {
+		return nil
+	}
+	dir := CollectionDir(collectionID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "collection.json"), append(b, '\n'), 0644)
+}
+
+func AppendUniqueCollectionItems(collectionID string, items []any, keyFn func(any) (string, error), header []string, rowFn func(any) ([]string, error)) (int, error) {
+	collectionID = strings.TrimSpace(collectionID)
+	if collectionID == "" || len(items) == 0 {
+		return 0, nil
+	}
+	return appendUniqueRecords(CollectionDir(collectionID), "items", "CollectionItems", items, keyFn, header, rowFn)
+}
@@ -0,0 +1,161 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CommentCoverage compares the comments stored for a note with the comment
+// count the platform reports for it. ReportedCount is -1 when the platform
+// did not report one; Ratio is then -1 as well. Input is what detail mode
+// takes to fetch the note again.
+type CommentCoverage struct {
+	Platform      string  `json:"platform"`
+	NoteID        string  `json:"note_id"`
+	Input         string  `json:"input"`
+	ReportedCount int64   `json:"reported_count"`
+	FetchedCount  int64   `json:"fetched_count"`
+	Ratio         float64 `json:"ratio"`
+	CheckedAt     int64   `json:"checked_at"`
+}
+
+var coverageMu sync.Mutex
+
+// NoteCommentCount returns the number of unique comments stored for a note,
+// as tracked by its comments.idx.
+func NoteCommentCount(noteID string) (int, error) {
+	seen, err := loadIndex(filepath.Join(NoteDir(noteID), "comments.idx"))
+	if err != nil {
+		return 0, err
+	}
+	return len(seen), nil
+}
+
+// RecordCommentCoverage computes a note's coverage after a comment fetch and
+// saves it to notes/<note_id>/comment_coverage.json and the platform's
+// comment_coverage.jsonl history. fetched is the number of comments fetched in
+// this run; the stored count from comments.idx wins when larger.
+func RecordCommentCoverage(platform string, noteID string, input string, reported int64, fetched int) (CommentCoverage, error) {
+	noteID = strings.TrimSpace(noteID)
+	if noteID == "" {
+		return CommentCoverage{}, errors.New("note_id is empty")
+	}
+	stored, err := NoteCommentCount(noteID)
+	if err != nil {
+		return CommentCoverage{}, err
+	}
+	if stored > fetched {
+		fetched = stored
+	}
+	cov := NewCommentCoverage(platform, noteID, input, reported, int64(fetched))
+
+	b, err := json.MarshalIndent(cov, "", "  ")
+	if err != nil {
+		return cov, err
+	}
+	dir := NoteDir(noteID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return cov, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "comment_coverage.json"), append(b, '\n'), 0644); err != nil {
+		return cov, err
+	}
+
+	coverageMu.Lock()
+	defer coverageMu.Unlock()
+	f, err := os.OpenFile(filepath.Join(PlatformDir(), "comment_coverage.jsonl"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return cov, err
+	}
+	defer f.Close()
+	return cov, json.NewEncoder(f).Encode(cov)
+}
+
+// NewCommentCoverage builds a coverage record. Ratio is fetched/reported,
+// capped at 1 since deleted comments keep counting on some platforms.
+func NewCommentCoverage(platform string, noteID string, input string, reported int64, fetched int64) CommentCoverage {
+	cov := CommentCoverage{
+		Platform:      platform,
+		NoteID:        noteID,
+		Input:         input,
+		ReportedCount: reported,
+		FetchedCount:  fetched,
+		CheckedAt:     time.Now().Unix(),
+	}
+	switch {
+	case reported < 0:
+		cov.ReportedCount, cov.Ratio = -1, -1
+	case reported == 0 || fetched >= reported:
+		cov.Ratio = 1
+	default:
+		cov.Ratio = float64(fetched) / float64(reported)
+	}
+	return cov
+}
+
+// LoadCommentCoverage reads the platform's comment_coverage.jsonl and returns
+// the latest record of every note, ordered by note id.
+func LoadCommentCoverage(platform string) ([]CommentCoverage, error) {
+	platform = strings.TrimSpace(platform)
+	if platform == "" {
+		return nil, errors.New("platform is empty")
+	}
+	path := filepath.Join(filepath.Dir(PlatformDir()), platform, "comment_coverage.jsonl")
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no comment coverage recorded for %s: %w", platform, err)
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	latest := make(map[string]CommentCoverage)
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		var c CommentCoverage
+		if err := json.Unmarshal(sc.Bytes(), &c); err != nil || c.NoteID == "" {
+			continue
+		}
+		if prev, ok := latest[c.NoteID]; ok && prev.CheckedAt > c.CheckedAt {
+			continue
+		}
+		latest[c.NoteID] = c
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	out := make([]CommentCoverage, 0, len(latest))
+	for _, c := range latest {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].NoteID < out[j].NoteID })
+	return out, nil
+}
+
+// CoverageBelow returns the records whose ratio is below threshold, lowest
+// coverage first. Notes without a reported count are skipped; max <= 0 means
+// no limit.
+func CoverageBelow(records []CommentCoverage, threshold float64, max int) []CommentCoverage {
+	var out []CommentCoverage
+	for _, c := range records {
+		if c.ReportedCount <= 0 || c.Ratio < 0 || c.Ratio >= threshold {
+			continue
+		}
+		out = append(out, c)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Ratio < out[j].Ratio })
+	if max > 0 && len(out) > max {
+		out = out[:max]
+	}
+	return out
+}
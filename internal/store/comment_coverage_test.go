@@ -0,0 +1,88 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"media-crawler-go/internal/config"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordCommentCoverage(t *testing.T) {
+	dataDir := t.TempDir()
+	oldCfg := config.AppConfig
+	config.AppConfig.DataDir = dataDir
+	config.AppConfig.Platform = "xhs"
+	t.Cleanup(func() { config.AppConfig = oldCfg })
+
+	items := []any{map[string]any{"id": "c1"}, map[string]any{"id": "c2"}, map[string]any{"id": "c3"}}
+	if _, err := AppendUniqueCommentsJSONL("n1", items, func(item any) (string, error) {
+		return item.(map[string]any)["id"].(string), nil
+	}); err != nil {
+		t.Fatalf("append comments: %v", err)
+	}
+
+	cov, err := RecordCommentCoverage("xhs", "n1", "https://www.xiaohongshu.com/explore/n1", 12, 1)
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if cov.FetchedCount != 3 || cov.ReportedCount != 12 || cov.Ratio != 0.25 {
+		t.Fatalf("unexpected coverage: %+v", cov)
+	}
+	b, err := os.ReadFile(filepath.Join(dataDir, "xhs", "notes", "n1", "comment_coverage.json"))
+	if err != nil {
+		t.Fatalf("read note coverage: %v", err)
+	}
+	var saved CommentCoverage
+	if err := json.Unmarshal(b, &saved); err != nil || saved.Input != cov.Input {
+		t.Fatalf("unexpected note coverage: %s err=%v", string(b), err)
+	}
+
+	if _, err := RecordCommentCoverage("xhs", "n2", "n2", -1, 5); err != nil {
+		t.Fatalf("record n2: %v", err)
+	}
+	if _, err := RecordCommentCoverage("xhs", "n3", "n3", 10, 9); err != nil {
+		t.Fatalf("record n3: %v", err)
+	}
+	// A later check of n1 replaces the earlier record.
+	if _, err := RecordCommentCoverage("xhs", "n1", "n1", 12, 6); err != nil {
+		t.Fatalf("record n1 again: %v", err)
+	}
+
+	records, err := LoadCommentCoverage("xhs")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(records) != 3 || records[0].NoteID != "n1" || records[0].FetchedCount != 6 || records[1].Ratio != -1 {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+	low := CoverageBelow(records, 0.95, 0)
+	if len(low) != 2 || low[0].NoteID != "n1" || low[1].NoteID != "n3" {
+		t.Fatalf("unexpected low coverage: %+v", low)
+	}
+	if low := CoverageBelow(records, 0.95, 1); len(low) != 1 || low[0].NoteID != "n1" {
+		t.Fatalf("max not applied: %+v", low)
+	}
+
+	if _, err := LoadCommentCoverage("weibo"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected missing file error, got %v", err)
+	}
+}
+
+func TestNewCommentCoverage(t *testing.T) {
+	cases := []struct {
+		reported, fetched int64
+		ratio             float64
+	}{
+		{-1, 3, -1},
+		{0, 0, 1},
+		{4, 8, 1},
+		{8, 2, 0.25},
+	}
+	for _, tc := range cases {
+		if got := NewCommentCoverage("douyin", "a", "a", tc.reported, tc.fetched); got.Ratio != tc.ratio {
+			t.Fatalf("reported=%d fetched=%d: ratio=%v want %v", tc.reported, tc.fetched, got.Ratio, tc.ratio)
+		}
+	}
+}
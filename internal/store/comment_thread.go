@@ -0,0 +1,407 @@
+package store
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// maxThreadDepth bounds reply chain walks so cyclic data cannot loop.
+const maxThreadDepth = 64
+
+var reReplyMention = regexp.MustCompile(`^\s*回复(?:\s*@|\s+)([^:：\s]+)\s*[:：]`)
+
+// LinkCommentReplies fills the thread fields of a batch of comments (usually
+// all comments fetched for one note). Missing root / reply-to ids default to
+// ParentCommentID, roots are resolved through reply chains found in the batch,
+// the replied-to user is copied from the target comment (or parsed from a
+// leading "回复 @name:" / "回复 name :" mention) and Depth is computed. Top-level comments
+// without a platform reply count get the number of replies in the batch.
+func LinkCommentReplies(items []*UnifiedComment) {
+	byID := make(map[string]*UnifiedComment, len(items))
+	for _, c := range items {
+		if c == nil || c.CommentID == "" {
+			continue
+		}
+		if c.ReplyToCommentID == "" {
+			c.ReplyToCommentID = c.ParentCommentID
+		}
+		if c.RootCommentID == "" {
+			c.RootCommentID = c.ParentCommentID
+		}
+		// Platforms use "0" or the comment's own id for "no parent".
+		if c.ReplyToCommentID == c.CommentID || c.ReplyToCommentID == "0" {
+			c.ReplyToCommentID = ""
+		}
+		if c.RootCommentID == c.CommentID || c.RootCommentID == "0" {
+			c.RootCommentID = ""
+		}
+		if c.ReplyToCommentID == "" {
+			c.ReplyToCommentID = c.RootCommentID
+		}
+		byID[c.CommentID] = c
+	}
+
+	replies := make(map[string]int64)
+	for _, c := range items {
+		if c == nil || c.CommentID == "" {
+			continue
+		}
+		if c.ReplyToCommentID == "" {
+			c.Depth = 0
+			continue
+		}
+		if c.ReplyToUserID == "" && c.ReplyToUserNickname == "" {
+			if m := reReplyMention.FindStringSubmatch(c.Content); m != nil {
+				c.ReplyToUserNickname = m[1]
+			}
+			if t := byID[c.ReplyToCommentID]; t != nil && (c.ReplyToUserNickname == "" || c.ReplyToUserNickname == t.UserNickname) {
+				c.ReplyToUserID = t.UserID
+				c.ReplyToUserNickname = t.UserNickname
+			}
+		}
+		c.RootCommentID, c.Depth = threadPath(c, byID)
+		replies[c.RootCommentID]++
+	}
+	for _, c := range items {
+		if c != nil && c.Depth == 0 && c.SubCommentCount == 0 {
+			c.SubCommentCount = replies[c.CommentID]
+		}
+	}
+}
+
+// threadPath follows reply-to links up to the top-level comment. When the
+// chain leaves the batch the platform's root id is trusted and a reply to a
+// comment other than the root counts as depth 2.
+func threadPath(c *UnifiedComment, byID map[string]*UnifiedComment) (string, int) {
+	cur := c
+	depth := 0
+	for cur.ReplyToCommentID != "" && depth < maxThreadDepth {
+		depth++
+		t := byID[cur.ReplyToCommentID]
+		if t == nil {
+			root := cur.RootCommentID
+			if root == "" {
+				root = cur.ReplyToCommentID
+			}
+			if cur.ReplyToCommentID != root {
+				depth++
+			}
+			return root, depth
+		}
+		cur = t
+	}
+	return cur.CommentID, depth
+}
+
+// CommentThread is one comment with its direct replies.
+type CommentThread struct {
+	Comment UnifiedComment   `json:"comment"`
+	Replies []*CommentThread `json:"replies,omitempty"`
+}
+
+// BuildCommentThreads links comments and nests each reply under the comment it
+// replies to (or under its root when that comment is missing). Comments whose
+// parents are not in the input are returned as top-level threads. Every level
+// is ordered by create time.
+func BuildCommentThreads(comments []UnifiedComment) []*CommentThread {
+	ptrs := make([]*UnifiedComment, 0, len(comments))
+	seen := make(map[string]struct{}, len(comments))
+	for i := range comments {
+		c := comments[i]
+		if c.CommentID != "" {
+			if _, ok := seen[c.CommentID]; ok {
+				continue
+			}
+			seen[c.CommentID] = struct{}{}
+		}
+		ptrs = append(ptrs, &c)
+	}
+	LinkCommentReplies(ptrs)
+
+	nodes := make(map[string]*CommentThread, len(ptrs))
+	all := make([]*CommentThread, 0, len(ptrs))
+	for _, c := range ptrs {
+		n := &CommentThread{Comment: *c}
+		all = append(all, n)
+		if c.CommentID != "" {
+			nodes[c.CommentID] = n
+		}
+	}
+	var roots []*CommentThread
+	for _, n := range all {
+		parent := nodes[n.Comment.ReplyToCommentID]
+		if parent == nil {
+			parent = nodes[n.Comment.RootCommentID]
+		}
+		if parent == nil || parent == n {
+			roots = append(roots, n)
+			continue
+		}
+		parent.Replies = append(parent.Replies, n)
+	}
+	sortThreads(roots)
+	return roots
+}
+
+func sortThreads(list []*CommentThread) {
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Comment.CreateTime != list[j].Comment.CreateTime {
+			return list[i].Comment.CreateTime < list[j].Comment.CreateTime
+		}
+		return list[i].Comment.CommentID < list[j].Comment.CommentID
+	})
+	for _, n := range list {
+		sortThreads(n.Replies)
+	}
+}
+
+// LoadNoteComments reads a note's comments from the configured store
+// backend, like the query API: the unified comments file of the file
+// backend (comments.jsonl, .csv, .xlsx or the Comments sheets of xlsx_book
+// workbooks) or the comments table / collection of a database. Database rows
+// hold the platform's raw comment, so they carry only the fields the query
+// API normalizes; BuildCommentThreads links the rest from the parent ids.
+func LoadNoteComments(platform string, noteID string) ([]UnifiedComment, error) {
+	platform = strings.TrimSpace(platform)
+	noteID = strings.TrimSpace(noteID)
+	if platform == "" {
+		return nil, errors.New("platform is empty")
+	}
+	if noteID == "" {
+		return nil, errors.New("note_id is empty")
+	}
+	if backendKind() == backendFile {
+		dir := filepath.Join(filepath.Dir(PlatformDir()), platform)
+		if len(unifiedCommentSources(dir)) == 0 {
+			return nil, fmt.Errorf("no unified comments file in %s: %w", dir, os.ErrNotExist)
+		}
+	}
+	rows, err := loadQueryRows(QueryComments, platform, noteID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]UnifiedComment, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, unifiedCommentFromRow(row))
+	}
+	return out, nil
+}
+
+// unifiedCommentFromRow rebuilds a unified comment from a stored row. Rows
+// of the unified comments files convert back unchanged.
+func unifiedCommentFromRow(row queryRow) UnifiedComment {
+	rec := normalizeQueryRow(QueryComments, queryFieldSets[QueryComments], row)
+	text := func(name string) string { return asString(rec[name]) }
+	count := func(v any) int64 {
+		n, _ := v.(int64)
+		return n
+	}
+	c := UnifiedComment{
+		Platform:            row.platform,
+		NoteID:              text("note_id"),
+		CommentID:           text("comment_id"),
+		ParentCommentID:     text("parent_comment_id"),
+		Content:             text("content"),
+		CreateTime:          count(asCount(firstValue(row.data, "CreateTime"))),
+		LikeCount:           count(rec["like_count"]),
+		UserID:              text("creator_id"),
+		UserSecUID:          asString(firstValue(row.data, "UserSecUID", "user.sec_uid")),
+		UserNickname:        text("creator_name"),
+		RootCommentID:       text("root_comment_id"),
+		ReplyToCommentID:    asString(firstValue(row.data, "ReplyToCommentID")),
+		ReplyToUserID:       asString(firstValue(row.data, "ReplyToUserID")),
+		ReplyToUserNickname: asString(firstValue(row.data, "ReplyToUserNickname")),
+		Depth:               int(count(asCount(firstValue(row.data, "Depth")))),
+		SubCommentCount:     count(rec["sub_comment_count"]),
+	}
+	if c.CreateTime == 0 {
+		c.CreateTime = count(rec["create_time"])
+	}
+	return c
+}
+
+// unifiedCommentCache keeps the parsed unified comments of each platform
+// directory until its files change, so thread and query requests do not
+// re-read a large file every time. The cached slices are shared and must
+// not be modified.
+var unifiedCommentCache = struct {
+	sync.Mutex
+	entries map[string]unifiedCommentEntry
+}{entries: map[string]unifiedCommentEntry{}}
+
+type unifiedCommentEntry struct {
+	stamp    string
+	comments []UnifiedComment
+}
+
+// unifiedCommentSources returns the files holding the unified comments of a
+// platform directory: the first of comments.jsonl, comments.csv and
+// comments.xlsx, or else the xlsx_book workbooks (read from their Comments
+// sheet).
+func unifiedCommentSources(dir string) []string {
+	for _, name := range []string{"comments.jsonl", "comments.csv", "comments.xlsx"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return []string{filepath.Join(dir, name)}
+		}
+	}
+	books, _ := filepath.Glob(filepath.Join(dir, "*_*_*.xlsx"))
+	sort.Strings(books)
+	return books
+}
+
+// loadUnifiedCommentFiles returns the unified comments of a platform
+// directory and the modification time of their newest file. The result is
+// shared; callers must not modify it.
+func loadUnifiedCommentFiles(dir string) ([]UnifiedComment, int64, error) {
+	sources := unifiedCommentSources(dir)
+	if len(sources) == 0 {
+		return nil, 0, fmt.Errorf("no unified comments file in %s: %w", dir, os.ErrNotExist)
+	}
+	var stamp strings.Builder
+	var mtime int64
+	for _, path := range sources {
+		st, err := os.Stat(path)
+		if err != nil {
+			return nil, 0, err
+		}
+		fmt.Fprintf(&stamp, "%s:%d:%d;", path, st.Size(), st.ModTime().UnixNano())
+		mtime = max(mtime, st.ModTime().Unix())
+	}
+
+	unifiedCommentCache.Lock()
+	defer unifiedCommentCache.Unlock()
+	if e, ok := unifiedCommentCache.entries[dir]; ok && e.stamp == stamp.String() {
+		return e.comments, mtime, nil
+	}
+	var out []UnifiedComment
+	for _, path := range sources {
+		var comments []UnifiedComment
+		var err error
+		switch filepath.Ext(path) {
+		case ".jsonl":
+			comments, err = readUnifiedCommentsJSONL(path)
+		case ".csv":
+			comments, err = readUnifiedCommentsCSV(path)
+		default:
+			comments, err = readUnifiedCommentsXLSX(path)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, comments...)
+	}
+	unifiedCommentCache.entries[dir] = unifiedCommentEntry{stamp: stamp.String(), comments: out}
+	return out, mtime, nil
+}
+
+func readUnifiedCommentsJSONL(path string) ([]UnifiedComment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []UnifiedComment
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		var c UnifiedComment
+		if err := json.Unmarshal(sc.Bytes(), &c); err != nil {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out, sc.Err()
+}
+
+func readUnifiedCommentsCSV(path string) ([]UnifiedComment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	// Rows written before the thread columns existed are shorter.
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return unifiedCommentsFromRecords(records), nil
+}
+
+// readUnifiedCommentsXLSX reads comments.xlsx, or the Comments sheet of an
+// xlsx_book workbook (none when the workbook has no such sheet).
+func readUnifiedCommentsXLSX(path string) ([]UnifiedComment, error) {
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sheet := "Comments"
+	if filepath.Base(path) == "comments.xlsx" {
+		sheet = f.GetSheetName(0)
+	}
+	if idx, err := f.GetSheetIndex(sheet); err != nil || idx < 0 {
+		return nil, nil
+	}
+	records, err := f.GetRows(sheet)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 || len(records[0]) == 0 || strings.TrimPrefix(records[0][0], "\uFEFF") != "platform" {
+		return nil, nil
+	}
+	return unifiedCommentsFromRecords(records), nil
+}
+
+// unifiedCommentsFromRecords parses rows in the UnifiedComment.CSVHeader
+// column order, skipping the header row.
+func unifiedCommentsFromRecords(records [][]string) []UnifiedComment {
+	var out []UnifiedComment
+	for i, rec := range records {
+		if len(rec) < 10 || (i == 0 && strings.TrimPrefix(rec[0], "\uFEFF") == "platform") {
+			continue
+		}
+		col := func(n int) string {
+			if n < len(rec) {
+				return rec[n]
+			}
+			return ""
+		}
+		createTime, _ := strconv.ParseInt(rec[5], 10, 64)
+		likeCount, _ := strconv.ParseInt(rec[6], 10, 64)
+		depth, _ := strconv.Atoi(col(14))
+		subCount, _ := strconv.ParseInt(col(15), 10, 64)
+		out = append(out, UnifiedComment{
+			Platform:            rec[0],
+			NoteID:              rec[1],
+			CommentID:           rec[2],
+			ParentCommentID:     rec[3],
+			Content:             rec[4],
+			CreateTime:          createTime,
+			LikeCount:           likeCount,
+			UserID:              rec[7],
+			UserSecUID:          rec[8],
+			UserNickname:        rec[9],
+			RootCommentID:       col(10),
+			ReplyToCommentID:    col(11),
+			ReplyToUserID:       col(12),
+			ReplyToUserNickname: col(13),
+			Depth:               depth,
+			SubCommentCount:     subCount,
+		})
+	}
+	return out
+}
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"errors"
+	"media-crawler-go/internal/config"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLinkCommentReplies(t *testing.T) {
+	items := []*UnifiedComment{
+		{CommentID: "c1", UserID: "u1", UserNickname: "alice"},
+		{CommentID: "c2", ParentCommentID: "c1", UserID: "u2", UserNickname: "bob"},
+		{CommentID: "c3", RootCommentID: "c1", ReplyToCommentID: "c2", UserID: "u3", UserNickname: "carol"},
+		{CommentID: "c4", ParentCommentID: "gone", Content: "回复 @dave: hi"},
+		{CommentID: "c5", ParentCommentID: "c5"},
+	}
+	LinkCommentReplies(items)
+
+	c1, c2, c3, c4, c5 := items[0], items[1], items[2], items[3], items[4]
+	if c1.Depth != 0 || c1.RootCommentID != "" || c1.SubCommentCount != 2 {
+		t.Fatalf("unexpected top-level: %+v", c1)
+	}
+	if c2.Depth != 1 || c2.RootCommentID != "c1" || c2.ReplyToCommentID != "c1" || c2.ReplyToUserID != "u1" {
+		t.Fatalf("unexpected reply: %+v", c2)
+	}
+	if c3.Depth != 2 || c3.RootCommentID != "c1" || c3.ReplyToUserNickname != "bob" {
+		t.Fatalf("unexpected nested reply: %+v", c3)
+	}
+	if c4.Depth != 1 || c4.RootCommentID != "gone" || c4.ReplyToUserNickname != "dave" {
+		t.Fatalf("unexpected orphan reply: %+v", c4)
+	}
+	if c5.Depth != 0 || c5.RootCommentID != "" || c5.ReplyToCommentID != "" {
+		t.Fatalf("self parent should be top-level: %+v", c5)
+	}
+}
+
+func TestBuildCommentThreads(t *testing.T) {
+	threads := BuildCommentThreads([]UnifiedComment{
+		{CommentID: "c3", RootCommentID: "c1", ReplyToCommentID: "c2", CreateTime: 3},
+		{CommentID: "c2", ParentCommentID: "c1", CreateTime: 2},
+		{CommentID: "c4", CreateTime: 0},
+		{CommentID: "c1", CreateTime: 1},
+		{CommentID: "c5", ParentCommentID: "c1", CreateTime: 5},
+	})
+	if len(threads) != 2 || threads[0].Comment.CommentID != "c4" || threads[1].Comment.CommentID != "c1" {
+		t.Fatalf("unexpected roots: %+v", threads)
+	}
+	c1 := threads[1]
+	if len(c1.Replies) != 2 || c1.Replies[0].Comment.CommentID != "c2" || c1.Replies[1].Comment.CommentID != "c5" {
+		t.Fatalf("unexpected replies: %+v", c1.Replies)
+	}
+	if r := c1.Replies[0].Replies; len(r) != 1 || r[0].Comment.CommentID != "c3" || r[0].Comment.Depth != 2 {
+		t.Fatalf("unexpected nested replies: %+v", r)
+	}
+}
+
+func TestLoadNoteCommentsCSV(t *testing.T) {
+	dataDir := t.TempDir()
+	oldCfg := config.AppConfig
+	config.AppConfig.DataDir = dataDir
+	config.AppConfig.Platform = "bilibili"
+	t.Cleanup(func() { config.AppConfig = oldCfg })
+
+	items := []any{
+		&UnifiedComment{Platform: "bilibili", NoteID: "n1", CommentID: "c1", Content: "a", SubCommentCount: 1},
+		&UnifiedComment{Platform: "bilibili", NoteID: "n1", CommentID: "c2", RootCommentID: "c1", ReplyToCommentID: "c1", Depth: 1},
+		&UnifiedComment{Platform: "bilibili", NoteID: "n2", CommentID: "c3"},
+	}
+	if _, err := AppendUniqueGlobalCommentsCSV(
+		items,
+		func(item any) (string, error) { return item.(*UnifiedComment).CommentID, nil },
+		(&UnifiedComment{}).CSVHeader(),
+		func(item any) ([]string, error) { return item.(*UnifiedComment).ToCSV(), nil },
+	); err != nil {
+		t.Fatalf("append csv: %v", err)
+	}
+	// A row written before the thread columns existed.
+	f, err := os.OpenFile(filepath.Join(dataDir, "bilibili", "comments.csv"), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("open csv: %v", err)
+	}
+	_, _ = f.WriteString("bilibili,n1,c0,,old,0,0,u,,nick\n")
+	_ = f.Close()
+
+	got, err := LoadNoteComments("bilibili", "n1")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 comments, got %+v", got)
+	}
+	if got[0].SubCommentCount != 1 || got[1].RootCommentID != "c1" || got[1].Depth != 1 || got[2].CommentID != "c0" {
+		t.Fatalf("unexpected comments: %+v", got)
+	}
+	if _, err := LoadNoteComments("weibo", "n1"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected missing file error, got %v", err)
+	}
+}
+
+func TestLoadNoteCommentsBackends(t *testing.T) {
+	tmp := t.TempDir()
+	oldCfg := config.AppConfig
+	config.AppConfig = config.Config{
+		DataDir:        filepath.Join(tmp, "data"),
+		Platform:       "douyin",
+		StoreBackend:   "sqlite",
+		SQLitePath:     filepath.Join(tmp, "data", "media_crawler.db"),
+		SaveDataOption: "json",
+	}
+	resetSQLiteForTest(t)
+	t.Cleanup(func() {
+		resetSQLiteForTest(t)
+		config.AppConfig = oldCfg
+	})
+
+	keyFn := func(item any) (string, error) { return item.(map[string]any)["cid"].(string), nil }
+	if _, err := AppendUniqueCommentsJSONL("a1", []any{
+		map[string]any{"cid": "c1", "text": "hi", "create_time": 1700000000, "user": map[string]any{"uid": "u1", "nickname": "alice"}},
+		map[string]any{"cid": "c2", "text": "yo", "reply_id": "c1", "create_time": 1700000100},
+	}, keyFn); err != nil {
+		t.Fatalf("save comments: %v", err)
+	}
+	got, err := LoadNoteComments("douyin", "a1")
+	if err != nil || len(got) != 2 {
+		t.Fatalf("sqlite comments: %+v err=%v", got, err)
+	}
+	threads := BuildCommentThreads(got)
+	if len(threads) != 1 || threads[0].Comment.UserNickname != "alice" || len(threads[0].Replies) != 1 {
+		t.Fatalf("sqlite threads: %+v", threads)
+	}
+
+	// xlsx_book keeps the unified rows in the Comments sheet of each workbook.
+	config.AppConfig.StoreBackend = ""
+	config.AppConfig.SaveDataOption = "xlsx_book"
+	BeginRunWorkbook()
+	if _, err := AppendUniqueGlobalCommentsBook(
+		[]any{
+			&UnifiedComment{Platform: "douyin", NoteID: "a2", CommentID: "c3"},
+			&UnifiedComment{Platform: "douyin", NoteID: "a2", CommentID: "c4", ParentCommentID: "c3"},
+		},
+		func(item any) (string, error) { return item.(*UnifiedComment).CommentID, nil },
+		(&UnifiedComment{}).CSVHeader(),
+		func(item any) ([]string, error) { return item.(*UnifiedComment).ToCSV(), nil },
+	); err != nil {
+		t.Fatalf("append book: %v", err)
+	}
+	got, err = LoadNoteComments("douyin", "a2")
+	if err != nil || len(got) != 2 || got[1].ParentCommentID != "c3" {
+		t.Fatalf("book comments: %+v err=%v", got, err)
+	}
+}
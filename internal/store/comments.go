@@ -52,6 +52,7 @@ func AppendUniqueCommentsXLSX(noteID string, items []any, keyFn func(any) (strin
 
 func AppendUniqueGlobalCommentsJSONL(items []any, keyFn func(any) (string, error)) (int, error) {
 	n, err := AppendUniqueJSONL(PlatformDir(), "comments.jsonl", "comments.global.idx", items, keyFn)
+	recordGlobalCommentsFetched("json", n)
 	if err != nil {
 		return n, err
 	}
@@ -62,13 +63,19 @@ func AppendUniqueGlobalCommentsJSONL(items []any, keyFn func(any) (string, error
 }
 
 func AppendUniqueGlobalCommentsCSV(items []any, keyFn func(any) (string, error), header []string, rowFn func(any) ([]string, error)) (int, error) {
-	return AppendUniqueCSV(PlatformDir(), "comments.csv", "comments.global.idx", items, keyFn, header, rowFn)
+	n, err := AppendUniqueCSV(PlatformDir(), "comments.csv", "comments.global.idx", items, keyFn, header, rowFn)
+	recordGlobalCommentsFetched("csv", n)
+	return n, err
 }
 
 func AppendUniqueGlobalCommentsXLSX(items []any, keyFn func(any) (string, error), header []string, rowFn func(any) ([]string, error)) (int, error) {
-	return AppendUniqueXLSX(PlatformDir(), "comments.xlsx", "comments.global.idx", items, keyFn, header, rowFn)
+	n, err := AppendUniqueXLSX(PlatformDir(), "comments.xlsx", "comments.global.idx", items, keyFn, header, rowFn)
+	recordGlobalCommentsFetched("xlsx", n)
+	return n, err
 }
 
 func AppendUniqueGlobalCommentsBook(items []any, keyFn func(any) (string, error), header []string, rowFn func(any) ([]string, error)) (int, error) {
-	return AppendUniqueBookSheetRows("Comments", "comments.book.idx", items, keyFn, header, rowFn)
+	n, err := AppendUniqueBookSheetRows("Comments", "comments.book.idx", items, keyFn, header, rowFn)
+	recordGlobalCommentsFetched("xlsx_book", n)
+	return n, err
 }
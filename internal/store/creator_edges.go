@@ -0,0 +1,80 @@
+package store
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"media-crawler-go/internal/config"
+)
+
+// Edge types of the creator relationship graph. An edge always means
+// "From follows To"; the type records which list it was collected from.
+const (
+	EdgeTypeFollower  = "follower"  // From is listed among To's fans
+	EdgeTypeFollowing = "following" // To is listed among From's followings
+)
+
+// CreatorEdge is one follow relationship between two accounts of a platform.
+type CreatorEdge struct {
+	Platform string `json:"platform"`
+	FromID   string `json:"from_id"`
+	FromName string `json:"from_name,omitempty"`
+	ToID     string `json:"to_id"`
+	ToName   string `json:"to_name,omitempty"`
+	Type     string `json:"type"`
+	SeenAt   int64  `json:"seen_at"`
+}
+
+func (e *CreatorEdge) CSVHeader() []string {
+	return []string{"platform", "from_id", "from_name", "to_id", "to_name", "type", "seen_at"}
+}
+
+func (e *CreatorEdge) ToCSV() []string {
+	return []string{e.Platform, e.FromID, e.FromName, e.ToID, e.ToName, e.Type, strconv.FormatInt(e.SeenAt, 10)}
+}
+
+func (e *CreatorEdge) Key() string {
+	return e.Type + ":" + e.FromID + ":" + e.ToID
+}
+
+// AppendCreatorEdges stores relationship edges in the DB backend (creator_edges
+// table/collection, seen_at refreshed on conflict) and appends the new ones to
+// data/<platform>/creator_edges.(jsonl|csv|xlsx). Platform and SeenAt are
+// filled in when empty.
+func AppendCreatorEdges(edges []CreatorEdge) (int, error) {
+	platform := strings.TrimSpace(config.AppConfig.Platform)
+	if platform == "" {
+		platform = "xhs"
+	}
+	now := time.Now().Unix()
+	items := make([]any, 0, len(edges))
+	for i := range edges {
+		e := &edges[i]
+		if strings.TrimSpace(e.FromID) == "" || strings.TrimSpace(e.ToID) == "" {
+			continue
+		}
+		if e.Platform == "" {
+			e.Platform = platform
+		}
+		if e.SeenAt == 0 {
+			e.SeenAt = now
+		}
+		items = append(items, e)
+	}
+	if len(items) == 0 {
+		return 0, nil
+	}
+	if err := sqlUpsertCreatorEdges(items); err != nil {
+		return 0, err
+	}
+	return appendUniqueRecords(
+		PlatformDir(),
+		"creator_edges",
+		"CreatorEdges",
+		items,
+		func(item any) (string, error) { return item.(*CreatorEdge).Key(), nil },
+		(&CreatorEdge{}).CSVHeader(),
+		func(item any) ([]string, error) { return item.(*CreatorEdge).ToCSV(), nil },
+	)
+}
@@ -0,0 +1,354 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// LoadCreatorEdges reads the relationship edges of a platform from the DB
+// backend, or from data/<platform>/creator_edges.(jsonl|csv|xlsx) with the
+// file backend.
+func LoadCreatorEdges(ctx context.Context, platform string) ([]CreatorEdge, error) {
+	platform = strings.TrimSpace(platform)
+	if platform == "" {
+		return nil, errors.New("platform is empty")
+	}
+	switch backendKind() {
+	case backendSQLite:
+		db, err := sqliteDB()
+		if err != nil {
+			return nil, err
+		}
+		return queryCreatorEdges(ctx, db, `SELECT data_json FROM creator_edges WHERE platform=? ORDER BY seen_at`, platform)
+	case backendMySQL:
+		db, err := mysqlDB()
+		if err != nil {
+			return nil, err
+		}
+		return queryCreatorEdges(ctx, db, `SELECT data_json FROM creator_edges WHERE platform=? ORDER BY seen_at`, platform)
+	case backendPostgres:
+		db, err := postgresDB()
+		if err != nil {
+			return nil, err
+		}
+		return queryCreatorEdges(ctx, db, `SELECT data_json FROM creator_edges WHERE platform=$1 ORDER BY seen_at`, platform)
+	case backendMongoDB:
+		return mongoLoadCreatorEdges(ctx, platform)
+	default:
+		return loadCreatorEdgeFiles(filepath.Join(filepath.Dir(PlatformDir()), platform))
+	}
+}
+
+func queryCreatorEdges(ctx context.Context, db *sql.DB, query string, platform string) ([]CreatorEdge, error) {
+	rows, err := db.QueryContext(ctx, query, platform)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []CreatorEdge
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return out, err
+		}
+		var e CreatorEdge
+		if err := json.Unmarshal([]byte(raw), &e); err != nil {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func mongoLoadCreatorEdges(ctx context.Context, platform string) ([]CreatorEdge, error) {
+	cli, err := mongoClient()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	cur, err := cli.Database(mongoDBName()).Collection("creator_edges").Find(ctx, bson.D{{Key: "platform", Value: platform}})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	var out []CreatorEdge
+	for cur.Next(ctx) {
+		var doc struct {
+			DataJSON string `bson:"data_json"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			continue
+		}
+		var e CreatorEdge
+		if err := json.Unmarshal([]byte(doc.DataJSON), &e); err != nil {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out, cur.Err()
+}
+
+// loadCreatorEdgeFiles reads creator_edges.jsonl (also written next to the
+// CreatorEdges sheet of xlsx_book), creator_edges.csv or creator_edges.xlsx.
+func loadCreatorEdgeFiles(dir string) ([]CreatorEdge, error) {
+	if f, err := os.Open(filepath.Join(dir, "creator_edges.jsonl")); err == nil {
+		defer f.Close()
+		var out []CreatorEdge
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+		for sc.Scan() {
+			var e CreatorEdge
+			if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+				continue
+			}
+			out = append(out, e)
+		}
+		return out, sc.Err()
+	}
+	if f, err := os.Open(filepath.Join(dir, "creator_edges.csv")); err == nil {
+		defer f.Close()
+		records, err := csv.NewReader(f).ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		return creatorEdgesFromRecords(records), nil
+	}
+	f, err := excelize.OpenFile(filepath.Join(dir, "creator_edges.xlsx"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no creator_edges.jsonl, creator_edges.csv or creator_edges.xlsx in %s", dir)
+		}
+		return nil, err
+	}
+	defer f.Close()
+	records, err := f.GetRows(f.GetSheetName(0))
+	if err != nil {
+		return nil, err
+	}
+	return creatorEdgesFromRecords(records), nil
+}
+
+// creatorEdgesFromRecords parses rows in the CreatorEdge.CSVHeader column
+// order, skipping the header row.
+func creatorEdgesFromRecords(records [][]string) []CreatorEdge {
+	var out []CreatorEdge
+	for i, r := range records {
+		if i == 0 || len(r) < 7 {
+			continue
+		}
+		seenAt, _ := strconv.ParseInt(r[6], 10, 64)
+		out = append(out, CreatorEdge{Platform: r[0], FromID: r[1], FromName: r[2], ToID: r[3], ToName: r[4], Type: r[5], SeenAt: seenAt})
+	}
+	return out
+}
+
+// WriteCreatorGraph writes edges as a directed graph in GraphML ("graphml")
+// or GEXF ("gexf") format. Nodes are labelled with the latest non-empty name
+// seen for each account.
+func WriteCreatorGraph(w io.Writer, format string, edges []CreatorEdge) error {
+	nodes := graphNodes(edges)
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "graphml", "":
+		return writeGraphML(w, nodes, edges)
+	case "gexf":
+		return writeGEXF(w, nodes, edges)
+	default:
+		return fmt.Errorf("unsupported graph format: %s (supported: graphml/gexf)", format)
+	}
+}
+
+type graphNode struct {
+	ID    string
+	Label string
+}
+
+func graphNodes(edges []CreatorEdge) []graphNode {
+	labels := map[string]string{}
+	seen := map[string]int64{}
+	add := func(id, name string, at int64) {
+		if _, ok := labels[id]; !ok {
+			labels[id] = ""
+		}
+		if name != "" && at >= seen[id] {
+			labels[id] = name
+			seen[id] = at
+		}
+	}
+	for _, e := range edges {
+		add(e.FromID, e.FromName, e.SeenAt)
+		add(e.ToID, e.ToName, e.SeenAt)
+	}
+	out := make([]graphNode, 0, len(labels))
+	for id, label := range labels {
+		if label == "" {
+			label = id
+		}
+		out = append(out, graphNode{ID: id, Label: label})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLDoc struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLNode `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	} `xml:"graph"`
+}
+
+func writeGraphML(w io.Writer, nodes []graphNode, edges []CreatorEdge) error {
+	var doc graphMLDoc
+	doc.Xmlns = "http://graphml.graphdrawing.org/xmlns"
+	doc.Keys = []graphMLKey{
+		{ID: "label", For: "node", AttrName: "label", AttrType: "string"},
+		{ID: "type", For: "edge", AttrName: "type", AttrType: "string"},
+		{ID: "platform", For: "edge", AttrName: "platform", AttrType: "string"},
+		{ID: "seen_at", For: "edge", AttrName: "seen_at", AttrType: "long"},
+	}
+	doc.Graph.ID = "creators"
+	doc.Graph.EdgeDefault = "directed"
+	for _, n := range nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: n.ID, Data: []graphMLData{{Key: "label", Value: n.Label}}})
+	}
+	for i, e := range edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			ID:     "e" + strconv.Itoa(i),
+			Source: e.FromID,
+			Target: e.ToID,
+			Data: []graphMLData{
+				{Key: "type", Value: e.Type},
+				{Key: "platform", Value: e.Platform},
+				{Key: "seen_at", Value: strconv.FormatInt(e.SeenAt, 10)},
+			},
+		})
+	}
+	return writeXMLDoc(w, doc)
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type gexfNode struct {
+	ID    string `xml:"id,attr"`
+	Label string `xml:"label,attr"`
+}
+
+type gexfEdge struct {
+	ID        string         `xml:"id,attr"`
+	Source    string         `xml:"source,attr"`
+	Target    string         `xml:"target,attr"`
+	Label     string         `xml:"label,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfDoc struct {
+	XMLName xml.Name `xml:"gexf"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Version string   `xml:"version,attr"`
+	Graph   struct {
+		Mode            string `xml:"mode,attr"`
+		DefaultEdgeType string `xml:"defaultedgetype,attr"`
+		Attributes      struct {
+			Class string          `xml:"class,attr"`
+			Attrs []gexfAttribute `xml:"attribute"`
+		} `xml:"attributes"`
+		Nodes []gexfNode `xml:"nodes>node"`
+		Edges []gexfEdge `xml:"edges>edge"`
+	} `xml:"graph"`
+}
+
+func writeGEXF(w io.Writer, nodes []graphNode, edges []CreatorEdge) error {
+	var doc gexfDoc
+	doc.Xmlns = "http://gexf.net/1.3"
+	doc.Version = "1.3"
+	doc.Graph.Mode = "static"
+	doc.Graph.DefaultEdgeType = "directed"
+	doc.Graph.Attributes.Class = "edge"
+	doc.Graph.Attributes.Attrs = []gexfAttribute{
+		{ID: "platform", Title: "platform", Type: "string"},
+		{ID: "seen_at", Title: "seen_at", Type: "long"},
+	}
+	for _, n := range nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gexfNode{ID: n.ID, Label: n.Label})
+	}
+	for i, e := range edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, gexfEdge{
+			ID:     "e" + strconv.Itoa(i),
+			Source: e.FromID,
+			Target: e.ToID,
+			Label:  e.Type,
+			AttValues: []gexfAttValue{
+				{For: "platform", Value: e.Platform},
+				{For: "seen_at", Value: strconv.FormatInt(e.SeenAt, 10)},
+			},
+		})
+	}
+	return writeXMLDoc(w, doc)
+}
+
+func writeXMLDoc(w io.Writer, doc any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
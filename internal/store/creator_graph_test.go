@@ -0,0 +1,133 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"media-crawler-go/internal/config"
+)
+
+func testEdges() []CreatorEdge {
+	return []CreatorEdge{
+		{FromID: "u1", FromName: "alice", ToID: "u2", ToName: "bob", Type: EdgeTypeFollowing, SeenAt: 10},
+		{FromID: "u3", FromName: "carol", ToID: "u1", ToName: "alice", Type: EdgeTypeFollower, SeenAt: 11},
+	}
+}
+
+func TestAppendCreatorEdgesFileAndLoad(t *testing.T) {
+	dataDir := t.TempDir()
+	oldCfg := config.AppConfig
+	config.AppConfig.DataDir = dataDir
+	config.AppConfig.Platform = "bilibili"
+	config.AppConfig.StoreBackend = "file"
+	config.AppConfig.SaveDataOption = "json"
+	t.Cleanup(func() { config.AppConfig = oldCfg })
+
+	n, err := AppendCreatorEdges(testEdges())
+	if err != nil || n != 2 {
+		t.Fatalf("append edges: n=%d err=%v", n, err)
+	}
+	if n, err := AppendCreatorEdges(testEdges()); err != nil || n != 0 {
+		t.Fatalf("expected duplicates to be skipped: n=%d err=%v", n, err)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "bilibili", "creator_edges.jsonl")); err != nil {
+		t.Fatalf("creator_edges.jsonl missing: %v", err)
+	}
+
+	edges, err := LoadCreatorEdges(context.Background(), "bilibili")
+	if err != nil {
+		t.Fatalf("load edges: %v", err)
+	}
+	if len(edges) != 2 || edges[0].Platform != "bilibili" || edges[1].FromID != "u3" || edges[1].Type != EdgeTypeFollower {
+		t.Fatalf("unexpected edges: %+v", edges)
+	}
+}
+
+func TestLoadCreatorEdgesXLSX(t *testing.T) {
+	for _, option := range []string{"xlsx", "xlsx_book"} {
+		dataDir := t.TempDir()
+		oldCfg := config.AppConfig
+		config.AppConfig.DataDir = dataDir
+		config.AppConfig.Platform = "weibo"
+		config.AppConfig.StoreBackend = "file"
+		config.AppConfig.SaveDataOption = option
+		BeginRunWorkbook()
+
+		if n, err := AppendCreatorEdges(testEdges()); err != nil || n != 2 {
+			t.Fatalf("%s: append edges: n=%d err=%v", option, n, err)
+		}
+		edges, err := LoadCreatorEdges(context.Background(), "weibo")
+		config.AppConfig = oldCfg
+		if err != nil {
+			t.Fatalf("%s: load edges: %v", option, err)
+		}
+		if len(edges) != 2 || edges[0].ToName != "bob" || edges[1].SeenAt != 11 {
+			t.Fatalf("%s: unexpected edges: %+v", option, edges)
+		}
+	}
+}
+
+func TestSQLiteUpsertCreatorEdges(t *testing.T) {
+	tmp := t.TempDir()
+	cwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	t.Cleanup(func() { _ = os.Chdir(cwd) })
+
+	config.AppConfig.Platform = "weibo"
+	config.AppConfig.StoreBackend = "sqlite"
+	config.AppConfig.SQLitePath = filepath.Join(tmp, "data", "media_crawler.db")
+	config.AppConfig.SaveDataOption = "json"
+
+	resetSQLiteForTest(t)
+
+	if _, err := AppendCreatorEdges(testEdges()); err != nil {
+		t.Fatalf("append edges: %v", err)
+	}
+	again := testEdges()[:1]
+	again[0].SeenAt = 99
+	if _, err := AppendCreatorEdges(again); err != nil {
+		t.Fatalf("upsert edge: %v", err)
+	}
+
+	edges, err := LoadCreatorEdges(context.Background(), "weibo")
+	if err != nil {
+		t.Fatalf("load edges: %v", err)
+	}
+	if len(edges) != 2 {
+		t.Fatalf("expected 2 edge rows, got %d", len(edges))
+	}
+	for _, e := range edges {
+		if e.FromID == "u1" && e.SeenAt != 99 {
+			t.Fatalf("expected seen_at to be refreshed, got %+v", e)
+		}
+	}
+}
+
+func TestWriteCreatorGraph(t *testing.T) {
+	for _, format := range []string{"graphml", "gexf"} {
+		var buf bytes.Buffer
+		if err := WriteCreatorGraph(&buf, format, testEdges()); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		out := buf.String()
+		if err := xml.Unmarshal(buf.Bytes(), new(struct{})); err != nil {
+			t.Fatalf("%s: invalid xml: %v", format, err)
+		}
+		for _, want := range []string{`"u1"`, `"u2"`, `"u3"`, "alice", "carol", EdgeTypeFollower} {
+			if !strings.Contains(out, want) {
+				t.Fatalf("%s output missing %s:\n%s", format, want, out)
+			}
+		}
+		if strings.Count(out, "<edge ") != 2 {
+			t.Fatalf("%s: expected 2 edges:\n%s", format, out)
+		}
+	}
+	if err := WriteCreatorGraph(&bytes.Buffer{}, "dot", nil); err == nil {
+		t.Fatalf("expected unsupported format error")
+	}
+}
@@ -2,6 +2,8 @@ package store
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"time"
 )
 
@@ -38,3 +40,34 @@ func Init(ctx context.Context) error {
 		return nil
 	}
 }
+
+// Close waits for an in-flight workbook write, then closes the database
+// backends opened by this process. The next write opens them again. Call it
+// once the crawl has stopped; it does not wait for other writers.
+func Close(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	bookMu.Lock()
+	defer bookMu.Unlock()
+
+	var errs []error
+	if sqliteInst != nil {
+		errs = append(errs, sqliteInst.Close())
+	}
+	sqliteOnce, sqliteInst, sqliteErr = sync.Once{}, nil, nil
+	if mysqlInst != nil {
+		errs = append(errs, mysqlInst.Close())
+	}
+	mysqlOnce, mysqlInst, mysqlErr = sync.Once{}, nil, nil
+	if pgInst != nil {
+		errs = append(errs, pgInst.Close())
+	}
+	pgOnce, pgInst, pgErr = sync.Once{}, nil, nil
+	if mongoCli != nil {
+		errs = append(errs, mongoCli.Disconnect(ctx))
+	}
+	mongoOnce, mongoCli, mongoErr = sync.Once{}, nil, nil
+	resetQueryIndexReady()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,28 @@
+package store
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// LiveDir is the directory of a live room holding its room snapshots and the
+// recorded live events (danmaku, gifts, super chats).
+func LiveDir(roomID string) string {
+	return filepath.Join(PlatformDir(), "live", roomID)
+}
+
+func AppendLiveRoomSnapshots(roomID string, items []any, keyFn func(any) (string, error), header []string, rowFn func(any) ([]string, error)) (int, error) {
+	roomID = strings.TrimSpace(roomID)
+	if roomID == "" || len(items) == 0 {
+		return 0, nil
+	}
+	return appendUniqueRecords(LiveDir(roomID), "room", "LiveRooms", items, keyFn, header, rowFn)
+}
+
+func AppendUniqueLiveEvents(roomID string, items []any, keyFn func(any) (string, error), header []string, rowFn func(any) ([]string, error)) (int, error) {
+	roomID = strings.TrimSpace(roomID)
+	if roomID == "" || len(items) == 0 {
+		return 0, nil
+	}
+	return appendUniqueRecords(LiveDir(roomID), "events", "LiveEvents", items, keyFn, header, rowFn)
+}
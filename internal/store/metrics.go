@@ -0,0 +1,46 @@
+package store
+
+import (
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/metrics"
+	"strings"
+	"time"
+)
+
+// observeStoreWrite records the latency and, when *err is set, the failure
+// of a store write. Call it deferred with the write's start time.
+func observeStoreWrite(backend sqlBackendKind, op string, start time.Time, err *error) {
+	metrics.StoreWriteDuration.Observe(time.Since(start).Seconds(), string(backend), op)
+	if err != nil && *err != nil {
+		metrics.StoreWriteErrors.Inc(string(backend), op)
+	}
+}
+
+func recordCommentsFetched(n int) {
+	if n <= 0 {
+		return
+	}
+	platform := strings.ToLower(strings.TrimSpace(config.AppConfig.Platform))
+	if platform == "" {
+		platform = "xhs"
+	}
+	metrics.CommentsFetched.Add(float64(n), platform)
+}
+
+// recordGlobalCommentsFetched counts the comments a global comment file write
+// added in the given save format. Only the file of the configured
+// SAVE_DATA_OPTION counts, since xlsx_book runs also append comments.jsonl.
+// Database backends count their own inserts instead, except in xlsx_book
+// mode, which writes comments to the workbook only.
+func recordGlobalCommentsFetched(format string, n int) {
+	option := strings.ToLower(strings.TrimSpace(config.AppConfig.SaveDataOption))
+	switch option {
+	case "csv", "xlsx", "xlsx_book":
+	default:
+		option = "json"
+	}
+	if format != option || (sqlEnabled() && format != "xlsx_book") {
+		return
+	}
+	recordCommentsFetched(n)
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/metrics"
+)
+
+func TestStoreWriteMetrics(t *testing.T) {
+	oldCfg := config.AppConfig
+	config.AppConfig = config.Config{DataDir: t.TempDir(), Platform: "metricsplat"}
+	t.Cleanup(func() { config.AppConfig = oldCfg })
+
+	before := metrics.StoreWriteDuration.Count("file", "append")
+	keyFn := func(item any) (string, error) { return item.(map[string]any)["id"].(string), nil }
+	items := []any{map[string]any{"id": "c1"}, map[string]any{"id": "c2"}}
+	if _, err := AppendUniqueGlobalCommentsJSONL(items, keyFn); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if _, err := AppendUniqueGlobalCommentsJSONL(items, keyFn); err != nil {
+		t.Fatalf("append again: %v", err)
+	}
+	if got := metrics.StoreWriteDuration.Count("file", "append") - before; got != 2 {
+		t.Fatalf("append writes observed: %d", got)
+	}
+	if got := metrics.CommentsFetched.Value("metricsplat"); got != 2 {
+		t.Fatalf("comments fetched: %v", got)
+	}
+}
+
+func TestCommentsFetchedCountsEachBackendOnce(t *testing.T) {
+	oldCfg := config.AppConfig
+	tmp := t.TempDir()
+	t.Cleanup(func() {
+		resetSQLiteForTest(t)
+		config.AppConfig = oldCfg
+	})
+	keyFn := func(item any) (string, error) { return item.(*UnifiedComment).CommentID, nil }
+	items := []any{&UnifiedComment{NoteID: "n1", CommentID: "c1"}, &UnifiedComment{NoteID: "n1", CommentID: "c2"}}
+
+	// Database backends count their inserts, not the file copies.
+	config.AppConfig = config.Config{DataDir: tmp, Platform: "metricsdb", StoreBackend: "sqlite", SQLitePath: filepath.Join(tmp, "media_crawler.db"), SaveDataOption: "json"}
+	resetSQLiteForTest(t)
+	for i := 0; i < 2; i++ {
+		if _, err := AppendUniqueCommentsJSONL("n1", items, keyFn); err != nil {
+			t.Fatalf("append note comments: %v", err)
+		}
+		if _, err := AppendUniqueGlobalCommentsJSONL(items, keyFn); err != nil {
+			t.Fatalf("append global comments: %v", err)
+		}
+	}
+	if got := metrics.CommentsFetched.Value("metricsdb"); got != 2 {
+		t.Fatalf("sqlite comments fetched: %v", got)
+	}
+
+	// xlsx_book counts the workbook rows, not the comments.jsonl copy.
+	config.AppConfig = config.Config{DataDir: tmp, Platform: "metricsbook", SaveDataOption: "xlsx_book"}
+	BeginRunWorkbook()
+	header := (&UnifiedComment{}).CSVHeader()
+	rowFn := func(item any) ([]string, error) { return item.(*UnifiedComment).ToCSV(), nil }
+	for i := 0; i < 2; i++ {
+		if _, err := AppendUniqueGlobalCommentsBook(items, keyFn, header, rowFn); err != nil {
+			t.Fatalf("append book: %v", err)
+		}
+		if _, err := AppendUniqueGlobalCommentsJSONL(items, keyFn); err != nil {
+			t.Fatalf("append jsonl: %v", err)
+		}
+	}
+	if got := metrics.CommentsFetched.Value("metricsbook"); got != 2 {
+		t.Fatalf("xlsx_book comments fetched: %v", got)
+	}
+}
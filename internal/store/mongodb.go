@@ -76,6 +76,10 @@ func initMongoSchema(ctx context.Context, cli *mongo.Client) error {
 			Keys:    bson.D{{Key: "platform", Value: 1}, {Key: "note_id", Value: 1}},
 			Options: options.Index().SetUnique(true).SetName("uniq_platform_note"),
 		},
+		{
+			Keys:    bson.D{{Key: "platform", Value: 1}, {Key: "query.create_time", Value: 1}},
+			Options: options.Index().SetName("idx_platform_query_time"),
+		},
 	})
 	if err != nil {
 		return fmt.Errorf("mongo create indexes notes: %w", err)
@@ -87,6 +91,10 @@ func initMongoSchema(ctx context.Context, cli *mongo.Client) error {
 			Keys:    bson.D{{Key: "platform", Value: 1}, {Key: "creator_id", Value: 1}},
 			Options: options.Index().SetUnique(true).SetName("uniq_platform_creator"),
 		},
+		{
+			Keys:    bson.D{{Key: "platform", Value: 1}, {Key: "query.updated_at", Value: 1}},
+			Options: options.Index().SetName("idx_platform_query_time"),
+		},
 	})
 	if err != nil {
 		return fmt.Errorf("mongo create indexes creators: %w", err)
@@ -102,10 +110,29 @@ func initMongoSchema(ctx context.Context, cli *mongo.Client) error {
 			Keys:    bson.D{{Key: "platform", Value: 1}, {Key: "note_id", Value: 1}},
 			Options: options.Index().SetName("idx_platform_note"),
 		},
+		{
+			Keys:    bson.D{{Key: "platform", Value: 1}, {Key: "query.create_time", Value: 1}},
+			Options: options.Index().SetName("idx_platform_query_time"),
+		},
 	})
 	if err != nil {
 		return fmt.Errorf("mongo create indexes comments: %w", err)
 	}
+
+	edges := db.Collection("creator_edges")
+	_, err = edges.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "platform", Value: 1}, {Key: "from_id", Value: 1}, {Key: "to_id", Value: 1}, {Key: "edge_type", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("uniq_platform_edge"),
+		},
+		{
+			Keys:    bson.D{{Key: "platform", Value: 1}, {Key: "to_id", Value: 1}},
+			Options: options.Index().SetName("idx_platform_edge_to"),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("mongo create indexes creator_edges: %w", err)
+	}
 	return nil
 }
 
@@ -139,6 +166,7 @@ func mongoUpsertNote(noteID string, note any) error {
 		"data_json":   string(b),
 		"updated_at":  now,
 		"updated_iso": time.Now().UTC().Format(time.RFC3339Nano),
+		"query":       queryIndexRecord(QueryNotes, platform, noteID, "", b, now),
 	}}}
 	_, err = coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
 	return err
@@ -174,6 +202,7 @@ func mongoUpsertCreator(creatorID string, data any) error {
 		"data_json":   string(b),
 		"updated_at":  now,
 		"updated_iso": time.Now().UTC().Format(time.RFC3339Nano),
+		"query":       queryIndexRecord(QueryCreators, platform, creatorID, "", b, now),
 	}}}
 	_, err = coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
 	return err
@@ -220,6 +249,7 @@ func mongoInsertComments(noteID string, items []any, keyFn func(any) (string, er
 			"created_at":   now,
 			"created_iso":  time.Now().UTC().Format(time.RFC3339Nano),
 			"note_id_norm": noteID,
+			"query":        queryIndexRecord(QueryComments, platform, id, noteID, b, now),
 		}}}
 		m := mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update).SetUpsert(true)
 		models = append(models, m)
@@ -232,7 +262,52 @@ func mongoInsertComments(noteID string, items []any, keyFn func(any) (string, er
 	defer cancel()
 
 	coll := cli.Database(mongoDBName()).Collection("comments")
+	res, err := coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return err
+	}
+	recordCommentsFetched(int(res.UpsertedCount))
+	return nil
+}
+
+
+func mongoUpsertCreatorEdges(edges []any) error {
+	cli, err := mongoClient()
+	if err != nil {
+		return err
+	}
+	if len(edges) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, 0, len(edges))
+	for _, item := range edges {
+		e := item.(*CreatorEdge)
+		b, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("marshal creator edge %s: %w", e.Key(), err)
+		}
+		filter := bson.D{
+			{Key: "platform", Value: e.Platform},
+			{Key: "from_id", Value: e.FromID},
+			{Key: "to_id", Value: e.ToID},
+			{Key: "edge_type", Value: e.Type},
+		}
+		update := bson.D{{Key: "$set", Value: bson.M{
+			"platform":  e.Platform,
+			"from_id":   e.FromID,
+			"to_id":     e.ToID,
+			"edge_type": e.Type,
+			"data_json": string(b),
+			"seen_at":   e.SeenAt,
+		}}}
+		models = append(models, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update).SetUpsert(true))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	coll := cli.Database(mongoDBName()).Collection("creator_edges")
 	_, err = coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
 	return err
 }
-
@@ -76,7 +76,18 @@ func initMySQLSchema(db *sql.DB) error {
 			PRIMARY KEY (platform, comment_id),
 			KEY idx_comments_note (platform, note_id)
 		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
+		`CREATE TABLE IF NOT EXISTS creator_edges (
+			platform VARCHAR(32) NOT NULL,
+			from_id VARCHAR(191) NOT NULL,
+			to_id VARCHAR(191) NOT NULL,
+			edge_type VARCHAR(16) NOT NULL,
+			data_json LONGTEXT NOT NULL,
+			seen_at BIGINT NOT NULL,
+			PRIMARY KEY (platform, from_id, to_id, edge_type),
+			KEY idx_creator_edges_to (platform, to_id)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
 	}
+	stmts = append(stmts, queryIndexSchema(backendMySQL)...)
 	for _, stmt := range stmts {
 		if _, err := db.Exec(stmt); err != nil {
 			return fmt.Errorf("mysql init schema: %w", err)
@@ -103,12 +114,14 @@ func mysqlUpsertNote(noteID string, note any) error {
 		platform = "xhs"
 	}
 	now := time.Now().Unix()
-	_, err = db.Exec(
+	if _, err := db.Exec(
 		`INSERT INTO notes(platform, note_id, data_json, updated_at) VALUES(?, ?, ?, ?)
 		 ON DUPLICATE KEY UPDATE data_json=VALUES(data_json), updated_at=VALUES(updated_at);`,
 		platform, noteID, string(b), now,
-	)
-	return err
+	); err != nil {
+		return err
+	}
+	return sqlIndexQueryRecord(db, backendMySQL, QueryNotes, platform, noteID, "", b, now)
 }
 
 func mysqlUpsertCreator(creatorID string, data any) error {
@@ -129,12 +142,14 @@ func mysqlUpsertCreator(creatorID string, data any) error {
 		platform = "xhs"
 	}
 	now := time.Now().Unix()
-	_, err = db.Exec(
+	if _, err := db.Exec(
 		`INSERT INTO creators(platform, creator_id, data_json, updated_at) VALUES(?, ?, ?, ?)
 		 ON DUPLICATE KEY UPDATE data_json=VALUES(data_json), updated_at=VALUES(updated_at);`,
 		platform, creatorID, string(b), now,
-	)
-	return err
+	); err != nil {
+		return err
+	}
+	return sqlIndexQueryRecord(db, backendMySQL, QueryCreators, platform, creatorID, "", b, now)
 }
 
 func mysqlInsertComments(noteID string, items []any, keyFn func(any) (string, error)) error {
@@ -167,6 +182,7 @@ func mysqlInsertComments(noteID string, items []any, keyFn func(any) (string, er
 	}
 	defer stmt.Close()
 
+	inserted := 0
 	for _, item := range items {
 		id, err := keyFn(item)
 		if err != nil {
@@ -180,7 +196,56 @@ func mysqlInsertComments(noteID string, items []any, keyFn func(any) (string, er
 		if err != nil {
 			return fmt.Errorf("marshal comment %s: %w", id, err)
 		}
-		if _, err := stmt.Exec(platform, id, noteID, string(b), now); err != nil {
+		res, err := stmt.Exec(platform, id, noteID, string(b), now)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue
+		}
+		inserted++
+		if err := sqlIndexQueryRecord(tx, backendMySQL, QueryComments, platform, id, noteID, b, now); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	recordCommentsFetched(inserted)
+	return nil
+}
+
+func mysqlUpsertCreatorEdges(edges []any) error {
+	db, err := mysqlDB()
+	if err != nil {
+		return err
+	}
+	if len(edges) == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.Prepare(
+		`INSERT INTO creator_edges(platform, from_id, to_id, edge_type, data_json, seen_at) VALUES(?, ?, ?, ?, ?, ?)
+		 ON DUPLICATE KEY UPDATE data_json=VALUES(data_json), seen_at=VALUES(seen_at);`,
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, item := range edges {
+		e := item.(*CreatorEdge)
+		b, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("marshal creator edge %s: %w", e.Key(), err)
+		}
+		if _, err := stmt.Exec(e.Platform, e.FromID, e.ToID, e.Type, string(b), e.SeenAt); err != nil {
 			return err
 		}
 	}
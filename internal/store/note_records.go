@@ -0,0 +1,84 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"media-crawler-go/internal/config"
+)
+
+// appendUniqueNoteRecords writes per-note sidecar records (danmaku, video
+// parts, ...) next to note.json using the configured file format.
+func appendUniqueNoteRecords(noteID, baseName, sheet string, items []any, keyFn func(any) (string, error), header []string, rowFn func(any) ([]string, error)) (int, error) {
+	noteID = strings.TrimSpace(noteID)
+	if noteID == "" || len(items) == 0 {
+		return 0, nil
+	}
+	return appendUniqueRecords(NoteDir(noteID), baseName, sheet, items, keyFn, header, rowFn)
+}
+
+func appendUniqueRecords(dir, baseName, sheet string, items []any, keyFn func(any) (string, error), header []string, rowFn func(any) ([]string, error)) (int, error) {
+	if len(items) == 0 {
+		return 0, nil
+	}
+	index := baseName + ".idx"
+	switch config.AppConfig.SaveDataOption {
+	case "csv":
+		return AppendUniqueCSV(dir, baseName+".csv", index, items, keyFn, header, rowFn)
+	case "xlsx":
+		return AppendUniqueXLSX(dir, baseName+".xlsx", index, items, keyFn, header, rowFn)
+	case "xlsx_book":
+		if _, err := AppendUniqueBookSheetRows(sheet, baseName+".book.idx", items, keyFn, header, rowFn); err != nil {
+			return 0, err
+		}
+		return AppendUniqueJSONL(dir, baseName+".jsonl", index, items, keyFn)
+	default:
+		return AppendUniqueJSONL(dir, baseName+".jsonl", index, items, keyFn)
+	}
+}
+
+func AppendUniqueNoteDanmaku(noteID string, items []any, keyFn func(any) (string, error), header []string, rowFn func(any) ([]string, error)) (int, error) {
+	return appendUniqueNoteRecords(noteID, "danmaku", "Danmaku", items, keyFn, header, rowFn)
+}
+
+func AppendUniqueNoteParts(noteID string, items []any, keyFn func(any) (string, error), header []string, rowFn func(any) ([]string, error)) (int, error) {
+	return appendUniqueNoteRecords(noteID, "parts", "Parts", items, keyFn, header, rowFn)
+}
+
+func AppendUniqueNoteReposts(noteID string, items []any, keyFn func(any) (string, error), header []string, rowFn func(any) ([]string, error)) (int, error) {
+	return appendUniqueNoteRecords(noteID, "reposts", "Reposts", items, keyFn, header, rowFn)
+}
+
+// SaveNoteRepostTree writes the nested repost tree of a status to
+// repost_tree.json, replacing the tree of a previous run.
+func SaveNoteRepostTree(noteID string, tree any) error {
+	noteID = strings.TrimSpace(noteID)
+	if noteID == "" {
+		return nil
+	}
+	dir := NoteDir(noteID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(tree, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "repost_tree.json"), append(b, '\n'), 0644)
+}
+
+// AppendUniqueTrending appends trending-list snapshot rows (e.g. weibo hot
+// search) to data/<platform>/trending/, a time series keyed by snapshot and
+// rank.
+func AppendUniqueTrending(items []any, keyFn func(any) (string, error), header []string, rowFn func(any) ([]string, error)) (int, error) {
+	return appendUniqueRecords(filepath.Join(PlatformDir(), "trending"), "hot_search", "Trending", items, keyFn, header, rowFn)
+}
+
+// AppendUniqueFeedSamples appends recommendation feed samples (e.g. the xhs
+// homefeed channels) to data/<platform>/feed/, a time series keyed by sample
+// time, channel and rank.
+func AppendUniqueFeedSamples(items []any, keyFn func(any) (string, error), header []string, rowFn func(any) ([]string, error)) (int, error) {
+	return appendUniqueRecords(filepath.Join(PlatformDir(), "feed"), "samples", "FeedSamples", items, keyFn, header, rowFn)
+}
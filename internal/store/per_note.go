@@ -2,6 +2,7 @@ package store
 
 import (
 	"bufio"
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"errors"
@@ -9,8 +10,11 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"media-crawler-go/internal/webhook"
 )
 
 func PlatformDir() string {
@@ -38,13 +42,34 @@ func NoteMediaDir(noteID string) string {
 }
 
 func SaveNoteDetail(noteID string, note interface{}) error {
+	return SaveNoteDetailContext(context.Background(), noteID, note)
+}
+
+// SaveNoteDetailContext is SaveNoteDetail for crawls that run inside a
+// managed task: the note.saved webhook carries the task id of ctx.
+func SaveNoteDetailContext(ctx context.Context, noteID string, note interface{}) error {
 	if strings.TrimSpace(noteID) == "" {
 		return errors.New("note_id is empty")
 	}
 	if err := sqlUpsertNote(noteID, note); err != nil {
 		return err
 	}
+	if err := saveNoteDetailFile(noteID, note); err != nil {
+		return err
+	}
+	if webhook.Enabled(webhook.NoteSaved) {
+		webhook.Emit(webhook.Event{
+			Type:     webhook.NoteSaved,
+			Platform: strings.TrimSpace(config.AppConfig.Platform),
+			TaskID:   crawler.TaskIDFrom(ctx),
+			Data:     map[string]any{"note_id": noteID, "note": note},
+		})
+	}
+	return nil
+}
 
+func saveNoteDetailFile(noteID string, note interface{}) (err error) {
+	defer observeStoreWrite(backendFile, "note", time.Now(), &err)
 	if config.AppConfig.SaveDataOption == "xlsx_book" {
 		return AppendBookContents(noteID, note)
 	}
@@ -94,7 +119,8 @@ func SaveNoteDetail(noteID string, note interface{}) error {
 	return nil
 }
 
-func AppendUniqueJSONL(dir, dataFilename, indexFilename string, items []any, keyFn func(any) (string, error)) (int, error) {
+func AppendUniqueJSONL(dir, dataFilename, indexFilename string, items []any, keyFn func(any) (string, error)) (_ int, err error) {
+	defer observeStoreWrite(backendFile, "append", time.Now(), &err)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return 0, err
 	}
@@ -148,7 +174,8 @@ func AppendUniqueJSONL(dir, dataFilename, indexFilename string, items []any, key
 	return len(filtered), nil
 }
 
-func AppendUniqueCSV(dir, dataFilename, indexFilename string, items []any, keyFn func(any) (string, error), header []string, rowFn func(any) ([]string, error)) (int, error) {
+func AppendUniqueCSV(dir, dataFilename, indexFilename string, items []any, keyFn func(any) (string, error), header []string, rowFn func(any) ([]string, error)) (_ int, err error) {
+	defer observeStoreWrite(backendFile, "append", time.Now(), &err)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return 0, err
 	}
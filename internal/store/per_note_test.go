@@ -2,10 +2,19 @@ package store
 
 import (
 	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	"media-crawler-go/internal/webhook"
 
 	"github.com/xuri/excelize/v2"
 )
@@ -144,3 +153,107 @@ func TestAppendUniqueXLSX(t *testing.T) {
 		t.Fatalf("unexpected header: %#v", rows[0])
 	}
 }
+
+func TestAppendBookContents(t *testing.T) {
+	dir := t.TempDir()
+	oldCfg := config.AppConfig
+	t.Cleanup(func() {
+		config.AppConfig = oldCfg
+		bookFilename = ""
+	})
+	config.AppConfig.DataDir = dir
+	config.AppConfig.Platform = "xhs"
+	config.AppConfig.CrawlerType = "search"
+	BeginRunWorkbook()
+
+	for _, id := range []string{"n1", "n2", "n1"} {
+		if err := AppendBookContents(id, map[string]any{"id": id}); err != nil {
+			t.Fatalf("AppendBookContents err: %v", err)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "xhs"))
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	var books []string
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			t.Fatalf("temporary workbook left behind: %s", e.Name())
+		}
+		if strings.HasSuffix(e.Name(), ".xlsx") {
+			books = append(books, e.Name())
+		}
+	}
+	if len(books) != 1 {
+		t.Fatalf("expected 1 workbook, got %v", books)
+	}
+	bookPath := filepath.Join(dir, "xhs", books[0])
+	if fi, err := os.Stat(bookPath); err != nil {
+		t.Fatalf("stat workbook: %v", err)
+	} else if fi.Mode().Perm() != 0644 {
+		t.Fatalf("new workbook mode: %v", fi.Mode())
+	}
+	if err := os.Chmod(bookPath, 0640); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+	if err := AppendBookContents("n3", map[string]any{"id": "n3"}); err != nil {
+		t.Fatalf("AppendBookContents err: %v", err)
+	}
+	if fi, err := os.Stat(bookPath); err != nil {
+		t.Fatalf("stat workbook: %v", err)
+	} else if fi.Mode().Perm() != 0640 {
+		t.Fatalf("rewritten workbook mode: %v", fi.Mode())
+	}
+	f, err := excelize.OpenFile(bookPath)
+	if err != nil {
+		t.Fatalf("open xlsx: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	rows, err := f.GetRows("Contents")
+	if err != nil {
+		t.Fatalf("get rows: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("expected header + 3 rows, got %d: %v", len(rows), rows)
+	}
+}
+
+func TestSaveNoteDetailWebhookTaskID(t *testing.T) {
+	oldCfg := config.AppConfig
+	config.AppConfig = config.Config{DataDir: t.TempDir(), Platform: "xhs", SaveDataOption: "json"}
+	t.Cleanup(func() { config.AppConfig = oldCfg })
+
+	events := make(chan webhook.Event, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e webhook.Event
+		_ = json.NewDecoder(r.Body).Decode(&e)
+		events <- e
+	}))
+	defer srv.Close()
+	d := webhook.New([]webhook.Subscription{{Name: "t", URL: srv.URL, Events: []string{webhook.NoteSaved}, Format: "json"}}, webhook.Options{})
+	old := webhook.SetDefault(d)
+	t.Cleanup(func() {
+		webhook.SetDefault(old)
+		_ = d.Close(context.Background())
+	})
+
+	if err := SaveNoteDetailContext(crawler.WithTaskID(context.Background(), "task-1"), "n1", map[string]any{"id": "n1"}); err != nil {
+		t.Fatalf("save in task: %v", err)
+	}
+	if err := SaveNoteDetail("n2", map[string]any{"id": "n2"}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	got := map[string]string{}
+	for i := 0; i < 2; i++ {
+		select {
+		case e := <-events:
+			got[e.Data["note_id"].(string)] = e.TaskID
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for note.saved, got %v", got)
+		}
+	}
+	if got["n1"] != "task-1" || got["n2"] != "" {
+		t.Fatalf("unexpected task ids: %v", got)
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
-func AppendUniqueXLSX(dir, dataFilename, indexFilename string, items []any, keyFn func(any) (string, error), header []string, rowFn func(any) ([]string, error)) (int, error) {
+func AppendUniqueXLSX(dir, dataFilename, indexFilename string, items []any, keyFn func(any) (string, error), header []string, rowFn func(any) ([]string, error)) (_ int, err error) {
+	defer observeStoreWrite(backendFile, "append", time.Now(), &err)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return 0, err
 	}
@@ -76,7 +78,7 @@ func AppendUniqueXLSX(dir, dataFilename, indexFilename string, items []any, keyF
 		nextRow++
 	}
 
-	if err := f.SaveAs(path); err != nil {
+	if err := saveBook(f, path); err != nil {
 		return 0, err
 	}
 
@@ -76,7 +76,18 @@ func initPostgresSchema(db *sql.DB) error {
 			PRIMARY KEY (platform, comment_id)
 		);`,
 		`CREATE INDEX IF NOT EXISTS idx_comments_note ON comments(platform, note_id);`,
+		`CREATE TABLE IF NOT EXISTS creator_edges (
+			platform TEXT NOT NULL,
+			from_id TEXT NOT NULL,
+			to_id TEXT NOT NULL,
+			edge_type TEXT NOT NULL,
+			data_json TEXT NOT NULL,
+			seen_at BIGINT NOT NULL,
+			PRIMARY KEY (platform, from_id, to_id, edge_type)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_creator_edges_to ON creator_edges(platform, to_id);`,
 	}
+	stmts = append(stmts, queryIndexSchema(backendPostgres)...)
 	for _, stmt := range stmts {
 		if _, err := db.Exec(stmt); err != nil {
 			return fmt.Errorf("postgres init schema: %w", err)
@@ -103,14 +114,16 @@ func postgresUpsertNote(noteID string, note any) error {
 		platform = "xhs"
 	}
 	now := time.Now().Unix()
-	_, err = db.Exec(
+	if _, err := db.Exec(
 		`INSERT INTO notes(platform, note_id, data_json, updated_at)
 		 VALUES($1, $2, $3, $4)
 		 ON CONFLICT (platform, note_id)
 		 DO UPDATE SET data_json=EXCLUDED.data_json, updated_at=EXCLUDED.updated_at;`,
 		platform, noteID, string(b), now,
-	)
-	return err
+	); err != nil {
+		return err
+	}
+	return sqlIndexQueryRecord(db, backendPostgres, QueryNotes, platform, noteID, "", b, now)
 }
 
 func postgresUpsertCreator(creatorID string, data any) error {
@@ -131,14 +144,16 @@ func postgresUpsertCreator(creatorID string, data any) error {
 		platform = "xhs"
 	}
 	now := time.Now().Unix()
-	_, err = db.Exec(
+	if _, err := db.Exec(
 		`INSERT INTO creators(platform, creator_id, data_json, updated_at)
 		 VALUES($1, $2, $3, $4)
 		 ON CONFLICT (platform, creator_id)
 		 DO UPDATE SET data_json=EXCLUDED.data_json, updated_at=EXCLUDED.updated_at;`,
 		platform, creatorID, string(b), now,
-	)
-	return err
+	); err != nil {
+		return err
+	}
+	return sqlIndexQueryRecord(db, backendPostgres, QueryCreators, platform, creatorID, "", b, now)
 }
 
 func postgresInsertComments(noteID string, items []any, keyFn func(any) (string, error)) error {
@@ -171,6 +186,7 @@ func postgresInsertComments(noteID string, items []any, keyFn func(any) (string,
 	}
 	defer stmt.Close()
 
+	inserted := 0
 	for _, item := range items {
 		id, err := keyFn(item)
 		if err != nil {
@@ -184,7 +200,56 @@ func postgresInsertComments(noteID string, items []any, keyFn func(any) (string,
 		if err != nil {
 			return fmt.Errorf("marshal comment %s: %w", id, err)
 		}
-		if _, err := stmt.Exec(platform, id, noteID, string(b), now); err != nil {
+		res, err := stmt.Exec(platform, id, noteID, string(b), now)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue
+		}
+		inserted++
+		if err := sqlIndexQueryRecord(tx, backendPostgres, QueryComments, platform, id, noteID, b, now); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	recordCommentsFetched(inserted)
+	return nil
+}
+
+func postgresUpsertCreatorEdges(edges []any) error {
+	db, err := postgresDB()
+	if err != nil {
+		return err
+	}
+	if len(edges) == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.Prepare(
+		`INSERT INTO creator_edges(platform, from_id, to_id, edge_type, data_json, seen_at) VALUES($1, $2, $3, $4, $5, $6)
+		 ON CONFLICT (platform, from_id, to_id, edge_type) DO UPDATE SET data_json=EXCLUDED.data_json, seen_at=EXCLUDED.seen_at;`,
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, item := range edges {
+		e := item.(*CreatorEdge)
+		b, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("marshal creator edge %s: %w", e.Key(), err)
+		}
+		if _, err := stmt.Exec(e.Platform, e.FromID, e.ToID, e.Type, string(b), e.SeenAt); err != nil {
 			return err
 		}
 	}
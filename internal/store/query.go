@@ -0,0 +1,617 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"media-crawler-go/internal/crawler"
+)
+
+// Kinds of stored records that can be queried.
+const (
+	QueryNotes    = "notes"
+	QueryComments = "comments"
+	QueryCreators = "creators"
+)
+
+const (
+	DefaultQueryLimit = 20
+	MaxQueryLimit     = 200
+)
+
+// ErrInvalidQuery wraps errors caused by the query itself (unknown field,
+// bad cursor, ...) rather than by the backend.
+var ErrInvalidQuery = errors.New("invalid query")
+
+// Query selects stored notes, comments or creators. Records are matched on
+// normalized fields extracted from each platform's raw data (see
+// QueryFields); Since/Until apply to create_time (updated_at for creators)
+// and Min holds lower bounds for count fields such as like_count. Sort is a
+// field name, prefixed with "-" for descending order; Cursor is the
+// NextCursor of the previous page.
+type Query struct {
+	Kind     string
+	Platform string
+	NoteID   string
+	Creator  string
+	Keyword  string
+	Since    int64
+	Until    int64
+	Min      map[string]int64
+	Sort     string
+	Cursor   string
+	Limit    int
+	Fields   []string
+}
+
+// QueryResult is one page of matching records. Matched counts every record
+// matching the filters; NextCursor is empty on the last page.
+type QueryResult struct {
+	Kind       string           `json:"kind"`
+	Items      []map[string]any `json:"items"`
+	Count      int              `json:"count"`
+	Matched    int              `json:"matched"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+type fieldType int
+
+const (
+	fieldText fieldType = iota
+	fieldCount
+	fieldTime
+)
+
+// queryField is a normalized field and the raw data paths it is read from,
+// first non-empty wins. Paths are dot separated; unified rows use Go field
+// names, platform records their own json names.
+type queryField struct {
+	name  string
+	typ   fieldType
+	paths []string
+}
+
+var queryFieldSets = map[string][]queryField{
+	QueryNotes: {
+		{"creator_id", fieldText, []string{"user.user_id", "author.uid", "owner.mid", "user.id", "author.id", "photo.author_id", "user_id", "UserId", "UserID"}},
+		{"creator_name", fieldText, []string{"user.nickname", "author.nickname", "owner.name", "user.screen_name", "author.name", "photo.author_name", "nickname", "Nickname", "UserNickname"}},
+		{"title", fieldText, []string{"title", "photo.caption", "Title"}},
+		{"content", fieldText, []string{"desc", "text_raw", "text", "content", "photo.caption", "Desc", "Content"}},
+		{"create_time", fieldTime, []string{"create_time", "pubdate", "created_at", "time", "ctime", "photo.timestamp", "CreateTime"}},
+		{"like_count", fieldCount, []string{"interact_info.liked_count", "statistics.digg_count", "stat.like", "attitudes_count", "photo.like_count", "liked_count", "like_count", "voteup_count", "LikedCount", "LikeCount"}},
+		{"comment_count", fieldCount, []string{"interact_info.comment_count", "statistics.comment_count", "stat.reply", "comments_count", "photo.comment_count", "comment_count", "CommentCount"}},
+		{"share_count", fieldCount, []string{"interact_info.share_count", "statistics.share_count", "stat.share", "reposts_count", "share_count", "ShareCount"}},
+		{"collect_count", fieldCount, []string{"interact_info.collected_count", "statistics.collect_count", "stat.favorite", "collect_count", "CollectedCount"}},
+		{"view_count", fieldCount, []string{"statistics.play_count", "stat.view", "photo.view_count", "view_count", "play_count"}},
+	},
+	QueryComments: {
+		{"parent_comment_id", fieldText, []string{"ParentCommentID", "parent_comment_id", "target_comment.id", "reply_id"}},
+		{"root_comment_id", fieldText, []string{"RootCommentID", "root_comment_id", "RootCommentId"}},
+		{"creator_id", fieldText, []string{"UserID", "user_id", "user.user_id", "user.uid", "user.id", "member.mid"}},
+		{"creator_name", fieldText, []string{"UserNickname", "user_nickname", "user.nickname", "user.screen_name", "member.uname", "nickname"}},
+		{"content", fieldText, []string{"Content", "content", "text"}},
+		{"create_time", fieldTime, []string{"CreateTime", "create_time", "ctime", "created_at"}},
+		{"like_count", fieldCount, []string{"LikeCount", "like_count", "digg_count", "liked_count"}},
+		{"sub_comment_count", fieldCount, []string{"SubCommentCount", "sub_comment_count", "reply_comment_total", "rcount"}},
+	},
+	QueryCreators: {
+		{"creator_name", fieldText, []string{"nickname", "name", "screen_name", "user.nickname", "author.name", "uname"}},
+		{"description", fieldText, []string{"desc", "description", "signature", "sign", "user.signature"}},
+		{"follower_count", fieldCount, []string{"fans", "follower_count", "followers_count", "user.follower_count", "follower"}},
+		{"following_count", fieldCount, []string{"follows", "following_count", "friends_count", "user.following_count", "following"}},
+		{"like_count", fieldCount, []string{"interaction", "total_favorited", "user.total_favorited", "like_count"}},
+		{"note_count", fieldCount, []string{"aweme_count", "user.aweme_count", "statuses_count", "note_count", "archive_count"}},
+	},
+}
+
+// idFields are the leading fields of every record of a kind; they come from
+// the storage key rather than the raw data.
+var idFields = map[string][]string{
+	QueryNotes:    {"platform", "note_id"},
+	QueryComments: {"platform", "comment_id", "note_id"},
+	QueryCreators: {"platform", "creator_id"},
+}
+
+// QueryFields returns the normalized field names of a kind, in output order.
+// "data" (the raw stored record) can be requested on top of these.
+func QueryFields(kind string) []string {
+	set, ok := queryFieldSets[kind]
+	if !ok {
+		return nil
+	}
+	out := append([]string{}, idFields[kind]...)
+	for _, f := range set {
+		out = append(out, f.name)
+	}
+	return append(out, "updated_at")
+}
+
+// queryRow is a stored record as loaded from a backend.
+type queryRow struct {
+	platform  string
+	id        string
+	noteID    string
+	updatedAt int64
+	data      map[string]any
+}
+
+// RunQuery runs q against the configured store backend. The database
+// backends (sqlite, mysql, postgres, mongodb) filter, sort and page on their
+// query index; the files under DATA_DIR are loaded and filtered in memory.
+func RunQuery(q Query) (QueryResult, error) {
+	p, err := planQuery(q)
+	if err != nil {
+		return QueryResult{}, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	var page []map[string]any
+	var matched int
+	switch k := backendKind(); k {
+	case backendSQLite, backendMySQL, backendPostgres:
+		db, err := sqlBackendDB(k)
+		if err != nil {
+			return QueryResult{}, err
+		}
+		page, matched, err = sqlRunQuery(ctx, db, k, p)
+		if err != nil {
+			return QueryResult{}, err
+		}
+	case backendMongoDB:
+		page, matched, err = mongoRunQuery(ctx, p)
+		if err != nil {
+			return QueryResult{}, err
+		}
+	default:
+		page, matched, err = fileRunQuery(p)
+		if err != nil {
+			return QueryResult{}, err
+		}
+	}
+
+	out := QueryResult{Kind: p.kind, Items: make([]map[string]any, 0, min(len(page), p.limit)), Matched: matched}
+	for i, rec := range page {
+		if i == p.limit {
+			last := page[i-1]
+			out.NextCursor = encodeQueryCursor(queryCursor{Sort: p.sortField, Desc: p.desc, Value: last[p.sortField], Key: recordKey(last)})
+			break
+		}
+		out.Items = append(out.Items, projectRecord(rec, q.Fields))
+	}
+	out.Count = len(out.Items)
+	return out, nil
+}
+
+// planQuery validates q.
+func planQuery(q Query) (queryPlan, error) {
+	p := queryPlan{kind: strings.ToLower(strings.TrimSpace(q.Kind))}
+	if _, ok := queryFieldSets[p.kind]; !ok {
+		return p, fmt.Errorf("%w: unknown kind %q", ErrInvalidQuery, p.kind)
+	}
+	p.types = queryFieldTypes(p.kind)
+
+	p.sortField = strings.TrimSpace(q.Sort)
+	if strings.HasPrefix(p.sortField, "-") {
+		p.sortField, p.desc = strings.TrimPrefix(p.sortField, "-"), true
+	}
+	if p.sortField == "" {
+		p.sortField, p.desc = defaultSortField(p.kind), true
+	}
+	if _, ok := p.types[p.sortField]; !ok {
+		return p, fmt.Errorf("%w: unknown sort field %q", ErrInvalidQuery, p.sortField)
+	}
+	for name := range q.Min {
+		if p.types[name] != fieldCount {
+			return p, fmt.Errorf("%w: %q is not a count field", ErrInvalidQuery, name)
+		}
+	}
+	for _, name := range q.Fields {
+		if _, ok := p.types[name]; !ok && name != "data" {
+			return p, fmt.Errorf("%w: unknown field %q", ErrInvalidQuery, name)
+		}
+	}
+	if strings.TrimSpace(q.Cursor) != "" {
+		c, err := decodeQueryCursor(q.Cursor)
+		if err != nil || c.Sort != p.sortField || c.Desc != p.desc || !cursorValueFits(c.Value, p.types[p.sortField]) {
+			return p, fmt.Errorf("%w: bad cursor", ErrInvalidQuery)
+		}
+		p.after = &c
+	}
+	p.limit = q.Limit
+	if p.limit <= 0 {
+		p.limit = DefaultQueryLimit
+	}
+	if p.limit > MaxQueryLimit {
+		p.limit = MaxQueryLimit
+	}
+
+	p.platform = strings.TrimSpace(q.Platform)
+	p.noteID = strings.TrimSpace(q.NoteID)
+	p.creator = strings.ToLower(strings.TrimSpace(q.Creator))
+	p.keyword = strings.ToLower(strings.TrimSpace(q.Keyword))
+	p.since, p.until, p.min = q.Since, q.Until, q.Min
+	p.timeField = defaultSortField(p.kind)
+	return p, nil
+}
+
+// cursorValueFits reports whether a cursor value has the type of its sort
+// field; missing values are stored as null.
+func cursorValueFits(v any, typ fieldType) bool {
+	switch v.(type) {
+	case nil:
+		return true
+	case string:
+		return typ == fieldText
+	case int64:
+		return typ != fieldText
+	}
+	return false
+}
+
+// fileRunQuery filters and sorts every stored record of p.kind in memory and
+// returns the page (plus one record when there are more) and the number of
+// matches.
+func fileRunQuery(p queryPlan) ([]map[string]any, int, error) {
+	rows, err := fileQueryRows(p.kind, p.platform, p.noteID)
+	if err != nil {
+		return nil, 0, err
+	}
+	var matched []map[string]any
+	for _, row := range rows {
+		rec := normalizeQueryRow(p.kind, queryFieldSets[p.kind], row)
+		if p.creator != "" && strings.ToLower(asString(rec["creator_id"])) != p.creator && strings.ToLower(asString(rec["creator_name"])) != p.creator {
+			continue
+		}
+		if p.keyword != "" && !recordContains(rec, p.keyword) {
+			continue
+		}
+		if p.since > 0 || p.until > 0 {
+			ts, ok := rec[p.timeField].(int64)
+			if !ok || (p.since > 0 && ts < p.since) || (p.until > 0 && ts > p.until) {
+				continue
+			}
+		}
+		if !meetsMinimums(rec, p.min) {
+			continue
+		}
+		matched = append(matched, rec)
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return lessRecords(matched[i], matched[j], p.sortField, p.desc)
+	})
+	start := 0
+	if p.after != nil {
+		start = sort.Search(len(matched), func(i int) bool {
+			return afterCursor(matched[i], p.after, p.sortField, p.desc)
+		})
+	}
+	end := min(start+p.limit+1, len(matched))
+	return matched[start:end], len(matched), nil
+}
+
+func defaultSortField(kind string) string {
+	if kind == QueryCreators {
+		return "updated_at"
+	}
+	return "create_time"
+}
+
+func normalizeQueryRow(kind string, fieldSet []queryField, row queryRow) map[string]any {
+	rec := map[string]any{"platform": row.platform}
+	switch kind {
+	case QueryNotes:
+		rec["note_id"] = row.id
+	case QueryComments:
+		rec["comment_id"] = row.id
+		noteID := row.noteID
+		if noteID == "" {
+			noteID = asString(firstValue(row.data, "NoteID", "note_id", "aweme_id"))
+		}
+		rec["note_id"] = noteID
+	case QueryCreators:
+		id := row.id
+		if id == "" {
+			id = asString(firstValue(row.data, "creator_id", "user_id", "uid", "mid", "id", "sec_uid", "user.uid"))
+		}
+		rec["creator_id"] = id
+	}
+	for _, f := range fieldSet {
+		v := firstValue(row.data, f.paths...)
+		switch f.typ {
+		case fieldCount:
+			rec[f.name] = asCount(v)
+		case fieldTime:
+			rec[f.name] = asUnixTime(v)
+		default:
+			if s := asString(v); s != "" {
+				rec[f.name] = s
+			} else {
+				rec[f.name] = nil
+			}
+		}
+	}
+	if row.updatedAt > 0 {
+		rec["updated_at"] = row.updatedAt
+	} else {
+		rec["updated_at"] = asUnixTime(firstValue(row.data, "last_modify_ts", "fetched_at", "updated_at"))
+	}
+	rec["data"] = row.data
+	return rec
+}
+
+// firstValue returns the first non-empty value found at one of the dot
+// separated paths.
+func firstValue(data map[string]any, paths ...string) any {
+	for _, p := range paths {
+		var cur any = data
+		for _, part := range strings.Split(p, ".") {
+			m, ok := cur.(map[string]any)
+			if !ok {
+				cur = nil
+				break
+			}
+			cur = m[part]
+		}
+		if cur == nil {
+			continue
+		}
+		if s, ok := cur.(string); ok && strings.TrimSpace(s) == "" {
+			continue
+		}
+		return cur
+	}
+	return nil
+}
+
+func asString(v any) string {
+	switch vv := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(vv)
+	case json.Number:
+		return vv.String()
+	case float64:
+		return strconv.FormatFloat(vv, 'f', -1, 64)
+	case map[string]any, []any:
+		return ""
+	default:
+		return strings.TrimSpace(fmt.Sprintf("%v", vv))
+	}
+}
+
+// asCount reads counters stored as numbers or display strings ("1.2万").
+func asCount(v any) any {
+	switch vv := v.(type) {
+	case json.Number:
+		if n, err := vv.Int64(); err == nil {
+			return n
+		}
+		if f, err := vv.Float64(); err == nil {
+			return int64(f)
+		}
+	case float64:
+		return int64(vv)
+	case int64:
+		return vv
+	case int:
+		return int64(vv)
+	case string:
+		if strings.TrimSpace(vv) != "" {
+			return crawler.ParseCount(vv)
+		}
+	}
+	return nil
+}
+
+var queryTimeLayouts = []string{time.RFC3339, time.RubyDate, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// asUnixTime normalizes unix seconds / milliseconds and common date strings
+// to unix seconds.
+func asUnixTime(v any) any {
+	var n int64
+	switch vv := v.(type) {
+	case json.Number:
+		f, err := vv.Float64()
+		if err != nil {
+			return nil
+		}
+		n = int64(f)
+	case float64:
+		n = int64(vv)
+	case int64:
+		n = vv
+	case string:
+		s := strings.TrimSpace(vv)
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			n = i
+			break
+		}
+		for _, layout := range queryTimeLayouts {
+			if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+				return t.Unix()
+			}
+		}
+		return nil
+	default:
+		return nil
+	}
+	if n <= 0 {
+		return nil
+	}
+	if n > 1e12 {
+		n /= 1000
+	}
+	return n
+}
+
+func recordContains(rec map[string]any, keyword string) bool {
+	for _, name := range queryKeywordFields {
+		if s, ok := rec[name].(string); ok && strings.Contains(strings.ToLower(s), keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func meetsMinimums(rec map[string]any, min map[string]int64) bool {
+	for name, bound := range min {
+		n, ok := rec[name].(int64)
+		if !ok || n < bound {
+			return false
+		}
+	}
+	return true
+}
+
+func recordKey(rec map[string]any) string {
+	for _, name := range []string{"comment_id", "note_id", "creator_id"} {
+		if id, ok := rec[name].(string); ok {
+			return asString(rec["platform"]) + "/" + id
+		}
+	}
+	return asString(rec["platform"])
+}
+
+// compareValues orders missing values before numbers and numbers before
+// strings.
+func compareValues(a, b any) int {
+	ra, rb := valueRank(a), valueRank(b)
+	if ra != rb {
+		return ra - rb
+	}
+	switch ra {
+	case 1:
+		fa, fb := toFloat(a), toFloat(b)
+		if fa < fb {
+			return -1
+		}
+		if fa > fb {
+			return 1
+		}
+		return 0
+	case 2:
+		return strings.Compare(asString(a), asString(b))
+	}
+	return 0
+}
+
+func valueRank(v any) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case int64, float64, json.Number:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func toFloat(v any) float64 {
+	switch vv := v.(type) {
+	case int64:
+		return float64(vv)
+	case float64:
+		return vv
+	case json.Number:
+		f, _ := vv.Float64()
+		return f
+	}
+	return math.NaN()
+}
+
+func lessRecords(a, b map[string]any, field string, desc bool) bool {
+	c := compareValues(a[field], b[field])
+	if c == 0 {
+		return recordKey(a) < recordKey(b)
+	}
+	if desc {
+		return c > 0
+	}
+	return c < 0
+}
+
+// afterCursor reports whether rec sorts after the cursor position.
+func afterCursor(rec map[string]any, c *queryCursor, field string, desc bool) bool {
+	cmp := compareValues(rec[field], c.Value)
+	if cmp == 0 {
+		return recordKey(rec) > c.Key
+	}
+	if desc {
+		return cmp < 0
+	}
+	return cmp > 0
+}
+
+func projectRecord(rec map[string]any, fields []string) map[string]any {
+	if len(fields) == 0 {
+		out := make(map[string]any, len(rec))
+		for k, v := range rec {
+			if k != "data" {
+				out[k] = v
+			}
+		}
+		return out
+	}
+	out := make(map[string]any, len(fields))
+	for _, name := range fields {
+		out[name] = rec[name]
+	}
+	return out
+}
+
+// queryCursor is the position after the last record of a page: its sort
+// value and key, plus the sort it belongs to.
+type queryCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d,omitempty"`
+	Value any    `json:"v"`
+	Key   string `json:"k"`
+}
+
+func encodeQueryCursor(c queryCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeQueryCursor(s string) (queryCursor, error) {
+	var c queryCursor
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return c, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&c); err != nil {
+		return c, err
+	}
+	if n, ok := c.Value.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			c.Value = i
+		}
+	}
+	return c, nil
+}
+
+// decodeQueryData decodes a stored JSON record keeping numbers exact (ids
+// stored as numbers would otherwise lose digits).
+func decodeQueryData(b []byte) (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var m map[string]any
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,536 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The database backends keep the normalized fields of every stored note,
+// comment and creator next to the raw record, so RunQuery can filter, sort
+// and page in the database instead of loading whole tables: SQL backends in
+// a <table>_query table with one column per QueryFields name, mongodb in a
+// "query" sub-document of the record. Records stored before the index
+// existed are indexed on the first query.
+
+// queryPlan is a validated Query.
+type queryPlan struct {
+	kind      string
+	platform  string
+	noteID    string
+	creator   string // lower case
+	keyword   string // lower case
+	since     int64
+	until     int64
+	timeField string
+	min       map[string]int64
+	sortField string
+	desc      bool
+	after     *queryCursor
+	limit     int
+	types     map[string]fieldType
+}
+
+// queryKeywordFields are the text fields a keyword is matched against.
+var queryKeywordFields = []string{"title", "content", "creator_name", "description"}
+
+// queryFieldTypes returns the type of every normalized field of kind.
+func queryFieldTypes(kind string) map[string]fieldType {
+	types := map[string]fieldType{"updated_at": fieldTime}
+	for _, name := range idFields[kind] {
+		types[name] = fieldText
+	}
+	for _, f := range queryFieldSets[kind] {
+		types[f.name] = f.typ
+	}
+	return types
+}
+
+func queryIndexTable(kind string) string {
+	return queryTables[kind].table + "_query"
+}
+
+// queryIndexRecord normalizes a stored record for the index. Records that
+// are not JSON objects are indexed with their ids only.
+func queryIndexRecord(kind string, platform string, id string, noteID string, raw []byte, at int64) map[string]any {
+	data, err := decodeQueryData(raw)
+	if err != nil {
+		data = map[string]any{}
+	}
+	rec := normalizeQueryRow(kind, queryFieldSets[kind], queryRow{platform: platform, id: id, noteID: noteID, updatedAt: at, data: data})
+	delete(rec, "data")
+	return rec
+}
+
+// queryIndexSchema creates the <table>_query tables of a SQL backend.
+func queryIndexSchema(k sqlBackendKind) []string {
+	var out []string
+	for _, kind := range []string{QueryNotes, QueryComments, QueryCreators} {
+		types := queryFieldTypes(kind)
+		table := queryIndexTable(kind)
+		keys := idFields[kind]
+		timeField := "create_time"
+		if kind == QueryCreators {
+			timeField = "updated_at"
+		}
+		var cols []string
+		for _, name := range QueryFields(kind) {
+			cols = append(cols, name+" "+queryColumnType(k, types[name], name == keys[0] || name == keys[1], name == "note_id"))
+		}
+		cols = append(cols, fmt.Sprintf("PRIMARY KEY (%s, %s)", keys[0], keys[1]))
+		indexes := [][2]string{{"time", timeField}}
+		if kind == QueryComments {
+			indexes = append(indexes, [2]string{"note", "note_id"})
+		}
+		if k == backendMySQL {
+			for _, idx := range indexes {
+				cols = append(cols, fmt.Sprintf("KEY idx_%s_%s (platform, %s)", table, idx[0], idx[1]))
+			}
+			out = append(out, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n\t%s\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;", table, strings.Join(cols, ",\n\t")))
+			continue
+		}
+		out = append(out, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n\t%s\n);", table, strings.Join(cols, ",\n\t")))
+		for _, idx := range indexes {
+			out = append(out, fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%s_%s ON %s(platform, %s);", table, idx[0], table, idx[1]))
+		}
+	}
+	return out
+}
+
+func queryColumnType(k sqlBackendKind, typ fieldType, key bool, indexed bool) string {
+	switch {
+	case typ != fieldText && k == backendSQLite:
+		return "INTEGER"
+	case typ != fieldText:
+		return "BIGINT"
+	case k != backendMySQL && key:
+		return "TEXT NOT NULL"
+	case k != backendMySQL:
+		return "TEXT"
+	case key:
+		return "VARCHAR(191) NOT NULL"
+	case indexed:
+		return "VARCHAR(191)"
+	default:
+		return "LONGTEXT"
+	}
+}
+
+// sqlIndexQueryRecord writes the index row of a stored record.
+func sqlIndexQueryRecord(ex sqlExecer, k sqlBackendKind, kind string, platform string, id string, noteID string, raw []byte, at int64) error {
+	rec := queryIndexRecord(kind, platform, id, noteID, raw, at)
+	cols := QueryFields(kind)
+	args := make([]any, len(cols))
+	marks := make([]string, len(cols))
+	for i, name := range cols {
+		args[i] = rec[name]
+		marks[i] = placeholder(k, i+1)
+	}
+	query := fmt.Sprintf("INSERT INTO %s(%s) VALUES(%s)", queryIndexTable(kind), strings.Join(cols, ", "), strings.Join(marks, ", "))
+	var set []string
+	for _, name := range cols[2:] {
+		if k == backendMySQL {
+			set = append(set, name+"=VALUES("+name+")")
+		} else {
+			set = append(set, name+"=excluded."+name)
+		}
+	}
+	if k == backendMySQL {
+		query += " ON DUPLICATE KEY UPDATE " + strings.Join(set, ", ")
+	} else {
+		query += fmt.Sprintf(" ON CONFLICT(%s, %s) DO UPDATE SET %s", cols[0], cols[1], strings.Join(set, ", "))
+	}
+	_, err := ex.Exec(query, args...)
+	return err
+}
+
+// queryIndexReady records the connections whose records of a kind are all
+// indexed.
+var queryIndexReady = struct {
+	sync.Mutex
+	done map[queryIndexKey]bool
+}{done: map[queryIndexKey]bool{}}
+
+type queryIndexKey struct {
+	conn any
+	kind string
+}
+
+func resetQueryIndexReady() {
+	queryIndexReady.Lock()
+	defer queryIndexReady.Unlock()
+	queryIndexReady.done = map[queryIndexKey]bool{}
+}
+
+// ensureSQLQueryIndex indexes the records of kind that have no index row
+// yet, once per connection.
+func ensureSQLQueryIndex(ctx context.Context, db *sql.DB, k sqlBackendKind, kind string) error {
+	queryIndexReady.Lock()
+	defer queryIndexReady.Unlock()
+	key := queryIndexKey{db, kind}
+	if queryIndexReady.done[key] {
+		return nil
+	}
+	t := queryTables[kind]
+	noteCol := "''"
+	if t.noteCol != "" {
+		noteCol = "d." + t.noteCol
+	}
+	query := fmt.Sprintf(`SELECT d.platform, d.%[1]s, %[2]s, d.data_json, d.%[3]s FROM %[4]s d
+		WHERE NOT EXISTS (SELECT 1 FROM %[5]s q WHERE q.platform = d.platform AND q.%[1]s = d.%[1]s) LIMIT 500`,
+		t.idCol, noteCol, t.timeCol, t.table, queryIndexTable(kind))
+	for {
+		rows, err := scanQueryRows(db.QueryContext(ctx, query))
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			break
+		}
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		for _, r := range rows {
+			if err := sqlIndexQueryRecord(tx, k, kind, r.platform, r.id, r.noteID, []byte(r.raw), r.updatedAt); err != nil {
+				_ = tx.Rollback()
+				return err
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	queryIndexReady.done[key] = true
+	return nil
+}
+
+// rawQueryRow is a stored record before its data is decoded.
+type rawQueryRow struct {
+	platform, id, noteID, raw string
+	updatedAt                 int64
+}
+
+func scanQueryRows(rows *sql.Rows, err error) ([]rawQueryRow, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []rawQueryRow
+	for rows.Next() {
+		var r rawQueryRow
+		if err := rows.Scan(&r.platform, &r.id, &r.noteID, &r.raw, &r.updatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// sqlWhere collects the conditions and arguments of a statement.
+type sqlWhere struct {
+	k     sqlBackendKind
+	conds []string
+	args  []any
+}
+
+func (w *sqlWhere) arg(v any) string {
+	w.args = append(w.args, v)
+	return placeholder(w.k, len(w.args))
+}
+
+func (w *sqlWhere) String() string {
+	if len(w.conds) == 0 {
+		return "1=1"
+	}
+	return strings.Join(w.conds, " AND ")
+}
+
+// escapeLike escapes a LIKE pattern for ESCAPE '!'.
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
+
+// sqlRunQuery runs p against the query index of a SQL backend and returns
+// the page (plus one record when there are more), and the number of matches.
+func sqlRunQuery(ctx context.Context, db *sql.DB, k sqlBackendKind, p queryPlan) ([]map[string]any, int, error) {
+	if err := ensureSQLQueryIndex(ctx, db, k, p.kind); err != nil {
+		return nil, 0, err
+	}
+	t := queryTables[p.kind]
+	idCol := idFields[p.kind][1]
+	w := &sqlWhere{k: k}
+	if p.platform != "" {
+		w.conds = append(w.conds, "q.platform = "+w.arg(p.platform))
+	}
+	if p.noteID != "" && p.kind != QueryCreators {
+		w.conds = append(w.conds, "q.note_id = "+w.arg(p.noteID))
+	}
+	if p.creator != "" {
+		w.conds = append(w.conds, fmt.Sprintf("(LOWER(q.creator_id) = %s OR LOWER(q.creator_name) = %s)", w.arg(p.creator), w.arg(p.creator)))
+	}
+	if p.keyword != "" {
+		var or []string
+		for _, name := range queryKeywordFields {
+			if _, ok := p.types[name]; ok {
+				or = append(or, fmt.Sprintf("LOWER(q.%s) LIKE %s ESCAPE '!'", name, w.arg("%"+escapeLike(p.keyword)+"%")))
+			}
+		}
+		w.conds = append(w.conds, "("+strings.Join(or, " OR ")+")")
+	}
+	if p.since > 0 {
+		w.conds = append(w.conds, fmt.Sprintf("q.%s >= %s", p.timeField, w.arg(p.since)))
+	}
+	if p.until > 0 {
+		w.conds = append(w.conds, fmt.Sprintf("q.%s <= %s", p.timeField, w.arg(p.until)))
+	}
+	for _, name := range sortedKeys(p.min) {
+		w.conds = append(w.conds, fmt.Sprintf("q.%s >= %s", name, w.arg(p.min[name])))
+	}
+
+	var matched int
+	countSQL := fmt.Sprintf("SELECT COUNT(*) FROM %s q WHERE %s", queryIndexTable(p.kind), w)
+	if err := db.QueryRowContext(ctx, countSQL, w.args...).Scan(&matched); err != nil {
+		return nil, 0, err
+	}
+
+	col := "q." + p.sortField
+	if c := p.after; c != nil {
+		cp, cid, _ := strings.Cut(c.Key, "/")
+		// Arguments are bound in the order they appear, for "?" placeholders.
+		keyAfter := func() string {
+			return fmt.Sprintf("(q.platform > %s OR (q.platform = %s AND q.%s > %s))", w.arg(cp), w.arg(cp), idCol, w.arg(cid))
+		}
+		switch {
+		case !p.desc && c.Value == nil:
+			w.conds = append(w.conds, fmt.Sprintf("(%s IS NOT NULL OR %s)", col, keyAfter()))
+		case !p.desc:
+			w.conds = append(w.conds, fmt.Sprintf("(%s > %s OR (%s = %s AND %s))", col, w.arg(c.Value), col, w.arg(c.Value), keyAfter()))
+		case c.Value == nil:
+			w.conds = append(w.conds, fmt.Sprintf("(%s IS NULL AND %s)", col, keyAfter()))
+		default:
+			w.conds = append(w.conds, fmt.Sprintf("(%s < %s OR %s IS NULL OR (%s = %s AND %s))", col, w.arg(c.Value), col, col, w.arg(c.Value), keyAfter()))
+		}
+	}
+	// Missing values sort first, as in compareValues.
+	order := fmt.Sprintf("(%s IS NULL) DESC, %s ASC", col, col)
+	if p.desc {
+		order = fmt.Sprintf("(%s IS NULL) ASC, %s DESC", col, col)
+	}
+	noteCol := "''"
+	if t.noteCol != "" {
+		noteCol = "d." + t.noteCol
+	}
+	pageSQL := fmt.Sprintf(`SELECT q.platform, q.%[1]s, %[2]s, d.data_json, d.%[3]s FROM %[4]s q
+		JOIN %[5]s d ON d.platform = q.platform AND d.%[1]s = q.%[1]s
+		WHERE %[6]s ORDER BY %[7]s, q.platform, q.%[1]s LIMIT %[8]d`,
+		idCol, noteCol, t.timeCol, queryIndexTable(p.kind), t.table, w, order, p.limit+1)
+	rows, err := scanQueryRows(db.QueryContext(ctx, pageSQL, w.args...))
+	if err != nil {
+		return nil, 0, err
+	}
+	return normalizeRawRows(p.kind, rows), matched, nil
+}
+
+func normalizeRawRows(kind string, rows []rawQueryRow) []map[string]any {
+	out := make([]map[string]any, 0, len(rows))
+	for _, r := range rows {
+		data, err := decodeQueryData([]byte(r.raw))
+		if err != nil {
+			data = map[string]any{}
+		}
+		out = append(out, normalizeQueryRow(kind, queryFieldSets[kind], queryRow{platform: r.platform, id: r.id, noteID: r.noteID, updatedAt: r.updatedAt, data: data}))
+	}
+	return out
+}
+
+func sortedKeys(m map[string]int64) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ensureMongoQueryIndex adds the query sub-document to the records of kind
+// stored before it existed, once per client.
+func ensureMongoQueryIndex(ctx context.Context, cli *mongo.Client, coll *mongo.Collection, kind string) error {
+	queryIndexReady.Lock()
+	defer queryIndexReady.Unlock()
+	key := queryIndexKey{cli, kind}
+	if queryIndexReady.done[key] {
+		return nil
+	}
+	t := queryTables[kind]
+	cur, err := coll.Find(ctx, bson.D{{Key: "query", Value: bson.D{{Key: "$exists", Value: false}}}})
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+	var models []mongo.WriteModel
+	flush := func() error {
+		if len(models) == 0 {
+			return nil
+		}
+		_, err := coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		models = models[:0]
+		return err
+	}
+	for cur.Next(ctx) {
+		var doc bson.M
+		if err := cur.Decode(&doc); err != nil {
+			continue
+		}
+		r := mongoRawQueryRow(t, doc)
+		rec := queryIndexRecord(kind, r.platform, r.id, r.noteID, []byte(r.raw), r.updatedAt)
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.D{{Key: "_id", Value: doc["_id"]}}).
+			SetUpdate(bson.D{{Key: "$set", Value: bson.M{"query": rec}}}))
+		if len(models) == 500 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	queryIndexReady.done[key] = true
+	return nil
+}
+
+func mongoRawQueryRow(t struct{ table, idCol, noteCol, timeCol string }, doc bson.M) rawQueryRow {
+	r := rawQueryRow{platform: asString(doc["platform"]), id: asString(doc[t.idCol])}
+	r.raw, _ = doc["data_json"].(string)
+	if t.noteCol != "" {
+		r.noteID = asString(doc[t.noteCol])
+	}
+	switch v := doc[t.timeCol].(type) {
+	case int64:
+		r.updatedAt = v
+	case int32:
+		r.updatedAt = int64(v)
+	}
+	return r
+}
+
+// mongoRunQuery runs p against the query sub-documents of a collection.
+func mongoRunQuery(ctx context.Context, p queryPlan) ([]map[string]any, int, error) {
+	cli, err := mongoClient()
+	if err != nil {
+		return nil, 0, err
+	}
+	t := queryTables[p.kind]
+	coll := cli.Database(mongoDBName()).Collection(t.table)
+	if err := ensureMongoQueryIndex(ctx, cli, coll, p.kind); err != nil {
+		return nil, 0, err
+	}
+	idCol := idFields[p.kind][1]
+	field := func(name string) string { return "query." + name }
+	var and bson.A
+	if p.platform != "" {
+		and = append(and, bson.D{{Key: "platform", Value: p.platform}})
+	}
+	if p.noteID != "" && p.kind != QueryCreators {
+		and = append(and, bson.D{{Key: field("note_id"), Value: p.noteID}})
+	}
+	if p.creator != "" {
+		exact := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(p.creator) + "$", Options: "i"}
+		and = append(and, bson.D{{Key: "$or", Value: bson.A{
+			bson.D{{Key: field("creator_id"), Value: exact}},
+			bson.D{{Key: field("creator_name"), Value: exact}},
+		}}})
+	}
+	if p.keyword != "" {
+		var or bson.A
+		for _, name := range queryKeywordFields {
+			if _, ok := p.types[name]; ok {
+				or = append(or, bson.D{{Key: field(name), Value: primitive.Regex{Pattern: regexp.QuoteMeta(p.keyword), Options: "i"}}})
+			}
+		}
+		and = append(and, bson.D{{Key: "$or", Value: or}})
+	}
+	if p.since > 0 {
+		and = append(and, bson.D{{Key: field(p.timeField), Value: bson.D{{Key: "$gte", Value: p.since}}}})
+	}
+	if p.until > 0 {
+		and = append(and, bson.D{{Key: field(p.timeField), Value: bson.D{{Key: "$lte", Value: p.until}}}})
+	}
+	for _, name := range sortedKeys(p.min) {
+		and = append(and, bson.D{{Key: field(name), Value: bson.D{{Key: "$gte", Value: p.min[name]}}}})
+	}
+	filter := func(and bson.A) bson.D {
+		if len(and) == 0 {
+			return bson.D{}
+		}
+		return bson.D{{Key: "$and", Value: and}}
+	}
+	matched, err := coll.CountDocuments(ctx, filter(and))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	col := field(p.sortField)
+	if c := p.after; c != nil {
+		cp, cid, _ := strings.Cut(c.Key, "/")
+		keyAfter := func(v any) bson.A {
+			return bson.A{
+				bson.D{{Key: col, Value: v}, {Key: "platform", Value: bson.D{{Key: "$gt", Value: cp}}}},
+				bson.D{{Key: col, Value: v}, {Key: "platform", Value: cp}, {Key: idCol, Value: bson.D{{Key: "$gt", Value: cid}}}},
+			}
+		}
+		var or bson.A
+		switch {
+		case !p.desc && c.Value == nil:
+			or = append(bson.A{bson.D{{Key: col, Value: bson.D{{Key: "$ne", Value: nil}}}}}, keyAfter(nil)...)
+		case !p.desc:
+			or = append(bson.A{bson.D{{Key: col, Value: bson.D{{Key: "$gt", Value: c.Value}}}}}, keyAfter(c.Value)...)
+		case c.Value == nil:
+			or = keyAfter(nil)
+		default:
+			or = append(bson.A{
+				bson.D{{Key: col, Value: bson.D{{Key: "$lt", Value: c.Value}}}},
+				bson.D{{Key: col, Value: nil}},
+			}, keyAfter(c.Value)...)
+		}
+		and = append(and, bson.D{{Key: "$or", Value: or}})
+	}
+	dir := 1
+	if p.desc {
+		dir = -1
+	}
+	// BSON orders null before numbers and strings, as compareValues does.
+	opts := options.Find().
+		SetSort(bson.D{{Key: col, Value: dir}, {Key: "platform", Value: 1}, {Key: idCol, Value: 1}}).
+		SetLimit(int64(p.limit + 1))
+	cur, err := coll.Find(ctx, filter(and), opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cur.Close(ctx)
+	var rows []rawQueryRow
+	for cur.Next(ctx) {
+		var doc bson.M
+		if err := cur.Decode(&doc); err != nil {
+			continue
+		}
+		rows = append(rows, mongoRawQueryRow(t, doc))
+	}
+	if err := cur.Err(); err != nil {
+		return nil, 0, err
+	}
+	return normalizeRawRows(p.kind, rows), int(matched), nil
+}
@@ -0,0 +1,362 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// queryTables maps a query kind to its table / collection and the columns
+// holding the record id, the note id and the record time.
+var queryTables = map[string]struct {
+	table, idCol, noteCol, timeCol string
+}{
+	QueryNotes:    {"notes", "note_id", "", "updated_at"},
+	QueryComments: {"comments", "comment_id", "note_id", "created_at"},
+	QueryCreators: {"creators", "creator_id", "", "updated_at"},
+}
+
+// loadQueryRows reads every stored record of a kind, narrowed to a platform
+// and note id when given.
+func loadQueryRows(kind string, platform string, noteID string) ([]queryRow, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	switch k := backendKind(); k {
+	case backendSQLite, backendMySQL, backendPostgres:
+		db, err := sqlBackendDB(k)
+		if err != nil {
+			return nil, err
+		}
+		return sqlQueryRows(ctx, db, k, kind, platform, noteID)
+	case backendMongoDB:
+		return mongoQueryRows(ctx, kind, platform, noteID)
+	default:
+		return fileQueryRows(kind, platform, noteID)
+	}
+}
+
+func sqlBackendDB(k sqlBackendKind) (*sql.DB, error) {
+	switch k {
+	case backendSQLite:
+		return sqliteDB()
+	case backendMySQL:
+		return mysqlDB()
+	default:
+		return postgresDB()
+	}
+}
+
+func sqlQueryRows(ctx context.Context, db *sql.DB, k sqlBackendKind, kind string, platform string, noteID string) ([]queryRow, error) {
+	t := queryTables[kind]
+	noteCol := "''"
+	if t.noteCol != "" {
+		noteCol = t.noteCol
+	}
+	query := fmt.Sprintf(`SELECT platform, %s, %s, data_json, %s FROM %s WHERE 1=1`, t.idCol, noteCol, t.timeCol, t.table)
+	var args []any
+	if platform != "" {
+		args = append(args, platform)
+		query += " AND platform=" + placeholder(k, len(args))
+	}
+	if noteID != "" {
+		col := t.noteCol
+		if col == "" {
+			col = t.idCol
+		}
+		if kind != QueryCreators {
+			args = append(args, noteID)
+			query += " AND " + col + "=" + placeholder(k, len(args))
+		}
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []queryRow
+	for rows.Next() {
+		var r queryRow
+		var raw string
+		if err := rows.Scan(&r.platform, &r.id, &r.noteID, &raw, &r.updatedAt); err != nil {
+			return out, err
+		}
+		data, err := decodeQueryData([]byte(raw))
+		if err != nil {
+			continue
+		}
+		r.data = data
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+func mongoQueryRows(ctx context.Context, kind string, platform string, noteID string) ([]queryRow, error) {
+	cli, err := mongoClient()
+	if err != nil {
+		return nil, err
+	}
+	t := queryTables[kind]
+	filter := bson.D{}
+	if platform != "" {
+		filter = append(filter, bson.E{Key: "platform", Value: platform})
+	}
+	if noteID != "" && kind != QueryCreators {
+		col := t.noteCol
+		if col == "" {
+			col = t.idCol
+		}
+		filter = append(filter, bson.E{Key: col, Value: noteID})
+	}
+	cur, err := cli.Database(mongoDBName()).Collection(t.table).Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	var out []queryRow
+	for cur.Next(ctx) {
+		var doc bson.M
+		if err := cur.Decode(&doc); err != nil {
+			continue
+		}
+		raw, _ := doc["data_json"].(string)
+		data, err := decodeQueryData([]byte(raw))
+		if err != nil {
+			continue
+		}
+		r := queryRow{
+			platform: asString(doc["platform"]),
+			id:       asString(doc[t.idCol]),
+			data:     data,
+		}
+		if t.noteCol != "" {
+			r.noteID = asString(doc[t.noteCol])
+		}
+		switch v := doc[t.timeCol].(type) {
+		case int64:
+			r.updatedAt = v
+		case int32:
+			r.updatedAt = int64(v)
+		}
+		out = append(out, r)
+	}
+	return out, cur.Err()
+}
+
+// fileQueryRows reads the file layout under DATA_DIR: notes/<id>/note.json
+// (or note.csv), the unified comments files and
+// creators/<id>/profile.json plus the creators_<date>.json|csv snapshots.
+func fileQueryRows(kind string, platform string, noteID string) ([]queryRow, error) {
+	dataDir := filepath.Dir(PlatformDir())
+	platforms := []string{platform}
+	if platform == "" {
+		platforms = nil
+		entries, err := os.ReadDir(dataDir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.IsDir() && e.Name() != "tasks" && e.Name() != "exports" {
+				platforms = append(platforms, e.Name())
+			}
+		}
+	}
+	var out []queryRow
+	for _, p := range platforms {
+		dir := filepath.Join(dataDir, p)
+		var rows []queryRow
+		var err error
+		switch kind {
+		case QueryNotes:
+			rows, err = fileNoteRows(dir, p, noteID)
+		case QueryComments:
+			rows, err = fileCommentRows(dir, p, noteID)
+		case QueryCreators:
+			rows, err = fileCreatorRows(dir, p)
+		}
+		if err != nil {
+			return out, err
+		}
+		out = append(out, rows...)
+	}
+	return out, nil
+}
+
+func fileNoteRows(dir string, platform string, noteID string) ([]queryRow, error) {
+	ids := []string{noteID}
+	if noteID == "" {
+		ids = nil
+		entries, err := os.ReadDir(filepath.Join(dir, "notes"))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				ids = append(ids, e.Name())
+			}
+		}
+	}
+	var out []queryRow
+	for _, id := range ids {
+		noteDir := filepath.Join(dir, "notes", id)
+		for _, name := range []string{"note.json", "note.csv"} {
+			recs, mtime, err := readRecordFile(filepath.Join(noteDir, name))
+			if err != nil || len(recs) == 0 {
+				continue
+			}
+			out = append(out, queryRow{platform: platform, id: id, updatedAt: mtime, data: recs[len(recs)-1]})
+			break
+		}
+	}
+	return out, nil
+}
+
+func fileCommentRows(dir string, platform string, noteID string) ([]queryRow, error) {
+	comments, mtime, err := loadUnifiedCommentFiles(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []queryRow
+	seen := make(map[string]struct{}, len(comments))
+	for i := range comments {
+		c := comments[i]
+		if c.CommentID == "" || (noteID != "" && c.NoteID != noteID) {
+			continue
+		}
+		if _, ok := seen[c.CommentID]; ok {
+			continue
+		}
+		seen[c.CommentID] = struct{}{}
+		b, err := json.Marshal(c)
+		if err != nil {
+			continue
+		}
+		data, err := decodeQueryData(b)
+		if err != nil {
+			continue
+		}
+		out = append(out, queryRow{platform: platform, id: c.CommentID, noteID: c.NoteID, updatedAt: mtime, data: data})
+	}
+	return out, nil
+}
+
+func fileCreatorRows(dir string, platform string) ([]queryRow, error) {
+	latest := make(map[string]queryRow)
+	var order []string
+	add := func(r queryRow) {
+		if r.id == "" {
+			r.id = asString(firstValue(r.data, "creator_id", "user_id", "uid", "mid", "id", "sec_uid", "user.uid"))
+		}
+		if r.id == "" {
+			return
+		}
+		if prev, ok := latest[r.id]; ok && prev.updatedAt > r.updatedAt {
+			return
+		}
+		if _, ok := latest[r.id]; !ok {
+			order = append(order, r.id)
+		}
+		latest[r.id] = r
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "creators"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		recs, mtime, err := readRecordFile(filepath.Join(dir, "creators", e.Name(), "profile.json"))
+		if err != nil || len(recs) == 0 {
+			continue
+		}
+		add(queryRow{platform: platform, id: e.Name(), updatedAt: mtime, data: recs[0]})
+	}
+
+	snapshots, _ := filepath.Glob(filepath.Join(dir, "creators_*"))
+	sort.Strings(snapshots)
+	for _, path := range snapshots {
+		recs, mtime, err := readRecordFile(path)
+		if err != nil {
+			continue
+		}
+		for _, rec := range recs {
+			add(queryRow{platform: platform, updatedAt: mtime, data: rec})
+		}
+	}
+
+	out := make([]queryRow, 0, len(order))
+	for _, id := range order {
+		out = append(out, latest[id])
+	}
+	return out, nil
+}
+
+// readRecordFile reads a JSON / JSONL file (one record per line) or a CSV
+// file (header row + records) and returns its records and modification time.
+// Other formats yield no records.
+func readRecordFile(path string) ([]map[string]any, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	var mtime int64
+	if st, err := f.Stat(); err == nil {
+		mtime = st.ModTime().Unix()
+	}
+
+	var out []map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".jsonl":
+		// A stream of values covers both JSONL and indented single records.
+		dec := json.NewDecoder(bufio.NewReader(f))
+		dec.UseNumber()
+		for {
+			var m map[string]any
+			if err := dec.Decode(&m); err != nil {
+				break
+			}
+			out = append(out, m)
+		}
+	case ".csv":
+		r := csv.NewReader(f)
+		r.FieldsPerRecord = -1
+		records, err := r.ReadAll()
+		if err != nil || len(records) < 2 {
+			return nil, mtime, err
+		}
+		header := records[0]
+		if len(header) > 0 {
+			header[0] = strings.TrimPrefix(header[0], "\uFEFF")
+		}
+		for _, rec := range records[1:] {
+			m := make(map[string]any, len(header))
+			for i, h := range header {
+				if i < len(rec) {
+					m[h] = rec[i]
+				}
+			}
+			out = append(out, m)
+		}
+	}
+	return out, mtime, nil
+}
@@ -0,0 +1,270 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"media-crawler-go/internal/config"
+)
+
+func writeTestFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+func TestRunQueryFileNotes(t *testing.T) {
+	dataDir := t.TempDir()
+	oldCfg := config.AppConfig
+	config.AppConfig = config.Config{DataDir: dataDir, Platform: "xhs"}
+	t.Cleanup(func() { config.AppConfig = oldCfg })
+
+	writeTestFile(t, filepath.Join(dataDir, "xhs", "notes", "n1", "note.json"),
+		`{"note_id":"n1","title":"Go 编程入门","desc":"d","user":{"user_id":"u1","nickname":"alice"},"interact_info":{"liked_count":"1.2万","comment_count":"30"}}`)
+	writeTestFile(t, filepath.Join(dataDir, "xhs", "notes", "n2", "note.json"),
+		`{"note_id":"n2","title":"cooking","user":{"user_id":"u2","nickname":"bob"},"interact_info":{"liked_count":"800"}}`)
+	writeTestFile(t, filepath.Join(dataDir, "xhs", "notes", "n3", "note.json"),
+		`{"note_id":"n3","title":"more go","user":{"user_id":"u1","nickname":"alice"},"interact_info":{"liked_count":"5000"}}`)
+	writeTestFile(t, filepath.Join(dataDir, "douyin", "notes", "a1", "note.json"),
+		`{"aweme_id":"a1","desc":"go go","create_time":1700000000,"author":{"uid":"9","nickname":"carol"},"statistics":{"digg_count":20000,"comment_count":5}}`)
+
+	res, err := RunQuery(Query{Kind: QueryNotes, Platform: "xhs", Creator: "alice", Min: map[string]int64{"like_count": 1000}, Sort: "-like_count"})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if res.Matched != 2 || res.Items[0]["note_id"] != "n1" || res.Items[0]["like_count"] != int64(12000) || res.Items[1]["note_id"] != "n3" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if _, ok := res.Items[0]["data"]; ok {
+		t.Fatalf("data should only be returned on request: %+v", res.Items[0])
+	}
+
+	// All platforms, keyword match, paged one by one.
+	var ids []string
+	cursor := ""
+	for i := 0; i < 5; i++ {
+		res, err := RunQuery(Query{Kind: QueryNotes, Keyword: "GO", Sort: "like_count", Limit: 1, Cursor: cursor, Fields: []string{"note_id", "platform"}})
+		if err != nil {
+			t.Fatalf("page %d: %v", i, err)
+		}
+		if res.Matched != 3 || len(res.Items) != 1 || len(res.Items[0]) != 2 {
+			t.Fatalf("unexpected page %d: %+v", i, res)
+		}
+		ids = append(ids, res.Items[0]["note_id"].(string))
+		if res.NextCursor == "" {
+			break
+		}
+		cursor = res.NextCursor
+	}
+	if len(ids) != 3 || ids[0] != "n3" || ids[1] != "n1" || ids[2] != "a1" {
+		t.Fatalf("unexpected page order: %v", ids)
+	}
+
+	res, err = RunQuery(Query{Kind: QueryNotes, Platform: "douyin", Since: 1690000000, Until: 1710000000, Fields: []string{"creator_name", "create_time", "data"}})
+	if err != nil {
+		t.Fatalf("time query: %v", err)
+	}
+	if res.Matched != 1 || res.Items[0]["creator_name"] != "carol" || res.Items[0]["create_time"] != int64(1700000000) || res.Items[0]["data"] == nil {
+		t.Fatalf("unexpected time query result: %+v", res)
+	}
+
+	for _, q := range []Query{
+		{Kind: "videos"},
+		{Kind: QueryNotes, Sort: "nope"},
+		{Kind: QueryNotes, Min: map[string]int64{"title": 1}},
+		{Kind: QueryNotes, Fields: []string{"nope"}},
+		{Kind: QueryNotes, Cursor: "%%%"},
+		{Kind: QueryNotes, Sort: "like_count", Cursor: encodeQueryCursor(queryCursor{Sort: "create_time", Key: "x"})},
+	} {
+		if _, err := RunQuery(q); !errors.Is(err, ErrInvalidQuery) {
+			t.Fatalf("%+v: expected invalid query, got %v", q, err)
+		}
+	}
+}
+
+func TestRunQueryFileCommentsAndCreators(t *testing.T) {
+	dataDir := t.TempDir()
+	oldCfg := config.AppConfig
+	config.AppConfig = config.Config{DataDir: dataDir, Platform: "bilibili"}
+	t.Cleanup(func() { config.AppConfig = oldCfg })
+
+	writeTestFile(t, filepath.Join(dataDir, "bilibili", "comments.jsonl"), `{"Platform":"bilibili","NoteID":"BV1","CommentID":"c1","Content":"great video","CreateTime":1700000000,"LikeCount":10,"UserID":"u1","UserNickname":"alice"}
+{"Platform":"bilibili","NoteID":"BV1","CommentID":"c2","ParentCommentID":"c1","Content":"agreed","CreateTime":1700000100,"LikeCount":2,"UserID":"u2"}
+{"Platform":"bilibili","NoteID":"BV2","CommentID":"c3","Content":"other","CreateTime":1700000200}
+`)
+	res, err := RunQuery(Query{Kind: QueryComments, Platform: "bilibili", NoteID: "BV1"})
+	if err != nil {
+		t.Fatalf("comments: %v", err)
+	}
+	if res.Matched != 2 || res.Items[0]["comment_id"] != "c2" || res.Items[0]["parent_comment_id"] != "c1" || res.Items[1]["like_count"] != int64(10) {
+		t.Fatalf("unexpected comments: %+v", res)
+	}
+
+	writeTestFile(t, filepath.Join(dataDir, "bilibili", "creators", "42", "profile.json"), "{\n  \"mid\": 42,\n  \"name\": \"up\",\n  \"sign\": \"hello\",\n  \"follower\": 1500\n}\n")
+	writeTestFile(t, filepath.Join(dataDir, "xhs", "creators_2024-01-01.json"), `{"user_id":"x1","nickname":"old","fans":1}
+{"user_id":"x1","nickname":"new","fans":3}
+{"user_id":"x2","nickname":"other","fans":9}
+`)
+	res, err = RunQuery(Query{Kind: QueryCreators, Sort: "-follower_count"})
+	if err != nil {
+		t.Fatalf("creators: %v", err)
+	}
+	if res.Matched != 3 || res.Items[0]["creator_id"] != "42" || res.Items[0]["description"] != "hello" {
+		t.Fatalf("unexpected creators: %+v", res)
+	}
+	if res.Items[2]["creator_id"] != "x1" || res.Items[2]["creator_name"] != "new" {
+		t.Fatalf("latest snapshot should win: %+v", res.Items[2])
+	}
+}
+
+func TestRunQuerySQLite(t *testing.T) {
+	tmp := t.TempDir()
+	oldCfg := config.AppConfig
+	config.AppConfig = config.Config{
+		DataDir:        filepath.Join(tmp, "data"),
+		Platform:       "douyin",
+		StoreBackend:   "sqlite",
+		SQLitePath:     filepath.Join(tmp, "data", "media_crawler.db"),
+		SaveDataOption: "json",
+	}
+	resetSQLiteForTest(t)
+	t.Cleanup(func() {
+		resetSQLiteForTest(t)
+		config.AppConfig = oldCfg
+	})
+
+	for _, n := range []map[string]any{
+		{"aweme_id": "a1", "desc": "first", "create_time": 1700000000, "author": map[string]any{"uid": "u1", "nickname": "alice"}, "statistics": map[string]any{"digg_count": 10}},
+		{"aweme_id": "a2", "desc": "second", "create_time": 1700000500, "author": map[string]any{"uid": "u2", "nickname": "bob"}, "statistics": map[string]any{"digg_count": 99}},
+	} {
+		if err := SaveNoteDetail(n["aweme_id"].(string), n); err != nil {
+			t.Fatalf("save note: %v", err)
+		}
+	}
+	keyFn := func(item any) (string, error) { return item.(map[string]any)["cid"].(string), nil }
+	if _, err := AppendUniqueCommentsJSONL("a1", []any{
+		map[string]any{"cid": "c1", "text": "hi", "digg_count": 3, "user": map[string]any{"uid": "u9", "nickname": "zed"}},
+		map[string]any{"cid": "c2", "text": "yo", "digg_count": 1},
+	}, keyFn); err != nil {
+		t.Fatalf("save comments: %v", err)
+	}
+	// Only the backend is read: removing the files must not matter.
+	if err := os.RemoveAll(filepath.Join(tmp, "data", "douyin")); err != nil {
+		t.Fatalf("remove files: %v", err)
+	}
+
+	res, err := RunQuery(Query{Kind: QueryNotes, Platform: "douyin", Min: map[string]int64{"like_count": 50}})
+	if err != nil {
+		t.Fatalf("notes: %v", err)
+	}
+	if res.Matched != 1 || res.Items[0]["note_id"] != "a2" || res.Items[0]["creator_id"] != "u2" {
+		t.Fatalf("unexpected notes: %+v", res)
+	}
+	res, err = RunQuery(Query{Kind: QueryComments, NoteID: "a1", Creator: "zed"})
+	if err != nil {
+		t.Fatalf("comments: %v", err)
+	}
+	if res.Matched != 1 || res.Items[0]["comment_id"] != "c1" || res.Items[0]["note_id"] != "a1" || res.Items[0]["content"] != "hi" {
+		t.Fatalf("unexpected comments: %+v", res)
+	}
+}
+
+func TestRunQuerySQLitePaging(t *testing.T) {
+	tmp := t.TempDir()
+	oldCfg := config.AppConfig
+	config.AppConfig = config.Config{
+		DataDir:        filepath.Join(tmp, "data"),
+		Platform:       "douyin",
+		StoreBackend:   "sqlite",
+		SQLitePath:     filepath.Join(tmp, "data", "media_crawler.db"),
+		SaveDataOption: "json",
+	}
+	resetSQLiteForTest(t)
+	t.Cleanup(func() {
+		resetSQLiteForTest(t)
+		config.AppConfig = oldCfg
+	})
+
+	db, err := sqliteDB()
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	// Rows written before the query index existed are indexed on first use.
+	for id, data := range map[string]string{
+		"n1": `{"desc":"one","create_time":1700000100,"statistics":{"digg_count":5}}`,
+		"n2": `{"desc":"two","create_time":1700000200,"statistics":{"digg_count":5}}`,
+		"n3": `{"desc":"three"}`,
+		"n4": `{"desc":"Sale 50%_off","create_time":1700000400,"statistics":{"digg_count":20}}`,
+		"n5": `{"desc":"50xoff","create_time":1700000500,"statistics":{"digg_count":1}}`,
+	} {
+		if _, err := db.Exec(`INSERT INTO notes(platform, note_id, data_json, updated_at) VALUES('douyin', ?, ?, 1)`, id, data); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+
+	pages := func(q Query) []string {
+		t.Helper()
+		var ids []string
+		for i := 0; i < 10; i++ {
+			res, err := RunQuery(q)
+			if err != nil {
+				t.Fatalf("query %+v: %v", q, err)
+			}
+			if res.Matched != 5 && q.Keyword == "" {
+				t.Fatalf("matched = %d", res.Matched)
+			}
+			for _, item := range res.Items {
+				ids = append(ids, item["note_id"].(string))
+			}
+			if res.NextCursor == "" {
+				return ids
+			}
+			q.Cursor = res.NextCursor
+		}
+		t.Fatalf("cursor did not end")
+		return nil
+	}
+	if got := strings.Join(pages(Query{Kind: QueryNotes, Sort: "-like_count", Limit: 2}), ","); got != "n4,n1,n2,n5,n3" {
+		t.Fatalf("-like_count order = %s", got)
+	}
+	if got := strings.Join(pages(Query{Kind: QueryNotes, Sort: "like_count", Limit: 2}), ","); got != "n3,n5,n1,n2,n4" {
+		t.Fatalf("like_count order = %s", got)
+	}
+	if got := strings.Join(pages(Query{Kind: QueryNotes, Sort: "content", Limit: 3}), ","); got != "n5,n4,n1,n3,n2" {
+		t.Fatalf("content order = %s", got)
+	}
+	if got := strings.Join(pages(Query{Kind: QueryNotes, Keyword: "50%_", Limit: 2}), ","); got != "n4" {
+		t.Fatalf("keyword match = %s", got)
+	}
+
+	res, err := RunQuery(Query{Kind: QueryNotes, Since: 1700000150, Until: 1700000450, Min: map[string]int64{"like_count": 5}, Sort: "create_time"})
+	if err != nil {
+		t.Fatalf("range: %v", err)
+	}
+	if res.Matched != 2 || res.Items[0]["note_id"] != "n2" || res.Items[1]["note_id"] != "n4" {
+		t.Fatalf("unexpected range: %+v", res)
+	}
+
+	// Later writes keep the index current.
+	if err := SaveNoteDetail("n5", map[string]any{"desc": "50xoff", "statistics": map[string]any{"digg_count": 30}}); err != nil {
+		t.Fatalf("save note: %v", err)
+	}
+	res, err = RunQuery(Query{Kind: QueryNotes, Sort: "-like_count", Limit: 1})
+	if err != nil {
+		t.Fatalf("after update: %v", err)
+	}
+	if res.Items[0]["note_id"] != "n5" || res.Items[0]["like_count"] != int64(30) {
+		t.Fatalf("index not updated: %+v", res)
+	}
+
+	if _, err := RunQuery(Query{Kind: QueryNotes, Sort: "like_count", Cursor: encodeQueryCursor(queryCursor{Sort: "like_count", Value: "x", Key: "douyin/n1"})}); !errors.Is(err, ErrInvalidQuery) {
+		t.Fatalf("expected invalid cursor, got %v", err)
+	}
+}
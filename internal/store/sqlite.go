@@ -86,7 +86,19 @@ func sqliteDB() (*sql.DB, error) {
 				PRIMARY KEY (platform, comment_id)
 			);`,
 			`CREATE INDEX IF NOT EXISTS idx_comments_note ON comments(platform, note_id);`,
+			`CREATE TABLE IF NOT EXISTS creator_edges (
+				platform TEXT NOT NULL,
+				from_id TEXT NOT NULL,
+				to_id TEXT NOT NULL,
+				edge_type TEXT NOT NULL,
+				data_json TEXT NOT NULL,
+				seen_at INTEGER NOT NULL,
+				PRIMARY KEY (platform, from_id, to_id, edge_type)
+			);`,
+			`CREATE INDEX IF NOT EXISTS idx_creator_edges_to ON creator_edges(platform, to_id);`,
 		}
+		stmts = append(stmts, sqliteFTSSchema...)
+		stmts = append(stmts, queryIndexSchema(backendSQLite)...)
 		for _, stmt := range stmts {
 			if _, err := db.Exec(stmt); err != nil {
 				_ = db.Close()
@@ -119,14 +131,27 @@ func sqliteUpsertNote(noteID string, note any) error {
 		platform = "xhs"
 	}
 	now := time.Now().Unix()
-	_, err = db.Exec(
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.Exec(
 		`INSERT INTO notes(platform, note_id, data_json, updated_at)
 		 VALUES(?, ?, ?, ?)
 		 ON CONFLICT(platform, note_id)
 		 DO UPDATE SET data_json=excluded.data_json, updated_at=excluded.updated_at;`,
 		platform, noteID, string(b), now,
-	)
-	return err
+	); err != nil {
+		return err
+	}
+	if err := sqliteIndexNote(tx, platform, noteID, b); err != nil {
+		return err
+	}
+	if err := sqlIndexQueryRecord(tx, backendSQLite, QueryNotes, platform, noteID, "", b, now); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func sqliteUpsertCreator(creatorID string, data any) error {
@@ -149,14 +174,24 @@ func sqliteUpsertCreator(creatorID string, data any) error {
 		platform = "xhs"
 	}
 	now := time.Now().Unix()
-	_, err = db.Exec(
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.Exec(
 		`INSERT INTO creators(platform, creator_id, data_json, updated_at)
 		 VALUES(?, ?, ?, ?)
 		 ON CONFLICT(platform, creator_id)
 		 DO UPDATE SET data_json=excluded.data_json, updated_at=excluded.updated_at;`,
 		platform, creatorID, string(b), now,
-	)
-	return err
+	); err != nil {
+		return err
+	}
+	if err := sqlIndexQueryRecord(tx, backendSQLite, QueryCreators, platform, creatorID, "", b, now); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func sqliteInsertComments(noteID string, items []any, keyFn func(any) (string, error)) error {
@@ -191,6 +226,7 @@ func sqliteInsertComments(noteID string, items []any, keyFn func(any) (string, e
 	defer stmt.Close()
 
 	now := time.Now().Unix()
+	inserted := 0
 	for _, item := range items {
 		id, err := keyFn(item)
 		if err != nil {
@@ -204,10 +240,67 @@ func sqliteInsertComments(noteID string, items []any, keyFn func(any) (string, e
 		if err != nil {
 			return fmt.Errorf("marshal comment %s: %w", id, err)
 		}
-		if _, err := stmt.Exec(platform, id, noteID, string(b), now); err != nil {
+		res, err := stmt.Exec(platform, id, noteID, string(b), now)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue
+		}
+		inserted++
+		if err := sqliteIndexComment(tx, platform, id, noteID, b); err != nil {
 			return err
 		}
+		if err := sqlIndexQueryRecord(tx, backendSQLite, QueryComments, platform, id, noteID, b, now); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	recordCommentsFetched(inserted)
+	return nil
+}
+
+func sqliteUpsertCreatorEdges(edges []any) error {
+	if !sqliteEnabled() {
+		return nil
+	}
+	db, err := sqliteDB()
+	if err != nil {
+		return err
+	}
+	if len(edges) == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.Prepare(
+		`INSERT INTO creator_edges(platform, from_id, to_id, edge_type, data_json, seen_at)
+		 VALUES(?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(platform, from_id, to_id, edge_type)
+		 DO UPDATE SET data_json=excluded.data_json, seen_at=excluded.seen_at;`,
+	)
+	if err != nil {
+		return err
 	}
+	defer stmt.Close()
 
+	for _, item := range edges {
+		e := item.(*CreatorEdge)
+		b, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("marshal creator edge %s: %w", e.Key(), err)
+		}
+		if _, err := stmt.Exec(e.Platform, e.FromID, e.ToID, e.Type, string(b), e.SeenAt); err != nil {
+			return err
+		}
+	}
 	return tx.Commit()
 }
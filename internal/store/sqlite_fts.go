@@ -0,0 +1,440 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// The sqlite backend keeps FTS5 indexes of note titles/content and comment
+// content. FTS5's built-in tokenizers do not segment Chinese, so text is
+// tokenized here before it is stored: runs of CJK characters become
+// overlapping bigrams plus the run's last character, other letters and digits
+// are kept as lowercase words. Queries are tokenized the same way (see
+// ftsMatchQuery), so any CJK substring of two or more characters matches as
+// a phrase and a single character matches as a prefix.
+var sqliteFTSSchema = []string{
+	`CREATE VIRTUAL TABLE IF NOT EXISTS notes_fts USING fts5(
+		platform UNINDEXED,
+		note_id UNINDEXED,
+		title,
+		content,
+		tokenize = 'unicode61 remove_diacritics 2'
+	);`,
+	`CREATE VIRTUAL TABLE IF NOT EXISTS comments_fts USING fts5(
+		platform UNINDEXED,
+		comment_id UNINDEXED,
+		note_id UNINDEXED,
+		content,
+		tokenize = 'unicode61 remove_diacritics 2'
+	);`,
+}
+
+// ErrSearchUnavailable is returned by Search when the store backend has no
+// full-text index.
+var ErrSearchUnavailable = errors.New("full-text search requires STORE_BACKEND=sqlite")
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
+
+// ftsTokenize splits text into index tokens. queryMode drops the trailing
+// single character of CJK runs, which the index only stores so that single
+// character searches can match the end of a run.
+func ftsTokenize(s string, queryMode bool) []string {
+	var out []string
+	var word, run []rune
+	flushWord := func() {
+		if len(word) > 0 {
+			out = append(out, string(word))
+			word = word[:0]
+		}
+	}
+	flushRun := func() {
+		switch {
+		case len(run) == 1:
+			out = append(out, string(run))
+		case len(run) > 1:
+			for i := 0; i+1 < len(run); i++ {
+				out = append(out, string(run[i:i+2]))
+			}
+			if !queryMode {
+				out = append(out, string(run[len(run)-1]))
+			}
+		}
+		run = run[:0]
+	}
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case isCJK(r):
+			flushWord()
+			run = append(run, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushRun()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushRun()
+		}
+	}
+	flushWord()
+	flushRun()
+	return out
+}
+
+func ftsText(s string) string {
+	return strings.Join(ftsTokenize(s, false), " ")
+}
+
+// ftsMatchQuery turns a user query into an FTS5 MATCH expression: every
+// whitespace separated term must match, as a phrase of its tokens (or as a
+// prefix for a lone CJK character). It also returns the terms for
+// highlighting.
+func ftsMatchQuery(q string) (string, []string) {
+	var parts, terms []string
+	for _, term := range strings.Fields(q) {
+		tokens := ftsTokenize(term, true)
+		if len(tokens) == 0 {
+			continue
+		}
+		terms = append(terms, term)
+		phrase := `"` + strings.ReplaceAll(strings.Join(tokens, " "), `"`, `""`) + `"`
+		if len(tokens) == 1 && len([]rune(tokens[0])) == 1 && isCJK([]rune(tokens[0])[0]) {
+			phrase += "*"
+		}
+		parts = append(parts, phrase)
+	}
+	return strings.Join(parts, " "), terms
+}
+
+type sqlExecer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// noteSearchText extracts the title and content of a stored note with the
+// same field paths the query API uses.
+func noteSearchText(data map[string]any) (string, string) {
+	return asString(firstValue(data, queryFieldPaths(QueryNotes, "title")...)), asString(firstValue(data, queryFieldPaths(QueryNotes, "content")...))
+}
+
+func commentSearchText(data map[string]any) string {
+	return asString(firstValue(data, queryFieldPaths(QueryComments, "content")...))
+}
+
+func queryFieldPaths(kind string, name string) []string {
+	for _, f := range queryFieldSets[kind] {
+		if f.name == name {
+			return f.paths
+		}
+	}
+	return nil
+}
+
+func sqliteIndexNote(ex sqlExecer, platform string, noteID string, raw []byte) error {
+	if _, err := ex.Exec(`DELETE FROM notes_fts WHERE platform=? AND note_id=?;`, platform, noteID); err != nil {
+		return err
+	}
+	data, err := decodeQueryData(raw)
+	if err != nil {
+		return nil
+	}
+	title, content := noteSearchText(data)
+	if title == "" && content == "" {
+		return nil
+	}
+	_, err = ex.Exec(`INSERT INTO notes_fts(platform, note_id, title, content) VALUES(?, ?, ?, ?);`, platform, noteID, ftsText(title), ftsText(content))
+	return err
+}
+
+func sqliteIndexComment(ex sqlExecer, platform string, commentID string, noteID string, raw []byte) error {
+	data, err := decodeQueryData(raw)
+	if err != nil {
+		return nil
+	}
+	content := commentSearchText(data)
+	if content == "" {
+		return nil
+	}
+	_, err = ex.Exec(`INSERT INTO comments_fts(platform, comment_id, note_id, content) VALUES(?, ?, ?, ?);`, platform, commentID, noteID, ftsText(content))
+	return err
+}
+
+// RebuildSearchIndex re-creates the sqlite FTS5 indexes from the notes and
+// comments tables, for databases written before the indexes existed or after
+// a tokenizer change.
+func RebuildSearchIndex(ctx context.Context) (int, int, error) {
+	if backendKind() != backendSQLite {
+		return 0, 0, ErrSearchUnavailable
+	}
+	db, err := sqliteDB()
+	if err != nil {
+		return 0, 0, err
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM notes_fts;`); err != nil {
+		return 0, 0, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM comments_fts;`); err != nil {
+		return 0, 0, err
+	}
+
+	type row struct{ platform, id, noteID, raw string }
+	load := func(query string) ([]row, error) {
+		rows, err := tx.QueryContext(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		var out []row
+		for rows.Next() {
+			var r row
+			if err := rows.Scan(&r.platform, &r.id, &r.noteID, &r.raw); err != nil {
+				return out, err
+			}
+			out = append(out, r)
+		}
+		return out, rows.Err()
+	}
+
+	notes, err := load(`SELECT platform, note_id, note_id, data_json FROM notes;`)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, r := range notes {
+		if err := sqliteIndexNote(tx, r.platform, r.id, []byte(r.raw)); err != nil {
+			return 0, 0, err
+		}
+	}
+	comments, err := load(`SELECT platform, comment_id, note_id, data_json FROM comments;`)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, r := range comments {
+		if err := sqliteIndexComment(tx, r.platform, r.id, r.noteID, []byte(r.raw)); err != nil {
+			return 0, 0, err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO notes_fts(notes_fts) VALUES('optimize');`); err != nil {
+		return 0, 0, err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO comments_fts(comments_fts) VALUES('optimize');`); err != nil {
+		return 0, 0, err
+	}
+	return len(notes), len(comments), tx.Commit()
+}
+
+// SearchQuery is a full-text search over notes ("notes"), comments
+// ("comments") or both (Kind empty).
+type SearchQuery struct {
+	Q        string
+	Kind     string
+	Platform string
+	Limit    int
+	Offset   int
+}
+
+// SearchHit is one matching note or comment. Snippet is an HTML-escaped
+// excerpt of the matching text with the query terms wrapped in <mark>.
+type SearchHit struct {
+	Kind        string  `json:"kind"`
+	Platform    string  `json:"platform"`
+	ID          string  `json:"id"`
+	NoteID      string  `json:"note_id"`
+	Title       string  `json:"title,omitempty"`
+	Snippet     string  `json:"snippet"`
+	CreatorName string  `json:"creator_name,omitempty"`
+	CreateTime  any     `json:"create_time,omitempty"`
+	Rank        float64 `json:"rank"`
+}
+
+type SearchResult struct {
+	Query string      `json:"q"`
+	Total int         `json:"total"`
+	Items []SearchHit `json:"items"`
+}
+
+// Search runs a full-text query against the sqlite FTS5 indexes. Hits are
+// ordered by bm25 rank (best first) across both kinds.
+func Search(ctx context.Context, q SearchQuery) (SearchResult, error) {
+	if backendKind() != backendSQLite {
+		return SearchResult{}, ErrSearchUnavailable
+	}
+	match, terms := ftsMatchQuery(q.Q)
+	if match == "" {
+		return SearchResult{}, fmt.Errorf("%w: empty search query", ErrInvalidQuery)
+	}
+	var kinds []string
+	switch strings.ToLower(strings.TrimSpace(q.Kind)) {
+	case "", "all":
+		kinds = []string{QueryNotes, QueryComments}
+	case QueryNotes:
+		kinds = []string{QueryNotes}
+	case QueryComments:
+		kinds = []string{QueryComments}
+	default:
+		return SearchResult{}, fmt.Errorf("%w: unknown kind %q", ErrInvalidQuery, q.Kind)
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	}
+	if limit > MaxQueryLimit {
+		limit = MaxQueryLimit
+	}
+	offset := q.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	db, err := sqliteDB()
+	if err != nil {
+		return SearchResult{}, err
+	}
+
+	out := SearchResult{Query: q.Q, Items: []SearchHit{}}
+	var hits []SearchHit
+	for _, kind := range kinds {
+		total, found, err := sqliteSearchKind(ctx, db, kind, match, strings.TrimSpace(q.Platform), offset+limit)
+		if err != nil {
+			return SearchResult{}, err
+		}
+		out.Total += total
+		hits = append(hits, found...)
+	}
+	// bm25 is negative; lower is better.
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Rank < hits[j].Rank })
+	if offset >= len(hits) {
+		return out, nil
+	}
+	hits = hits[offset:]
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	for i := range hits {
+		hits[i].Snippet = highlightSnippet(hits[i].Snippet, terms, 60)
+	}
+	out.Items = hits
+	return out, nil
+}
+
+func sqliteSearchKind(ctx context.Context, db *sql.DB, kind string, match string, platform string, max int) (int, []SearchHit, error) {
+	var base string
+	if kind == QueryNotes {
+		base = `FROM notes_fts f JOIN notes n ON n.platform = f.platform AND n.note_id = f.note_id WHERE notes_fts MATCH ?`
+	} else {
+		base = `FROM comments_fts f JOIN comments n ON n.platform = f.platform AND n.comment_id = f.comment_id WHERE comments_fts MATCH ?`
+	}
+	args := []any{match}
+	if platform != "" {
+		base += ` AND f.platform = ?`
+		args = append(args, platform)
+	}
+
+	var total int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) `+base, args...).Scan(&total); err != nil {
+		return 0, nil, err
+	}
+	idCol := "f.note_id"
+	if kind == QueryComments {
+		idCol = "f.comment_id"
+	}
+	rows, err := db.QueryContext(ctx, `SELECT f.platform, `+idCol+`, f.note_id, n.data_json, bm25(`+kind+`_fts) AS score `+base+` ORDER BY score LIMIT ?`, append(args, max)...)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+	var out []SearchHit
+	for rows.Next() {
+		h := SearchHit{Kind: kind}
+		var raw string
+		if err := rows.Scan(&h.Platform, &h.ID, &h.NoteID, &raw, &h.Rank); err != nil {
+			return total, out, err
+		}
+		data, _ := decodeQueryData([]byte(raw))
+		rec := normalizeQueryRow(kind, queryFieldSets[kind], queryRow{platform: h.Platform, id: h.ID, noteID: h.NoteID, data: data})
+		h.CreatorName = asString(rec["creator_name"])
+		h.CreateTime = rec["create_time"]
+		if kind == QueryNotes {
+			title, content := noteSearchText(data)
+			h.Title = title
+			h.Snippet = strings.TrimSpace(title + "\n" + content)
+		} else {
+			h.Snippet = commentSearchText(data)
+		}
+		out = append(out, h)
+	}
+	return total, out, rows.Err()
+}
+
+// highlightSnippet cuts a window of about width runes around the first term
+// occurrence in text, HTML-escapes it and wraps every (case-insensitive)
+// term occurrence in <mark></mark>.
+func highlightSnippet(text string, terms []string, width int) string {
+	rs := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	if len(lower) != len(rs) {
+		lower = rs
+	}
+	type span struct{ start, end int }
+	var spans []span
+	for _, term := range terms {
+		tr := []rune(strings.ToLower(term))
+		if len(tr) == 0 {
+			continue
+		}
+		for i := 0; i+len(tr) <= len(lower); i++ {
+			if string(lower[i:i+len(tr)]) == string(tr) {
+				spans = append(spans, span{i, i + len(tr)})
+				i += len(tr) - 1
+			}
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	from, to := 0, len(rs)
+	if len(rs) > width {
+		center := 0
+		if len(spans) > 0 {
+			center = spans[0].start
+		}
+		from = center - width/3
+		if from < 0 {
+			from = 0
+		}
+		to = from + width
+		if to > len(rs) {
+			to = len(rs)
+			from = to - width
+		}
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, sp := range spans {
+		if sp.start < pos || sp.end > to {
+			continue
+		}
+		b.WriteString(html.EscapeString(string(rs[pos:sp.start])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(rs[sp.start:sp.end])))
+		b.WriteString("</mark>")
+		pos = sp.end
+	}
+	b.WriteString(html.EscapeString(string(rs[pos:to])))
+	if to < len(rs) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
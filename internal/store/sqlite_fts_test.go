@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"media-crawler-go/internal/config"
+)
+
+func TestFTSTokenize(t *testing.T) {
+	if got, want := ftsTokenize("Go语言入门 v1.2!", false), []string{"go", "语言", "言入", "入门", "门", "v1", "2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("index tokens: got %v want %v", got, want)
+	}
+	if got, want := ftsTokenize("语言入门", true), []string{"语言", "言入", "入门"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("query tokens: got %v want %v", got, want)
+	}
+	if got, _ := ftsMatchQuery(`入门 go"x 门`); got != `"入门" "go x" "门"*` {
+		t.Fatalf("match query: %s", got)
+	}
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	got := highlightSnippet("Learn <Go> and go fast", []string{"go"}, 60)
+	if got != "Learn &lt;<mark>Go</mark>&gt; and <mark>go</mark> fast" {
+		t.Fatalf("snippet: %s", got)
+	}
+	long := strings.Repeat("甲", 50) + "防晒霜" + strings.Repeat("乙", 50)
+	got = highlightSnippet(long, []string{"防晒"}, 20)
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") || !strings.Contains(got, "<mark>防晒</mark>霜") {
+		t.Fatalf("windowed snippet: %s", got)
+	}
+}
+
+func TestSQLiteSearch(t *testing.T) {
+	tmp := t.TempDir()
+	oldCfg := config.AppConfig
+	config.AppConfig = config.Config{
+		DataDir:        filepath.Join(tmp, "data"),
+		Platform:       "xhs",
+		StoreBackend:   "sqlite",
+		SQLitePath:     filepath.Join(tmp, "data", "media_crawler.db"),
+		SaveDataOption: "json",
+	}
+	resetSQLiteForTest(t)
+	t.Cleanup(func() {
+		resetSQLiteForTest(t)
+		config.AppConfig = oldCfg
+	})
+
+	for _, n := range []map[string]any{
+		{"note_id": "n1", "title": "夏天防晒霜推荐", "desc": "油皮亲测好用", "user": map[string]any{"nickname": "alice"}},
+		{"note_id": "n2", "title": "Winter skincare", "desc": "no sunscreen needed"},
+	} {
+		if err := SaveNoteDetail(n["note_id"].(string), n); err != nil {
+			t.Fatalf("save note: %v", err)
+		}
+	}
+	keyFn := func(item any) (string, error) { return item.(map[string]any)["id"].(string), nil }
+	if _, err := AppendUniqueCommentsJSONL("n1", []any{
+		map[string]any{"id": "c1", "content": "这款防晒不闷痘"},
+		map[string]any{"id": "c2", "content": "Sunscreen every day"},
+	}, keyFn); err != nil {
+		t.Fatalf("save comments: %v", err)
+	}
+
+	res, err := Search(context.Background(), SearchQuery{Q: "防晒"})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if res.Total != 2 || len(res.Items) != 2 {
+		t.Fatalf("unexpected hits: %+v", res)
+	}
+	var note SearchHit
+	for _, h := range res.Items {
+		if h.Kind == QueryNotes {
+			note = h
+		}
+	}
+	if note.ID != "n1" || note.Title != "夏天防晒霜推荐" || note.CreatorName != "alice" || !strings.Contains(note.Snippet, "<mark>防晒</mark>") {
+		t.Fatalf("unexpected note hit: %+v", note)
+	}
+
+	// Substrings inside a run, single characters and latin words all match.
+	for q, want := range map[string]int{"晒霜": 1, "痘": 1, "SUNSCREEN": 2, "防晒 推荐": 1, "冬天": 0} {
+		res, err := Search(context.Background(), SearchQuery{Q: q})
+		if err != nil || res.Total != want {
+			t.Fatalf("%q: total=%d err=%v", q, res.Total, err)
+		}
+	}
+	res, err = Search(context.Background(), SearchQuery{Q: "sunscreen", Kind: QueryComments})
+	if err != nil || res.Total != 1 || res.Items[0].ID != "c2" || res.Items[0].NoteID != "n1" {
+		t.Fatalf("comment search: %+v err=%v", res, err)
+	}
+	if res, _ := Search(context.Background(), SearchQuery{Q: "防晒", Platform: "douyin"}); res.Total != 0 {
+		t.Fatalf("platform filter: %+v", res)
+	}
+
+	// Updating a note replaces its index row.
+	if err := SaveNoteDetail("n1", map[string]any{"note_id": "n1", "title": "秋天穿搭"}); err != nil {
+		t.Fatalf("update note: %v", err)
+	}
+	if res, _ := Search(context.Background(), SearchQuery{Q: "防晒", Kind: QueryNotes}); res.Total != 0 {
+		t.Fatalf("stale index row: %+v", res)
+	}
+
+	db, err := sqliteDB()
+	if err != nil {
+		t.Fatalf("db: %v", err)
+	}
+	if _, err := db.Exec(`DELETE FROM notes_fts; DELETE FROM comments_fts;`); err != nil {
+		t.Fatalf("clear index: %v", err)
+	}
+	notes, comments, err := RebuildSearchIndex(context.Background())
+	if err != nil || notes != 2 || comments != 2 {
+		t.Fatalf("rebuild: notes=%d comments=%d err=%v", notes, comments, err)
+	}
+	if res, _ := Search(context.Background(), SearchQuery{Q: "穿搭 sunscreen"}); res.Total != 0 {
+		t.Fatalf("terms must all match: %+v", res)
+	}
+	if res, _ := Search(context.Background(), SearchQuery{Q: "穿搭"}); res.Total != 1 {
+		t.Fatalf("rebuilt index: %+v", res)
+	}
+
+	if _, err := Search(context.Background(), SearchQuery{Q: "!!"}); !errors.Is(err, ErrInvalidQuery) {
+		t.Fatalf("expected invalid query, got %v", err)
+	}
+	config.AppConfig.StoreBackend = "file"
+	if _, err := Search(context.Background(), SearchQuery{Q: "x"}); !errors.Is(err, ErrSearchUnavailable) {
+		t.Fatalf("expected unavailable, got %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"sync"
@@ -17,6 +18,7 @@ func resetSQLiteForTest(t *testing.T) {
 	sqliteInst = nil
 	sqliteErr = nil
 	sqliteOnce = sync.Once{}
+	resetQueryIndexReady()
 }
 
 func TestSQLiteUpsertNote(t *testing.T) {
@@ -94,3 +96,35 @@ func TestSQLiteGlobalCommentDedupe(t *testing.T) {
 		t.Fatalf("expected global dedupe (1 row), got %d", count)
 	}
 }
+
+func TestCloseReopensSQLite(t *testing.T) {
+	tmp := t.TempDir()
+	config.AppConfig.Platform = "xhs"
+	config.AppConfig.StoreBackend = "sqlite"
+	config.AppConfig.SQLitePath = filepath.Join(tmp, "media_crawler.db")
+	config.AppConfig.DataDir = tmp
+	config.AppConfig.SaveDataOption = "json"
+	resetSQLiteForTest(t)
+	t.Cleanup(func() { resetSQLiteForTest(t) })
+
+	if err := SaveNoteDetail("n1", map[string]any{"id": "n1"}); err != nil {
+		t.Fatalf("SaveNoteDetail err: %v", err)
+	}
+	old := sqliteInst
+	if err := Close(context.Background()); err != nil {
+		t.Fatalf("Close err: %v", err)
+	}
+	if sqliteInst != nil || old.Ping() == nil {
+		t.Fatalf("expected sqlite to be closed")
+	}
+	if err := SaveNoteDetail("n2", map[string]any{"id": "n2"}); err != nil {
+		t.Fatalf("SaveNoteDetail after Close err: %v", err)
+	}
+	var count int
+	if err := sqliteInst.QueryRow(`SELECT COUNT(*) FROM notes WHERE platform=?`, "xhs").Scan(&count); err != nil {
+		t.Fatalf("query count err: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("expected 2 note rows, got %d", count)
+	}
+}
@@ -1,6 +1,11 @@
 package store
 
-func sqlUpsertNote(noteID string, note any) error {
+import "time"
+
+func sqlUpsertNote(noteID string, note any) (err error) {
+	if k := backendKind(); k != backendFile {
+		defer observeStoreWrite(k, "note", time.Now(), &err)
+	}
 	switch backendKind() {
 	case backendSQLite:
 		return sqliteUpsertNote(noteID, note)
@@ -15,7 +20,10 @@ func sqlUpsertNote(noteID string, note any) error {
 	}
 }
 
-func sqlUpsertCreator(creatorID string, data any) error {
+func sqlUpsertCreator(creatorID string, data any) (err error) {
+	if k := backendKind(); k != backendFile {
+		defer observeStoreWrite(k, "creator", time.Now(), &err)
+	}
 	switch backendKind() {
 	case backendSQLite:
 		return sqliteUpsertCreator(creatorID, data)
@@ -30,7 +38,28 @@ func sqlUpsertCreator(creatorID string, data any) error {
 	}
 }
 
-func sqlInsertComments(noteID string, items []any, keyFn func(any) (string, error)) error {
+func sqlUpsertCreatorEdges(edges []any) (err error) {
+	if k := backendKind(); k != backendFile {
+		defer observeStoreWrite(k, "creator_edges", time.Now(), &err)
+	}
+	switch backendKind() {
+	case backendSQLite:
+		return sqliteUpsertCreatorEdges(edges)
+	case backendMySQL:
+		return mysqlUpsertCreatorEdges(edges)
+	case backendPostgres:
+		return postgresUpsertCreatorEdges(edges)
+	case backendMongoDB:
+		return mongoUpsertCreatorEdges(edges)
+	default:
+		return nil
+	}
+}
+
+func sqlInsertComments(noteID string, items []any, keyFn func(any) (string, error)) (err error) {
+	if k := backendKind(); k != backendFile {
+		defer observeStoreWrite(k, "comments", time.Now(), &err)
+	}
 	switch backendKind() {
 	case backendSQLite:
 		return sqliteInsertComments(noteID, items, keyFn)
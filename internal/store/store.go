@@ -1,12 +1,16 @@
 package store
 
 import (
+	"bufio"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"media-crawler-go/internal/config"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 )
@@ -68,9 +72,9 @@ func (s *CsvStore) Save(data interface{}, filename string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	fileExists := false
-	if _, err := os.Stat(path); err == nil {
-		fileExists = true
+	fileExists, err := rotateStaleCSV(path, item.CSVHeader())
+	if err != nil {
+		return err
 	}
 
 	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
@@ -96,6 +100,44 @@ func (s *CsvStore) Save(data interface{}, filename string) error {
 	return writer.Write(item.ToCSV())
 }
 
+// rotateStaleCSV reports whether path already starts with the given header. A file
+// written with a different header (e.g. before new columns were added) is
+// renamed to <name>.<n>.csv so appended rows never mix two schemas.
+func rotateStaleCSV(path string, header []string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	r := csv.NewReader(bufio.NewReader(f))
+	r.FieldsPerRecord = -1
+	got, err := r.Read()
+	f.Close()
+	if err == io.EOF {
+		return false, nil
+	}
+	if err == nil && len(got) > 0 {
+		got[0] = strings.TrimPrefix(got[0], "\xEF\xBB\xBF")
+		if slices.Equal(got, header) {
+			return true, nil
+		}
+	}
+
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, ext)
+	for n := 1; ; n++ {
+		dst := fmt.Sprintf("%s.%d%s", stem, n, ext)
+		if _, err := os.Stat(dst); os.IsNotExist(err) {
+			if err := os.Rename(path, dst); err != nil {
+				return false, err
+			}
+			return false, nil
+		}
+	}
+}
+
 func GetStore() Store {
 	path := PlatformDir()
 
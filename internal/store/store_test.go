@@ -0,0 +1,56 @@
+package store
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type csvRow struct{ header, row []string }
+
+func (r csvRow) CSVHeader() []string { return r.header }
+func (r csvRow) ToCSV() []string     { return r.row }
+
+func TestCsvStoreRotatesOnHeaderChange(t *testing.T) {
+	dir := t.TempDir()
+	s := NewCsvStore(dir)
+	old := csvRow{header: []string{"id", "title"}, row: []string{"1", "a"}}
+	cur := csvRow{header: []string{"id", "type", "title"}, row: []string{"2", "68", "b"}}
+
+	if err := s.Save(old, "contents.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(old, "contents.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(cur, "contents.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(cur, "contents.csv"); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(name string) [][]string {
+		t.Helper()
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		recs, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(b), "\xEF\xBB\xBF"))).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return recs
+	}
+	rotated := read("contents.1.csv")
+	if len(rotated) != 3 || !slices.Equal(rotated[0], old.header) {
+		t.Fatalf("unexpected rotated file: %v", rotated)
+	}
+	fresh := read("contents.csv")
+	if len(fresh) != 3 || !slices.Equal(fresh[0], cur.header) || !slices.Equal(fresh[2], cur.row) {
+		t.Fatalf("unexpected current file: %v", fresh)
+	}
+}
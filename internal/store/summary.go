@@ -0,0 +1,81 @@
+package store
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WriteSummaryCSV writes the stored notes or comments of platform as CSV,
+// one row per record with the normalized fields of QueryFields (the unified
+// schema of the query API). Only records of noteIDs are written when it is
+// not empty. Rows are ordered by note and record id, so the same records
+// always give the same bytes. It returns the number of rows.
+func WriteSummaryCSV(w io.Writer, kind string, platform string, noteIDs []string) (int, error) {
+	fieldSet, ok := queryFieldSets[kind]
+	if !ok || kind == QueryCreators {
+		return 0, fmt.Errorf("%w: no summary for kind %q", ErrInvalidQuery, kind)
+	}
+	platform = strings.TrimSpace(platform)
+	noteID := ""
+	if len(noteIDs) == 1 {
+		noteID = noteIDs[0]
+	}
+	rows, err := loadQueryRows(kind, platform, noteID)
+	if err != nil {
+		return 0, err
+	}
+	wanted := map[string]bool{}
+	for _, id := range noteIDs {
+		wanted[id] = true
+	}
+
+	recs := make([]map[string]any, 0, len(rows))
+	for _, row := range rows {
+		rec := normalizeQueryRow(kind, fieldSet, row)
+		if len(wanted) > 0 && !wanted[asString(rec["note_id"])] {
+			continue
+		}
+		recs = append(recs, rec)
+	}
+	idField := idFields[kind][1]
+	sort.SliceStable(recs, func(i, j int) bool {
+		a, b := asString(recs[i]["note_id"]), asString(recs[j]["note_id"])
+		if a != b {
+			return a < b
+		}
+		return asString(recs[i][idField]) < asString(recs[j][idField])
+	})
+
+	header := QueryFields(kind)
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return 0, err
+	}
+	line := make([]string, len(header))
+	for _, rec := range recs {
+		for i, name := range header {
+			line[i] = summaryValue(rec[name])
+		}
+		if err := cw.Write(line); err != nil {
+			return 0, err
+		}
+	}
+	cw.Flush()
+	return len(recs), cw.Error()
+}
+
+func summaryValue(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case string:
+		return x
+	}
+	return fmt.Sprint(v)
+}
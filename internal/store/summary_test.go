@@ -0,0 +1,37 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"media-crawler-go/internal/config"
+)
+
+func TestWriteSummaryCSV(t *testing.T) {
+	dataDir := t.TempDir()
+	oldCfg := config.AppConfig
+	config.AppConfig = config.Config{DataDir: dataDir, Platform: "bilibili"}
+	t.Cleanup(func() { config.AppConfig = oldCfg })
+
+	writeTestFile(t, filepath.Join(dataDir, "bilibili", "comments.jsonl"), `{"Platform":"bilibili","NoteID":"BV2","CommentID":"c3","Content":"other"}
+{"Platform":"bilibili","NoteID":"BV1","CommentID":"c2","Content":"agreed, \"quoted\"","LikeCount":2}
+{"Platform":"bilibili","NoteID":"BV1","CommentID":"c1","Content":"great video","LikeCount":10}
+`)
+	var b strings.Builder
+	n, err := WriteSummaryCSV(&b, QueryComments, "bilibili", []string{"BV1"})
+	if err != nil {
+		t.Fatalf("summary: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if n != 2 || len(lines) != 3 || lines[0] != strings.Join(QueryFields(QueryComments), ",") {
+		t.Fatalf("unexpected summary (%d rows):\n%s", n, b.String())
+	}
+	if !strings.HasPrefix(lines[1], "bilibili,c1,BV1,") || !strings.Contains(lines[2], `"agreed, ""quoted"""`) {
+		t.Fatalf("unexpected rows:\n%s", b.String())
+	}
+	if _, err := WriteSummaryCSV(&b, QueryCreators, "bilibili", nil); !errors.Is(err, ErrInvalidQuery) {
+		t.Fatalf("creators summary: %v", err)
+	}
+}
@@ -2,17 +2,30 @@ package store
 
 import "strconv"
 
+// UnifiedComment is the cross-platform comment row of data/<platform>/comments.*.
+//
+// Thread fields: RootCommentID is the top-level comment of the thread and
+// ReplyToCommentID the comment directly replied to (both empty for top-level
+// comments); Depth is 0 for top-level comments, 1 for replies to them and
+// 2+ for replies to replies. SubCommentCount is the reply count reported by
+// the platform.
 type UnifiedComment struct {
-	Platform        string
-	NoteID          string
-	CommentID       string
-	ParentCommentID string
-	Content         string
-	CreateTime      int64
-	LikeCount       int64
-	UserID          string
-	UserSecUID      string
-	UserNickname    string
+	Platform            string
+	NoteID              string
+	CommentID           string
+	ParentCommentID     string
+	Content             string
+	CreateTime          int64
+	LikeCount           int64
+	UserID              string
+	UserSecUID          string
+	UserNickname        string
+	RootCommentID       string
+	ReplyToCommentID    string
+	ReplyToUserID       string
+	ReplyToUserNickname string
+	Depth               int
+	SubCommentCount     int64
 }
 
 func (c *UnifiedComment) CSVHeader() []string {
@@ -27,6 +40,12 @@ func (c *UnifiedComment) CSVHeader() []string {
 		"user_id",
 		"user_sec_uid",
 		"user_nickname",
+		"root_comment_id",
+		"reply_to_comment_id",
+		"reply_to_user_id",
+		"reply_to_user_nickname",
+		"depth",
+		"sub_comment_count",
 	}
 }
 
@@ -42,6 +61,11 @@ func (c *UnifiedComment) ToCSV() []string {
 		c.UserID,
 		c.UserSecUID,
 		c.UserNickname,
+		c.RootCommentID,
+		c.ReplyToCommentID,
+		c.ReplyToUserID,
+		c.ReplyToUserNickname,
+		strconv.Itoa(c.Depth),
+		strconv.FormatInt(c.SubCommentCount, 10),
 	}
 }
-
@@ -118,10 +118,10 @@ func AppendUniqueBookSheetRows(sheet string, indexFilename string, items []any,
 		return 0, nil
 	}
 
+	path := workbookPath()
 	bookMu.Lock()
 	defer bookMu.Unlock()
 
-	path := workbookPath()
 	sheets := []string{"Contents", "Comments", "Creators"}
 	found := false
 	for _, s := range sheets {
@@ -160,7 +160,7 @@ func AppendUniqueBookSheetRows(sheet string, indexFilename string, items []any,
 		nextRow++
 	}
 
-	if err := f.SaveAs(path); err != nil {
+	if err := saveBook(f, path); err != nil {
 		return 0, err
 	}
 	if err := appendIndex(indexPath, newKeys); err != nil {
@@ -182,10 +182,10 @@ func appendUniqueBookRow(sheet string, indexFilename string, key string, header
 		return nil
 	}
 
+	path := workbookPath()
 	bookMu.Lock()
 	defer bookMu.Unlock()
 
-	path := workbookPath()
 	sheets := []string{"Contents", "Comments", "Creators"}
 	found := false
 	for _, s := range sheets {
@@ -220,12 +220,40 @@ func appendUniqueBookRow(sheet string, indexFilename string, key string, header
 	}
 	applyAutoWidth(f, sheet, header, row)
 
-	if err := f.SaveAs(path); err != nil {
+	if err := saveBook(f, path); err != nil {
 		return err
 	}
 	return appendIndex(indexPath, []string{key})
 }
 
+// saveBook writes f next to path and renames it into place, so an
+// interrupted save leaves the previous workbook intact.
+func saveBook(f *excelize.File, path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	// CreateTemp uses 0600; keep the workbook's mode, or the 0644 of a fresh
+	// file, across the rename.
+	mode := os.FileMode(0644)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := f.Write(tmp); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
 func openOrCreateBook(path string, sheets []string) (*excelize.File, error) {
 	if _, err := os.Stat(path); err == nil {
 		f, err := excelize.OpenFile(path)
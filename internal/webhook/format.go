@@ -0,0 +1,201 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// buildRequest renders a delivery for its subscription's format. Signatures
+// cover the attempt time, so every retry is signed anew.
+//
+//   - json: the Event; with a secret, X-Webhook-Signature is
+//     "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)) and
+//     X-Webhook-Timestamp holds the unix timestamp.
+//   - dingtalk, feishu, wecom, slack: a text message built by summary.
+//     DingTalk and Feishu secrets use the platforms' own signing.
+func buildRequest(dl delivery, now time.Time) (*http.Request, error) {
+	target := dl.sub.URL
+	var body []byte
+	var err error
+	switch dl.sub.Format {
+	case "dingtalk":
+		if dl.sub.Secret != "" {
+			ts := strconv.FormatInt(now.UnixMilli(), 10)
+			mac := hmac.New(sha256.New, []byte(dl.sub.Secret))
+			mac.Write([]byte(ts + "\n" + dl.sub.Secret))
+			sign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+			target = addQuery(target, url.Values{"timestamp": {ts}, "sign": {sign}})
+		}
+		body, err = json.Marshal(map[string]any{"msgtype": "text", "text": map[string]any{"content": dl.text}})
+	case "feishu":
+		msg := map[string]any{"msg_type": "text", "content": map[string]any{"text": dl.text}}
+		if dl.sub.Secret != "" {
+			ts := strconv.FormatInt(now.Unix(), 10)
+			mac := hmac.New(sha256.New, []byte(ts+"\n"+dl.sub.Secret))
+			msg["timestamp"] = ts
+			msg["sign"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		}
+		body, err = json.Marshal(msg)
+	case "wecom":
+		body, err = json.Marshal(map[string]any{"msgtype": "text", "text": map[string]any{"content": dl.text}})
+	case "slack":
+		body, err = json.Marshal(map[string]any{"text": dl.text})
+	default:
+		body = dl.raw
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "media-crawler-go-webhook")
+	if dl.sub.Format == "json" {
+		req.Header.Set("X-Webhook-Event", dl.event.Type)
+		req.Header.Set("X-Webhook-ID", dl.event.ID)
+		if dl.sub.Secret != "" {
+			ts := strconv.FormatInt(now.Unix(), 10)
+			req.Header.Set("X-Webhook-Timestamp", ts)
+			req.Header.Set("X-Webhook-Signature", Sign(dl.sub.Secret, ts, body))
+		}
+	}
+	return req, nil
+}
+
+// Sign returns the X-Webhook-Signature of body sent at timestamp, for
+// receivers verifying json deliveries.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func addQuery(target string, q url.Values) string {
+	sep := "?"
+	if strings.Contains(target, "?") {
+		sep = "&"
+	}
+	return target + sep + q.Encode()
+}
+
+// checkResponse fails non-2xx answers and the error codes that the chat
+// platforms return with 200.
+func checkResponse(format string, resp *http.Response) error {
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("http %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	switch format {
+	case "dingtalk", "wecom", "feishu":
+		var r struct {
+			ErrCode    *int   `json:"errcode"`
+			ErrMsg     string `json:"errmsg"`
+			Code       *int   `json:"code"`
+			Msg        string `json:"msg"`
+			StatusCode *int   `json:"StatusCode"`
+		}
+		if json.Unmarshal(b, &r) != nil {
+			return nil
+		}
+		switch {
+		case r.ErrCode != nil && *r.ErrCode != 0:
+			return fmt.Errorf("errcode %d: %s", *r.ErrCode, r.ErrMsg)
+		case r.Code != nil && *r.Code != 0:
+			return fmt.Errorf("code %d: %s", *r.Code, r.Msg)
+		case r.StatusCode != nil && *r.StatusCode != 0:
+			return fmt.Errorf("status code %d", *r.StatusCode)
+		}
+	}
+	return nil
+}
+
+var summaryTitles = map[string]string{
+	TaskStarted:   "task started",
+	TaskFinished:  "task finished",
+	TaskFailed:    "task failed",
+	TaskCanceled:  "task canceled",
+	RiskHint:      "risk control detected",
+	LoginRequired: "login required",
+	NoteSaved:     "note saved",
+}
+
+// summaryKeys are the data fields shown in chat messages, in order.
+var summaryKeys = []string{
+	"mode", "keywords", "login_type", "hint", "url", "note_id", "title",
+	"processed", "succeeded", "failed", "failure_kinds", "duration_sec", "error_kind", "error",
+}
+
+// summary renders e as a short plain-text chat message.
+func summary(e Event) string {
+	title := summaryTitles[e.Type]
+	if title == "" {
+		title = e.Type
+	}
+	var b strings.Builder
+	b.WriteString("[media-crawler] ")
+	b.WriteString(title)
+	if e.Platform != "" {
+		b.WriteString(": " + e.Platform)
+	}
+	for _, k := range summaryKeys {
+		v, ok := e.Data[k]
+		if !ok || isEmpty(v) {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s: %s", k, formatValue(v))
+	}
+	if e.TaskID != "" {
+		b.WriteString("\ntask: " + e.TaskID)
+	}
+	b.WriteString("\ntime: " + e.Time)
+	return b.String()
+}
+
+func isEmpty(v any) bool {
+	switch x := v.(type) {
+	case nil:
+		return true
+	case string:
+		return x == ""
+	case []string:
+		return len(x) == 0
+	case map[string]int:
+		return len(x) == 0
+	}
+	return false
+}
+
+func formatValue(v any) string {
+	switch x := v.(type) {
+	case []string:
+		return strings.Join(x, ", ")
+	case map[string]int:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, 0, len(keys))
+		for _, k := range keys {
+			parts = append(parts, fmt.Sprintf("%s=%d", k, x[k]))
+		}
+		return strings.Join(parts, ", ")
+	}
+	return fmt.Sprint(v)
+}
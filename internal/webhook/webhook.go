@@ -0,0 +1,489 @@
+// Package webhook delivers task lifecycle and data events to configured
+// HTTP endpoints. Deliveries run in the background, are retried with
+// exponential backoff, and end up in a dead-letter file when every attempt
+// failed.
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/logger"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types.
+const (
+	TaskStarted   = "task.started"
+	TaskFinished  = "task.finished"
+	TaskFailed    = "task.failed"
+	TaskCanceled  = "task.canceled"
+	RiskHint      = "risk.hint"
+	LoginRequired = "login.required"
+	// NoteSaved is sent for every stored note, with the note as payload.
+	// Subscriptions only receive it when they list it explicitly.
+	NoteSaved = "note.saved"
+)
+
+// Event is the JSON body of deliveries in the json format.
+type Event struct {
+	ID       string         `json:"id"`
+	Type     string         `json:"type"`
+	Time     string         `json:"time"`
+	Platform string         `json:"platform,omitempty"`
+	TaskID   string         `json:"task_id,omitempty"`
+	Data     map[string]any `json:"data,omitempty"`
+}
+
+// Subscription is a validated config.WebhookConfig.
+type Subscription struct {
+	Name   string
+	URL    string
+	Events []string
+	Secret string
+	Format string
+}
+
+// Matches reports whether the subscription wants events of type typ.
+func (s Subscription) Matches(typ string) bool {
+	if len(s.Events) == 0 {
+		return typ != NoteSaved
+	}
+	for _, f := range s.Events {
+		switch {
+		case f == typ:
+			return true
+		case f == "*":
+			if typ != NoteSaved {
+				return true
+			}
+		case strings.HasSuffix(f, ".*") && strings.HasPrefix(typ, strings.TrimSuffix(f, "*")):
+			return true
+		}
+	}
+	return false
+}
+
+var formats = map[string]bool{"json": true, "dingtalk": true, "feishu": true, "wecom": true, "slack": true}
+
+// formatForHost picks the chat format from well-known incoming webhook
+// hosts when none is configured.
+func formatForHost(host string) string {
+	switch {
+	case host == "oapi.dingtalk.com":
+		return "dingtalk"
+	case host == "open.feishu.cn" || host == "open.larksuite.com":
+		return "feishu"
+	case host == "qyapi.weixin.qq.com":
+		return "wecom"
+	case host == "hooks.slack.com":
+		return "slack"
+	}
+	return "json"
+}
+
+// ParseSubscriptions validates webhook configs.
+func ParseSubscriptions(cfgs []config.WebhookConfig) ([]Subscription, error) {
+	out := make([]Subscription, 0, len(cfgs))
+	for i, c := range cfgs {
+		s := Subscription{
+			Name:   strings.TrimSpace(c.Name),
+			URL:    strings.TrimSpace(c.URL),
+			Secret: c.Secret,
+			Format: strings.ToLower(strings.TrimSpace(c.Format)),
+		}
+		if s.Name == "" {
+			s.Name = fmt.Sprintf("webhook-%d", i+1)
+		}
+		u, err := url.Parse(s.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("webhook %s: invalid url %q", s.Name, s.URL)
+		}
+		if s.Format == "" {
+			s.Format = formatForHost(u.Hostname())
+		}
+		if !formats[s.Format] {
+			return nil, fmt.Errorf("webhook %s: unknown format %q (json|dingtalk|feishu|wecom|slack)", s.Name, c.Format)
+		}
+		for _, e := range c.Events {
+			if e = strings.TrimSpace(e); e != "" {
+				s.Events = append(s.Events, e)
+			}
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// Options tune delivery.
+type Options struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	Timeout    time.Duration
+	// DeadLetter is the JSONL file of deliveries that failed for good.
+	DeadLetter string
+	Workers    int
+	QueueSize  int
+	HTTPClient *http.Client
+}
+
+type delivery struct {
+	sub   Subscription
+	event Event
+	raw   []byte
+	text  string
+}
+
+// Dispatcher queues events and delivers them to its subscriptions.
+type Dispatcher struct {
+	subs []Subscription
+	opts Options
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan delivery
+	abort  chan struct{}
+	wg     sync.WaitGroup
+	dlMu   sync.Mutex
+}
+
+// New starts a dispatcher with workers delivering to subs.
+func New(subs []Subscription, opts Options) *Dispatcher {
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = time.Second
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = 5 * time.Minute
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1000
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: opts.Timeout}
+	}
+	d := &Dispatcher{
+		subs:  subs,
+		opts:  opts,
+		queue: make(chan delivery, opts.QueueSize),
+		abort: make(chan struct{}),
+	}
+	for i := 0; i < opts.Workers; i++ {
+		d.wg.Add(1)
+		go d.work()
+	}
+	return d
+}
+
+// Wants reports whether any subscription receives events of type typ, so
+// callers can skip building expensive payloads.
+func (d *Dispatcher) Wants(typ string) bool {
+	if d == nil {
+		return false
+	}
+	for _, s := range d.subs {
+		if s.Matches(typ) {
+			return true
+		}
+	}
+	return false
+}
+
+// Emit queues e for every matching subscription. Payloads are encoded
+// before Emit returns, so e.Data may be reused afterwards.
+func (d *Dispatcher) Emit(e Event) {
+	if d == nil || !d.Wants(e.Type) {
+		return
+	}
+	if e.ID == "" {
+		e.ID = newEventID()
+	}
+	if e.Time == "" {
+		e.Time = time.Now().UTC().Format(time.RFC3339)
+	}
+	raw, err := json.Marshal(e)
+	if err != nil {
+		logger.Warn("encode webhook event failed", "type", e.Type, "err", err)
+		return
+	}
+	text := summary(e)
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, s := range d.subs {
+		if !s.Matches(e.Type) {
+			continue
+		}
+		dl := delivery{sub: s, event: e, raw: raw, text: text}
+		if d.closed {
+			d.deadLetter(dl, 0, "dispatcher closed")
+			continue
+		}
+		select {
+		case d.queue <- dl:
+		default:
+			d.deadLetter(dl, 0, "queue full")
+		}
+	}
+}
+
+// Close stops accepting events and waits until queued deliveries are done
+// or ctx ends; deliveries still pending then go to the dead-letter file.
+func (d *Dispatcher) Close(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return nil
+	}
+	d.closed = true
+	close(d.queue)
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		close(d.abort)
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+	for dl := range d.queue {
+		d.deliver(dl)
+	}
+}
+
+func (d *Dispatcher) deliver(dl delivery) {
+	var lastErr error
+	for attempt := 0; attempt <= d.opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			delay := d.opts.BaseDelay << (attempt - 1)
+			if delay > d.opts.MaxDelay || delay <= 0 {
+				delay = d.opts.MaxDelay
+			}
+			t := time.NewTimer(delay)
+			select {
+			case <-t.C:
+			case <-d.abort:
+				t.Stop()
+				d.deadLetter(dl, attempt, fmt.Sprintf("shutdown after: %v", lastErr))
+				return
+			}
+		}
+		select {
+		case <-d.abort:
+			d.deadLetter(dl, attempt, "shutdown")
+			return
+		default:
+		}
+		retry, err := d.send(dl)
+		if err == nil {
+			logger.Debug("webhook delivered", "webhook", dl.sub.Name, "type", dl.event.Type, "id", dl.event.ID)
+			return
+		}
+		lastErr = err
+		logger.Warn("webhook delivery failed", "webhook", dl.sub.Name, "type", dl.event.Type, "attempt", attempt+1, "err", err)
+		if !retry {
+			d.deadLetter(dl, attempt+1, err.Error())
+			return
+		}
+	}
+	d.deadLetter(dl, d.opts.MaxRetries+1, lastErr.Error())
+}
+
+// send makes one attempt. retry is false for answers that will not change,
+// such as 4xx other than 408 and 429.
+func (d *Dispatcher) send(dl delivery) (retry bool, err error) {
+	req, err := buildRequest(dl, time.Now())
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), d.opts.Timeout)
+	defer cancel()
+	resp, err := d.opts.HTTPClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(dl.sub.Format, resp); err != nil {
+		code := resp.StatusCode
+		return code >= 500 || code == http.StatusTooManyRequests || code == http.StatusRequestTimeout || code < 300, err
+	}
+	return false, nil
+}
+
+// DeadLetter is one line of the dead-letter file.
+type DeadLetter struct {
+	Time     string          `json:"time"`
+	Webhook  string          `json:"webhook"`
+	Format   string          `json:"format"`
+	Attempts int             `json:"attempts"`
+	Error    string          `json:"error"`
+	Event    json.RawMessage `json:"event"`
+}
+
+func (d *Dispatcher) deadLetter(dl delivery, attempts int, reason string) {
+	logger.Error("webhook delivery dropped", "webhook", dl.sub.Name, "type", dl.event.Type, "id", dl.event.ID, "attempts", attempts, "err", reason)
+	path := d.opts.DeadLetter
+	if path == "" {
+		return
+	}
+	b, err := json.Marshal(DeadLetter{
+		Time:     time.Now().UTC().Format(time.RFC3339Nano),
+		Webhook:  dl.sub.Name,
+		Format:   dl.sub.Format,
+		Attempts: attempts,
+		Error:    reason,
+		Event:    dl.raw,
+	})
+	if err != nil {
+		return
+	}
+	d.dlMu.Lock()
+	defer d.dlMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		logger.Warn("write webhook dead letter failed", "err", err)
+		return
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		logger.Warn("write webhook dead letter failed", "err", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		logger.Warn("write webhook dead letter failed", "err", err)
+	}
+}
+
+func newEventID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+var (
+	defaultMu sync.RWMutex
+	defaultD  *Dispatcher
+)
+
+// DeadLetterPath is WEBHOOK_DEAD_LETTER, by default
+// <DATA_DIR>/webhooks_dead.jsonl.
+func DeadLetterPath() string {
+	if p := strings.TrimSpace(config.AppConfig.WebhookDeadLetter); p != "" {
+		return p
+	}
+	dataDir := strings.TrimSpace(config.AppConfig.DataDir)
+	if dataDir == "" {
+		dataDir = "data"
+	}
+	return filepath.Join(dataDir, "webhooks_dead.jsonl")
+}
+
+// InitFromConfig replaces the default dispatcher with one built from
+// config.AppConfig. Without subscriptions events are dropped.
+func InitFromConfig() error {
+	cfg := config.AppConfig
+	subs, err := ParseSubscriptions(cfg.Webhooks)
+	if err != nil {
+		return err
+	}
+	var d *Dispatcher
+	if len(subs) > 0 {
+		d = New(subs, Options{
+			MaxRetries: cfg.WebhookMaxRetries,
+			BaseDelay:  time.Duration(cfg.WebhookRetryBaseDelayMs) * time.Millisecond,
+			Timeout:    time.Duration(cfg.WebhookTimeoutSec) * time.Second,
+			DeadLetter: DeadLetterPath(),
+		})
+		logger.Info("webhooks enabled", "subscriptions", len(subs))
+	}
+	old := SetDefault(d)
+	if old != nil {
+		go func() { _ = old.Close(context.Background()) }()
+	}
+	return nil
+}
+
+// SetDefault installs d as the dispatcher used by Emit and returns the
+// previous one.
+func SetDefault(d *Dispatcher) *Dispatcher {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	old := defaultD
+	defaultD = d
+	return old
+}
+
+func current() *Dispatcher {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultD
+}
+
+// Enabled reports whether the default dispatcher delivers events of type
+// typ.
+func Enabled(typ string) bool {
+	return current().Wants(typ)
+}
+
+// Emit sends e through the default dispatcher.
+func Emit(e Event) {
+	current().Emit(e)
+}
+
+var (
+	throttleMu sync.Mutex
+	throttled  = map[string]time.Time{}
+)
+
+// EmitThrottled is Emit that drops e if an event with the same key was sent
+// within window, for events that concurrent workers raise in bursts.
+func EmitThrottled(key string, window time.Duration, e Event) {
+	if !Enabled(e.Type) {
+		return
+	}
+	now := time.Now()
+	throttleMu.Lock()
+	if last, ok := throttled[key]; ok && now.Sub(last) < window {
+		throttleMu.Unlock()
+		return
+	}
+	throttled[key] = now
+	throttleMu.Unlock()
+	Emit(e)
+}
+
+// Close flushes the default dispatcher, waiting at most until ctx ends.
+func Close(ctx context.Context) error {
+	return current().Close(ctx)
+}
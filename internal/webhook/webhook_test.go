@@ -0,0 +1,196 @@
+package webhook
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"media-crawler-go/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDispatcherSignsRetriesAndDeadLetters(t *testing.T) {
+	var mu sync.Mutex
+	var calls int
+	var got []Event
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		if sig := Sign("s3cret", r.Header.Get("X-Webhook-Timestamp"), body); sig != r.Header.Get("X-Webhook-Signature") {
+			t.Errorf("bad signature %q, want %q", r.Header.Get("X-Webhook-Signature"), sig)
+		}
+		var e Event
+		_ = json.Unmarshal(body, &e)
+		if r.Header.Get("X-Webhook-Event") != e.Type || r.Header.Get("X-Webhook-ID") != e.ID {
+			t.Errorf("headers do not match event: %v", r.Header)
+		}
+		got = append(got, e)
+	}))
+	defer ok.Close()
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such hook", http.StatusNotFound)
+	}))
+	defer rejecting.Close()
+
+	subs, err := ParseSubscriptions([]config.WebhookConfig{
+		{Name: "ops", URL: ok.URL, Events: []string{"task.*"}, Secret: "s3cret"},
+		{Name: "gone", URL: rejecting.URL},
+	})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	deadLetter := filepath.Join(t.TempDir(), "dead.jsonl")
+	d := New(subs, Options{MaxRetries: 3, BaseDelay: time.Millisecond, DeadLetter: deadLetter})
+
+	d.Emit(Event{Type: TaskFinished, Platform: "xhs", TaskID: "t1", Data: map[string]any{"processed": 3}})
+	d.Emit(Event{Type: RiskHint, Platform: "xhs", Data: map[string]any{"hint": "captcha"}})
+	d.Emit(Event{Type: NoteSaved, Platform: "xhs", Data: map[string]any{"note_id": "n1"}})
+	if err := d.Close(context.Background()); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	if calls != 2 || len(got) != 1 || got[0].Type != TaskFinished || got[0].TaskID != "t1" || got[0].ID == "" {
+		t.Fatalf("calls=%d got=%+v", calls, got)
+	}
+
+	f, err := os.Open(deadLetter)
+	if err != nil {
+		t.Fatalf("open dead letter: %v", err)
+	}
+	defer f.Close()
+	var dead []DeadLetter
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var dl DeadLetter
+		if err := json.Unmarshal(sc.Bytes(), &dl); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		dead = append(dead, dl)
+	}
+	// the rejecting hook gets task.finished and risk.hint, but not the
+	// unlisted note.saved; a 404 is not retried
+	if len(dead) != 2 {
+		t.Fatalf("unexpected dead letters: %+v", dead)
+	}
+	for _, dl := range dead {
+		if dl.Webhook != "gone" || dl.Attempts != 1 || !strings.Contains(dl.Error, "404") {
+			t.Fatalf("unexpected dead letter: %+v", dl)
+		}
+	}
+
+	// events after Close go straight to the dead-letter file
+	d.Emit(Event{Type: TaskStarted})
+	b, _ := os.ReadFile(deadLetter)
+	if n := strings.Count(string(b), "\n"); n != 4 || !strings.Contains(string(b), "dispatcher closed") {
+		t.Fatalf("expected 4 dead letters, got %d: %s", n, b)
+	}
+}
+
+func TestDispatcherGivesUpAfterRetries(t *testing.T) {
+	var calls int
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		// chat platforms report errors with 200
+		_, _ = w.Write([]byte(`{"errcode":310000,"errmsg":"sign not match"}`))
+	}))
+	defer srv.Close()
+	deadLetter := filepath.Join(t.TempDir(), "dead.jsonl")
+	d := New([]Subscription{{Name: "ding", URL: srv.URL, Format: "dingtalk"}}, Options{MaxRetries: 2, BaseDelay: time.Millisecond, DeadLetter: deadLetter})
+	d.Emit(Event{Type: TaskFailed, Platform: "douyin"})
+	_ = d.Close(context.Background())
+
+	b, _ := os.ReadFile(deadLetter)
+	var dl DeadLetter
+	if err := json.Unmarshal(b, &dl); err != nil {
+		t.Fatalf("unmarshal %q: %v", b, err)
+	}
+	if calls != 3 || dl.Attempts != 3 || !strings.Contains(dl.Error, "sign not match") {
+		t.Fatalf("calls=%d dead letter=%+v", calls, dl)
+	}
+}
+
+func TestChatFormats(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	e := Event{ID: "e1", Type: TaskFinished, Time: "2023-11-14T22:13:20Z", Platform: "xhs", TaskID: "t1",
+		Data: map[string]any{"mode": "search", "keywords": []string{"a", "b"}, "processed": 2, "failure_kinds": map[string]int{}}}
+	text := summary(e)
+	for _, want := range []string{"task finished: xhs", "keywords: a, b", "processed: 2", "task: t1"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("summary %q lacks %q", text, want)
+		}
+	}
+	if strings.Contains(text, "failure_kinds") {
+		t.Fatalf("summary shows empty values: %q", text)
+	}
+
+	dl := delivery{event: e, text: text}
+	dl.sub = Subscription{URL: "https://oapi.dingtalk.com/robot/send?access_token=x", Format: "dingtalk", Secret: "sec"}
+	req, err := buildRequest(dl, now)
+	if err != nil {
+		t.Fatalf("dingtalk: %v", err)
+	}
+	mac := hmac.New(sha256.New, []byte("sec"))
+	mac.Write([]byte("1700000000000\nsec"))
+	q := req.URL.Query()
+	if q.Get("access_token") != "x" || q.Get("timestamp") != "1700000000000" || q.Get("sign") != base64.StdEncoding.EncodeToString(mac.Sum(nil)) {
+		t.Fatalf("dingtalk url %s", req.URL)
+	}
+
+	dl.sub = Subscription{URL: "https://open.feishu.cn/open-apis/bot/v2/hook/x", Format: "feishu", Secret: "sec"}
+	req, _ = buildRequest(dl, now)
+	var feishu map[string]any
+	_ = json.NewDecoder(req.Body).Decode(&feishu)
+	if feishu["msg_type"] != "text" || feishu["timestamp"] != "1700000000" || feishu["sign"] == "" {
+		t.Fatalf("feishu body %v", feishu)
+	}
+
+	for format, key := range map[string]string{"wecom": "msgtype", "slack": "text"} {
+		dl.sub = Subscription{URL: "https://example.com/hook", Format: format}
+		req, _ = buildRequest(dl, now)
+		var body map[string]any
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		if _, ok := body[key]; !ok {
+			t.Fatalf("%s body %v", format, body)
+		}
+	}
+}
+
+func TestSubscriptions(t *testing.T) {
+	subs, err := ParseSubscriptions([]config.WebhookConfig{
+		{URL: "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=x"},
+		{URL: "https://example.com/hook", Events: []string{"*", "note.saved"}},
+	})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if subs[0].Name != "webhook-1" || subs[0].Format != "wecom" {
+		t.Fatalf("unexpected subscription: %+v", subs[0])
+	}
+	if subs[0].Matches(NoteSaved) || !subs[0].Matches(LoginRequired) || !subs[1].Matches(NoteSaved) {
+		t.Fatalf("unexpected event filters")
+	}
+	for _, bad := range []config.WebhookConfig{{URL: "ftp://x"}, {URL: "https://x", Format: "teams"}} {
+		if _, err := ParseSubscriptions([]config.WebhookConfig{bad}); err == nil {
+			t.Fatalf("expected error for %+v", bad)
+		}
+	}
+}
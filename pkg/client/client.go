@@ -0,0 +1,539 @@
+// Package client is a typed Go client for the media-crawler-go HTTP API
+// (see /openapi.json). The request and response types in types_gen.go are
+// generated from the API's OpenAPI document.
+//
+//	c := client.New("http://127.0.0.1:8080", client.WithToken(os.Getenv("API_TOKEN")))
+//	st, err := c.Run(ctx, client.RunRequest{Platform: "xhs", Keywords: "golang"})
+package client
+
+//go:generate go run ../../cmd/openapi-gen -o types_gen.go
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Client calls the API at BaseURL. The zero value is not usable; create
+// clients with New.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	token      string
+	user, pass string
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithToken authenticates requests with an API token or JWT as a bearer
+// token.
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithBasicAuth authenticates requests with an API_BASIC_AUTH user.
+func WithBasicAuth(user, pass string) Option {
+	return func(c *Client) { c.user, c.pass = user, pass }
+}
+
+// WithHTTPClient sets the http.Client used for requests. The default is
+// http.DefaultClient.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// New returns a client for the API served at baseURL, e.g.
+// "http://127.0.0.1:8080".
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{baseURL: strings.TrimRight(baseURL, "/"), httpClient: http.DefaultClient}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// APIError is a non-2xx answer of the API.
+type APIError struct {
+	StatusCode int
+	// Message is the "error" (or, on Python-compatible routes, "detail")
+	// field of the body, or the body itself.
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("api: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// StatusCode returns the HTTP status of an *APIError in err's chain, or 0.
+func StatusCode(err error) int {
+	var e *APIError
+	if errors.As(err, &e) {
+		return e.StatusCode
+	}
+	return 0
+}
+
+// Health reports whether the server is up.
+func (c *Client) Health(ctx context.Context) (Health, error) {
+	var out Health
+	return out, c.getJSON(ctx, "/healthz", nil, &out)
+}
+
+// WhoAmI returns the caller as authenticated by the server.
+func (c *Client) WhoAmI(ctx context.Context) (WhoAmI, error) {
+	var out WhoAmI
+	return out, c.getJSON(ctx, "/api/auth/whoami", nil, &out)
+}
+
+// Status returns the task status with live progress.
+func (c *Client) Status(ctx context.Context) (Status, error) {
+	var out Status
+	return out, c.getJSON(ctx, "/status", nil, &out)
+}
+
+// Run starts a task. It fails with 409 while another task is running.
+func (c *Client) Run(ctx context.Context, req RunRequest) (Status, error) {
+	var out Status
+	return out, c.doJSON(ctx, http.MethodPost, "/run", nil, req, &out)
+}
+
+// Stop cancels the running task; Stopped is false when none was running.
+func (c *Client) Stop(ctx context.Context) (StopResponse, error) {
+	var out StopResponse
+	return out, c.doJSON(ctx, http.MethodPost, "/stop", nil, nil, &out)
+}
+
+// SubmitSMS hands an SMS verification code to a waiting phone login.
+func (c *Client) SubmitSMS(ctx context.Context, n SMSNotification) error {
+	return c.doJSON(ctx, http.MethodPost, "/sms", nil, n, nil)
+}
+
+// Logs returns up to limit recent log events; limit <= 0 uses the server
+// default.
+func (c *Client) Logs(ctx context.Context, limit int) ([]LogEvent, error) {
+	var out LogsResponse
+	err := c.getJSON(ctx, "/logs", pageQuery(limit, 0), &out)
+	return out.Logs, err
+}
+
+// TaskHistory returns recorded tasks, newest first.
+func (c *Client) TaskHistory(ctx context.Context, limit, offset int) (TaskHistory, error) {
+	var out TaskHistory
+	return out, c.getJSON(ctx, "/tasks/history", pageQuery(limit, offset), &out)
+}
+
+// Task returns one recorded task.
+func (c *Client) Task(ctx context.Context, id string) (TaskRecord, error) {
+	var out TaskRecord
+	return out, c.getJSON(ctx, "/tasks/"+url.PathEscape(id), nil, &out)
+}
+
+// TaskLogs returns log events of a task, oldest first.
+func (c *Client) TaskLogs(ctx context.Context, id string, limit, offset int) (TaskLogs, error) {
+	var out TaskLogs
+	return out, c.getJSON(ctx, "/tasks/"+url.PathEscape(id)+"/logs", pageQuery(limit, offset), &out)
+}
+
+// Audit returns audit log entries, newest first. It needs the admin role.
+func (c *Client) Audit(ctx context.Context, limit, offset int) (AuditPage, error) {
+	var out AuditPage
+	return out, c.getJSON(ctx, "/api/audit", pageQuery(limit, offset), &out)
+}
+
+// DataFiles lists data files; platform and fileType may be empty.
+func (c *Client) DataFiles(ctx context.Context, platform, fileType string) ([]DataFile, error) {
+	q := url.Values{}
+	setIf(q, "platform", platform)
+	setIf(q, "file_type", fileType)
+	var out DataFiles
+	err := c.getJSON(ctx, "/data/files", q, &out)
+	return out.Files, err
+}
+
+// PreviewFile returns up to limit rows of a data file given by its path
+// relative to DATA_DIR.
+func (c *Client) PreviewFile(ctx context.Context, path string, limit int) (DataPreview, error) {
+	var out DataPreview
+	return out, c.getJSON(ctx, "/data/files/"+escapePath(path), pageQuery(limit, 0), &out)
+}
+
+// Download streams a data file. The caller closes the reader.
+func (c *Client) Download(ctx context.Context, path string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, http.MethodGet, "/data/download/"+escapePath(path), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// ExportOptions select the files of Export; zero values use the server
+// defaults. Offset and ETag resume an interrupted download: pass the number
+// of bytes already received and the ETag of the first response.
+type ExportOptions struct {
+	Platform    string
+	TaskID      string
+	NoteIDs     []string
+	Since       string // unix seconds or ms, RFC 3339 or YYYY-MM-DD
+	Until       string
+	NoManifest  bool
+	NoSummaries bool
+
+	Offset int64
+	ETag   string
+}
+
+// ExportDownload is a zip archive being downloaded.
+type ExportDownload struct {
+	Body io.ReadCloser
+	// ETag identifies the archive, for resuming.
+	ETag string
+	// Offset is where Body starts in the archive: the requested offset,
+	// or 0 if the archive changed and is sent from the start.
+	Offset int64
+}
+
+// Export downloads a zip of data files with a manifest and CSV summaries.
+// The caller closes Body.
+func (c *Client) Export(ctx context.Context, opts ExportOptions) (ExportDownload, error) {
+	q := url.Values{}
+	setIf(q, "platform", opts.Platform)
+	setIf(q, "task_id", opts.TaskID)
+	if len(opts.NoteIDs) > 0 {
+		q.Set("note_id", strings.Join(opts.NoteIDs, ","))
+	}
+	setIf(q, "since", opts.Since)
+	setIf(q, "until", opts.Until)
+	if opts.NoManifest {
+		q.Set("manifest", "false")
+	}
+	if opts.NoSummaries {
+		q.Set("summaries", "false")
+	}
+	header := http.Header{}
+	if opts.Offset > 0 {
+		header.Set("Range", "bytes="+strconv.FormatInt(opts.Offset, 10)+"-")
+		if opts.ETag != "" {
+			header.Set("If-Range", opts.ETag)
+		}
+	}
+	resp, err := c.doHeader(ctx, http.MethodGet, "/data/export", q, nil, header)
+	if err != nil {
+		return ExportDownload{}, err
+	}
+	out := ExportDownload{Body: resp.Body, ETag: resp.Header.Get("ETag")}
+	if resp.StatusCode == http.StatusPartialContent {
+		out.Offset = opts.Offset
+	}
+	return out, nil
+}
+
+// DataStats returns file counts and sizes under DATA_DIR.
+func (c *Client) DataStats(ctx context.Context) (DataStats, error) {
+	var out DataStats
+	return out, c.getJSON(ctx, "/data/stats", nil, &out)
+}
+
+// WordcloudOptions are the query parameters of Wordcloud; zero values use
+// the server defaults.
+type WordcloudOptions struct {
+	Platform    string
+	NoteID      string
+	Format      string // svg or png
+	NoSave      bool
+	NoCache     bool
+	MaxComments int
+	MaxWords    int
+	MinCount    int
+	Width       int
+	Height      int
+}
+
+// Wordcloud is a rendered word cloud.
+type Wordcloud struct {
+	ContentType string
+	Data        []byte
+	// GeneratedFile is the saved file relative to DATA_DIR, if any.
+	GeneratedFile string
+}
+
+// Wordcloud renders a word cloud of stored comments.
+func (c *Client) Wordcloud(ctx context.Context, opts WordcloudOptions) (Wordcloud, error) {
+	q := url.Values{}
+	setIf(q, "platform", opts.Platform)
+	setIf(q, "note_id", opts.NoteID)
+	setIf(q, "format", opts.Format)
+	if opts.NoSave {
+		q.Set("save", "false")
+	}
+	if opts.NoCache {
+		q.Set("cache", "false")
+	}
+	setInt(q, "max_comments", opts.MaxComments)
+	setInt(q, "max_words", opts.MaxWords)
+	setInt(q, "min_count", opts.MinCount)
+	setInt(q, "width", opts.Width)
+	setInt(q, "height", opts.Height)
+	resp, err := c.do(ctx, http.MethodGet, "/data/wordcloud", q, nil)
+	if err != nil {
+		return Wordcloud{}, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Wordcloud{}, err
+	}
+	return Wordcloud{
+		ContentType:   resp.Header.Get("Content-Type"),
+		Data:          data,
+		GeneratedFile: resp.Header.Get("X-Generated-File"),
+	}, nil
+}
+
+// CommentThread returns the comments of a note nested by reply.
+func (c *Client) CommentThread(ctx context.Context, platform, noteID string) (CommentThreads, error) {
+	q := url.Values{"note_id": {noteID}}
+	setIf(q, "platform", platform)
+	var out CommentThreads
+	return out, c.getJSON(ctx, "/data/comments/thread", q, &out)
+}
+
+// QueryParams filter /api/notes, /api/comments and /api/creators. Extra
+// holds further parameters such as min_liked_count.
+type QueryParams struct {
+	Platform string
+	NoteID   string
+	Creator  string
+	Keyword  string
+	Since    string
+	Until    string
+	Sort     string
+	Limit    int
+	Cursor   string
+	Fields   []string
+	Extra    url.Values
+}
+
+func (p QueryParams) values() url.Values {
+	q := url.Values{}
+	for k, vs := range p.Extra {
+		q[k] = append([]string(nil), vs...)
+	}
+	setIf(q, "platform", p.Platform)
+	setIf(q, "note_id", p.NoteID)
+	setIf(q, "creator", p.Creator)
+	setIf(q, "keyword", p.Keyword)
+	setIf(q, "since", p.Since)
+	setIf(q, "until", p.Until)
+	setIf(q, "sort", p.Sort)
+	setInt(q, "limit", p.Limit)
+	setIf(q, "cursor", p.Cursor)
+	setIf(q, "fields", strings.Join(p.Fields, ","))
+	return q
+}
+
+// Notes queries stored notes. Page on with Cursor = NextCursor.
+func (c *Client) Notes(ctx context.Context, p QueryParams) (QueryResult, error) {
+	var out QueryResult
+	return out, c.getJSON(ctx, "/api/notes", p.values(), &out)
+}
+
+// Comments queries stored comments.
+func (c *Client) Comments(ctx context.Context, p QueryParams) (QueryResult, error) {
+	var out QueryResult
+	return out, c.getJSON(ctx, "/api/comments", p.values(), &out)
+}
+
+// Creators queries stored creators.
+func (c *Client) Creators(ctx context.Context, p QueryParams) (QueryResult, error) {
+	var out QueryResult
+	return out, c.getJSON(ctx, "/api/creators", p.values(), &out)
+}
+
+// Search runs a full-text search; kind is "notes", "comments" or empty for
+// both.
+func (c *Client) Search(ctx context.Context, q, kind, platform string, limit, offset int) (SearchResult, error) {
+	v := pageQuery(limit, offset)
+	v.Set("q", q)
+	setIf(v, "kind", kind)
+	setIf(v, "platform", platform)
+	var out SearchResult
+	return out, c.getJSON(ctx, "/api/search", v, &out)
+}
+
+// ConfigPlatforms returns the platforms and their crawler modes.
+func (c *Client) ConfigPlatforms(ctx context.Context) ([]PlatformInfo, error) {
+	var out Platforms
+	err := c.getJSON(ctx, "/config/platforms", nil, &out)
+	return out.Platforms, err
+}
+
+// ConfigOptions returns option lists, descriptions and current defaults.
+func (c *Client) ConfigOptions(ctx context.Context) (Options, error) {
+	var out Options
+	return out, c.getJSON(ctx, "/config/options", nil, &out)
+}
+
+// EnvCheck reports whether the environment can run crawls.
+func (c *Client) EnvCheck(ctx context.Context) (EnvReport, error) {
+	var out EnvReport
+	return out, c.getJSON(ctx, "/env/check", nil, &out)
+}
+
+// Metrics returns the Prometheus text exposition.
+func (c *Client) Metrics(ctx context.Context) (string, error) {
+	resp, err := c.do(ctx, http.MethodGet, "/metrics", nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	return string(b), err
+}
+
+// WatchStatus follows /sse/status and calls fn with every status until ctx
+// is done, fn returns false or the stream ends. intervalMS <= 0 uses the
+// server default.
+func (c *Client) WatchStatus(ctx context.Context, intervalMS int, fn func(Status) bool) error {
+	q := url.Values{}
+	setInt(q, "interval_ms", intervalMS)
+	resp, err := c.do(ctx, http.MethodGet, "/sse/status", q, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		data, ok := strings.CutPrefix(sc.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var st Status
+		if err := json.Unmarshal([]byte(data), &st); err != nil {
+			return fmt.Errorf("decode status event: %w", err)
+		}
+		if !fn(st) {
+			return nil
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return sc.Err()
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, q url.Values, out any) error {
+	return c.doJSON(ctx, http.MethodGet, path, q, nil, out)
+}
+
+func (c *Client) doJSON(ctx context.Context, method, path string, q url.Values, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	resp, err := c.do(ctx, method, path, q, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+// do sends a request and returns the response if its status is 2xx; other
+// answers become *APIError.
+func (c *Client) do(ctx context.Context, method, path string, q url.Values, body io.Reader) (*http.Response, error) {
+	return c.doHeader(ctx, method, path, q, body, nil)
+}
+
+// doHeader is do with extra request headers.
+func (c *Client) doHeader(ctx context.Context, method, path string, q url.Values, body io.Reader, header http.Header) (*http.Response, error) {
+	u := c.baseURL + path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case c.token != "":
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	case c.user != "":
+		req.SetBasicAuth(c.user, c.pass)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	apiErr := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(b))}
+	var shape struct {
+		Error  string `json:"error"`
+		Detail string `json:"detail"`
+	}
+	if json.Unmarshal(b, &shape) == nil {
+		if shape.Error != "" {
+			apiErr.Message = shape.Error
+		} else if shape.Detail != "" {
+			apiErr.Message = shape.Detail
+		}
+	}
+	return nil, apiErr
+}
+
+func pageQuery(limit, offset int) url.Values {
+	q := url.Values{}
+	setInt(q, "limit", limit)
+	setInt(q, "offset", offset)
+	return q
+}
+
+func setIf(q url.Values, key, value string) {
+	if value != "" {
+		q.Set(key, value)
+	}
+}
+
+func setInt(q url.Values, key string, n int) {
+	if n > 0 {
+		q.Set(key, strconv.Itoa(n))
+	}
+}
+
+func escapePath(p string) string {
+	parts := strings.Split(strings.TrimLeft(p, "/"), "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"io"
+	"media-crawler-go/internal/api"
+	"media-crawler-go/internal/config"
+	"media-crawler-go/internal/crawler"
+	_ "media-crawler-go/internal/platform/xhs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClientAgainstServer(t *testing.T) {
+	dataDir := t.TempDir()
+	oldCfg := config.AppConfig
+	config.AppConfig = config.Config{
+		Platform:    "xhs",
+		CrawlerType: "search",
+		DataDir:     dataDir,
+		APITokens:   []string{"ci:operator:op-token"},
+	}
+	t.Cleanup(func() { config.AppConfig = oldCfg })
+
+	out := filepath.Join(dataDir, "xhs", "jsonl", "search_contents.jsonl")
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(out, []byte("{\"note_id\":\"n1\"}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(api.NewServer(api.NewTaskManagerWithRunner(func(ctx context.Context) (crawler.Result, error) {
+		return crawler.Result{Processed: 1, Succeeded: 1}, nil
+	})).Handler())
+	defer srv.Close()
+	ctx := context.Background()
+
+	_, err := New(srv.URL).Status(ctx)
+	if StatusCode(err) != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %v", err)
+	}
+
+	c := New(srv.URL, WithToken("op-token"))
+	if _, err := c.Audit(ctx, 10, 0); StatusCode(err) != http.StatusForbidden || err.(*APIError).Message == "" {
+		t.Fatalf("expected 403 with message, got %v", err)
+	}
+	me, err := c.WhoAmI(ctx)
+	if err != nil || me.Name != "ci" || me.Role != "operator" {
+		t.Fatalf("whoami=%+v err=%v", me, err)
+	}
+
+	st, err := c.Run(ctx, RunRequest{Keywords: "golang"})
+	if err != nil || st.State != "running" || st.TaskID == "" {
+		t.Fatalf("run status=%+v err=%v", st, err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for st.State != "idle" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		if st, err = c.Status(ctx); err != nil {
+			t.Fatalf("status: %v", err)
+		}
+	}
+	hist, err := c.TaskHistory(ctx, 10, 0)
+	if err != nil || hist.Total != 1 || hist.Items[0].State != "finished" || hist.Items[0].Result.Succeeded != 1 {
+		t.Fatalf("history=%+v err=%v", hist, err)
+	}
+	if _, err := c.Task(ctx, "nope"); StatusCode(err) != http.StatusNotFound {
+		t.Fatalf("expected 404, got %v", err)
+	}
+
+	files, err := c.DataFiles(ctx, "xhs", "jsonl")
+	if err != nil || len(files) != 1 || files[0].Path != "xhs/jsonl/search_contents.jsonl" {
+		t.Fatalf("files=%+v err=%v", files, err)
+	}
+	preview, err := c.PreviewFile(ctx, files[0].Path, 10)
+	if err != nil || preview.Total != 1 || len(preview.Data) != 1 {
+		t.Fatalf("preview=%+v err=%v", preview, err)
+	}
+	rc, err := c.Download(ctx, files[0].Path)
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	b, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(b) != "{\"note_id\":\"n1\"}\n" {
+		t.Fatalf("download=%q", b)
+	}
+
+	ex, err := c.Export(ctx, ExportOptions{Platform: "xhs"})
+	if err != nil || ex.ETag == "" {
+		t.Fatalf("export=%+v err=%v", ex, err)
+	}
+	archive, _ := io.ReadAll(ex.Body)
+	ex.Body.Close()
+	ex, err = c.Export(ctx, ExportOptions{Platform: "xhs", Offset: 10, ETag: ex.ETag})
+	if err != nil || ex.Offset != 10 {
+		t.Fatalf("resumed export=%+v err=%v", ex, err)
+	}
+	rest, _ := io.ReadAll(ex.Body)
+	ex.Body.Close()
+	if len(archive) < 10 || string(rest) != string(archive[10:]) {
+		t.Fatalf("resumed export differs: %d+%d bytes", len(archive), len(rest))
+	}
+
+	var watched Status
+	if err := c.WatchStatus(ctx, 100, func(s Status) bool { watched = s; return false }); err != nil || watched.State != "idle" {
+		t.Fatalf("watch=%+v err=%v", watched, err)
+	}
+}
@@ -0,0 +1,367 @@
+// Code generated by openapi-gen from the API's OpenAPI document. DO NOT EDIT.
+
+package client
+
+type AuditEntry struct {
+	Action     string         `json:"action"`
+	Actor      string         `json:"actor"`
+	AuthMethod string         `json:"auth_method,omitempty"`
+	Details    map[string]any `json:"details,omitempty"`
+	RemoteAddr string         `json:"remote_addr,omitempty"`
+	Role       string         `json:"role,omitempty"`
+	Status     int            `json:"status"`
+	Time       string         `json:"time"`
+}
+
+type AuditPage struct {
+	Items  []AuditEntry `json:"items"`
+	Limit  int          `json:"limit"`
+	Offset int          `json:"offset"`
+	Total  int          `json:"total"`
+}
+
+type CommentThread struct {
+	Comment UnifiedComment  `json:"comment"`
+	Replies []CommentThread `json:"replies,omitempty"`
+}
+
+type CommentThreads struct {
+	NoteID   string          `json:"note_id"`
+	Platform string          `json:"platform"`
+	Threads  []CommentThread `json:"threads"`
+	Total    int             `json:"total"`
+}
+
+type DataFile struct {
+	ModifiedAt  int64  `json:"modified_at"`
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	RecordCount *int   `json:"record_count,omitempty"`
+	Size        int64  `json:"size"`
+	Type        string `json:"type"`
+}
+
+type DataFiles struct {
+	Files []DataFile `json:"files"`
+}
+
+type DataPreview struct {
+	Columns []string `json:"columns,omitempty"`
+	Data    []any    `json:"data"`
+	Total   int      `json:"total"`
+}
+
+type DataStats struct {
+	ByPlatform  map[string]int `json:"by_platform"`
+	ByType      map[string]int `json:"by_type"`
+	GeneratedAt int64          `json:"generated_at,omitempty"`
+	TotalFiles  int            `json:"total_files"`
+	TotalSize   int64          `json:"total_size"`
+}
+
+type Detail struct {
+	Detail string `json:"detail"`
+}
+
+type EnvReport struct {
+	Notes        []string `json:"Notes"`
+	Arch         string   `json:"arch"`
+	CDPEndpoint  string   `json:"cdp_endpoint,omitempty"`
+	CDPError     string   `json:"cdp_error,omitempty"`
+	CDPReachable bool     `json:"cdp_reachable"`
+	ChromeError  string   `json:"chrome_error,omitempty"`
+	ChromeOK     bool     `json:"chrome_ok"`
+	ChromePath   string   `json:"chrome_path,omitempty"`
+	DataDir      string   `json:"data_dir"`
+	DataDirError string   `json:"data_dir_error,omitempty"`
+	DataDirOK    bool     `json:"data_dir_ok"`
+	Generated    string   `json:"generated"`
+	GoVersion    string   `json:"go_version"`
+	OK           bool     `json:"ok"`
+	OS           string   `json:"os"`
+}
+
+type Error struct {
+	Error string `json:"error"`
+}
+
+type Health struct {
+	OK bool `json:"ok"`
+}
+
+type LogEvent struct {
+	Attrs map[string]any `json:"attrs,omitempty"`
+	Level string         `json:"level"`
+	Msg   string         `json:"msg"`
+	Time  string         `json:"time"`
+}
+
+type LogsResponse struct {
+	Logs []LogEvent `json:"logs"`
+}
+
+type OKStatus struct {
+	Status string `json:"status"`
+}
+
+type Options map[string]any
+
+type PlatformInfo struct {
+	Key   string   `json:"key"`
+	Label string   `json:"label"`
+	Modes []string `json:"modes"`
+}
+
+type Platforms struct {
+	Platforms []PlatformInfo `json:"platforms"`
+}
+
+type Progress struct {
+	CommentsFetched int64  `json:"comments_fetched"`
+	Current         string `json:"current,omitempty"`
+	Done            int    `json:"done"`
+	EtaSec          int64  `json:"eta_sec,omitempty"`
+	Failed          int    `json:"failed"`
+	Keyword         string `json:"keyword,omitempty"`
+	MediaDownloaded int64  `json:"media_downloaded"`
+	Page            int    `json:"page,omitempty"`
+	StartedAt       int64  `json:"started_at"`
+	Succeeded       int    `json:"succeeded"`
+	Total           int    `json:"total"`
+	UpdatedAt       int64  `json:"updated_at"`
+}
+
+type PythonCrawlerStartRequest struct {
+	Cookies           string `json:"cookies"`
+	CrawlerType       string `json:"crawler_type"`
+	CreatorIDs        string `json:"creator_ids"`
+	EnableComments    *bool  `json:"enable_comments,omitempty"`
+	EnableSubComments *bool  `json:"enable_sub_comments,omitempty"`
+	Headless          *bool  `json:"headless,omitempty"`
+	Keywords          string `json:"keywords"`
+	LoginType         string `json:"login_type"`
+	Platform          string `json:"platform"`
+	SaveOption        string `json:"save_option"`
+	SpecifiedIDs      string `json:"specified_ids"`
+	StartPage         int    `json:"start_page"`
+}
+
+type PythonEnvCheck struct {
+	Error   string `json:"error,omitempty"`
+	Message string `json:"message"`
+	Output  string `json:"output,omitempty"`
+	Success bool   `json:"success"`
+}
+
+type PythonLogEntry struct {
+	ID        int    `json:"id"`
+	Level     string `json:"level"`
+	Message   string `json:"message"`
+	Timestamp string `json:"timestamp"`
+}
+
+type PythonLogs struct {
+	Logs []PythonLogEntry `json:"logs"`
+}
+
+type PythonMessage struct {
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
+type PythonOption struct {
+	Icon  string `json:"icon,omitempty"`
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+type PythonOptions struct {
+	CrawlerTypes []PythonOption `json:"crawler_types"`
+	LoginTypes   []PythonOption `json:"login_types"`
+	SaveOptions  []PythonOption `json:"save_options"`
+}
+
+type PythonPlatforms struct {
+	Platforms []PythonOption `json:"platforms"`
+}
+
+type PythonStatus struct {
+	CrawlerType  string `json:"crawler_type"`
+	ErrorMessage string `json:"error_message,omitempty"`
+	Platform     string `json:"platform"`
+	StartedAt    string `json:"started_at,omitempty"`
+	Status       string `json:"status"`
+}
+
+type QueryResult struct {
+	Count      int              `json:"count"`
+	Items      []map[string]any `json:"items"`
+	Kind       string           `json:"kind"`
+	Matched    int              `json:"matched"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+type Result struct {
+	Failed       int            `json:"failed,omitempty"`
+	FailureKinds map[string]int `json:"failure_kinds,omitempty"`
+	FinishedAt   int64          `json:"finished_at,omitempty"`
+	Mode         string         `json:"mode,omitempty"`
+	Platform     string         `json:"platform,omitempty"`
+	Processed    int            `json:"processed,omitempty"`
+	StartedAt    int64          `json:"started_at,omitempty"`
+	Succeeded    int            `json:"succeeded,omitempty"`
+}
+
+type RunRequest struct {
+	BiliCreatorIDList         []string `json:"bili_creator_id_list,omitempty"`
+	BiliEnableWatchLater      *bool    `json:"bili_enable_watch_later,omitempty"`
+	BiliFavMediaIDList        []string `json:"bili_fav_media_id_list,omitempty"`
+	BiliLiveRecordSec         *int     `json:"bili_live_record_sec,omitempty"`
+	BiliLiveRoomIDList        []string `json:"bili_live_room_id_list,omitempty"`
+	BiliSeasonIDList          []string `json:"bili_season_id_list,omitempty"`
+	BiliSeriesIDList          []string `json:"bili_series_id_list,omitempty"`
+	BiliSpecifiedVideoURLList []string `json:"bili_specified_video_url_list,omitempty"`
+	Cookies                   string   `json:"cookies,omitempty"`
+	CrawlerType               string   `json:"crawler_type,omitempty"`
+	DyCreatorIDList           []string `json:"dy_creator_id_list,omitempty"`
+	DyMixIDList               []string `json:"dy_mix_id_list,omitempty"`
+	DyMusicIDList             []string `json:"dy_music_id_list,omitempty"`
+	DySpecifiedNoteURLList    []string `json:"dy_specified_note_url_list,omitempty"`
+	EnableComments            *bool    `json:"enable_comments,omitempty"`
+	EnableSubComments         *bool    `json:"enable_sub_comments,omitempty"`
+	Headless                  *bool    `json:"headless,omitempty"`
+	Keywords                  string   `json:"keywords,omitempty"`
+	KsCreatorURLList          []string `json:"ks_creator_url_list,omitempty"`
+	KsSpecifiedNoteURLList    []string `json:"ks_specified_note_url_list,omitempty"`
+	LoginPhone                string   `json:"login_phone,omitempty"`
+	LoginType                 string   `json:"login_type,omitempty"`
+	Platform                  string   `json:"platform,omitempty"`
+	SaveDataOption            string   `json:"save_data_option,omitempty"`
+	SqlitePath                string   `json:"sqlite_path,omitempty"`
+	StartPage                 *int     `json:"start_page,omitempty"`
+	StoreBackend              string   `json:"store_backend,omitempty"`
+	TiebaCreatorURLList       []string `json:"tieba_creator_url_list,omitempty"`
+	TiebaSpecifiedNoteURLList []string `json:"tieba_specified_note_url_list,omitempty"`
+	WbCreatorIDList           []string `json:"wb_creator_id_list,omitempty"`
+	WbSpecifiedNoteURLList    []string `json:"wb_specified_note_url_list,omitempty"`
+	XhsCreatorIDList          []string `json:"xhs_creator_id_list,omitempty"`
+	XhsFeedChannelList        []string `json:"xhs_feed_channel_list,omitempty"`
+	XhsSpecifiedNoteURLList   []string `json:"xhs_specified_note_url_list,omitempty"`
+	XhsTopicIDList            []string `json:"xhs_topic_id_list,omitempty"`
+	XhsTopicSort              string   `json:"xhs_topic_sort,omitempty"`
+	ZhihuCreatorURLList       []string `json:"zhihu_creator_url_list,omitempty"`
+	ZhihuSpecifiedNoteURLList []string `json:"zhihu_specified_note_url_list,omitempty"`
+}
+
+type SMSNotification struct {
+	Code             string `json:"code"`
+	Content          string `json:"content"`
+	CurrentNumber    string `json:"current_number"`
+	FromNumber       string `json:"from_number"`
+	Phone            string `json:"phone"`
+	Platform         string `json:"platform"`
+	SMSContent       string `json:"sms_content"`
+	Timestamp        string `json:"timestamp"`
+	VerificationCode string `json:"verification_code"`
+}
+
+type SearchHit struct {
+	CreateTime  any     `json:"create_time,omitempty"`
+	CreatorName string  `json:"creator_name,omitempty"`
+	ID          string  `json:"id"`
+	Kind        string  `json:"kind"`
+	NoteID      string  `json:"note_id"`
+	Platform    string  `json:"platform"`
+	Rank        float64 `json:"rank"`
+	Snippet     string  `json:"snippet"`
+	Title       string  `json:"title,omitempty"`
+}
+
+type SearchResult struct {
+	Items []SearchHit `json:"items"`
+	Q     string      `json:"q"`
+	Total int         `json:"total"`
+}
+
+type Status struct {
+	CrawlerType    string         `json:"crawler_type,omitempty"`
+	Failed         int            `json:"failed,omitempty"`
+	FailureKinds   map[string]int `json:"failure_kinds,omitempty"`
+	FinishedAt     int64          `json:"finished_at,omitempty"`
+	LastError      string         `json:"last_error,omitempty"`
+	LastErrorKind  string         `json:"last_error_kind,omitempty"`
+	LastErrorURL   string         `json:"last_error_url,omitempty"`
+	LastHTTPStatus int            `json:"last_http_status,omitempty"`
+	LastRiskHint   string         `json:"last_risk_hint,omitempty"`
+	Platform       string         `json:"platform,omitempty"`
+	Processed      int            `json:"processed,omitempty"`
+	Progress       *Progress      `json:"progress,omitempty"`
+	StartedAt      int64          `json:"started_at,omitempty"`
+	State          string         `json:"state"`
+	Succeeded      int            `json:"succeeded,omitempty"`
+	TaskID         string         `json:"task_id,omitempty"`
+}
+
+type StopResponse struct {
+	Stopped bool `json:"stopped"`
+}
+
+type TaskHistory struct {
+	Items  []TaskRecord `json:"items"`
+	Limit  int          `json:"limit"`
+	Offset int          `json:"offset"`
+	Total  int          `json:"total"`
+}
+
+type TaskLogs struct {
+	Logs       []LogEvent `json:"logs"`
+	NextOffset int        `json:"next_offset,omitempty"`
+	Offset     int        `json:"offset"`
+	Total      int        `json:"total"`
+}
+
+type TaskOutputFile struct {
+	Created bool   `json:"created"`
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+}
+
+type TaskRecord struct {
+	Config         map[string]any   `json:"config,omitempty"`
+	Files          []TaskOutputFile `json:"files,omitempty"`
+	FilesTruncated bool             `json:"files_truncated,omitempty"`
+	FinishedAt     int64            `json:"finished_at,omitempty"`
+	ID             string           `json:"id"`
+	LastError      string           `json:"last_error,omitempty"`
+	LastErrorKind  string           `json:"last_error_kind,omitempty"`
+	Progress       *Progress        `json:"progress,omitempty"`
+	Request        RunRequest       `json:"request"`
+	Result         Result           `json:"result"`
+	StartedAt      int64            `json:"started_at"`
+	State          string           `json:"state"`
+}
+
+type UnifiedComment struct {
+	CommentID           string `json:"CommentID"`
+	Content             string `json:"Content"`
+	CreateTime          int64  `json:"CreateTime"`
+	Depth               int    `json:"Depth"`
+	LikeCount           int64  `json:"LikeCount"`
+	NoteID              string `json:"NoteID"`
+	ParentCommentID     string `json:"ParentCommentID"`
+	Platform            string `json:"Platform"`
+	ReplyToCommentID    string `json:"ReplyToCommentID"`
+	ReplyToUserID       string `json:"ReplyToUserID"`
+	ReplyToUserNickname string `json:"ReplyToUserNickname"`
+	RootCommentID       string `json:"RootCommentID"`
+	SubCommentCount     int64  `json:"SubCommentCount"`
+	UserID              string `json:"UserID"`
+	UserNickname        string `json:"UserNickname"`
+	UserSecUID          string `json:"UserSecUID"`
+}
+
+type WhoAmI struct {
+	Method string `json:"method"`
+	Name   string `json:"name"`
+	Role   string `json:"role"`
+}